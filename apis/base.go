@@ -42,10 +42,18 @@ func NewRouter(app core.App) (*router.Router[*core.RequestEvent], error) {
 	bindLogsApi(app, apiGroup)
 	bindBackupApi(app, apiGroup)
 	bindCronApi(app, apiGroup)
+	bindMailOutboxApi(app, apiGroup)
+	bindMailTemplateApi(app, apiGroup)
+	bindMailCatcherApi(app, apiGroup)
 	bindFileApi(app, apiGroup)
+	bindUploadApi(app, apiGroup)
 	bindBatchApi(app, apiGroup)
 	bindRealtimeApi(app, apiGroup)
 	bindHealthApi(app, apiGroup)
+	bindSCIMApi(app, apiGroup)
+
+	bindJWKSApi(app, pbRouter.RouterGroup)
+	bindOAuth2ServerApi(app, pbRouter.RouterGroup)
 
 	return pbRouter, nil
 }
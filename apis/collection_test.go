@@ -48,13 +48,16 @@ func TestCollectionsList(t *testing.T) {
 			ExpectedContent: []string{
 				`"page":1`,
 				`"perPage":30`,
-				`"totalItems":16`,
+				`"totalItems":19`,
 				`"items":[{`,
 				`"name":"` + core.CollectionNameSuperusers + `"`,
 				`"name":"` + core.CollectionNameAuthOrigins + `"`,
 				`"name":"` + core.CollectionNameExternalAuths + `"`,
 				`"name":"` + core.CollectionNameMFAs + `"`,
 				`"name":"` + core.CollectionNameOTPs + `"`,
+				`"name":"` + core.CollectionNameOAuth2Clients + `"`,
+				`"name":"` + core.CollectionNameOAuth2Codes + `"`,
+				`"name":"` + core.CollectionNameMailTemplates + `"`,
 				`"name":"users"`,
 				`"name":"nologin"`,
 				`"name":"clients"`,
@@ -86,9 +89,10 @@ func TestCollectionsList(t *testing.T) {
 			ExpectedContent: []string{
 				`"page":2`,
 				`"perPage":2`,
-				`"totalItems":16`,
+				`"totalItems":19`,
 				`"items":[{`,
-				`"name":"` + core.CollectionNameMFAs + `"`,
+				`"name":"` + core.CollectionNameOAuth2Clients + `"`,
+				`"name":"` + core.CollectionNameAuthOrigins + `"`,
 			},
 			ExpectedEvents: map[string]int{
 				"*":                        0,
@@ -515,7 +519,7 @@ func TestCollectionCreate(t *testing.T) {
 				`"name":"new"`,
 				`"type":"auth"`,
 				`"system":false`,
-				`"passwordAuth":{"enabled":true,"identityFields":["email"]}`,
+				`"passwordAuth":{"enabled":true,"identityFields":["email"],"lockoutThreshold":0,"lockoutWindow":900,"lockoutDuration":900}`,
 				`"authRule":""`,
 				`"manageRule":null`,
 				`"name":"test"`,
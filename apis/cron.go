@@ -5,10 +5,12 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/pocketbase/dbx"
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/tools/cron"
 	"github.com/thewandererbg/pgbase/tools/router"
 	"github.com/thewandererbg/pgbase/tools/routine"
+	"github.com/thewandererbg/pgbase/tools/search"
 )
 
 // bindCronApi registers the crons api endpoint.
@@ -16,6 +18,7 @@ func bindCronApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	subGroup := rg.Group("/crons").Bind(RequireSuperuserAuth())
 	subGroup.GET("", cronsList)
 	subGroup.POST("/{id}", cronRun)
+	subGroup.GET("/{id}/runs", cronRunsList)
 }
 
 func cronsList(e *core.RequestEvent) error {
@@ -35,25 +38,50 @@ func cronsList(e *core.RequestEvent) error {
 }
 
 func cronRun(e *core.RequestEvent) error {
-	cronId := e.Request.PathValue("id")
-
-	var foundJob *cron.Job
-
-	jobs := e.App.Cron().Jobs()
-	for _, j := range jobs {
-		if j.Id() == cronId {
-			foundJob = j
-			break
-		}
-	}
-
+	foundJob := findCronJob(e.App, e.Request.PathValue("id"))
 	if foundJob == nil {
 		return e.NotFoundError("Missing or invalid cron job", nil)
 	}
 
+	if foundJob.IsRunning() && !foundJob.Options().AllowOverlap {
+		return e.BadRequestError("The cron job is already running.", nil)
+	}
+
 	routine.FireAndForget(func() {
+		// the run result is reported in the cron job runs history
 		foundJob.Run()
 	})
 
 	return e.NoContent(http.StatusNoContent)
 }
+
+var cronRunsFilterFields = []string{
+	"id", "job", "trigger", "instance", "status", "error", "duration", "started", "ended",
+}
+
+func cronRunsList(e *core.RequestEvent) error {
+	// note: allow listing the history of removed jobs too
+	jobId := e.Request.PathValue("id")
+
+	fieldResolver := search.NewSimpleFieldResolver(cronRunsFilterFields...)
+
+	result, err := search.NewProvider(fieldResolver).
+		Query(e.App.CronRunQuery().AndWhere(dbx.HashExp{"job": jobId})).
+		ParseAndExec(e.Request.URL.Query().Encode(), &[]*core.CronRun{})
+
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func findCronJob(app core.App, jobId string) *cron.Job {
+	for _, j := range app.Cron().Jobs() {
+		if j.Id() == jobId {
+			return j
+		}
+	}
+
+	return nil
+}
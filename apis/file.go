@@ -7,13 +7,16 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/tools/filesystem"
 	"github.com/thewandererbg/pgbase/tools/list"
 	"github.com/thewandererbg/pgbase/tools/router"
+	"github.com/thewandererbg/pgbase/tools/security"
 	"github.com/spf13/cast"
 	"golang.org/x/sync/semaphore"
 	"golang.org/x/sync/singleflight"
@@ -22,6 +25,10 @@ import (
 var imageContentTypes = []string{"image/png", "image/jpg", "image/jpeg", "image/gif", "image/webp"}
 var defaultThumbSizes = []string{"100x100"}
 
+// presignedDownloadExpiry is the expiration duration of the
+// presigned file download urls (see [core.S3Config.PresignedDownloads]).
+const presignedDownloadExpiry = 10 * time.Minute
+
 // bindFileApi registers the file api endpoints and the corresponding handlers.
 func bindFileApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	maxWorkers := cast.ToInt64(os.Getenv("PB_THUMBS_MAX_WORKERS"))
@@ -43,6 +50,8 @@ func bindFileApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	sub := rg.Group("/files")
 	sub.POST("/token", api.fileToken).Bind(RequireAuth())
 	sub.GET("/{collection}/{recordId}/{filename}", api.download).Bind(collectionPathRateLimit("", "file"))
+	sub.POST("/{collection}/presign", filePresign).Bind(RequireAuth())
+	sub.POST("/{collection}/presign/{id}", filePresignFinalize).Bind(RequireAuth())
 }
 
 type fileApi struct {
@@ -126,28 +135,94 @@ func (api *fileApi) download(e *core.RequestEvent) error {
 
 	baseFilesPath := record.BaseFilesPath()
 
+	// the file key as it is in the request url (used for the transform signature verification)
+	requestFileKey := baseFilesPath + "/" + filename
+
 	// fetch the original view file field related record
+	fileRecord := record
 	if collection.IsView() {
-		fileRecord, err := e.App.FindRecordByViewFile(collection.Id, fileField.Name, filename)
+		fileRecord, err = e.App.FindRecordByViewFile(collection.Id, fileField.Name, filename)
 		if err != nil {
 			return e.NotFoundError("", fmt.Errorf("failed to fetch view file field record: %w", err))
 		}
 		baseFilesPath = fileRecord.BaseFilesPath()
 	}
 
+	// resolve the deduplicated file blob (if any)
+	originalPath, err := e.App.FindRecordFileKey(fileRecord, filename)
+	if err != nil {
+		return e.InternalServerError("Failed to resolve the file location.", err)
+	}
+
 	fsys, err := e.App.NewFilesystem()
 	if err != nil {
 		return e.InternalServerError("Filesystem initialization failure.", err)
 	}
 	defer fsys.Close()
 
-	originalPath := baseFilesPath + "/" + filename
 	servedPath := originalPath
 	servedName := filename
 
+	transformsConfig := e.App.Settings().ImageTransforms
+
+	transform, transformErr := filesystem.ParseImageTransform(e.Request.URL.Query())
+	if transformErr != nil && transformsConfig.Enabled {
+		return e.BadRequestError("Invalid image transform parameters.", transformErr)
+	}
+
 	// check for valid thumb size param
 	thumbSize := e.Request.URL.Query().Get("thumb")
-	if thumbSize != "" && (list.ExistInSlice(thumbSize, defaultThumbSizes) || list.ExistInSlice(thumbSize, fileField.Thumbs)) {
+	if transform != nil && transformsConfig.Enabled {
+		if err := checkImageTransformAccess(e, transformsConfig, transform, requestFileKey); err != nil {
+			return err
+		}
+
+		// extract the original file meta attributes and check it existence
+		oAttrs, oAttrsErr := fsys.Attributes(originalPath)
+		if oAttrsErr != nil {
+			return e.NotFoundError("", oAttrsErr)
+		}
+
+		// check if it is an image
+		if list.ExistInSlice(oAttrs.ContentType, imageContentTypes) {
+			resolved := *transform
+
+			switch resolved.Format {
+			case filesystem.ImageFormatAuto:
+				resolved.Format = filesystem.NegotiateImageFormat(e.Request.Header.Get("Accept"), oAttrs.ContentType)
+				e.Response.Header().Add("Vary", "Accept")
+			case "":
+				// fallback to png for the original formats without encoder (e.g. webp)
+				resolved.Format = filesystem.ImageFormatFromContentType(oAttrs.ContentType)
+				if resolved.Format == "" {
+					resolved.Format = filesystem.ImageFormatPNG
+				}
+			}
+
+			// store the result in the thumbs prefix so that it is deleted together with the original
+			servedName = resolved.Key() + "_" + strings.TrimSuffix(filename, filepath.Ext(filename)) + filesystem.ImageFormatExt(resolved.Format)
+			servedPath = baseFilesPath + "/thumbs_" + filename + "/" + servedName
+
+			// create a new transformed image if it doesn't exist
+			if exists, _ := fsys.Exists(servedPath); !exists {
+				err := api.generateThumb(e, servedPath, func() error {
+					return fsys.TransformImage(originalPath, servedPath, resolved)
+				})
+				if err != nil {
+					e.App.Logger().Warn(
+						"Fallback to original - failed to transform image "+servedName,
+						slog.Any("error", err),
+						slog.String("original", originalPath),
+						slog.String("transformed", servedPath),
+					)
+
+					// fallback to the original
+					servedName = filename
+					servedPath = originalPath
+				}
+			}
+		}
+	} else if thumbSize != "" && (list.ExistInSlice(thumbSize, defaultThumbSizes) || list.ExistInSlice(thumbSize, fileField.Thumbs)) {
 		// extract the original file meta attributes and check it existence
 		oAttrs, oAttrsErr := fsys.Attributes(originalPath)
 		if oAttrsErr != nil {
@@ -192,6 +267,19 @@ func (api *fileApi) download(e *core.RequestEvent) error {
 	e.Response.Header().Del("X-Frame-Options")
 
 	return e.App.OnFileDownloadRequest().Trigger(event, func(e *core.FileDownloadRequestEvent) error {
+		if s3 := e.App.Settings().S3; s3.Enabled && s3.PresignedDownloads {
+			err := fsys.ServeSignedURL(e.Response, e.Request, e.ServedPath, e.ServedName, presignedDownloadExpiry)
+			if err == nil {
+				return nil
+			}
+
+			e.App.Logger().Warn(
+				"Fallback to proxy - failed to redirect to a presigned download url",
+				slog.Any("error", err),
+				slog.String("path", e.ServedPath),
+			)
+		}
+
 		if err := fsys.Serve(e.Response, e.Request, e.ServedPath, e.ServedName); err != nil {
 			return e.NotFoundError("", err)
 		}
@@ -207,6 +295,15 @@ func (api *fileApi) createThumb(
 	thumbPath string,
 	thumbSize string,
 ) error {
+	return api.generateThumb(e, thumbPath, func() error {
+		return fsys.CreateThumb(originalPath, thumbPath, thumbSize)
+	})
+}
+
+// generateThumb runs the generate func that creates the thumb (or transformed image)
+// at thumbPath location, limiting the number of concurrent generation processes
+// and deduplicating the ones for the same thumbPath.
+func (api *fileApi) generateThumb(e *core.RequestEvent, thumbPath string, generate func() error) error {
 	ch := api.thumbGenPending.DoChan(thumbPath, func() (any, error) {
 		ctx, cancel := context.WithTimeout(e.Request.Context(), api.thumbGenMaxWait)
 		defer cancel()
@@ -216,7 +313,7 @@ func (api *fileApi) createThumb(
 		}
 		defer api.thumbGenSem.Release(1)
 
-		return nil, fsys.CreateThumb(originalPath, thumbPath, thumbSize)
+		return nil, generate()
 	})
 
 	res := <-ch
@@ -225,3 +322,31 @@ func (api *fileApi) createThumb(
 
 	return res.Err
 }
+
+// checkImageTransformAccess checks whether the image transform is allowed
+// to be applied to the file at fileKey location.
+//
+// A transform is allowed for superusers, if it is part of the configured
+// allowlist or if the request has a valid "sig" query parameter.
+func checkImageTransformAccess(
+	e *core.RequestEvent,
+	config core.ImageTransformsConfig,
+	transform *filesystem.ImageTransform,
+	fileKey string,
+) error {
+	maxDimension := config.GetMaxDimension()
+	if transform.Width > maxDimension || transform.Height > maxDimension {
+		return e.BadRequestError(fmt.Sprintf("The image transform width and height must be less than or equal to %d.", maxDimension), nil)
+	}
+
+	if e.HasSuperuserAuth() || config.IsAllowed(*transform) {
+		return nil
+	}
+
+	sig := e.Request.URL.Query().Get("sig")
+	if config.Secret != "" && sig != "" && security.Equal(sig, transform.Signature(fileKey, config.Secret)) {
+		return nil
+	}
+
+	return e.ForbiddenError("The image transform is not allowed.", nil)
+}
@@ -0,0 +1,327 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
+	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
+	"github.com/thewandererbg/pgbase/tools/list"
+	"github.com/thewandererbg/pgbase/tools/search"
+	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// presignedUploadExpiry is the expiration duration of the presigned file upload urls.
+const presignedUploadExpiry = 1 * time.Hour
+
+// filePresign creates a new upload for the specified collection file field
+// and returns a presigned url that could be used to upload the file
+// directly to the storage service (e.g. S3) without going through the app.
+//
+// The collection create rule (or the update rule if a target record id is
+// specified) is checked against the request before creating the upload
+// and the upload is bound to the file field so that it can't be
+// finalized or attached to another field with looser limits.
+//
+// Once the file is uploaded the client must call [filePresignFinalize]
+// and then attach the upload to a record via its "@upload:ID" reference.
+func filePresign(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	if collection.IsView() {
+		return e.BadRequestError("Unsupported collection type.", nil)
+	}
+
+	if !e.HasSuperuserAuth() && collection.CreateRule == nil && collection.UpdateRule == nil {
+		return e.ForbiddenError("Only superusers can perform this action.", nil)
+	}
+
+	form := &filePresignForm{collection: collection}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	if err = checkPresignRecordAccess(e, collection, form.Record); err != nil {
+		return err
+	}
+
+	fsys, err := e.App.NewFilesystem()
+	if err != nil {
+		return e.InternalServerError("Filesystem initialization failure.", err)
+	}
+	defer fsys.Close()
+
+	upload, err := e.App.CreateFieldUpload(e.Auth.Collection().Id, e.Auth.Id, collection.Id, form.Field, form.Filename, form.Size)
+	if err != nil {
+		return e.InternalServerError("Failed to create upload.", err)
+	}
+
+	url, err := fsys.SignedURL(upload.FileKey(), &blob.SignedURLOptions{
+		Method:        http.MethodPut,
+		Expiry:        presignedUploadExpiry,
+		ContentType:   form.ContentType,
+		ContentLength: form.Size,
+	})
+	if err != nil {
+		if deleteErr := e.App.AuxDelete(upload); deleteErr != nil {
+			err = errors.Join(err, deleteErr)
+		}
+
+		if errors.Is(err, errors.ErrUnsupported) {
+			return e.BadRequestError("The configured files storage doesn't support presigned uploads.", err)
+		}
+
+		return e.InternalServerError("Failed to generate the presigned upload url.", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"upload": upload.Id,
+		"ref":    UploadRefPrefix + upload.Id,
+		"url":    url,
+		"method": http.MethodPut,
+		"headers": map[string]string{
+			"Content-Type":   form.ContentType,
+			"Content-Length": strconv.FormatInt(form.Size, 10),
+		},
+		"expires": types.NowDateTime().Add(presignedUploadExpiry),
+	})
+}
+
+// filePresignFinalize checks the directly uploaded file of a presigned
+// upload and marks the upload as completed so that it could be attached
+// to a record with the regular create/update record endpoints.
+func filePresignFinalize(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	form := &filePresignFinalizeForm{collection: collection}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	id := e.Request.PathValue("id")
+	if !uploadLocks.lock(id) {
+		return e.Error(http.StatusLocked, "The upload is currently being modified by another request.", nil)
+	}
+	defer uploadLocks.unlock(id)
+
+	upload, err := findRequestUpload(e)
+	if err != nil {
+		return err
+	}
+
+	if !upload.CanAttachTo(collection.Id, form.Field) {
+		return e.BadRequestError("An error occurred while validating the submitted data.", validation.Errors{
+			"field": validation.NewError("validation_upload_field_mismatch", "The upload is bound to a different file field."),
+		})
+	}
+
+	if !upload.IsCompleted() {
+		fsys, err := e.App.NewFilesystem()
+		if err != nil {
+			return e.InternalServerError("Filesystem initialization failure.", err)
+		}
+		defer fsys.Close()
+
+		attrs, err := fsys.Attributes(upload.FileKey())
+		if err != nil {
+			return e.BadRequestError("Missing uploaded file.", err)
+		}
+
+		field, _ := collection.Fields.GetByName(form.Field).(*core.FileField)
+
+		var invalidErr error
+		if attrs.Size != upload.Size {
+			invalidErr = validation.NewError("validation_upload_size_mismatch", "The uploaded file size doesn't match with the upload size.")
+		} else if len(field.MimeTypes) > 0 && !list.ExistInSlice(filesystem.BaseContentType(attrs.ContentType), field.MimeTypes) {
+			invalidErr = validation.NewError("validation_invalid_mime_type", "The uploaded file type is not allowed.")
+		}
+
+		if invalidErr != nil {
+			// delete the invalid object so that the client could retry the upload
+			if err := fsys.Delete(upload.FileKey()); err != nil {
+				e.App.Logger().Warn("Failed to delete invalid presigned upload file", "uploadId", upload.Id, "error", err)
+			}
+
+			return e.BadRequestError("Invalid uploaded file.", validation.Errors{"file": invalidErr})
+		}
+
+		err = e.App.AdvanceUploadOffset(upload, upload.Size)
+		if err != nil {
+			if errors.Is(err, core.ErrUploadOffsetMismatch) {
+				return e.Error(http.StatusConflict, "The upload offset was modified by another request.", nil)
+			}
+			return e.InternalServerError("Failed to finalize the upload.", err)
+		}
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"upload": upload.Id,
+		"ref":    UploadRefPrefix + upload.Id,
+	})
+}
+
+// -------------------------------------------------------------------
+
+type filePresignForm struct {
+	collection *core.Collection
+
+	Field       string `form:"field" json:"field"`
+	Record      string `form:"record" json:"record"`
+	Filename    string `form:"filename" json:"filename"`
+	ContentType string `form:"contentType" json:"contentType"`
+	Size        int64  `form:"size" json:"size"`
+}
+
+func (form *filePresignForm) validate() error {
+	field, _ := form.collection.Fields.GetByName(form.Field).(*core.FileField)
+
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Field, validation.Required, validation.By(validateFileFieldName(field))),
+		validation.Field(&form.Filename, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.ContentType, validation.Required, validation.By(func(value any) error {
+			if field == nil || len(field.MimeTypes) == 0 {
+				return nil
+			}
+
+			if !list.ExistInSlice(filesystem.BaseContentType(value.(string)), field.MimeTypes) {
+				return validation.NewError("validation_invalid_mime_type", "The file type is not allowed.")
+			}
+
+			return nil
+		})),
+		validation.Field(&form.Size, validation.Required, validation.By(func(value any) error {
+			if field == nil {
+				return nil
+			}
+
+			maxSize := field.MaxSize
+			if maxSize <= 0 {
+				maxSize = core.DefaultFileFieldMaxSize
+			}
+
+			return validation.Max(maxSize).Validate(value)
+		})),
+	)
+}
+
+// checkPresignRecordAccess checks whether the current request could
+// create a new collection record or update the one with the specified id.
+//
+// The create rule is checked against an empty record because
+// the actual record data is not known yet (it is checked again
+// with the regular create record request).
+func checkPresignRecordAccess(e *core.RequestEvent, collection *core.Collection, recordId string) error {
+	if e.HasSuperuserAuth() {
+		return nil
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	if recordId != "" {
+		if collection.UpdateRule == nil {
+			return e.ForbiddenError("Only superusers can perform this action.", nil)
+		}
+
+		ruleFunc := func(q *dbx.SelectQuery) error {
+			if *collection.UpdateRule == "" {
+				return nil
+			}
+
+			resolver := core.NewRecordFieldResolver(e.App, collection, requestInfo, true)
+			expr, err := search.FilterData(*collection.UpdateRule).BuildExpr(resolver)
+			if err != nil {
+				return err
+			}
+			resolver.UpdateQuery(q)
+			q.AndWhere(expr)
+
+			return nil
+		}
+
+		if _, err := e.App.FindRecordById(collection, recordId, ruleFunc); err != nil {
+			return firstApiError(err, e.NotFoundError("", err))
+		}
+
+		return nil
+	}
+
+	if collection.CreateRule == nil {
+		return e.ForbiddenError("Only superusers can perform this action.", nil)
+	}
+
+	if *collection.CreateRule == "" {
+		return nil
+	}
+
+	dummyRecord := core.NewRecord(collection)
+	dummyRecord.Id = "__temp_id__" + security.PseudorandomString(6)
+
+	newQuery, dummyCollection, err := newRecordSnapshotQuery(e.App, dummyRecord)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	query := newQuery()
+
+	resolver := core.NewRecordFieldResolver(e.App, dummyCollection, requestInfo, true)
+	expr, err := search.FilterData(*collection.CreateRule).BuildExpr(resolver)
+	if err != nil {
+		return e.ForbiddenError("", err)
+	}
+	query.AndWhere(expr)
+
+	resolver.UpdateQuery(query)
+
+	var exists int
+	err = query.Limit(1).Row(&exists)
+	if err != nil || exists == 0 {
+		return e.ForbiddenError("The authorized record is not allowed to perform this action.", err)
+	}
+
+	return nil
+}
+
+type filePresignFinalizeForm struct {
+	collection *core.Collection
+
+	Field string `form:"field" json:"field"`
+}
+
+func (form *filePresignFinalizeForm) validate() error {
+	field, _ := form.collection.Fields.GetByName(form.Field).(*core.FileField)
+
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Field, validation.Required, validation.By(validateFileFieldName(field))),
+	)
+}
+
+func validateFileFieldName(field *core.FileField) validation.RuleFunc {
+	return func(value any) error {
+		if field == nil {
+			return validation.NewError("validation_invalid_file_field", "Missing or invalid file field.")
+		}
+
+		return nil
+	}
+}
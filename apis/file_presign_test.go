@@ -0,0 +1,458 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+func TestFilePresign(t *testing.T) {
+	t.Parallel()
+
+	enableS3 := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		app.Settings().S3 = core.S3Config{
+			Enabled:   true,
+			Bucket:    "test_bucket",
+			Region:    "test_region",
+			Endpoint:  "https://example.com",
+			AccessKey: "test_key",
+			Secret:    "test_secret",
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodPost,
+			URL:             "/api/files/demo3/presign",
+			Body:            strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":10}`),
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "missing collection",
+			Method: http.MethodPost,
+			URL:    "/api/files/missing/presign",
+			Body:   strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "view collection",
+			Method: http.MethodPost,
+			URL:    "/api/files/view1/presign",
+			Body:   strings.NewReader(`{"field":"file_many","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "collection with superusers only create and update rules",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection, err := app.FindCollectionByNameOrId("demo3")
+				if err != nil {
+					t.Fatal(err)
+				}
+				collection.CreateRule = nil
+				collection.UpdateRule = nil
+				if err := app.UnsafeWithoutHooks().Save(collection); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "auth record failing the create rule",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableS3(t, app, e)
+				setPresignTestRules(t, app, types.Pointer(`@request.auth.collectionName = "demo3"`), nil)
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "auth record failing the update rule of the target record",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","record":"7nwo8tuiatetxdm","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableS3(t, app, e)
+				setPresignTestRules(t, app, types.Pointer(""), types.Pointer(`title = "missing"`))
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "auth record passing the update rule of the target record",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","record":"7nwo8tuiatetxdm","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableS3(t, app, e)
+				setPresignTestRules(t, app, types.Pointer(`@request.auth.collectionName = "demo3"`), types.Pointer(`@request.auth.id != ""`))
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"ref":"@upload:`},
+			ExpectedEvents: map[string]int{
+				"*":                         0,
+				"OnModelCreate":             1,
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelValidate":           1,
+			},
+		},
+		{
+			Name:   "invalid data",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"title","filename":"","contentType":"","size":999999999}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"field":{"code":"validation_invalid_file_field"`,
+				`"filename":{"code":"validation_required"`,
+				`"contentType":{"code":"validation_required"`,
+			},
+			NotExpectedContent: []string{`"size"`},
+			ExpectedEvents:     map[string]int{"*": 0},
+		},
+		{
+			Name:   "size and mime type limits",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":999999999}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection, err := app.FindCollectionByNameOrId("demo3")
+				if err != nil {
+					t.Fatal(err)
+				}
+				collection.Fields.GetByName("files").(*core.FileField).MimeTypes = []string{"image/png"}
+				if err := app.UnsafeWithoutHooks().Save(collection); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"contentType":{"code":"validation_invalid_mime_type"`,
+				`"size":{"code":"validation_max_less_equal_than_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "local files storage",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents: map[string]int{
+				"*":                         0,
+				"OnModelCreate":             1,
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelValidate":           1,
+				"OnModelDelete":             1,
+				"OnModelDeleteExecute":      1,
+				"OnModelAfterDeleteSuccess": 1,
+			},
+		},
+		{
+			Name:   "S3 files storage",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign",
+			Body:   strings.NewReader(`{"field":"files","filename":"test.txt","contentType":"text/plain","size":10}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableS3(t, app, e)
+				setPresignTestRules(t, app, types.Pointer(`@request.auth.id != ""`), nil)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				upload := &core.Upload{}
+				if err := app.UploadQuery().OrderBy("created DESC").Limit(1).One(upload); err != nil {
+					t.Fatal(err)
+				}
+
+				if upload.FieldName != "files" || upload.FieldCollectionRef == "" {
+					t.Fatalf("Expected the upload to be bound to the files field, got %q %q", upload.FieldCollectionRef, upload.FieldName)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"upload":"`,
+				`"ref":"@upload:`,
+				`"url":"https://test_bucket.example.com/.uploads/`,
+				`X-Amz-SignedHeaders=content-length%3Bcontent-type%3Bhost`,
+				`"method":"PUT"`,
+				`"headers":{"Content-Length":"10","Content-Type":"text/plain"}`,
+				`"expires":"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                         0,
+				"OnModelCreate":             1,
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelValidate":           1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestFilePresignFinalize(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodPost,
+			URL:             "/api/files/demo3/presign/test_upload",
+			Body:            strings.NewReader(`{"field":"files"}`),
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid field",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"title"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 0, time.Hour)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"field":{"code":"validation_invalid_file_field"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "upload of another auth record",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				createTestUpload(t, app, "test_upload", "_pb_users_auth_", "oap640cot4yru2s", 4, 0, time.Hour)
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "upload bound to another file field",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				upload := createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 0, time.Hour)
+				upload.FieldCollectionRef = "wsmn24bux7wo113"
+				upload.FieldName = "files"
+				if err := app.AuxSave(upload); err != nil {
+					t.Fatal(err)
+				}
+				uploadTestFile(t, app, upload.FileKey(), "test")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"field":{"code":"validation_upload_field_mismatch"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "missing uploaded file",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 0, time.Hour)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "uploaded file size mismatch",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				upload := createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 0, time.Hour)
+				uploadTestFile(t, app, upload.FileKey(), "test123")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				upload, err := app.FindUploadById("test_upload")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if upload.IsCompleted() {
+					t.Fatal("Expected the upload to remain incomplete")
+				}
+
+				fsys, err := app.NewFilesystem()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer fsys.Close()
+
+				if exists, _ := fsys.Exists(upload.FileKey()); exists {
+					t.Fatal("Expected the invalid uploaded file to be deleted")
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"file":{"code":"validation_upload_size_mismatch"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "uploaded file mime type mismatch",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection, err := app.FindCollectionByNameOrId("demo3")
+				if err != nil {
+					t.Fatal(err)
+				}
+				collection.Fields.GetByName("files").(*core.FileField).MimeTypes = []string{"image/png"}
+				if err := app.UnsafeWithoutHooks().Save(collection); err != nil {
+					t.Fatal(err)
+				}
+
+				upload := createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 0, time.Hour)
+				uploadTestFile(t, app, upload.FileKey(), "test")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"file":{"code":"validation_invalid_mime_type"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "valid uploaded file",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				upload := createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 0, time.Hour)
+				uploadTestFile(t, app, upload.FileKey(), "test")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				upload, err := app.FindUploadById("test_upload")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !upload.IsCompleted() {
+					t.Fatalf("Expected the upload to be completed, got offset %d", upload.Offset)
+				}
+
+				checkUploadFile(t, app, upload.FileKey(), "test")
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"upload":"test_upload"`,
+				`"ref":"@upload:test_upload"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "already completed upload",
+			Method: http.MethodPost,
+			URL:    "/api/files/demo3/presign/test_upload",
+			Body:   strings.NewReader(`{"field":"files"}`),
+			Headers: map[string]string{
+				"Authorization": uploadsTestUserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				createTestUpload(t, app, "test_upload", "_pb_users_auth_", "4q1xlclmfloku33", 4, 4, time.Hour)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"upload":"test_upload"`,
+				`"ref":"@upload:test_upload"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+// setPresignTestRules replaces the demo3 collection create and update rules.
+func setPresignTestRules(t testing.TB, app *tests.TestApp, createRule *string, updateRule *string) {
+	collection, err := app.FindCollectionByNameOrId("demo3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.CreateRule = createRule
+	collection.UpdateRule = updateRule
+
+	if err := app.UnsafeWithoutHooks().Save(collection); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -7,12 +7,15 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/pocketbase/dbx"
 	"github.com/thewandererbg/pgbase/apis"
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
 	"github.com/thewandererbg/pgbase/tools/types"
 )
 
@@ -144,6 +147,14 @@ func TestFileDownload(t *testing.T) {
 		t.Fatal(thumbErr)
 	}
 
+	// mock S3 server responding only to the HEAD object attributes requests
+	s3Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", "10")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s3Server.Close()
+
 	scenarios := []tests.ApiScenario{
 		{
 			Name:            "missing collection",
@@ -379,6 +390,253 @@ func TestFileDownload(t *testing.T) {
 				"OnFileDownloadRequest": 1,
 			},
 		},
+		{
+			Name:   "S3 presigned downloads",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?download=1",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().S3 = core.S3Config{
+					Enabled:            true,
+					Bucket:             "test_bucket",
+					Region:             "test_region",
+					Endpoint:           s3Server.URL,
+					AccessKey:          "test_key",
+					Secret:             "test_secret",
+					ForcePathStyle:     true,
+					PresignedDownloads: true,
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				location := res.Header.Get("Location")
+
+				expectedParts := []string{
+					s3Server.URL + "/test_bucket/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?",
+					"X-Amz-Expires=600",
+					"X-Amz-Signature=",
+					"response-content-disposition=attachment%3B%20filename%3D300_1SEi6Q6U72.png",
+					"response-content-type=image%2Fpng",
+				}
+				for _, part := range expectedParts {
+					if !strings.Contains(location, part) {
+						t.Fatalf("Expected %q in the Location header, got %q", part, location)
+					}
+				}
+
+				if v := res.Header.Get("Cache-Control"); v != "private, no-store" {
+					t.Fatalf("Expected Cache-Control %q, got %q", "private, no-store", v)
+				}
+			},
+			ExpectedStatus:  307,
+			ExpectedContent: []string{"Temporary Redirect"},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+
+		// image transforms
+		// -----------------------------------------------------------
+		{
+			Name:            "image transform with disabled transforms (should ignore the params)",
+			Method:          http.MethodGet,
+			URL:             "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=abc",
+			ExpectedStatus:  200,
+			ExpectedContent: []string{string(testImg)},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+		{
+			Name:   "image transform with invalid params",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=abc",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "image transform exceeding the max dimension",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=101",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+				app.Settings().ImageTransforms.Allowed = []string{"w=101"}
+				app.Settings().ImageTransforms.MaxDimension = 100
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "image transform that is not allowed",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=50&sig=invalid",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+				app.Settings().ImageTransforms.Allowed = []string{"w=50&h=50"}
+				app.Settings().ImageTransforms.Secret = strings.Repeat("a", 32)
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "allowed image transform",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?h=50&w=50&fit=cover",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+				app.Settings().ImageTransforms.Allowed = []string{"w=50&h=50"}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectedName := "w50-h50_300_1SEi6Q6U72.png"
+				if v := res.Header.Get("Content-Disposition"); !strings.Contains(v, expectedName) {
+					t.Fatalf("Expected served file %q, got %q", expectedName, v)
+				}
+
+				fsys, err := app.NewFilesystem()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer fsys.Close()
+
+				if exists, _ := fsys.Exists("_pb_users_auth_/4q1xlclmfloku33/thumbs_300_1SEi6Q6U72.png/" + expectedName); !exists {
+					t.Fatal("Expected the transformed image to be cached in the thumbs prefix")
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{"PNG"},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+		{
+			Name:   "signed image transform",
+			Method: http.MethodGet,
+			URL: "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=40&format=jpg&q=70&sig=" +
+				filesystem.ImageTransform{Width: 40, Format: "jpeg", Quality: 70}.Signature("_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png", strings.Repeat("a", 32)),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+				app.Settings().ImageTransforms.Secret = strings.Repeat("a", 32)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if v := res.Header.Get("Content-Type"); v != "image/jpeg" {
+					t.Fatalf("Expected image/jpeg Content-Type, got %q", v)
+				}
+
+				if v := res.Header.Get("Content-Disposition"); !strings.Contains(v, "w40-q70_300_1SEi6Q6U72.jpg") {
+					t.Fatalf("Expected jpg served file, got %q", v)
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{"JFIF"},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+		{
+			Name:   "superuser image transform with Accept header negotiation",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=30&format=auto",
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+				"Accept":        "image/avif,image/webp,image/jpeg",
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if v := res.Header.Get("Content-Type"); v != "image/jpeg" {
+					t.Fatalf("Expected image/jpeg Content-Type, got %q", v)
+				}
+
+				if v := res.Header.Get("Vary"); !strings.Contains(v, "Accept") {
+					t.Fatalf("Expected Vary Accept header, got %q", v)
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{"JFIF"},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+		{
+			Name:   "superuser image transform with webp format (should fallback to a negotiated format)",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png?w=30&format=webp",
+			Headers: map[string]string{
+				"Authorization": uploadsTestSuperuserToken,
+				"Accept":        "image/webp,image/jpeg",
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if v := res.Header.Get("Content-Type"); v != "image/jpeg" {
+					t.Fatalf("Expected image/jpeg Content-Type, got %q", v)
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{"JFIF"},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+		{
+			Name:   "image transform of a non-image file (should fallback to the original)",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/oap640cot4yru2s/test_kfd2wYLxkz.txt?w=30",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().ImageTransforms.Enabled = true
+				app.Settings().ImageTransforms.Allowed = []string{"w=30"}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{string(testFile)},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
+		{
+			Name:   "deduplicated file (should serve the referenced blob)",
+			Method: http.MethodGet,
+			URL:    "/api/files/_pb_users_auth_/oap640cot4yru2s/test_kfd2wYLxkz.txt",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				fsys, err := app.NewFilesystem()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer fsys.Close()
+
+				if err := fsys.Upload([]byte("deduplicated content"), core.FileBlobKey("testhash")); err != nil {
+					t.Fatal(err)
+				}
+
+				_, err = app.DB().Insert(core.FileRefsTableName, dbx.Params{
+					"collectionRef": "_pb_users_auth_",
+					"recordRef":     "oap640cot4yru2s",
+					"filename":      "test_kfd2wYLxkz.txt",
+					"hash":          "testhash",
+				}).Execute()
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{"deduplicated content"},
+			ExpectedEvents: map[string]int{
+				"*":                     0,
+				"OnFileDownloadRequest": 1,
+			},
+		},
 
 		// rate limit checks
 		// -----------------------------------------------------------
@@ -0,0 +1,23 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/router"
+)
+
+// bindJWKSApi registers the public JSON Web Key Set endpoint.
+func bindJWKSApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	rg.GET("/.well-known/jwks.json", jwksList)
+}
+
+// jwksList returns the public keys of all configured auth token signing keys
+// so that external services could verify the issued auth tokens offline.
+func jwksList(e *core.RequestEvent) error {
+	e.Response.Header().Set("Cache-Control", "public, max-age=300")
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"keys": e.App.Settings().SigningKeys.JWKS(),
+	})
+}
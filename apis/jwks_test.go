@@ -0,0 +1,85 @@
+package apis_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+func TestJWKSList(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "no signing keys",
+			Method:          http.MethodGet,
+			URL:             "/.well-known/jwks.json",
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`{"keys":[]}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "with active and rotated signing keys",
+			Method: http.MethodGet,
+			URL:    "/.well-known/jwks.json",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				keys := &app.Settings().SigningKeys
+
+				if _, err := keys.Rotate("_pb_users_auth_", security.JWTAlgRS256); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := keys.Rotate("_pb_users_auth_", security.JWTAlgEdDSA); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"kty":"RSA"`,
+				`"alg":"RS256"`,
+				`"kty":"OKP"`,
+				`"alg":"EdDSA"`,
+			},
+			NotExpectedContent: []string{
+				"PRIVATE KEY",
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				result := struct {
+					Keys []security.JWK `json:"keys"`
+				}{}
+				if err := json.Unmarshal(body, &result); err != nil {
+					t.Fatal(err)
+				}
+
+				if len(result.Keys) != 2 {
+					t.Fatalf("Expected 2 keys, got %d", len(result.Keys))
+				}
+
+				for _, jwk := range result.Keys {
+					if _, ok := app.Settings().SigningKeys.FindKey(jwk.Kid); !ok {
+						t.Fatalf("Missing signing key %q", jwk.Kid)
+					}
+
+					if _, err := jwk.PublicKey(); err != nil {
+						t.Fatalf("Failed to load JWK %q public key: %v", jwk.Kid, err)
+					}
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
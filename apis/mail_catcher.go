@@ -0,0 +1,79 @@
+package apis
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/mailer"
+	"github.com/thewandererbg/pgbase/tools/router"
+)
+
+// bindMailCatcherApi registers the dev mail catcher api endpoints.
+func bindMailCatcherApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/mails/catcher").Bind(RequireSuperuserAuth())
+	sub.GET("", mailCatcherList)
+	sub.DELETE("", mailCatcherClear)
+	sub.GET("/{id}", mailCatcherView)
+	sub.DELETE("/{id}", mailCatcherDelete)
+	sub.GET("/{id}/attachments/{name}", mailCatcherAttachment)
+}
+
+type mailCatcherListResponse struct {
+	Items []*mailer.CapturedMessage `json:"items"`
+
+	// Enabled indicates whether the new app mail messages are
+	// currently stored in the catcher (aka. dev mode + enabled setting).
+	Enabled bool `json:"enabled"`
+}
+
+func mailCatcherList(e *core.RequestEvent) error {
+	return e.JSON(http.StatusOK, mailCatcherListResponse{
+		Items:   e.App.MailCatcher().Messages(),
+		Enabled: e.App.IsDev() && e.App.Settings().MailCatcher.Enabled,
+	})
+}
+
+func mailCatcherView(e *core.RequestEvent) error {
+	message, ok := e.App.MailCatcher().Message(e.Request.PathValue("id"))
+	if !ok {
+		return e.NotFoundError("", nil)
+	}
+
+	return e.JSON(http.StatusOK, message)
+}
+
+func mailCatcherAttachment(e *core.RequestEvent) error {
+	message, ok := e.App.MailCatcher().Message(e.Request.PathValue("id"))
+	if !ok {
+		return e.NotFoundError("", nil)
+	}
+
+	attachment, ok := message.Attachment(e.Request.PathValue("name"))
+	if !ok {
+		return e.NotFoundError("", nil)
+	}
+
+	// always serve for download and sandbox the content
+	// since it is not trusted (similar to the regular files serve)
+	e.Response.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": attachment.Name,
+	}))
+	e.Response.Header().Set("Content-Security-Policy", "default-src 'none'; media-src 'self'; style-src 'unsafe-inline'; sandbox")
+
+	return e.Blob(http.StatusOK, attachment.ContentType, attachment.Content)
+}
+
+func mailCatcherDelete(e *core.RequestEvent) error {
+	if !e.App.MailCatcher().Delete(e.Request.PathValue("id")) {
+		return e.NotFoundError("", nil)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func mailCatcherClear(e *core.RequestEvent) error {
+	e.App.MailCatcher().Clear()
+
+	return e.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,294 @@
+package apis_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/mailer"
+)
+
+// stubMailCatcher stores a catcher message for each of the provided
+// subjects with ids "catcher_<subject>".
+func stubMailCatcher(t testing.TB, app *tests.TestApp, subjects ...string) {
+	for _, subject := range subjects {
+		err := app.MailCatcher().Send(&mailer.Message{
+			Subject: subject,
+			HTML:    "<p>" + subject + "</p>",
+			Attachments: map[string]io.Reader{
+				"test.txt": strings.NewReader("test"),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app.MailCatcher().Messages()[0].Id = "catcher_" + subject
+	}
+}
+
+func TestMailCatcherList(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodGet,
+			URL:             "/api/mails/catcher",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as regular user",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher",
+			Headers: map[string]string{
+				"Authorization": mailOutboxUserToken,
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (empty)",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"items":[]`,
+				`"enabled":false`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a", "b")
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"items":[{"id":"catcher_b"`,
+				`{"id":"catcher_a"`,
+				`"attachments":[{"name":"test.txt","contentType":"text/plain; charset=utf-8","size":4,"inline":false}]`,
+				`"enabled":false`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestMailCatcherView(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodGet,
+			URL:             "/api/mails/catcher/catcher_a",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (missing message)",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher/missing",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a")
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher/catcher_a",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a", "b")
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"id":"catcher_a"`,
+				`"subject":"a"`,
+				`"html":"\u003cp\u003ea\u003c/p\u003e"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestMailCatcherAttachment(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodGet,
+			URL:             "/api/mails/catcher/catcher_a/attachments/test.txt",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (missing attachment)",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher/catcher_a/attachments/missing.txt",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a")
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser",
+			Method: http.MethodGet,
+			URL:    "/api/mails/catcher/catcher_a/attachments/test.txt",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if v := res.Header.Get("Content-Disposition"); v != `attachment; filename=test.txt` {
+					t.Fatalf("Expected attachment Content-Disposition, got %q", v)
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{"test"},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestMailCatcherDelete(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodDelete,
+			URL:             "/api/mails/catcher/catcher_a",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (missing message)",
+			Method: http.MethodDelete,
+			URL:    "/api/mails/catcher/missing",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser",
+			Method: http.MethodDelete,
+			URL:    "/api/mails/catcher/catcher_a",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a", "b")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				messages := app.MailCatcher().Messages()
+				if len(messages) != 1 || messages[0].Id != "catcher_b" {
+					t.Fatalf("Expected only catcher_b to remain, got %v", messages)
+				}
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestMailCatcherClear(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodDelete,
+			URL:             "/api/mails/catcher",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as regular user",
+			Method: http.MethodDelete,
+			URL:    "/api/mails/catcher",
+			Headers: map[string]string{
+				"Authorization": mailOutboxUserToken,
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser",
+			Method: http.MethodDelete,
+			URL:    "/api/mails/catcher",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailCatcher(t, app, "a", "b")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if total := len(app.MailCatcher().Messages()); total != 0 {
+					t.Fatalf("Expected no messages, got %d", total)
+				}
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,110 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/router"
+	"github.com/thewandererbg/pgbase/tools/search"
+)
+
+// bindMailOutboxApi registers the mail outbox management api endpoints.
+func bindMailOutboxApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/mails/outbox").Bind(RequireSuperuserAuth())
+	sub.GET("", mailOutboxList)
+	sub.DELETE("", mailOutboxPurge)
+	sub.GET("/{id}", mailOutboxView)
+	sub.POST("/{id}/retry", mailOutboxRetry)
+	sub.DELETE("/{id}", mailOutboxDelete)
+}
+
+var mailOutboxFilterFields = []string{
+	"id", "created", "updated", "status", "attempts", "nextAttempt", "lastError", "message",
+	`^message\.[\w\.\:]*\w+$`,
+}
+
+func mailOutboxList(e *core.RequestEvent) error {
+	fieldResolver := search.NewSimpleFieldResolver(mailOutboxFilterFields...)
+
+	result, err := search.NewProvider(fieldResolver).
+		Query(e.App.MailOutboxQuery()).
+		ParseAndExec(e.Request.URL.Query().Encode(), &[]*core.MailOutboxMessage{})
+
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func mailOutboxView(e *core.RequestEvent) error {
+	message, err := findMailOutboxMessage(e)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, message)
+}
+
+func mailOutboxRetry(e *core.RequestEvent) error {
+	message, err := findMailOutboxMessage(e)
+	if err != nil {
+		return err
+	}
+
+	if err := e.App.RetryMailOutboxMessage(message); err != nil {
+		return e.BadRequestError("Failed to retry the mail outbox message.", err)
+	}
+
+	return e.JSON(http.StatusOK, message)
+}
+
+func mailOutboxDelete(e *core.RequestEvent) error {
+	message, err := findMailOutboxMessage(e)
+	if err != nil {
+		return err
+	}
+
+	if err := e.App.Delete(message); err != nil {
+		return e.BadRequestError("Failed to delete the mail outbox message.", err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func mailOutboxPurge(e *core.RequestEvent) error {
+	fieldResolver := search.NewSimpleFieldResolver(mailOutboxFilterFields...)
+
+	filter := e.Request.URL.Query().Get(search.FilterQueryParam)
+
+	var expr dbx.Expression
+	if filter != "" {
+		var err error
+		expr, err = search.FilterData(filter).BuildExpr(fieldResolver)
+		if err != nil {
+			return e.BadRequestError("Invalid filter format.", err)
+		}
+	}
+
+	deleted, err := e.App.PurgeMailOutbox(expr)
+	if err != nil {
+		return e.BadRequestError("Failed to purge the mail outbox.", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{"deleted": deleted})
+}
+
+func findMailOutboxMessage(e *core.RequestEvent) (*core.MailOutboxMessage, error) {
+	id := e.Request.PathValue("id")
+	if id == "" {
+		return nil, e.NotFoundError("", nil)
+	}
+
+	message, err := e.App.FindMailOutboxMessageById(id)
+	if err != nil || message == nil {
+		return nil, e.NotFoundError("", err)
+	}
+
+	return message, nil
+}
@@ -0,0 +1,45 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/mails"
+	"github.com/thewandererbg/pgbase/tools/router"
+)
+
+// bindMailTemplateApi registers the mail templates api endpoints.
+//
+// Note that the mail templates CRUD is handled by the regular
+// records api of the system mailTemplates collection.
+func bindMailTemplateApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/mails/templates").Bind(RequireSuperuserAuth())
+	sub.POST("/{name}/preview", mailTemplatePreview)
+}
+
+type mailTemplatePreviewForm struct {
+	// Data is the optional template data.
+	//
+	// The missing placeholder values are filled with their sample values.
+	Data map[string]any `form:"data" json:"data"`
+}
+
+func mailTemplatePreview(e *core.RequestEvent) error {
+	name := e.Request.PathValue("name")
+
+	if _, err := e.App.FindMailTemplateByName(name); err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	form := new(mailTemplatePreviewForm)
+	if err := e.BindBody(form); err != nil {
+		return e.BadRequestError("An error occurred while loading the submitted data.", err)
+	}
+
+	rendered, err := mails.PreviewTemplate(e.App, name, form.Data)
+	if err != nil {
+		return e.BadRequestError("Failed to render the mail template.", err)
+	}
+
+	return e.JSON(http.StatusOK, rendered)
+}
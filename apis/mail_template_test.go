@@ -0,0 +1,103 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func stubMailTemplate(t testing.TB, app *tests.TestApp) {
+	mt := core.NewMailTemplate(app)
+	mt.SetName("welcome")
+	mt.SetType(core.MailTemplateTypeTemplate)
+	mt.SetSubject("Welcome {{.name}}")
+	mt.SetHTML("<p>Hello {{.name}}, you have {{.credits}} credits</p>")
+	mt.SetText("Hello {{.name}}")
+	mt.SetPlaceholders([]core.MailTemplatePlaceholder{
+		{Name: "name", Type: core.MailTemplatePlaceholderText, Required: true, Sample: "Sample"},
+		{Name: "credits", Type: core.MailTemplatePlaceholderNumber, Required: true},
+	})
+	if err := app.Save(mt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMailTemplatePreview(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthorized",
+			Method:          http.MethodPost,
+			URL:             "/api/mails/templates/welcome/preview",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as regular user",
+			Method: http.MethodPost,
+			URL:    "/api/mails/templates/welcome/preview",
+			Headers: map[string]string{
+				"Authorization": mailOutboxUserToken,
+			},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (missing template)",
+			Method: http.MethodPost,
+			URL:    "/api/mails/templates/missing/preview",
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (missing required placeholder without sample)",
+			Method: http.MethodPost,
+			URL:    "/api/mails/templates/welcome/preview",
+			Body:   strings.NewReader(`{"data":{"name":"John"}}`),
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailTemplate(t, app)
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{"credits":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "authorized as superuser (sample data)",
+			Method: http.MethodPost,
+			URL:    "/api/mails/templates/welcome/preview",
+			Body:   strings.NewReader(`{"data":{"credits":5}}`),
+			Headers: map[string]string{
+				"Authorization": mailOutboxSuperuserToken,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				stubMailTemplate(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"subject":"Welcome Sample"`,
+				`Hello Sample, you have 5 credits`,
+				`"text":"Hello Sample"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
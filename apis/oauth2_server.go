@@ -0,0 +1,426 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/list"
+	"github.com/thewandererbg/pgbase/tools/router"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+const (
+	oauth2ServerScopeOpenId  = "openid"
+	oauth2ServerScopeEmail   = "email"
+	oauth2ServerScopeProfile = "profile"
+)
+
+var oauth2ServerSupportedScopes = []string{
+	oauth2ServerScopeOpenId,
+	oauth2ServerScopeEmail,
+	oauth2ServerScopeProfile,
+}
+
+// bindOAuth2ServerApi registers the OAuth2/OpenID Connect authorization server api endpoints.
+func bindOAuth2ServerApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	rg.GET("/.well-known/openid-configuration", oauth2ServerDiscovery).BindFunc(checkOAuth2ServerEnabled)
+
+	subGroup := rg.Group("/api/oauth2").BindFunc(checkOAuth2ServerEnabled)
+	subGroup.GET("/authorize", oauth2ServerAuthorizeRedirect)
+	subGroup.POST("/authorize", oauth2ServerAuthorize).Bind(RequireAuth())
+	subGroup.POST("/token", oauth2ServerToken)
+	subGroup.GET("/userinfo", oauth2ServerUserInfo)
+}
+
+func checkOAuth2ServerEnabled(e *core.RequestEvent) error {
+	if !e.App.Settings().OAuth2Server.Enabled {
+		return e.NotFoundError("The OAuth2 server is not enabled.", nil)
+	}
+
+	return e.Next()
+}
+
+func oauth2ServerIssuer(app core.App) string {
+	return strings.TrimRight(app.Settings().Meta.AppURL, "/")
+}
+
+// oauth2ServerDiscovery returns the OpenID Connect provider metadata.
+func oauth2ServerDiscovery(e *core.RequestEvent) error {
+	issuer := oauth2ServerIssuer(e.App)
+
+	e.Response.Header().Set("Cache-Control", "public, max-age=300")
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/api/oauth2/authorize",
+		"token_endpoint":                        issuer + "/api/oauth2/token",
+		"userinfo_endpoint":                     issuer + "/api/oauth2/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{security.JWTAlgRS256, security.JWTAlgEdDSA},
+		"scopes_supported":                      oauth2ServerSupportedScopes,
+		"claims_supported":                      []string{"iss", "sub", "aud", "iat", "exp", "nonce", "email", "email_verified", "name", "preferred_username"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// -------------------------------------------------------------------
+
+// oauth2ServerAuthorizeRedirect validates the authorize request client
+// and forwards it (with its original query parameters) to the configured login and consent page.
+func oauth2ServerAuthorizeRedirect(e *core.RequestEvent) error {
+	query := e.Request.URL.Query()
+
+	form := &oauth2AuthorizeForm{
+		ClientId:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		ResponseType:        query.Get("response_type"),
+		Scope:               query.Get("scope"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	if _, err := form.loadClient(e.App); err != nil {
+		return firstApiError(err, e.BadRequestError("Invalid OAuth2 authorize request.", err))
+	}
+
+	loginURL, err := url.Parse(e.App.Settings().OAuth2Server.LoginURL)
+	if err != nil {
+		return e.InternalServerError("Invalid OAuth2 server login URL.", err)
+	}
+
+	loginQuery := loginURL.Query()
+	for k, v := range query {
+		loginQuery[k] = v
+	}
+	loginURL.RawQuery = loginQuery.Encode()
+
+	return e.Redirect(http.StatusTemporaryRedirect, loginURL.String())
+}
+
+// oauth2ServerAuthorize submits the authenticated user consent for the
+// authorize request and returns the client redirect url with the authorization code.
+func oauth2ServerAuthorize(e *core.RequestEvent) error {
+	form := &oauth2AuthorizeForm{}
+	if err := e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+
+	client, err := form.loadClient(e.App)
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	if e.Auth.Collection().Id != client.CollectionRef() {
+		return e.ForbiddenError("The authorized record is not allowed to perform this action.", nil)
+	}
+
+	event := new(core.OAuth2AuthorizeRequestEvent)
+	event.RequestEvent = e
+	event.Collection = e.Auth.Collection()
+	event.Client = client
+	event.Record = e.Auth
+	event.Scopes = form.scopes()
+	event.RedirectURI = form.RedirectURI
+	event.State = form.State
+	event.Approved = form.Approve
+
+	return e.App.OnOAuth2AuthorizeRequest().Trigger(event, func(e *core.OAuth2AuthorizeRequestEvent) error {
+		redirectURL, err := url.Parse(e.RedirectURI)
+		if err != nil {
+			return e.BadRequestError("Invalid redirect URI.", err)
+		}
+
+		query := redirectURL.Query()
+		if e.State != "" {
+			query.Set("state", e.State)
+		}
+
+		if !e.Approved {
+			query.Set("error", "access_denied")
+		} else {
+			code := core.NewOAuth2Code(e.App)
+			code.SetCollectionRef(e.Record.Collection().Id)
+			code.SetRecordRef(e.Record.Id)
+			code.SetClientRef(e.Client.Id)
+			code.SetRedirectURI(e.RedirectURI)
+			code.SetScopes(e.Scopes)
+			code.SetNonce(form.Nonce)
+			code.SetCodeChallenge(form.CodeChallenge)
+			plainCode := code.RefreshCode()
+
+			if err := e.App.Save(code); err != nil {
+				return e.InternalServerError("Failed to create OAuth2 authorization code.", err)
+			}
+
+			query.Set("code", plainCode)
+		}
+
+		redirectURL.RawQuery = query.Encode()
+
+		return e.JSON(http.StatusOK, map[string]any{"redirectURL": redirectURL.String()})
+	})
+}
+
+type oauth2AuthorizeForm struct {
+	ClientId            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	ResponseType        string `form:"response_type" json:"response_type"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Approve             bool   `form:"approve" json:"approve"`
+}
+
+// loadClient validates the form fields and returns the related OAuth2 client.
+func (form *oauth2AuthorizeForm) loadClient(app core.App) (*core.OAuth2Client, error) {
+	err := validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.RedirectURI, validation.Required),
+		validation.Field(&form.ResponseType, validation.Required, validation.In("code")),
+		validation.Field(&form.Nonce, validation.Length(0, 255)),
+		validation.Field(&form.CodeChallenge, validation.Length(0, 255)),
+		validation.Field(
+			&form.CodeChallengeMethod,
+			validation.When(form.CodeChallenge != "", validation.Required),
+			validation.In("S256"),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := app.FindOAuth2ClientById(form.ClientId)
+	if err != nil {
+		return nil, validation.Errors{"client_id": validation.NewError("validation_invalid_client", "Invalid or missing OAuth2 client.")}
+	}
+
+	if !client.HasRedirectURI(form.RedirectURI) {
+		return nil, validation.Errors{"redirect_uri": validation.NewError("validation_invalid_redirect_uri", "The redirect URI is not registered for the client.")}
+	}
+
+	if client.IsPublic() && form.CodeChallenge == "" {
+		return nil, validation.Errors{"code_challenge": validation.NewError("validation_required_code_challenge", "Public clients must use PKCE.")}
+	}
+
+	return client, nil
+}
+
+// scopes returns the list of the requested and supported scopes.
+func (form *oauth2AuthorizeForm) scopes() []string {
+	scopes := list.ToUniqueStringSlice(strings.Fields(form.Scope))
+
+	return slices.DeleteFunc(scopes, func(s string) bool {
+		return !slices.Contains(oauth2ServerSupportedScopes, s)
+	})
+}
+
+// -------------------------------------------------------------------
+
+// oauth2ServerToken exchanges a valid authorization code for an auth token
+// (and an ID token if the "openid" scope was requested).
+//
+// Errors are returned in the RFC 6749 format for better interoperability with the OAuth2 client libraries.
+func oauth2ServerToken(e *core.RequestEvent) error {
+	form := &oauth2TokenForm{}
+	if err := e.BindBody(form); err != nil {
+		return oauth2ServerError(e, http.StatusBadRequest, "invalid_request", "Failed to load the submitted data.")
+	}
+
+	if clientId, clientSecret, ok := e.Request.BasicAuth(); ok {
+		form.ClientId, _ = url.QueryUnescape(clientId)
+		form.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	if form.GrantType != "authorization_code" {
+		return oauth2ServerError(e, http.StatusBadRequest, "unsupported_grant_type", "Only the authorization_code grant type is supported.")
+	}
+
+	if form.Code == "" || form.RedirectURI == "" {
+		return oauth2ServerError(e, http.StatusBadRequest, "invalid_request", "Missing required code or redirect_uri parameter.")
+	}
+
+	client, err := e.App.FindOAuth2ClientById(form.ClientId)
+	if err != nil || (!client.IsPublic() && !client.ValidateSecret(form.ClientSecret)) {
+		return oauth2ServerError(e, http.StatusUnauthorized, "invalid_client", "Invalid client credentials.")
+	}
+
+	code, err := e.App.FindOAuth2CodeByCode(form.Code)
+	if err != nil {
+		return oauth2ServerError(e, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code.")
+	}
+
+	// authorization codes are single-use
+	if err := e.App.Delete(code); err != nil {
+		return oauth2ServerError(e, http.StatusInternalServerError, "server_error", "Failed to invalidate the authorization code.")
+	}
+
+	if code.ClientRef() != client.Id ||
+		code.RedirectURI() != form.RedirectURI ||
+		code.HasExpired(e.App.Settings().OAuth2Server.CodeDurationTime()) ||
+		!code.ValidateCodeVerifier(form.CodeVerifier) {
+		return oauth2ServerError(e, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code.")
+	}
+
+	record, err := e.App.FindRecordById(code.CollectionRef(), code.RecordRef())
+	if err != nil {
+		return oauth2ServerError(e, http.StatusBadRequest, "invalid_grant", "Missing authorization code auth record.")
+	}
+
+	scopes := code.Scopes()
+
+	event := new(core.OAuth2TokenRequestEvent)
+	event.RequestEvent = e
+	event.Collection = record.Collection()
+	event.Client = client
+	event.Code = code
+	event.Record = record
+	event.IdTokenClaims = oauth2ServerClaims(record, scopes)
+	event.IdTokenClaims["iss"] = oauth2ServerIssuer(e.App)
+	event.IdTokenClaims["aud"] = client.Id
+	event.IdTokenClaims["iat"] = time.Now().Unix()
+	if nonce := code.Nonce(); nonce != "" {
+		event.IdTokenClaims["nonce"] = nonce
+	}
+
+	return e.App.OnOAuth2TokenRequest().Trigger(event, func(e *core.OAuth2TokenRequestEvent) error {
+		accessToken, err := e.App.NewOAuth2AccessToken(e.Record, e.Client.Id, scopes)
+		if err != nil {
+			return oauth2ServerError(e.RequestEvent, http.StatusInternalServerError, "server_error", "Failed to create access token.")
+		}
+
+		result := map[string]any{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+			"expires_in":   e.Record.Collection().AuthToken.Duration,
+			"scope":        strings.Join(scopes, " "),
+		}
+
+		if slices.Contains(scopes, oauth2ServerScopeOpenId) {
+			idToken, err := oauth2ServerIdToken(e.App, e.Record.Collection(), e.IdTokenClaims)
+			if err != nil {
+				e.App.Logger().Error("Failed to create OAuth2 ID token", "error", err, "clientId", e.Client.Id)
+				return oauth2ServerError(e.RequestEvent, http.StatusInternalServerError, "server_error", "Failed to create ID token.")
+			}
+
+			result["id_token"] = idToken
+		}
+
+		e.Response.Header().Set("Cache-Control", "no-store")
+
+		return e.JSON(http.StatusOK, result)
+	})
+}
+
+type oauth2TokenForm struct {
+	GrantType    string `form:"grant_type" json:"grant_type"`
+	Code         string `form:"code" json:"code"`
+	RedirectURI  string `form:"redirect_uri" json:"redirect_uri"`
+	ClientId     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+	CodeVerifier string `form:"code_verifier" json:"code_verifier"`
+}
+
+func oauth2ServerError(e *core.RequestEvent, status int, code string, description string) error {
+	e.Response.Header().Set("Cache-Control", "no-store")
+
+	return e.JSON(status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// oauth2ServerIdToken signs the provided ID token claims with the
+// active collection signing key.
+func oauth2ServerIdToken(app core.App, collection *core.Collection, claims map[string]any) (string, error) {
+	signingKey, ok := app.Settings().SigningKeys.ActiveKey(collection.Id)
+	if !ok {
+		return "", errors.New("missing active signing key for collection " + collection.Name)
+	}
+
+	signer, err := signingKey.Signer()
+	if err != nil {
+		return "", err
+	}
+
+	return security.NewSignedJWT(
+		jwt.MapClaims(claims),
+		signer,
+		signingKey.Id,
+		app.Settings().OAuth2Server.IdTokenDurationTime(),
+	)
+}
+
+// -------------------------------------------------------------------
+
+// oauth2ServerUserInfo returns the standard OpenID Connect claims of the
+// OAuth2 access token record, filtered by the token granted scopes.
+//
+// Only OAuth2 access tokens issued to a still existing client are accepted.
+func oauth2ServerUserInfo(e *core.RequestEvent) error {
+	token := getAuthTokenFromRequest(e)
+
+	record, err := e.App.FindAuthRecordByToken(token, core.TokenTypeOAuth2Access)
+	if err != nil {
+		e.Response.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		return oauth2ServerError(e, http.StatusUnauthorized, "invalid_token", "Missing or invalid access token.")
+	}
+
+	// already verified
+	claims, _ := security.ParseUnverifiedJWT(token)
+
+	clientId, _ := claims[core.TokenClaimAudience].(string)
+	if _, err := e.App.FindOAuth2ClientById(clientId); err != nil {
+		e.Response.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		return oauth2ServerError(e, http.StatusUnauthorized, "invalid_token", "Missing or invalid access token.")
+	}
+
+	scope, _ := claims[core.TokenClaimScope].(string)
+
+	return e.JSON(http.StatusOK, oauth2ServerClaims(record, strings.Fields(scope)))
+}
+
+// oauth2ServerClaims returns the standard OpenID Connect claims of the
+// provided auth record for the specified scopes.
+func oauth2ServerClaims(record *core.Record, scopes []string) map[string]any {
+	claims := map[string]any{
+		"sub": record.Id,
+	}
+
+	if slices.Contains(scopes, oauth2ServerScopeEmail) && record.Email() != "" {
+		claims["email"] = record.Email()
+		claims["email_verified"] = record.Verified()
+	}
+
+	if slices.Contains(scopes, oauth2ServerScopeProfile) {
+		mappedFields := record.Collection().OAuth2.MappedFields
+
+		nameField := mappedFields.Name
+		if nameField == "" {
+			nameField = "name"
+		}
+		if name := record.GetString(nameField); name != "" {
+			claims["name"] = name
+		}
+
+		if mappedFields.Username != "" {
+			if username := record.GetString(mappedFields.Username); username != "" {
+				claims["preferred_username"] = username
+			}
+		}
+	}
+
+	return claims
+}
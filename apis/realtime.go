@@ -32,7 +32,13 @@ const RealtimeClientAuthKey = "auth"
 func bindRealtimeApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	sub := rg.Group("/realtime")
 	sub.GET("", realtimeConnect).Bind(SkipSuccessActivityLog())
+	sub.GET("/ws", realtimeConnectWS).Bind(SkipSuccessActivityLog())
 	sub.POST("", realtimeSetSubscriptions)
+	sub.GET("/presence", realtimeListPresence)
+	sub.POST("/presence", realtimeSetPresence)
+	sub.POST("/broadcast", realtimePublishBroadcast)
+	sub.GET("/clients", realtimeListClients).Bind(RequireSuperuserAuth())
+	sub.DELETE("/clients/{id}", realtimeDisconnectClient).Bind(RequireSuperuserAuth())
 
 	bindRealtimeEvents(app)
 }
@@ -63,13 +69,21 @@ func realtimeConnect(e *core.RequestEvent) error {
 
 	connectEvent := new(core.RealtimeConnectRequestEvent)
 	connectEvent.RequestEvent = e
-	connectEvent.Client = subscriptions.NewDefaultClient()
+	connectEvent.Client = newRealtimeClient(e.App)
 	connectEvent.IdleTimeout = 5 * time.Minute
 
+	// the last received event id of the previous connection (if any)
+	// used for replaying the missed messages after the subscriptions submit
+	lastEventId := realtimeLastEventId(e)
+	if lastEventId != "" {
+		connectEvent.Client.Set(realtimeLastEventIdKey, lastEventId)
+	}
+
 	return e.App.OnRealtimeConnectRequest().Trigger(connectEvent, func(ce *core.RealtimeConnectRequestEvent) error {
 		// register new subscription client
 		ce.App.SubscriptionsBroker().Register(ce.Client)
 		defer func() {
+			realtimePresenceUntrackAll(e.App, ce.Client.Id())
 			e.App.SubscriptionsBroker().Unregister(ce.Client.Id())
 		}()
 
@@ -81,7 +95,7 @@ func realtimeConnect(e *core.RequestEvent) error {
 		connectMsgEvent.Client = ce.Client
 		connectMsgEvent.Message = &subscriptions.Message{
 			Name: "PB_CONNECT",
-			Data: []byte(`{"clientId":"` + ce.Client.Id() + `"}`),
+			Data: realtimeConnectMessageData(ce.Client.Id(), lastEventId),
 		}
 		connectMsgErr := ce.App.OnRealtimeMessageSend().Trigger(connectMsgEvent, func(me *core.RealtimeMessageEvent) error {
 			err := me.Message.WriteSSE(me.Response, me.Client.Id())
@@ -122,7 +136,10 @@ func realtimeConnect(e *core.RequestEvent) error {
 				msgEvent.Client = ce.Client
 				msgEvent.Message = &msg
 				msgErr := ce.App.OnRealtimeMessageSend().Trigger(msgEvent, func(me *core.RealtimeMessageEvent) error {
-					err := me.Message.WriteSSE(me.Response, me.Client.Id())
+					// the messages without id (e.g. custom messages) are written
+					// without the SSE id field so that the client could keep
+					// its last record event id for the missed messages replay
+					err := me.Message.WriteSSE(me.Response, me.Message.Id)
 					if err != nil {
 						return err
 					}
@@ -213,6 +230,18 @@ func realtimeSetSubscriptions(e *core.RequestEvent) error {
 			slog.Any("subscriptions", e.Subscriptions),
 		)
 
+		// send the initial result set of the new live query subscriptions (if any)
+		realtimeSyncQuerySubscriptions(e.App, e.Client)
+
+		// replay the missed messages from the previous connection (if any)
+		if err := realtimeReplayEvents(e.App, e.Client); err != nil {
+			e.App.Logger().Debug(
+				"Failed to replay the realtime events.",
+				slog.String("clientId", e.Client.Id()),
+				slog.String("error", err.Error()),
+			)
+		}
+
 		return e.NoContent(http.StatusNoContent)
 	})
 }
@@ -266,6 +295,11 @@ func realtimeUnsetClientsAuthState(app core.App, authModel core.Model) error {
 }
 
 func bindRealtimeEvents(app core.App) {
+	bindRealtimePresenceEvents(app)
+	bindRealtimeBroadcastEvents(app)
+	bindRealtimeReplayEvents(app)
+	bindRealtimeQueryEvents(app)
+
 	// update the clients that has auth record association
 	app.OnModelAfterUpdateSuccess().Bind(&hook.Handler[*core.ModelEvent]{
 		Func: func(e *core.ModelEvent) error {
@@ -379,7 +413,23 @@ func bindRealtimeEvents(app core.App) {
 			// custom model it'll fail to resolve since the record is already deleted
 			collection := realtimeResolveRecordCollection(e.App, e.Model)
 			if collection != nil {
-				err := realtimeBroadcastDryCacheKey(e.App, getDryCacheKey("delete", e.Model))
+				// store the event for replay
+				// (custom models are skipped since they can't be resolved after the delete)
+				var eventId string
+				if record := realtimeResolveRecordModel(e.Model); record != nil {
+					var err error
+					eventId, err = realtimeStoreEvent(e.App, "delete", record)
+					if err != nil {
+						app.Logger().Debug(
+							"Failed to store record delete replay event",
+							slog.String("id", record.Id),
+							slog.String("collectionName", collection.Name),
+							slog.String("error", err.Error()),
+						)
+					}
+				}
+
+				err := realtimeBroadcastDryCacheKey(e.App, getDryCacheKey("delete", e.Model), eventId)
 				if err != nil {
 					app.Logger().Debug(
 						"Failed to broadcast record delete",
@@ -420,14 +470,7 @@ func bindRealtimeEvents(app core.App) {
 // resolveRecord converts *if possible* the provided model interface to a Record.
 // This is usually helpful if the provided model is a custom Record model struct.
 func realtimeResolveRecord(app core.App, model core.Model, optCollectionType string) *core.Record {
-	var record *core.Record
-	switch m := model.(type) {
-	case *core.Record:
-		record = m
-	case core.RecordProxy:
-		record = m.ProxyRecord()
-	}
-
+	record := realtimeResolveRecordModel(model)
 	if record != nil {
 		if optCollectionType == "" || record.Collection().Type == optCollectionType {
 			return record
@@ -453,6 +496,19 @@ func realtimeResolveRecord(app core.App, model core.Model, optCollectionType str
 	return record
 }
 
+// realtimeResolveRecordModel returns the underlying Record of the provided
+// model (if it is a Record or a RecordProxy) without querying the db.
+func realtimeResolveRecordModel(model core.Model) *core.Record {
+	switch m := model.(type) {
+	case *core.Record:
+		return m
+	case core.RecordProxy:
+		return m.ProxyRecord()
+	}
+
+	return nil
+}
+
 // realtimeResolveRecordCollection extracts *if possible* the Collection model from the provided model interface.
 // This is usually helpful if the provided model is a custom Record model struct.
 func realtimeResolveRecordCollection(app core.App, model core.Model) (collection *core.Collection) {
@@ -488,21 +544,27 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 		return errors.New("[broadcastRecord] Record collection not set")
 	}
 
+	// store the event for replay (the delete events are stored after their successful commit)
+	var eventId string
+	if !dryCache {
+		var err error
+		eventId, err = realtimeStoreEvent(app, action, record)
+		if err != nil {
+			app.Logger().Debug(
+				"[broadcastRecord] failed to store the replay event",
+				slog.String("id", record.Id),
+				slog.String("collectionName", collection.Name),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
 	}
 
-	subscriptionRuleMap := map[string]*string{
-		(collection.Name + "/" + record.Id + "?"): collection.ViewRule,
-		(collection.Id + "/" + record.Id + "?"):   collection.ViewRule,
-		(collection.Name + "/*?"):                 collection.ListRule,
-		(collection.Id + "/*?"):                   collection.ListRule,
-
-		// @deprecated: the same as the wildcard topic but kept for backward compatibility
-		(collection.Name + "?"): collection.ListRule,
-		(collection.Id + "?"):   collection.ListRule,
-	}
+	subscriptionRuleMap := realtimeRecordSubscriptionRules(collection, record.Id)
 
 	dryCacheKey := getDryCacheKey(action, record)
 
@@ -513,144 +575,207 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 		accessCheckApp = optAccessCheckApp[0]
 	}
 
+	canAccess := func(record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool {
+		return realtimeCanAccessRecord(accessCheckApp, record, requestInfo, accessRule)
+	}
+
 	for _, chunk := range chunks {
 		group.Go(func() error {
-			var clientAuth *core.Record
-
 			for _, client := range chunk {
 				// note: not executed concurrently to avoid races and to ensure
 				// that the access checks are applied for the current record db state
-				for prefix, rule := range subscriptionRuleMap {
-					subs := client.Subscriptions(prefix)
-					if len(subs) == 0 {
-						continue
-					}
+				messages := realtimeRecordMessages(app, client, action, record, subscriptionRuleMap, canAccess)
 
-					clientAuth, _ = client.Get(RealtimeClientAuthKey).(*core.Record)
+				for _, msg := range messages {
+					msg.Id = eventId
 
-					for sub, options := range subs {
-						// mock request data
-						requestInfo := &core.RequestInfo{
-							Context: core.RequestInfoContextRealtime,
-							Method:  "GET",
-							Query:   options.Query,
-							Headers: options.Headers,
-							Auth:    clientAuth,
+					if dryCache {
+						cached, ok := client.Get(dryCacheKey).([]subscriptions.Message)
+						if !ok {
+							cached = []subscriptions.Message{msg}
+						} else {
+							cached = append(cached, msg)
 						}
+						client.Set(dryCacheKey, cached)
+					} else {
+						routine.FireAndForget(func() {
+							client.Send(msg)
+						})
+					}
+				}
+			}
 
-						if !realtimeCanAccessRecord(accessCheckApp, record, requestInfo, rule) {
-							continue
-						}
+			return nil
+		})
+	}
 
-						// create a clean record copy without expand and unknown fields because we don't know yet
-						// which exact fields the client subscription requested or has permissions to access
-						cleanRecord := record.Fresh()
-
-						// trigger the enrich hooks
-						enrichErr := triggerRecordEnrichHooks(app, requestInfo, []*core.Record{cleanRecord}, func() error {
-							// apply expand
-							rawExpand := options.Query[expandQueryParam]
-							if rawExpand != "" {
-								expandErrs := app.ExpandRecord(cleanRecord, strings.Split(rawExpand, ","), expandFetch(app, requestInfo))
-								if len(expandErrs) > 0 {
-									app.Logger().Debug(
-										"[broadcastRecord] expand errors",
-										slog.String("id", cleanRecord.Id),
-										slog.String("collectionName", cleanRecord.Collection().Name),
-										slog.String("sub", sub),
-										slog.String("expand", rawExpand),
-										slog.Any("errors", expandErrs),
-									)
-								}
-							}
-
-							// ignore the auth record email visibility checks
-							// for auth owner, superuser or manager
-							if collection.IsAuth() {
-								if isSameAuth(clientAuth, cleanRecord) ||
-									realtimeCanAccessRecord(accessCheckApp, cleanRecord, requestInfo, collection.ManageRule) {
-									cleanRecord.IgnoreEmailVisibility(true)
-								}
-							}
-
-							return nil
-						})
-						if enrichErr != nil {
-							app.Logger().Debug(
-								"[broadcastRecord] record enrich error",
-								slog.String("id", cleanRecord.Id),
-								slog.String("collectionName", cleanRecord.Collection().Name),
-								slog.String("sub", sub),
-								slog.Any("error", enrichErr),
-							)
-							continue
-						}
+	return group.Wait()
+}
 
-						data := &recordData{
-							Action: action,
-							Record: cleanRecord,
-						}
+// realtimeRecordSubscriptionRules returns the record subscription topic prefixes
+// mapped to their corresponding collection access rule.
+func realtimeRecordSubscriptionRules(collection *core.Collection, recordId string) map[string]*string {
+	return map[string]*string{
+		(collection.Name + "/" + recordId + "?"): collection.ViewRule,
+		(collection.Id + "/" + recordId + "?"):   collection.ViewRule,
+		(collection.Name + "/*?"):                collection.ListRule,
+		(collection.Id + "/*?"):                  collection.ListRule,
 
-						// check fields
-						rawFields := options.Query[fieldsQueryParam]
-						if rawFields != "" {
-							decoded, err := picker.Pick(cleanRecord, rawFields)
-							if err == nil {
-								data.Record = decoded
-							} else {
-								app.Logger().Debug(
-									"[broadcastRecord] pick fields error",
-									slog.String("id", cleanRecord.Id),
-									slog.String("collectionName", cleanRecord.Collection().Name),
-									slog.String("sub", sub),
-									slog.String("fields", rawFields),
-									slog.String("error", err.Error()),
-								)
-							}
-						}
+		// @deprecated: the same as the wildcard topic but kept for backward compatibility
+		(collection.Name + "?"): collection.ListRule,
+		(collection.Id + "?"):   collection.ListRule,
+	}
+}
 
-						dataBytes, err := json.Marshal(data)
-						if err != nil {
-							app.Logger().Debug(
-								"[broadcastRecord] data marshal error",
-								slog.String("id", cleanRecord.Id),
-								slog.String("collectionName", cleanRecord.Collection().Name),
-								slog.String("error", err.Error()),
-							)
-							continue
-						}
+// realtimeRecordMessages returns the record event messages for all
+// client subscriptions matching the provided subscriptionRuleMap and
+// satisfying their access rules.
+func realtimeRecordMessages(
+	app core.App,
+	client subscriptions.Client,
+	action string,
+	record *core.Record,
+	subscriptionRuleMap map[string]*string,
+	canAccess func(record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool,
+) []subscriptions.Message {
+	var messages []subscriptions.Message
 
-						msg := subscriptions.Message{
-							Name: sub,
-							Data: dataBytes,
-						}
+	collection := record.Collection()
 
-						if dryCache {
-							messages, ok := client.Get(dryCacheKey).([]subscriptions.Message)
-							if !ok {
-								messages = []subscriptions.Message{msg}
-							} else {
-								messages = append(messages, msg)
-							}
-							client.Set(dryCacheKey, messages)
-						} else {
-							routine.FireAndForget(func() {
-								client.Send(msg)
-							})
-						}
-					}
-				}
+	for prefix, rule := range subscriptionRuleMap {
+		subs := client.Subscriptions(prefix)
+		if len(subs) == 0 {
+			continue
+		}
+
+		clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+
+		for sub, options := range subs {
+			// mock request data
+			requestInfo := &core.RequestInfo{
+				Context: core.RequestInfoContextRealtime,
+				Method:  "GET",
+				Query:   options.Query,
+				Headers: options.Headers,
+				Auth:    clientAuth,
 			}
 
-			return nil
-		})
+			if !canAccess(record, requestInfo, rule) {
+				continue
+			}
+
+			payload, ok := realtimeRecordPayload(app, requestInfo, options, sub, record, canAccess)
+			if !ok {
+				continue
+			}
+
+			data := &recordData{
+				Action: action,
+				Record: payload,
+			}
+
+			dataBytes, err := json.Marshal(data)
+			if err != nil {
+				app.Logger().Debug(
+					"[broadcastRecord] data marshal error",
+					slog.String("id", record.Id),
+					slog.String("collectionName", collection.Name),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+
+			messages = append(messages, subscriptions.Message{
+				Name: sub,
+				Data: dataBytes,
+			})
+		}
 	}
 
-	return group.Wait()
+	return messages
+}
+
+// realtimeRecordPayload returns a clean copy of the record with applied
+// enrich hooks and the subscription "expand" and "fields" options.
+//
+// Returns false if the record enrich hooks have failed.
+func realtimeRecordPayload(
+	app core.App,
+	requestInfo *core.RequestInfo,
+	options subscriptions.SubscriptionOptions,
+	sub string,
+	record *core.Record,
+	canAccess func(record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool,
+) (any, bool) {
+	collection := record.Collection()
+
+	// create a clean record copy without expand and unknown fields because we don't know yet
+	// which exact fields the client subscription requested or has permissions to access
+	cleanRecord := record.Fresh()
+
+	// trigger the enrich hooks
+	enrichErr := triggerRecordEnrichHooks(app, requestInfo, []*core.Record{cleanRecord}, func() error {
+		// apply expand
+		rawExpand := options.Query[expandQueryParam]
+		if rawExpand != "" {
+			expandErrs := app.ExpandRecord(cleanRecord, strings.Split(rawExpand, ","), expandFetch(app, requestInfo))
+			if len(expandErrs) > 0 {
+				app.Logger().Debug(
+					"[broadcastRecord] expand errors",
+					slog.String("id", cleanRecord.Id),
+					slog.String("collectionName", cleanRecord.Collection().Name),
+					slog.String("sub", sub),
+					slog.String("expand", rawExpand),
+					slog.Any("errors", expandErrs),
+				)
+			}
+		}
+
+		// ignore the auth record email visibility checks
+		// for auth owner, superuser or manager
+		if collection.IsAuth() {
+			if isSameAuth(requestInfo.Auth, cleanRecord) ||
+				canAccess(cleanRecord, requestInfo, collection.ManageRule) {
+				cleanRecord.IgnoreEmailVisibility(true)
+			}
+		}
+
+		return nil
+	})
+	if enrichErr != nil {
+		app.Logger().Debug(
+			"[broadcastRecord] record enrich error",
+			slog.String("id", cleanRecord.Id),
+			slog.String("collectionName", cleanRecord.Collection().Name),
+			slog.String("sub", sub),
+			slog.Any("error", enrichErr),
+		)
+		return nil, false
+	}
+
+	// check fields
+	rawFields := options.Query[fieldsQueryParam]
+	if rawFields != "" {
+		decoded, err := picker.Pick(cleanRecord, rawFields)
+		if err == nil {
+			return decoded, true
+		}
+
+		app.Logger().Debug(
+			"[broadcastRecord] pick fields error",
+			slog.String("id", cleanRecord.Id),
+			slog.String("collectionName", cleanRecord.Collection().Name),
+			slog.String("sub", sub),
+			slog.String("fields", rawFields),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return cleanRecord, true
 }
 
 // realtimeBroadcastDryCacheKey broadcasts the dry cached key related messages.
-func realtimeBroadcastDryCacheKey(app core.App, key string) error {
+func realtimeBroadcastDryCacheKey(app core.App, key string, eventId string) error {
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
@@ -672,6 +797,7 @@ func realtimeBroadcastDryCacheKey(app core.App, key string) error {
 
 				routine.FireAndForget(func() {
 					for _, msg := range messages {
+						msg.Id = eventId
 						client.Send(msg)
 					}
 				})
@@ -0,0 +1,355 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/routine"
+	"github.com/thewandererbg/pgbase/tools/search"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// RealtimeBroadcastTopicPrefix is the realtime subscription topic
+	// prefix of the broadcast channels (e.g. "@broadcast/typing").
+	RealtimeBroadcastTopicPrefix = "@broadcast/"
+
+	// realtimeBroadcastPubSubTopic is the pub/sub topic used to
+	// forward the broadcast messages to the other app instances.
+	realtimeBroadcastPubSubTopic = "realtime.broadcast"
+
+	// realtimeBroadcastMaxDataSize is the max allowed size of a client published message data (in bytes).
+	realtimeBroadcastMaxDataSize = 4096
+)
+
+// realtimeBroadcastMessage is the data of a single broadcast channel
+// realtime message (and of the broadcast pub/sub messages).
+type realtimeBroadcastMessage struct {
+	Channel string                  `json:"channel"`
+	Data    json.RawMessage         `json:"data"`
+	Sender  realtimeBroadcastSender `json:"sender"`
+}
+
+// realtimeBroadcastSender holds the auth state of the broadcast message publisher
+// (both fields are empty for guests and server-side published messages).
+type realtimeBroadcastSender struct {
+	AuthId           string `json:"authId"`
+	AuthCollectionId string `json:"authCollectionId"`
+}
+
+// Broadcast publishes data to all realtime clients subscribed to the
+// specified broadcast channel (including the clients of the other
+// app instances if the multi-instance mode is enabled).
+//
+// The channel must be defined in the app settings. Its publish rule is not
+// checked but the subscribers still must satisfy the channel subscribe rule.
+//
+// Example:
+//
+//	err := apis.Broadcast(app, "rooms:abc", map[string]any{"typing": true})
+func Broadcast(app core.App, channel string, data any) error {
+	if _, _, ok := app.Settings().Broadcast.FindChannel(channel); !ok {
+		return fmt.Errorf("missing broadcast channel %q", channel)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return realtimeBroadcast(app, &realtimeBroadcastMessage{Channel: channel, Data: raw}, "")
+}
+
+type realtimeBroadcastForm struct {
+	ClientId string          `form:"clientId" json:"clientId"`
+	Channel  string          `form:"channel" json:"channel"`
+	Data     json.RawMessage `form:"data" json:"data"`
+}
+
+func (form *realtimeBroadcastForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.Length(0, 255)),
+		validation.Field(&form.Channel, validation.Required, validation.Length(1, 255), validation.Match(core.BroadcastChannelNameRegex)),
+		validation.Field(&form.Data, validation.By(validateRealtimeBroadcastData)),
+	)
+}
+
+func validateRealtimeBroadcastData(value any) error {
+	v, _ := value.(json.RawMessage)
+	if len(v) == 0 {
+		return nil
+	}
+
+	if len(v) > realtimeBroadcastMaxDataSize {
+		return validation.NewError("validation_broadcast_data_too_large", "The broadcast data is too large.")
+	}
+
+	if !json.Valid(v) {
+		return validation.NewError("validation_invalid_json", "Must be a valid json value.")
+	}
+
+	return nil
+}
+
+// realtimePublishBroadcast publishes a client message to a broadcast channel.
+//
+// The optional clientId could be used to exclude the publisher's
+// own realtime connection from the channel subscribers.
+func realtimePublishBroadcast(e *core.RequestEvent) error {
+	form := new(realtimeBroadcastForm)
+
+	err := e.BindBody(form)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = form.validate()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	if form.ClientId != "" {
+		client, err := e.App.SubscriptionsBroker().ClientById(form.ClientId)
+		if err != nil {
+			return e.NotFoundError("Missing or invalid client id.", err)
+		}
+
+		clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+		if clientAuth != nil && !isSameAuth(clientAuth, e.Auth) {
+			return e.ForbiddenError("The current and the previous request authorization don't match.", nil)
+		}
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = realtimeCheckBroadcastPublish(e.App, form.Channel, requestInfo)
+	if err != nil {
+		return e.ForbiddenError("You are not allowed to publish to the broadcast channel.", err)
+	}
+
+	err = realtimeBroadcast(e.App, &realtimeBroadcastMessage{
+		Channel: form.Channel,
+		Data:    form.Data,
+		Sender:  newRealtimeBroadcastSender(e.Auth),
+	}, form.ClientId)
+	if err != nil {
+		return e.BadRequestError("Failed to publish the broadcast message.", err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// realtimeCheckBroadcastPublish checks whether the specified requestInfo
+// satisfies the publish rule of the named broadcast channel.
+func realtimeCheckBroadcastPublish(app core.App, channelName string, requestInfo *core.RequestInfo) error {
+	channel, param, ok := app.Settings().Broadcast.FindChannel(channelName)
+	if !ok {
+		return errors.New("missing broadcast channel")
+	}
+
+	if !realtimeCanAccessBroadcastChannel(app, channelName, param, requestInfo, channel.PublishRule) {
+		return errors.New("the broadcast channel publish rule failed")
+	}
+
+	return nil
+}
+
+func newRealtimeBroadcastSender(auth *core.Record) realtimeBroadcastSender {
+	if auth == nil {
+		return realtimeBroadcastSender{}
+	}
+
+	return realtimeBroadcastSender{
+		AuthId:           auth.Id,
+		AuthCollectionId: auth.Collection().Id,
+	}
+}
+
+// realtimeBroadcast delivers the broadcast message to the local channel
+// subscribers and forwards it to the other app instances (if any).
+func realtimeBroadcast(app core.App, msg *realtimeBroadcastMessage, excludeClientId string) error {
+	if len(msg.Data) == 0 {
+		msg.Data = json.RawMessage("null")
+	}
+
+	if app.MultiInstanceEnabled() {
+		if err := app.PublishPubSubMessage(realtimeBroadcastPubSubTopic, msg); err != nil {
+			return err
+		}
+	}
+
+	return realtimeBroadcastNotify(app, msg, excludeClientId)
+}
+
+// realtimeBroadcastNotify sends the broadcast message to all local clients
+// subscribed to its channel that satisfy the channel subscribe rule.
+func realtimeBroadcastNotify(app core.App, msg *realtimeBroadcastMessage, excludeClientId string) error {
+	channel, param, ok := app.Settings().Broadcast.FindChannel(msg.Channel)
+	if !ok {
+		return nil // the channel was removed
+	}
+
+	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
+	if len(chunks) == 0 {
+		return nil // no subscribers
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	prefix := RealtimeBroadcastTopicPrefix + msg.Channel + "?"
+
+	group := new(errgroup.Group)
+
+	for _, chunk := range chunks {
+		group.Go(func() error {
+			for _, client := range chunk {
+				if client.Id() == excludeClientId {
+					continue
+				}
+
+				subs := client.Subscriptions(prefix)
+				if len(subs) == 0 {
+					continue
+				}
+
+				clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+
+				for sub, options := range subs {
+					// mock request data
+					requestInfo := &core.RequestInfo{
+						Context: core.RequestInfoContextRealtime,
+						Method:  http.MethodGet,
+						Query:   options.Query,
+						Headers: options.Headers,
+						Auth:    clientAuth,
+					}
+
+					if !realtimeCanAccessBroadcastChannel(app, msg.Channel, param, requestInfo, channel.SubscribeRule) {
+						continue
+					}
+
+					routine.FireAndForget(func() {
+						client.Send(subscriptions.Message{
+							Name: sub,
+							Data: data,
+						})
+					})
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+// realtimeCanAccessBroadcastChannel checks whether the specified requestInfo
+// satisfies the broadcast channel accessRule.
+//
+// The rule is evaluated against a single row with the channel "name" and
+// wildcard "param" fields (similar to the record create rule checks).
+func realtimeCanAccessBroadcastChannel(
+	app core.App,
+	name string,
+	param string,
+	requestInfo *core.RequestInfo,
+	accessRule *string,
+) bool {
+	// superusers can access everything
+	if requestInfo.HasSuperuserAuth() {
+		return true
+	}
+
+	// only superusers can access the channel
+	if accessRule == nil {
+		return false
+	}
+
+	// empty public rule, aka. everyone can access
+	if *accessRule == "" {
+		return true
+	}
+
+	dummyCollection := core.NewBaseCollection("__pb_broadcast__")
+	dummyCollection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "param"},
+	)
+
+	// note: the id is required only for the multi-match subqueries (the value doesn't matter)
+	withFrom := fmt.Sprintf(
+		"WITH {{%s}} as (SELECT {:__pb_broadcast__id} AS [[id]], {:__pb_broadcast__name} AS [[name]], {:__pb_broadcast__param} AS [[param]])",
+		dummyCollection.Name,
+	)
+
+	query := app.DB().Select("(1)").
+		PreFragment(withFrom).
+		From(dummyCollection.Name).
+		AndBind(dbx.Params{
+			"__pb_broadcast__id":    name,
+			"__pb_broadcast__name":  name,
+			"__pb_broadcast__param": param,
+		})
+
+	resolver := core.NewRecordFieldResolver(app, dummyCollection, requestInfo, true)
+	resolver.SetAllowedFields(append(
+		[]string{`^(name|param)(\:\w+)?$`},
+		slices.DeleteFunc(resolver.AllowedFields(), func(pattern string) bool {
+			return !strings.HasPrefix(pattern, `^\@`)
+		})...,
+	))
+
+	expr, err := search.FilterData(*accessRule).BuildExpr(resolver)
+	if err != nil {
+		app.Logger().Debug(
+			"[broadcast] invalid channel rule",
+			slog.String("channel", name),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	query.AndWhere(expr)
+
+	resolver.UpdateQuery(query)
+
+	var exists int
+	err = query.Limit(1).Row(&exists)
+
+	return err == nil && exists > 0
+}
+
+// bindRealtimeBroadcastEvents registers the multi-instance broadcast messages handler.
+func bindRealtimeBroadcastEvents(app core.App) {
+	if !app.MultiInstanceEnabled() {
+		return
+	}
+
+	app.OnPubSubMessage(realtimeBroadcastPubSubTopic).BindFunc(func(e *core.PubSubMessageEvent) error {
+		msg := new(realtimeBroadcastMessage)
+		if err := json.Unmarshal(e.Payload, msg); err != nil {
+			return err
+		}
+
+		if err := realtimeBroadcastNotify(e.App, msg, ""); err != nil {
+			return err
+		}
+
+		return e.Next()
+	})
+}
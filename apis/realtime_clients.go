@@ -0,0 +1,82 @@
+package apis
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+)
+
+// realtimeClientInfo is the public representation of a single realtime client.
+type realtimeClientInfo struct {
+	Auth          *realtimeClientAuthInfo   `json:"auth"`
+	Id            string                    `json:"id"`
+	Subscriptions []string                  `json:"subscriptions"`
+	Stats         subscriptions.ClientStats `json:"stats"`
+}
+
+type realtimeClientAuthInfo struct {
+	Id             string `json:"id"`
+	CollectionId   string `json:"collectionId"`
+	CollectionName string `json:"collectionName"`
+}
+
+// newRealtimeClient creates a new realtime subscriptions client
+// with the queue options from the app settings.
+func newRealtimeClient(app core.App) *subscriptions.DefaultClient {
+	config := app.Settings().Realtime
+
+	return subscriptions.NewDefaultClientWithQueue(config.QueueSize, subscriptions.OverflowPolicy(config.OverflowPolicy))
+}
+
+// realtimeListClients returns the clients connected to the current app instance.
+func realtimeListClients(e *core.RequestEvent) error {
+	clients := e.App.SubscriptionsBroker().Clients()
+
+	result := make([]*realtimeClientInfo, 0, len(clients))
+
+	for _, client := range clients {
+		info := &realtimeClientInfo{
+			Id:            client.Id(),
+			Subscriptions: make([]string, 0, len(client.Subscriptions())),
+			Stats:         client.Stats(),
+		}
+
+		for sub := range client.Subscriptions() {
+			info.Subscriptions = append(info.Subscriptions, sub)
+		}
+		slices.Sort(info.Subscriptions)
+
+		if auth, _ := client.Get(RealtimeClientAuthKey).(*core.Record); auth != nil {
+			info.Auth = &realtimeClientAuthInfo{
+				Id:             auth.Id,
+				CollectionId:   auth.Collection().Id,
+				CollectionName: auth.Collection().Name,
+			}
+		}
+
+		result = append(result, info)
+	}
+
+	slices.SortFunc(result, func(a, b *realtimeClientInfo) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// realtimeDisconnectClient forcibly disconnects a single realtime client.
+func realtimeDisconnectClient(e *core.RequestEvent) error {
+	client, err := e.App.SubscriptionsBroker().ClientById(e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("Missing or invalid client id.", err)
+	}
+
+	// the client connection handler terminates the connection on discard
+	// and takes care for the rest of the cleanup (e.g. presence)
+	e.App.SubscriptionsBroker().Unregister(client.Id())
+
+	return e.NoContent(http.StatusNoContent)
+}
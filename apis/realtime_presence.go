@@ -0,0 +1,412 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/routine"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+)
+
+const (
+	// realtimePresenceMessageName is the name of the realtime message
+	// used to notify the presence topic members for join/update/leave changes.
+	realtimePresenceMessageName = "PB_PRESENCE"
+
+	// realtimePresencePubSubTopic is the pub/sub topic used to
+	// sync the presence state between multiple app instances.
+	realtimePresencePubSubTopic = "realtime.presence"
+
+	// realtimePresenceMaxStateSize is the max allowed size of a member custom state (in bytes).
+	realtimePresenceMaxStateSize = 2048
+
+	// realtimePresenceMaxTopics is the max number of presence topics a single client can join.
+	realtimePresenceMaxTopics = 50
+
+	// realtimePresenceStaleTimeout is the max allowed time without a heartbeat
+	// from another app instance before removing its presence members.
+	realtimePresenceStaleTimeout = 3 * time.Minute
+)
+
+var errRealtimePresenceMaxTopics = errors.New("max allowed presence topics reached")
+
+// Presence actions.
+const (
+	realtimePresenceJoin   = "join"
+	realtimePresenceUpdate = "update"
+	realtimePresenceLeave  = "leave"
+
+	// internal multi-instance sync actions
+	realtimePresenceHeartbeat = "heartbeat"
+	realtimePresenceSync      = "sync"
+)
+
+// realtimePresenceEvent is the data of a single PB_PRESENCE
+// message (and of the presence pub/sub messages).
+type realtimePresenceEvent struct {
+	Action string                       `json:"action"`
+	Topic  string                       `json:"topic"`
+	Member subscriptions.PresenceMember `json:"member"`
+}
+
+type realtimePresenceForm struct {
+	ClientId string          `form:"clientId" json:"clientId"`
+	Topic    string          `form:"topic" json:"topic"`
+	Action   string          `form:"action" json:"action"`
+	State    json.RawMessage `form:"state" json:"state"`
+}
+
+func (form *realtimePresenceForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Topic, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Action, validation.Required, validation.In(realtimePresenceJoin, realtimePresenceLeave)),
+		validation.Field(&form.State, validation.By(validateRealtimePresenceState)),
+	)
+}
+
+func validateRealtimePresenceState(value any) error {
+	v, _ := value.(json.RawMessage)
+	if len(v) == 0 {
+		return nil
+	}
+
+	if len(v) > realtimePresenceMaxStateSize {
+		return validation.NewError("validation_presence_state_too_large", "The presence state is too large.")
+	}
+
+	if !json.Valid(v) {
+		return validation.NewError("validation_invalid_json", "Must be a valid json value.")
+	}
+
+	return nil
+}
+
+// realtimeSetPresence joins (or updates) or leaves a presence topic for the specified SSE client.
+func realtimeSetPresence(e *core.RequestEvent) error {
+	form := new(realtimePresenceForm)
+
+	err := e.BindBody(form)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = form.validate()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	client, err := e.App.SubscriptionsBroker().ClientById(form.ClientId)
+	if err != nil {
+		return e.NotFoundError("Missing or invalid client id.", err)
+	}
+
+	clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+	if clientAuth != nil && !isSameAuth(clientAuth, e.Auth) {
+		return e.ForbiddenError("The current and the previous request authorization don't match.", nil)
+	}
+
+	if form.Action == realtimePresenceLeave {
+		realtimePresenceUntrack(e.App, client.Id(), realtimePresenceNormalizeTopic(e.App, form.Topic))
+		return e.NoContent(http.StatusNoContent)
+	}
+
+	topic, err := realtimePresenceCheckAccess(e.App, form.Topic, e.Auth)
+	if err != nil {
+		return e.ForbiddenError("You are not allowed to join the presence topic.", err)
+	}
+
+	_, err = realtimePresenceTrack(e.App, client.Id(), topic, e.Auth, form.State)
+	if err != nil {
+		return e.BadRequestError("The client has reached the max allowed number of presence topics.", err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// realtimeListPresence returns the current members of a presence topic.
+func realtimeListPresence(e *core.RequestEvent) error {
+	topic, err := realtimePresenceCheckAccess(e.App, e.Request.URL.Query().Get("topic"), e.Auth)
+	if err != nil {
+		return e.ForbiddenError("You are not allowed to access the presence topic.", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"topic":   topic,
+		"members": e.App.SubscriptionsBroker().Presence(topic),
+	})
+}
+
+// realtimePresenceNormalizeTopic replaces the collection id of the presence topic with its name (if found).
+func realtimePresenceNormalizeTopic(app core.App, topic string) string {
+	collectionIdOrName, recordId, _ := strings.Cut(topic, "/")
+
+	collection, err := app.FindCachedCollectionByNameOrId(collectionIdOrName)
+	if err != nil {
+		return topic
+	}
+
+	return collection.Name + "/" + recordId
+}
+
+// realtimePresenceCheckAccess checks whether auth can access the
+// presence topic in the format "collectionIdOrName/recordId"
+// (aka. whether auth satisfies the record collection view API rule).
+//
+// Returns the normalized topic ("collectionName/recordId").
+func realtimePresenceCheckAccess(app core.App, topic string, auth *core.Record) (string, error) {
+	collectionIdOrName, recordId, ok := strings.Cut(topic, "/")
+	if !ok || collectionIdOrName == "" || recordId == "" || strings.Contains(recordId, "/") {
+		return "", errors.New(`the presence topic must be in the format "collectionIdOrName/recordId"`)
+	}
+
+	collection, err := app.FindCachedCollectionByNameOrId(collectionIdOrName)
+	if err != nil {
+		return "", err
+	}
+
+	record, err := app.FindRecordById(collection, recordId)
+	if err != nil {
+		return "", err
+	}
+
+	requestInfo := &core.RequestInfo{
+		Context: core.RequestInfoContextRealtime,
+		Method:  http.MethodGet,
+		Auth:    auth,
+	}
+
+	if !realtimeCanAccessRecord(app, record, requestInfo, collection.ViewRule) {
+		return "", errors.New("the presence topic record view rule failed")
+	}
+
+	return collection.Name + "/" + record.Id, nil
+}
+
+// realtimePresenceTrack joins (or updates the state of) the local client in the presence topic
+// and notifies the other topic members.
+//
+// Note that the topic access is expected to be already checked.
+func realtimePresenceTrack(app core.App, clientId string, topic string, auth *core.Record, state json.RawMessage) (subscriptions.PresenceMember, error) {
+	broker := app.SubscriptionsBroker()
+
+	clientTopics := broker.ClientPresence(clientId)
+	if _, ok := clientTopics[topic]; !ok && len(clientTopics) >= realtimePresenceMaxTopics {
+		return subscriptions.PresenceMember{}, errRealtimePresenceMaxTopics
+	}
+
+	member := subscriptions.PresenceMember{ClientId: clientId}
+	if len(state) > 0 {
+		member.State = state
+	}
+	if auth != nil {
+		member.AuthId = auth.Id
+		member.AuthCollectionId = auth.Collection().Id
+	}
+
+	member, isNew := broker.TrackPresence(topic, member)
+
+	action := realtimePresenceUpdate
+	if isNew {
+		action = realtimePresenceJoin
+	}
+
+	realtimePresenceBroadcast(app, &realtimePresenceEvent{Action: action, Topic: topic, Member: member})
+
+	return member, nil
+}
+
+// realtimePresenceUntrack removes the local client from the presence topic
+// and notifies the other topic members.
+func realtimePresenceUntrack(app core.App, clientId string, topic string) {
+	member, ok := app.SubscriptionsBroker().UntrackPresence(topic, "", clientId)
+	if !ok {
+		return
+	}
+
+	realtimePresenceBroadcast(app, &realtimePresenceEvent{Action: realtimePresenceLeave, Topic: topic, Member: member})
+}
+
+// realtimePresenceUntrackAll removes the local client from all of its presence topics
+// (usually called on client disconnect).
+func realtimePresenceUntrackAll(app core.App, clientId string) {
+	for topic := range app.SubscriptionsBroker().ClientPresence(clientId) {
+		realtimePresenceUntrack(app, clientId, topic)
+	}
+}
+
+// realtimePresenceBroadcast notifies the local topic members about the presence change
+// and forwards it to the other app instances (if multi-instance mode is enabled).
+func realtimePresenceBroadcast(app core.App, event *realtimePresenceEvent) {
+	realtimePresenceNotify(app, event)
+
+	if app.MultiInstanceEnabled() {
+		if err := app.PublishPubSubMessage(realtimePresencePubSubTopic, event); err != nil {
+			app.Logger().Warn(
+				"Failed to publish realtime presence change",
+				slog.String("topic", event.Topic),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// realtimePresenceNotify sends the presence change to all local
+// topic members except the one that caused it.
+func realtimePresenceNotify(app core.App, event *realtimePresenceEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		app.Logger().Debug("Failed to serialize realtime presence change", slog.String("error", err.Error()))
+		return
+	}
+
+	msg := subscriptions.Message{
+		Name: realtimePresenceMessageName,
+		Data: data,
+	}
+
+	broker := app.SubscriptionsBroker()
+
+	for _, member := range broker.Presence(event.Topic) {
+		if !member.IsLocal() ||
+			(member.InstanceId == event.Member.InstanceId && member.ClientId == event.Member.ClientId) {
+			continue
+		}
+
+		client, err := broker.ClientById(member.ClientId)
+		if err != nil {
+			continue
+		}
+
+		routine.FireAndForget(func() {
+			client.Send(msg)
+		})
+	}
+}
+
+// bindRealtimePresenceEvents registers the multi-instance presence sync handlers.
+//
+// Each app instance forwards its local presence changes to the other instances
+// and periodically sends a heartbeat so that the members of no longer
+// running instances could be removed.
+func bindRealtimePresenceEvents(app core.App) {
+	if !app.MultiInstanceEnabled() {
+		return
+	}
+
+	var mu sync.Mutex
+	lastSeen := map[string]time.Time{}
+
+	app.OnPubSubMessage(realtimePresencePubSubTopic).BindFunc(func(e *core.PubSubMessageEvent) error {
+		event := new(realtimePresenceEvent)
+		if err := json.Unmarshal(e.Payload, event); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		lastSeen[e.InstanceId] = time.Now()
+		mu.Unlock()
+
+		broker := e.App.SubscriptionsBroker()
+
+		switch event.Action {
+		case realtimePresenceJoin, realtimePresenceUpdate:
+			event.Member.InstanceId = e.InstanceId
+
+			existing := broker.Presence(event.Topic)
+
+			member, isNew := broker.TrackPresence(event.Topic, event.Member)
+			if isNew {
+				event.Action = realtimePresenceJoin
+			} else {
+				event.Action = realtimePresenceUpdate
+
+				// skip the notification for unchanged resynced members
+				for _, m := range existing {
+					if m.InstanceId == member.InstanceId && m.ClientId == member.ClientId && string(m.State) == string(member.State) {
+						return e.Next()
+					}
+				}
+			}
+
+			event.Member = member
+			realtimePresenceNotify(e.App, event)
+		case realtimePresenceLeave:
+			member, ok := broker.UntrackPresence(event.Topic, e.InstanceId, event.Member.ClientId)
+			if ok {
+				event.Member = member
+				realtimePresenceNotify(e.App, event)
+			}
+		case realtimePresenceSync:
+			// republish all local members for the newly started instance
+			local := broker.FilterPresence(func(topic string, member subscriptions.PresenceMember) bool {
+				return member.IsLocal()
+			})
+			for topic, members := range local {
+				for _, member := range members {
+					err := e.App.PublishPubSubMessage(realtimePresencePubSubTopic, &realtimePresenceEvent{
+						Action: realtimePresenceJoin,
+						Topic:  topic,
+						Member: member,
+					})
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return e.Next()
+	})
+
+	app.Cron().Add("__pbRealtimePresenceHeartbeat__", "* * * * *", func() {
+		err := app.PublishPubSubMessage(realtimePresencePubSubTopic, &realtimePresenceEvent{Action: realtimePresenceHeartbeat})
+		if err != nil {
+			app.Logger().Warn("Failed to publish realtime presence heartbeat", slog.String("error", err.Error()))
+		}
+
+		// remove the members of the no longer active instances
+		mu.Lock()
+		staleInstances := map[string]struct{}{}
+		for instanceId, t := range lastSeen {
+			if time.Since(t) > realtimePresenceStaleTimeout {
+				staleInstances[instanceId] = struct{}{}
+				delete(lastSeen, instanceId)
+			}
+		}
+		mu.Unlock()
+
+		removed := app.SubscriptionsBroker().UntrackPresenceFunc(func(topic string, member subscriptions.PresenceMember) bool {
+			if member.IsLocal() {
+				return false
+			}
+
+			mu.Lock()
+			_, seen := lastSeen[member.InstanceId]
+			mu.Unlock()
+
+			_, stale := staleInstances[member.InstanceId]
+
+			return stale || !seen
+		})
+
+		for topic, members := range removed {
+			for _, member := range members {
+				realtimePresenceNotify(app, &realtimePresenceEvent{Action: realtimePresenceLeave, Topic: topic, Member: member})
+			}
+		}
+	})
+
+	// request the current presence state from the other running instances
+	err := app.PublishPubSubMessage(realtimePresencePubSubTopic, &realtimePresenceEvent{Action: realtimePresenceSync})
+	if err != nil {
+		app.Logger().Warn("Failed to request realtime presence sync", slog.String("error", err.Error()))
+	}
+}
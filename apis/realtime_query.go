@@ -0,0 +1,635 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/spf13/cast"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/list"
+	"github.com/thewandererbg/pgbase/tools/routine"
+	"github.com/thewandererbg/pgbase/tools/search"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+)
+
+const (
+	// RealtimeQueryTopicPrefix is the realtime subscription topic prefix
+	// of the live query subscriptions.
+	//
+	// The query is defined with the "filter", "sort" and "limit" subscription
+	// options query parameters, for example:
+	//
+	//	@query/posts?options={"query":{"filter":"published=true","sort":"-created","limit":10}}
+	//
+	// The "expand" and "fields" query parameters are also supported
+	// and are applied to the records of the emitted changes.
+	RealtimeQueryTopicPrefix = "@query/"
+
+	// realtimeQueryLimitQueryParam is the name of the live query subscription limit option.
+	realtimeQueryLimitQueryParam = "limit"
+
+	// realtimeQueryMaxLimit is the max allowed live query limit.
+	realtimeQueryMaxLimit = 500
+
+	// realtimeQueryStateKey is the name of the realtime client store key
+	// that holds the client live query subscriptions state.
+	realtimeQueryStateKey = "@queryState"
+
+	// realtimeQueryDebounce is the delay after a record change before
+	// reevaluating the affected live query subscriptions so that
+	// the changes in quick succession could be coalesced.
+	realtimeQueryDebounce = 20 * time.Millisecond
+)
+
+// Live query change actions.
+const (
+	realtimeQueryActionEnter  = "enter"
+	realtimeQueryActionLeave  = "leave"
+	realtimeQueryActionUpdate = "update"
+	realtimeQueryActionMove   = "move"
+)
+
+// realtimeQueryChange is the data of a single live query subscription message.
+//
+// Index is the record position in the new query result set (-1 for "leave")
+// and PrevIndex is its position in the previous one (-1 for "enter").
+//
+// The changes of a single record event are sent in order so that
+// clients could apply them sequentially: first remove the "leave"
+// records, then remove and reinsert at Index the "move" records,
+// insert at Index the "enter" records and replace the "update" ones.
+type realtimeQueryChange struct {
+	Record    any    `json:"record,omitempty"` /* map or core.Record */
+	Action    string `json:"action"`
+	Id        string `json:"id"`
+	Index     int    `json:"index"`
+	PrevIndex int    `json:"prevIndex"`
+}
+
+// realtimeQueryState holds the last known result set of the
+// client live query subscriptions (as ordered list of record ids).
+type realtimeQueryState struct {
+	results map[string][]string
+	pending []subscriptions.Message
+	mu      sync.Mutex
+	sending bool
+
+	// dirty holds the changed record ids (and whether they were updated)
+	// of the subscriptions scheduled for reevaluation.
+	dirty     map[string]map[string]bool
+	dirtyMu   sync.Mutex
+	scheduled bool
+}
+
+// markDirty registers the record change for the specified subscriptions
+// and schedules their reevaluation (if not already).
+func (s *realtimeQueryState) markDirty(app core.App, client subscriptions.Client, subs map[string]subscriptions.SubscriptionOptions, recordId string, action string) {
+	s.dirtyMu.Lock()
+	defer s.dirtyMu.Unlock()
+
+	if s.dirty == nil {
+		s.dirty = map[string]map[string]bool{}
+	}
+
+	for sub := range subs {
+		changed, ok := s.dirty[sub]
+		if !ok {
+			changed = map[string]bool{}
+			s.dirty[sub] = changed
+		}
+		changed[recordId] = changed[recordId] || action == "update"
+	}
+
+	if s.scheduled {
+		return
+	}
+
+	s.scheduled = true
+
+	time.AfterFunc(realtimeQueryDebounce, func() {
+		realtimeFlushClientQueries(app, client, s)
+	})
+}
+
+// takeDirty returns and resets the current dirty subscriptions.
+func (s *realtimeQueryState) takeDirty() map[string]map[string]bool {
+	s.dirtyMu.Lock()
+	defer s.dirtyMu.Unlock()
+
+	dirty := s.dirty
+	s.dirty = nil
+	s.scheduled = false
+
+	return dirty
+}
+
+// enqueue schedules the messages to be sent to the client
+// preserving their order between the different record events.
+//
+// Must be called with locked s.mu.
+func (s *realtimeQueryState) enqueue(client subscriptions.Client, messages []subscriptions.Message) {
+	s.pending = append(s.pending, messages...)
+
+	if s.sending || len(s.pending) == 0 {
+		return
+	}
+
+	s.sending = true
+
+	routine.FireAndForget(func() {
+		for {
+			s.mu.Lock()
+			if len(s.pending) == 0 {
+				s.sending = false
+				s.mu.Unlock()
+				return
+			}
+			msg := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+
+			client.Send(msg)
+		}
+	})
+}
+
+// realtimeSyncQuerySubscriptions initializes the result set of the newly added
+// client live query subscriptions (sending an "enter" change for each found record)
+// and removes the state of the ones that are no longer subscribed.
+func realtimeSyncQuerySubscriptions(app core.App, client subscriptions.Client) {
+	subs := client.Subscriptions(RealtimeQueryTopicPrefix)
+
+	state, _ := client.Get(realtimeQueryStateKey).(*realtimeQueryState)
+	if state == nil {
+		if len(subs) == 0 {
+			return
+		}
+
+		state = &realtimeQueryState{results: map[string][]string{}}
+		client.Set(realtimeQueryStateKey, state)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	for sub := range state.results {
+		if _, ok := subs[sub]; !ok {
+			delete(state.results, sub)
+		}
+	}
+
+	// sort for deterministic messages order
+	sortedSubs := make([]string, 0, len(subs))
+	for sub := range subs {
+		sortedSubs = append(sortedSubs, sub)
+	}
+	slices.Sort(sortedSubs)
+
+	var messages []subscriptions.Message
+
+	for _, sub := range sortedSubs {
+		if _, ok := state.results[sub]; ok {
+			continue // already synced
+		}
+
+		// mark as synced even on error so that the subscription
+		// could still receive its changes on the next successful query
+		state.results[sub] = []string{}
+
+		records, requestInfo, err := realtimeExecQuery(app, client, sub, subs[sub], nil)
+		if err != nil {
+			app.Logger().Debug(
+				"[liveQuery] failed to execute the subscription query",
+				slog.String("clientId", client.Id()),
+				slog.String("sub", sub),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		changes := realtimeQueryDiff(nil, records, nil)
+
+		state.results[sub] = realtimeQueryRecordIds(records)
+
+		messages = append(messages, realtimeQueryMessages(app, sub, subs[sub], requestInfo, records, changes)...)
+	}
+
+	state.enqueue(client, messages)
+}
+
+// realtimeNotifyQueries schedules the reevaluation of the client live query
+// subscriptions of the specified collection.
+//
+// The subscriptions are reevaluated in the background after a short delay,
+// coalescing all record changes that happened in the meantime.
+func realtimeNotifyQueries(app core.App, collection *core.Collection, recordId string, action string) {
+	prefixes := []string{
+		RealtimeQueryTopicPrefix + collection.Name + "?",
+		RealtimeQueryTopicPrefix + collection.Id + "?",
+	}
+
+	for _, chunk := range app.SubscriptionsBroker().ChunkedClients(clientsChunkSize) {
+		for _, client := range chunk {
+			state, _ := client.Get(realtimeQueryStateKey).(*realtimeQueryState)
+			if state == nil {
+				continue // no live query subscriptions
+			}
+
+			subs := client.Subscriptions(prefixes...)
+			if len(subs) == 0 {
+				continue
+			}
+
+			state.markDirty(app, client, subs, recordId, action)
+		}
+	}
+}
+
+// realtimeFlushClientQueries reevaluates the dirty client live query
+// subscriptions and sends their result set changes.
+func realtimeFlushClientQueries(app core.App, client subscriptions.Client, state *realtimeQueryState) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	dirty := state.takeDirty()
+	if len(dirty) == 0 {
+		return
+	}
+
+	// sort for deterministic messages order
+	sortedSubs := make([]string, 0, len(dirty))
+	for sub := range dirty {
+		sortedSubs = append(sortedSubs, sub)
+	}
+	slices.Sort(sortedSubs)
+
+	// the subscriptions could have been changed in the meantime
+	subs := client.Subscriptions(RealtimeQueryTopicPrefix)
+
+	var messages []subscriptions.Message
+
+	for _, sub := range sortedSubs {
+		options, ok := subs[sub]
+		if !ok {
+			continue // no longer subscribed
+		}
+
+		prev, ok := state.results[sub]
+		if !ok {
+			continue // not synced yet
+		}
+
+		changed := dirty[sub]
+
+		if !realtimeQueryCouldChange(app, client, sub, options, prev, changed) {
+			continue
+		}
+
+		records, requestInfo, err := realtimeExecQuery(app, client, sub, options, nil)
+		if err != nil {
+			app.Logger().Debug(
+				"[liveQuery] failed to execute the subscription query",
+				slog.String("clientId", client.Id()),
+				slog.String("sub", sub),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		changes := realtimeQueryDiff(prev, records, changed)
+		if len(changes) == 0 {
+			continue
+		}
+
+		state.results[sub] = realtimeQueryRecordIds(records)
+
+		messages = append(messages, realtimeQueryMessages(app, sub, options, requestInfo, records, changes)...)
+	}
+
+	state.enqueue(client, messages)
+}
+
+// realtimeQueryCouldChange reports whether any of the changed records
+// could affect the live query subscription result set, aka. whether
+// it is part of the current result set or it matches the subscription
+// filter and the collection list rule (and therefore it could enter).
+func realtimeQueryCouldChange(
+	app core.App,
+	client subscriptions.Client,
+	sub string,
+	options subscriptions.SubscriptionOptions,
+	prev []string,
+	changed map[string]bool,
+) bool {
+	ids := make([]string, 0, len(changed))
+	for id := range changed {
+		if slices.Contains(prev, id) {
+			return true
+		}
+		ids = append(ids, id)
+	}
+
+	records, _, err := realtimeExecQuery(app, client, sub, options, ids)
+	if err != nil {
+		// let the full query handle (and report) the error
+		return true
+	}
+
+	return len(records) > 0
+}
+
+// realtimeExecQuery executes the live query subscription search
+// against the client auth state and the collection list rule.
+//
+// If onlyIds is not nil, the search is limited to the specified record ids
+// and it is not sorted (e.g. to check whether they match the subscription filter).
+func realtimeExecQuery(
+	app core.App,
+	client subscriptions.Client,
+	sub string,
+	options subscriptions.SubscriptionOptions,
+	onlyIds []string,
+) ([]*core.Record, *core.RequestInfo, error) {
+	collectionIdOrName := strings.TrimPrefix(sub, RealtimeQueryTopicPrefix)
+	collectionIdOrName, _, _ = strings.Cut(collectionIdOrName, "?")
+
+	collection, err := app.FindCachedCollectionByNameOrId(collectionIdOrName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+
+	// mock request data
+	requestInfo := &core.RequestInfo{
+		Context: core.RequestInfoContextRealtime,
+		Method:  "GET",
+		Query:   options.Query,
+		Headers: options.Headers,
+		Auth:    clientAuth,
+	}
+
+	if collection.ListRule == nil && !requestInfo.HasSuperuserAuth() {
+		return nil, nil, errors.New("only superusers can query the collection")
+	}
+
+	// forbid users and guests to query special filter/sort fields
+	err = checkForSuperuserOnlyRuleFields(requestInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit := search.DefaultPerPage
+	if raw := options.Query[realtimeQueryLimitQueryParam]; raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return nil, nil, errors.New("invalid live query limit")
+		}
+	}
+	limit = min(limit, realtimeQueryMaxLimit)
+
+	query := app.RecordQuery(collection)
+
+	if onlyIds != nil {
+		query.AndWhere(dbx.In(collection.Name+".id", list.ToInterfaceSlice(onlyIds)...))
+		limit = len(onlyIds)
+	}
+
+	fieldsResolver := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+
+	if !requestInfo.HasSuperuserAuth() && *collection.ListRule != "" {
+		expr, err := search.FilterData(*collection.ListRule).BuildExpr(fieldsResolver)
+		if err != nil {
+			return nil, nil, err
+		}
+		query.AndWhere(expr)
+	}
+
+	// hidden fields are searchable only by superusers
+	fieldsResolver.SetAllowHiddenFields(requestInfo.HasSuperuserAuth())
+
+	searchProvider := search.NewProvider(fieldsResolver).
+		Query(query).
+		SkipTotal(true).
+		Page(1).
+		PerPage(limit)
+
+	if raw := options.Query[search.SortQueryParam]; raw != "" && onlyIds == nil {
+		for _, sortField := range search.ParseSortFromString(raw) {
+			searchProvider.AddSort(sortField)
+		}
+	}
+
+	if raw := options.Query[search.FilterQueryParam]; raw != "" {
+		searchProvider.AddFilter(search.FilterData(raw))
+	}
+
+	records := []*core.Record{}
+
+	_, err = searchProvider.Exec(&records)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return records, requestInfo, nil
+}
+
+// realtimeQueryDiff returns the changes between the prev and next query result sets.
+//
+// changed holds the ids of the records that triggered the reevaluation
+// and whether an "update" change should be emitted for them.
+//
+// Since only the changed records are expected to change their positions, the
+// other records are reported as moved only if their relative order has
+// also changed (e.g. because of a sort by a relation field).
+func realtimeQueryDiff(prev []string, next []*core.Record, changed map[string]bool) []realtimeQueryChange {
+	prevIndexes := make(map[string]int, len(prev))
+	for i, id := range prev {
+		prevIndexes[id] = i
+	}
+
+	nextIndexes := make(map[string]int, len(next))
+	for i, r := range next {
+		nextIndexes[r.Id] = i
+	}
+
+	var changes []realtimeQueryChange
+
+	// leave (in reverse order so that the indexes remain valid while removing)
+	for i := len(prev) - 1; i >= 0; i-- {
+		if _, ok := nextIndexes[prev[i]]; !ok {
+			changes = append(changes, realtimeQueryChange{
+				Action:    realtimeQueryActionLeave,
+				Id:        prev[i],
+				Index:     -1,
+				PrevIndex: i,
+			})
+		}
+	}
+
+	// the relative order of the records present in both result sets
+	prevCommon := make([]string, 0, len(prev))
+	for _, id := range prev {
+		if _, ok := nextIndexes[id]; ok {
+			prevCommon = append(prevCommon, id)
+		}
+	}
+	nextCommon := make([]string, 0, len(next))
+	for _, r := range next {
+		if _, ok := prevIndexes[r.Id]; ok {
+			nextCommon = append(nextCommon, r.Id)
+		}
+	}
+
+	withoutChanged := func(ids []string) []string {
+		return slices.DeleteFunc(slices.Clone(ids), func(id string) bool {
+			_, ok := changed[id]
+			return ok
+		})
+	}
+
+	moved := map[string]bool{}
+	if slices.Equal(withoutChanged(prevCommon), withoutChanged(nextCommon)) {
+		// only the changed records could have moved
+		for i, id := range prevCommon {
+			if _, ok := changed[id]; ok && nextCommon[i] != id {
+				moved[id] = true
+			}
+		}
+	} else {
+		for i, id := range nextCommon {
+			if prevCommon[i] != id {
+				moved[id] = true
+			}
+		}
+	}
+
+	// enter, move and update (in the new result set order)
+	for i, r := range next {
+		prevIndex, existing := prevIndexes[r.Id]
+
+		switch {
+		case !existing:
+			changes = append(changes, realtimeQueryChange{
+				Action:    realtimeQueryActionEnter,
+				Id:        r.Id,
+				Index:     i,
+				PrevIndex: -1,
+			})
+		case moved[r.Id]:
+			changes = append(changes, realtimeQueryChange{
+				Action:    realtimeQueryActionMove,
+				Id:        r.Id,
+				Index:     i,
+				PrevIndex: prevIndex,
+			})
+		case changed[r.Id]:
+			changes = append(changes, realtimeQueryChange{
+				Action:    realtimeQueryActionUpdate,
+				Id:        r.Id,
+				Index:     i,
+				PrevIndex: prevIndex,
+			})
+		}
+	}
+
+	return changes
+}
+
+// realtimeQueryMessages converts the live query changes into subscription messages.
+func realtimeQueryMessages(
+	app core.App,
+	sub string,
+	options subscriptions.SubscriptionOptions,
+	requestInfo *core.RequestInfo,
+	records []*core.Record,
+	changes []realtimeQueryChange,
+) []subscriptions.Message {
+	canAccess := func(record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool {
+		return realtimeCanAccessRecord(app, record, requestInfo, accessRule)
+	}
+
+	messages := make([]subscriptions.Message, 0, len(changes))
+
+	for _, change := range changes {
+		if change.Index >= 0 {
+			payload, ok := realtimeRecordPayload(app, requestInfo, options, sub, records[change.Index], canAccess)
+			if !ok {
+				continue
+			}
+			change.Record = payload
+		}
+
+		data, err := json.Marshal(change)
+		if err != nil {
+			app.Logger().Debug(
+				"[liveQuery] data marshal error",
+				slog.String("id", change.Id),
+				slog.String("sub", sub),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		messages = append(messages, subscriptions.Message{
+			Name: sub,
+			Data: data,
+		})
+	}
+
+	return messages
+}
+
+func realtimeQueryRecordIds(records []*core.Record) []string {
+	ids := make([]string, len(records))
+	for i, r := range records {
+		ids[i] = r.Id
+	}
+	return ids
+}
+
+// bindRealtimeQueryEvents registers the live query subscriptions record change handlers.
+//
+// The handlers only schedule the reevaluation of the affected subscriptions
+// so that the live queries are executed outside of the record write path.
+//
+// Note that the live queries are reevaluated only on changes of their own
+// collection records (e.g. a filter by a relation field will not be
+// reevaluated on change of the related record).
+func bindRealtimeQueryEvents(app core.App) {
+	handler := func(action string) func(e *core.ModelEvent) error {
+		return func(e *core.ModelEvent) error {
+			collection := realtimeResolveRecordCollection(e.App, e.Model)
+			if collection != nil {
+				// use the main app because the reevaluation happens
+				// in the background (e.App could be a transaction)
+				realtimeNotifyQueries(app, collection, cast.ToString(e.Model.PK()), action)
+			}
+
+			return e.Next()
+		}
+	}
+
+	app.OnModelAfterCreateSuccess().Bind(&hook.Handler[*core.ModelEvent]{
+		Func:     handler("create"),
+		Priority: -99,
+	})
+
+	app.OnModelAfterUpdateSuccess().Bind(&hook.Handler[*core.ModelEvent]{
+		Func:     handler("update"),
+		Priority: -99,
+	})
+
+	app.OnModelAfterDeleteSuccess().Bind(&hook.Handler[*core.ModelEvent]{
+		Func:     handler("delete"),
+		Priority: -99,
+	})
+}
@@ -0,0 +1,262 @@
+package apis_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/apis"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+)
+
+func TestRealtimeQuerySubscriptions(t *testing.T) {
+	client := subscriptions.NewDefaultClient()
+
+	resetClient := func() {
+		client.Unsubscribe()
+		client.Unset("@queryState")
+	}
+
+	type change struct {
+		Action    string         `json:"action"`
+		Id        string         `json:"id"`
+		Index     int            `json:"index"`
+		PrevIndex int            `json:"prevIndex"`
+		Record    map[string]any `json:"record"`
+	}
+
+	readChanges := func(t testing.TB, sub string, total int) []change {
+		var changes []change
+
+		for i := 0; i < total; i++ {
+			select {
+			case msg := <-client.Channel():
+				if msg.Name != sub {
+					t.Fatalf("Expected message %q, got %q", sub, msg.Name)
+				}
+
+				var c change
+				if err := json.Unmarshal(msg.Data, &c); err != nil {
+					t.Fatal(err)
+				}
+				changes = append(changes, c)
+			case <-time.After(100 * time.Millisecond):
+				t.Fatalf("Expected %d changes, got %d", total, len(changes))
+			}
+		}
+
+		// no more messages are expected
+		select {
+		case msg := <-client.Channel():
+			t.Fatalf("Unexpected message %q: %s", msg.Name, msg.Data)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		return changes
+	}
+
+	checkChange := func(t testing.TB, c change, action string, id string, index int, prevIndex int) {
+		if c.Action != action || c.Id != id || c.Index != index || c.PrevIndex != prevIndex {
+			t.Fatalf("Expected %s %s (index %d, prevIndex %d), got %v", action, id, index, prevIndex, c)
+		}
+
+		if action == "leave" {
+			if c.Record != nil {
+				t.Fatalf("Expected no leave record, got %v", c.Record)
+			}
+		} else if c.Record["id"] != id {
+			t.Fatalf("Expected record %q, got %v", id, c.Record)
+		}
+	}
+
+	updateTitle := func(t testing.TB, app *tests.TestApp, id string, title string) {
+		record, err := app.FindRecordById("demo2", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record.Set("title", title)
+
+		if err := app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sub := apis.RealtimeQueryTopicPrefix + `demo2?options={"query":{"filter":"title!='live_query'","sort":"id","limit":2,"fields":"id"}}`
+
+	subscribeBody := func() *strings.Reader {
+		raw, _ := json.Marshal(map[string]any{
+			"clientId":      client.Id(),
+			"subscriptions": []string{sub},
+		})
+		return strings.NewReader(string(raw))
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "initial result set",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   subscribeBody(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				changes := readChanges(t, sub, 2)
+				checkChange(t, changes[0], "enter", "0yxhwia2amd8gec", 0, -1)
+				checkChange(t, changes[1], "enter", "achvryl401bhse3", 1, -1)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             2,
+			},
+		},
+		{
+			Name:   "update within the result set",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   subscribeBody(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readChanges(t, sub, 2)
+
+				updateTitle(t, app, "achvryl401bhse3", "live_query_update")
+
+				changes := readChanges(t, sub, 1)
+				checkChange(t, changes[0], "update", "achvryl401bhse3", 1, 1)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             2,
+			},
+		},
+		{
+			Name:   "coalesced updates within the result set",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   subscribeBody(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readChanges(t, sub, 2)
+
+				// the after success hooks of the transaction changes
+				// are triggered together after the commit
+				err := app.RunInTransaction(func(txApp core.App) error {
+					record, err := txApp.FindRecordById("demo2", "achvryl401bhse3")
+					if err != nil {
+						return err
+					}
+
+					for _, title := range []string{"live_query_update1", "live_query_update2"} {
+						record.Set("title", title)
+						if err := txApp.Save(record); err != nil {
+							return err
+						}
+					}
+
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				changes := readChanges(t, sub, 1)
+				checkChange(t, changes[0], "update", "achvryl401bhse3", 1, 1)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             2,
+			},
+		},
+		{
+			Name:   "update outside of the result set",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   subscribeBody(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readChanges(t, sub, 2)
+
+				updateTitle(t, app, "llvuca81nly1qls", "live_query_update")
+
+				readChanges(t, sub, 0)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             2,
+			},
+		},
+		{
+			Name:   "leave and enter the result set",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   subscribeBody(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readChanges(t, sub, 2)
+
+				updateTitle(t, app, "0yxhwia2amd8gec", "live_query")
+
+				changes := readChanges(t, sub, 2)
+				checkChange(t, changes[0], "leave", "0yxhwia2amd8gec", -1, 0)
+				checkChange(t, changes[1], "enter", "llvuca81nly1qls", 1, -1)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             2,
+			},
+		},
+		{
+			Name:   "superuser only filter fields",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body: strings.NewReader(`{
+				"clientId": "` + client.Id() + `",
+				"subscriptions": ["@query/demo2?options={\"query\":{\"filter\":\"@collection.demo2.title='test1'\"}}"]
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readChanges(t, "", 0)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,642 @@
+package apis
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/routine"
+	"github.com/thewandererbg/pgbase/tools/search"
+	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+const (
+	// realtimeResyncMessageName is the name of the realtime message used to
+	// notify the client that some of the missed messages can no longer
+	// be replayed and it should refetch its data.
+	realtimeResyncMessageName = subscriptions.ResyncMessageName
+
+	// realtimeLastEventIdKey is the name of the realtime client store key
+	// that holds the last received event id of the previous connection.
+	realtimeLastEventIdKey = "lastEventId"
+
+	// realtimeLastEventIdQueryParam is the name of the query parameter that could be
+	// used as alternative to the Last-Event-ID header (e.g. for custom SSE clients).
+	realtimeLastEventIdQueryParam = "lastEventId"
+
+	// realtimeReplayMaxTopicEvents is the max number of buffered events per topic (aka. collection).
+	realtimeReplayMaxTopicEvents = 100
+
+	// realtimeEventBufferStoreKey is the app store key of the current realtime event buffer.
+	realtimeEventBufferStoreKey = "@realtimeEventBuffer"
+
+	// realtimeAuxEventBufferEpoch is the event id epoch of the shared aux db buffer.
+	realtimeAuxEventBufferEpoch = "db"
+)
+
+// realtimeEvent is a single buffered record event.
+type realtimeEvent struct {
+	Id     string
+	Action string
+	Record *core.Record
+}
+
+// realtimeEventBuffer stores the latest record events so that they
+// could be replayed to the reconnected realtime clients.
+//
+// The event ids have the format "epoch-seq", where seq is monotonically increasing
+// number and epoch identifies the buffer instance (e.g. it changes on app restart).
+type realtimeEventBuffer interface {
+	// Add stores a new record event and returns its assigned id.
+	Add(app core.App, action string, record *core.Record) (string, error)
+
+	// Skip marks that a topic (aka. collection id) event was not stored
+	// so that the topic events before it can no longer be replayed.
+	Skip(app core.App, topic string) error
+
+	// Since returns the ordered list of buffered events of the specified
+	// topics (aka. collection ids) that happened after lastEventId.
+	//
+	// The returned bool is false if some of the events are no longer available
+	// (e.g. evicted from the buffer or lastEventId is from another epoch).
+	Since(app core.App, lastEventId string, topics []string) ([]*realtimeEvent, bool, error)
+}
+
+// realtimeGetEventBuffer returns the app realtime event buffer.
+//
+// In multi-instance mode the buffer is stored in the shared aux db,
+// otherwise - in memory.
+func realtimeGetEventBuffer(app core.App) realtimeEventBuffer {
+	return app.Store().GetOrSet(realtimeEventBufferStoreKey, func() any {
+		if app.MultiInstanceEnabled() {
+			return &realtimeAuxEventBuffer{}
+		}
+
+		return newRealtimeMemoryEventBuffer()
+	}).(realtimeEventBuffer)
+}
+
+// realtimeStoreEvent stores the record event in the app event buffer and returns its id.
+//
+// The event is stored only if at least one of the app clients is subscribed
+// to the record collection. Otherwise the topic is marked as skipped and
+// the clients that reconnect with an older event id will receive PB_RESYNC.
+func realtimeStoreEvent(app core.App, action string, record *core.Record) (string, error) {
+	buffer := realtimeGetEventBuffer(app)
+
+	collection := record.Collection()
+
+	if !realtimeHasTopicSubscribers(app, collection) {
+		return "", buffer.Skip(app, collection.Id)
+	}
+
+	return buffer.Add(app, action, record)
+}
+
+// realtimeHasTopicSubscribers checks whether any of the app clients
+// is subscribed to the specified collection records.
+func realtimeHasTopicSubscribers(app core.App, collection *core.Collection) bool {
+	prefixes := []string{
+		collection.Name + "/",
+		collection.Id + "/",
+		collection.Name + "?",
+		collection.Id + "?",
+	}
+
+	for _, client := range app.SubscriptionsBroker().Clients() {
+		if len(client.Subscriptions(prefixes...)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatRealtimeEventId(epoch string, seq int64) string {
+	return epoch + "-" + strconv.FormatInt(seq, 10)
+}
+
+func parseRealtimeEventId(id string) (string, int64, bool) {
+	epoch, rawSeq, ok := strings.Cut(id, "-")
+	if !ok || epoch == "" {
+		return "", 0, false
+	}
+
+	seq, err := strconv.ParseInt(rawSeq, 10, 64)
+	if err != nil || seq < 0 {
+		return "", 0, false
+	}
+
+	return epoch, seq, true
+}
+
+// -------------------------------------------------------------------
+
+type realtimeMemoryEventBuffer struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    int64
+	topics map[string]*realtimeMemoryTopicEvents
+}
+
+type realtimeMemoryTopicEvents struct {
+	events []*realtimeMemoryEvent
+
+	// evictedSeq is the seq of the latest evicted topic event
+	evictedSeq int64
+}
+
+type realtimeMemoryEvent struct {
+	seq int64
+	realtimeEvent
+}
+
+func newRealtimeMemoryEventBuffer() *realtimeMemoryEventBuffer {
+	return &realtimeMemoryEventBuffer{
+		epoch:  strconv.FormatInt(time.Now().UnixMilli(), 36) + security.PseudorandomString(4),
+		topics: map[string]*realtimeMemoryTopicEvents{},
+	}
+}
+
+// Add implements [realtimeEventBuffer.Add] interface method.
+func (b *realtimeMemoryEventBuffer) Add(app core.App, action string, record *core.Record) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+
+	event := &realtimeMemoryEvent{seq: b.seq}
+	event.Id = formatRealtimeEventId(b.epoch, b.seq)
+	event.Action = action
+	event.Record = record.Fresh()
+
+	topic, ok := b.topics[record.Collection().Id]
+	if !ok {
+		topic = &realtimeMemoryTopicEvents{}
+		b.topics[record.Collection().Id] = topic
+	}
+
+	topic.events = append(topic.events, event)
+
+	if total := len(topic.events); total > realtimeReplayMaxTopicEvents {
+		evicted := total - realtimeReplayMaxTopicEvents
+		topic.evictedSeq = topic.events[evicted-1].seq
+		topic.events = slices.Clone(topic.events[evicted:])
+	}
+
+	return event.Id, nil
+}
+
+// Skip implements [realtimeEventBuffer.Skip] interface method.
+func (b *realtimeMemoryEventBuffer) Skip(app core.App, topicId string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+
+	topic, ok := b.topics[topicId]
+	if !ok {
+		topic = &realtimeMemoryTopicEvents{}
+		b.topics[topicId] = topic
+	}
+
+	// the older topic events are no longer useful
+	topic.events = nil
+	topic.evictedSeq = b.seq
+
+	return nil
+}
+
+// Since implements [realtimeEventBuffer.Since] interface method.
+func (b *realtimeMemoryEventBuffer) Since(app core.App, lastEventId string, topics []string) ([]*realtimeEvent, bool, error) {
+	epoch, lastSeq, ok := parseRealtimeEventId(lastEventId)
+	if !ok || epoch != b.epoch {
+		return nil, false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var found []*realtimeMemoryEvent
+
+	for _, topicId := range topics {
+		topic, ok := b.topics[topicId]
+		if !ok {
+			continue
+		}
+
+		if topic.evictedSeq > lastSeq {
+			return nil, false, nil
+		}
+
+		for _, event := range topic.events {
+			if event.seq > lastSeq {
+				found = append(found, event)
+			}
+		}
+	}
+
+	slices.SortFunc(found, func(a, b *realtimeMemoryEvent) int {
+		return int(a.seq - b.seq)
+	})
+
+	result := make([]*realtimeEvent, len(found))
+	for i, event := range found {
+		result[i] = &event.realtimeEvent
+	}
+
+	return result, true, nil
+}
+
+// -------------------------------------------------------------------
+
+// realtimeAuxEventBuffer is a realtime event buffer that is stored in
+// the aux db so that the events could be replayed by any app instance.
+//
+// The event ids are assigned and committed under a transaction advisory lock
+// so that an event id is never visible before the smaller ones
+// (otherwise [realtimeAuxEventBuffer.Since] could miss an event that was
+// committed after a bigger id was already delivered).
+//
+// The old topic events are periodically pruned by the app cron.
+type realtimeAuxEventBuffer struct {
+	mu sync.Mutex
+
+	// skipped holds the topics that were already marked as skipped
+	// since the last stored event of the current app instance
+	// (the record events of an instance are broadcasted only to its own clients).
+	skipped map[string]struct{}
+}
+
+// Add implements [realtimeEventBuffer.Add] interface method.
+//
+// Only the client-safe record export is stored
+// (aka. without the hidden, password and tokenKey fields).
+func (b *realtimeAuxEventBuffer) Add(app core.App, action string, record *core.Record) (string, error) {
+	safeRecord := record.Fresh()
+	safeRecord.IgnoreEmailVisibility(true) // the email visibility is checked per client on replay
+
+	export := safeRecord.PublicExport()
+	delete(export, core.FieldNameCollectionId)
+	delete(export, core.FieldNameCollectionName)
+
+	raw, err := json.Marshal(export)
+	if err != nil {
+		return "", err
+	}
+
+	var seq int64
+
+	err = app.AuxRunInTransaction(func(txApp core.App) error {
+		if err := realtimeLockAuxEvents(txApp); err != nil {
+			return err
+		}
+
+		return txApp.AuxDB().NewQuery(
+			"INSERT INTO {{_realtimeEvents}} ([[topic]], [[action]], [[record]]) VALUES ({:topic}, {:action}, {:record}) RETURNING [[id]]",
+		).Bind(dbx.Params{
+			"topic":  record.Collection().Id,
+			"action": action,
+			"record": string(raw),
+		}).Row(&seq)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	delete(b.skipped, record.Collection().Id)
+	b.mu.Unlock()
+
+	return formatRealtimeEventId(realtimeAuxEventBufferEpoch, seq), nil
+}
+
+// Skip implements [realtimeEventBuffer.Skip] interface method.
+func (b *realtimeAuxEventBuffer) Skip(app core.App, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.skipped[topic]; ok {
+		return nil // already marked
+	}
+
+	err := app.AuxRunInTransaction(func(txApp core.App) error {
+		if err := realtimeLockAuxEvents(txApp); err != nil {
+			return err
+		}
+
+		_, err := txApp.AuxDB().NewQuery(`
+			INSERT INTO {{_realtimeEventsPruned}} ([[topic]], [[lastId]])
+			VALUES ({:topic}, nextval(pg_get_serial_sequence('{{_realtimeEvents}}', 'id')))
+			ON CONFLICT ([[topic]]) DO UPDATE SET [[lastId]] = GREATEST({{_realtimeEventsPruned}}.[[lastId]], EXCLUDED.[[lastId]])
+		`).Bind(dbx.Params{"topic": topic}).Execute()
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if b.skipped == nil {
+		b.skipped = map[string]struct{}{}
+	}
+	b.skipped[topic] = struct{}{}
+
+	return nil
+}
+
+// realtimeLockAuxEvents acquires the _realtimeEvents id assignment lock
+// for the duration of the current aux db transaction.
+func realtimeLockAuxEvents(txApp core.App) error {
+	_, err := txApp.AuxDB().NewQuery("SELECT pg_advisory_xact_lock(hashtext('_realtimeEvents'))").Execute()
+	return err
+}
+
+// Since implements [realtimeEventBuffer.Since] interface method.
+func (b *realtimeAuxEventBuffer) Since(app core.App, lastEventId string, topics []string) ([]*realtimeEvent, bool, error) {
+	epoch, lastSeq, ok := parseRealtimeEventId(lastEventId)
+	if !ok || epoch != realtimeAuxEventBufferEpoch {
+		return nil, false, nil
+	}
+
+	if len(topics) == 0 {
+		return nil, true, nil
+	}
+
+	topicsParam := make([]any, len(topics))
+	for i, topic := range topics {
+		topicsParam[i] = topic
+	}
+
+	var pruned int
+	err := app.AuxDB().Select("count(*)").
+		From("_realtimeEventsPruned").
+		AndWhere(dbx.In("topic", topicsParam...)).
+		AndWhere(dbx.NewExp("[[lastId]] > {:lastSeq}", dbx.Params{"lastSeq": lastSeq})).
+		Row(&pruned)
+	if err != nil {
+		return nil, false, err
+	}
+	if pruned > 0 {
+		return nil, false, nil
+	}
+
+	rows := []struct {
+		Id     int64         `db:"id"`
+		Topic  string        `db:"topic"`
+		Action string        `db:"action"`
+		Record types.JSONRaw `db:"record"`
+	}{}
+
+	err = app.AuxDB().Select("id", "topic", "action", "record").
+		From("_realtimeEvents").
+		AndWhere(dbx.In("topic", topicsParam...)).
+		AndWhere(dbx.NewExp("[[id]] > {:lastSeq}", dbx.Params{"lastSeq": lastSeq})).
+		OrderBy("id ASC").
+		All(&rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := make([]*realtimeEvent, 0, len(rows))
+
+	for _, row := range rows {
+		collection, err := app.FindCachedCollectionByNameOrId(row.Topic)
+		if err != nil {
+			continue // deleted collection
+		}
+
+		data := map[string]any{}
+		if err := json.Unmarshal(row.Record, &data); err != nil {
+			return nil, false, err
+		}
+
+		record := core.NewRecord(collection)
+		record.Load(data)
+
+		result = append(result, &realtimeEvent{
+			Id:     formatRealtimeEventId(realtimeAuxEventBufferEpoch, row.Id),
+			Action: row.Action,
+			Record: record,
+		})
+	}
+
+	return result, true, nil
+}
+
+// realtimePruneAuxEvents deletes the old aux db buffered events leaving
+// only the last realtimeReplayMaxTopicEvents of each topic.
+func realtimePruneAuxEvents(app core.App) error {
+	_, err := app.AuxDB().NewQuery(`
+		WITH [[deleted]] AS (
+			DELETE FROM {{_realtimeEvents}} WHERE [[id]] IN (
+				SELECT [[id]] FROM (
+					SELECT [[id]], ROW_NUMBER() OVER (PARTITION BY [[topic]] ORDER BY [[id]] DESC) AS [[rn]]
+					FROM {{_realtimeEvents}}
+				) [[ranked]] WHERE [[rn]] > {:max}
+			)
+			RETURNING [[topic]], [[id]]
+		)
+		INSERT INTO {{_realtimeEventsPruned}} ([[topic]], [[lastId]])
+		SELECT [[topic]], MAX([[id]]) FROM [[deleted]] GROUP BY [[topic]]
+		ON CONFLICT ([[topic]]) DO UPDATE SET [[lastId]] = GREATEST({{_realtimeEventsPruned}}.[[lastId]], EXCLUDED.[[lastId]])
+	`).Bind(dbx.Params{"max": realtimeReplayMaxTopicEvents}).Execute()
+
+	return err
+}
+
+// -------------------------------------------------------------------
+
+// realtimeReplayEvents sends to the client the buffered record events of its
+// current subscriptions that happened after the last event id of its
+// previous connection (if any).
+//
+// If some of the events can no longer be replayed, a single PB_RESYNC message is sent instead.
+func realtimeReplayEvents(app core.App, client subscriptions.Client) error {
+	lastEventId, _ := client.Get(realtimeLastEventIdKey).(string)
+	if lastEventId == "" {
+		return nil
+	}
+
+	// replay only once per connection
+	client.Unset(realtimeLastEventIdKey)
+
+	var topics []string
+	for sub := range client.Subscriptions() {
+		collectionIdOrName, _, _ := strings.Cut(sub, "/")
+		collectionIdOrName, _, _ = strings.Cut(collectionIdOrName, "?")
+
+		collection, err := app.FindCachedCollectionByNameOrId(collectionIdOrName)
+		if err != nil || slices.Contains(topics, collection.Id) {
+			continue
+		}
+
+		topics = append(topics, collection.Id)
+	}
+
+	if len(topics) == 0 {
+		return nil
+	}
+
+	events, ok, err := realtimeGetEventBuffer(app).Since(app, lastEventId, topics)
+	if err != nil {
+		return err
+	}
+
+	var messages []subscriptions.Message
+
+	if !ok {
+		data, err := json.Marshal(map[string]any{"lastEventId": lastEventId})
+		if err != nil {
+			return err
+		}
+
+		messages = append(messages, subscriptions.Message{
+			Name: realtimeResyncMessageName,
+			Data: data,
+		})
+	} else {
+		canAccess := func(record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool {
+			return realtimeCanAccessRecordSnapshot(app, record, requestInfo, accessRule)
+		}
+
+		for _, event := range events {
+			subscriptionRuleMap := realtimeRecordSubscriptionRules(event.Record.Collection(), event.Record.Id)
+
+			eventMessages := realtimeRecordMessages(app, client, event.Action, event.Record, subscriptionRuleMap, canAccess)
+			for _, msg := range eventMessages {
+				msg.Id = event.Id
+				messages = append(messages, msg)
+			}
+		}
+	}
+
+	// send in a separate goroutine to preserve the order
+	// and to not block the caller if the client is no longer listening
+	routine.FireAndForget(func() {
+		for _, msg := range messages {
+			client.Send(msg)
+		}
+	})
+
+	app.Logger().Debug(
+		"Realtime events replayed.",
+		slog.String("clientId", client.Id()),
+		slog.String("lastEventId", lastEventId),
+		slog.Int("total", len(messages)),
+	)
+
+	return nil
+}
+
+// realtimeCanAccessRecordSnapshot is similar to [realtimeCanAccessRecord]
+// but the access rule and the subscription filter are checked against
+// the provided record state instead of its current db state
+// (the buffered record could be already modified or deleted).
+func realtimeCanAccessRecordSnapshot(
+	app core.App,
+	record *core.Record,
+	requestInfo *core.RequestInfo,
+	accessRule *string,
+) bool {
+	filter := requestInfo.Query[search.FilterQueryParam]
+
+	if requestInfo.HasSuperuserAuth() && filter == "" {
+		return true
+	}
+
+	if !requestInfo.HasSuperuserAuth() && accessRule == nil {
+		return false
+	}
+
+	if filter != "" && checkForSuperuserOnlyRuleFields(requestInfo) != nil {
+		return false
+	}
+
+	newQuery, snapshotCollection, err := newRecordSnapshotQuery(app, record)
+	if err != nil {
+		return false
+	}
+
+	check := func(rule string, allowHiddenFields bool) bool {
+		query := newQuery()
+
+		resolver := core.NewRecordFieldResolver(app, snapshotCollection, requestInfo, allowHiddenFields)
+		expr, err := search.FilterData(rule).BuildExpr(resolver)
+		if err != nil {
+			return false
+		}
+		query.AndWhere(expr)
+
+		resolver.UpdateQuery(query)
+
+		var exists int
+		err = query.Limit(1).Row(&exists)
+
+		return err == nil && exists > 0
+	}
+
+	// check the access rule
+	if !requestInfo.HasSuperuserAuth() && *accessRule != "" && !check(*accessRule, true) {
+		return false
+	}
+
+	// check the subscription client-side filter (if any)
+	if filter != "" && !check(filter, false) {
+		return false
+	}
+
+	return true
+}
+
+// realtimeLastEventId returns the Last-Event-ID header value
+// (or its query parameter fallback) of the realtime connect request.
+func realtimeLastEventId(e *core.RequestEvent) string {
+	lastEventId := e.Request.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = e.Request.URL.Query().Get(realtimeLastEventIdQueryParam)
+	}
+
+	if len(lastEventId) > 255 {
+		return "" // not a valid event id
+	}
+
+	return lastEventId
+}
+
+// realtimeConnectMessageData returns the PB_CONNECT message data.
+//
+// The resumed lastEventId (if any) is part of the payload because the
+// PB_CONNECT SSE id is always the client id. Clients that don't rely on the
+// Last-Event-ID header could use it as lastEventId query parameter on reconnect.
+func realtimeConnectMessageData(clientId string, lastEventId string) []byte {
+	payload := map[string]string{"clientId": clientId}
+	if lastEventId != "" {
+		payload["lastEventId"] = lastEventId
+	}
+
+	data, _ := json.Marshal(payload)
+
+	return data
+}
+
+// bindRealtimeReplayEvents registers the aux db event buffer prune job (if used).
+func bindRealtimeReplayEvents(app core.App) {
+	if !app.MultiInstanceEnabled() {
+		return
+	}
+
+	app.Cron().Add("__pbRealtimeEventsPrune__", "* * * * *", func() {
+		if err := realtimePruneAuxEvents(app); err != nil {
+			app.Logger().Warn("Failed to prune the realtime replay events", slog.String("error", err.Error()))
+		}
+	})
+}
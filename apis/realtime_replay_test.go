@@ -0,0 +1,297 @@
+package apis_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+)
+
+func TestRealtimeConnectLastEventId(t *testing.T) {
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "with Last-Event-ID header",
+			Method: http.MethodGet,
+			URL:    "/api/realtime",
+			Headers: map[string]string{
+				"Last-Event-ID": "test-123",
+			},
+			Timeout:        100 * time.Millisecond,
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`event:PB_CONNECT`,
+				`"lastEventId":"test-123"`,
+			},
+			NotExpectedContent: []string{
+				`id:test-123`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                        0,
+				"OnRealtimeConnectRequest": 1,
+				"OnRealtimeMessageSend":    1,
+			},
+		},
+		{
+			Name:           "with lastEventId query parameter",
+			Method:         http.MethodGet,
+			URL:            "/api/realtime?lastEventId=test-456",
+			Timeout:        100 * time.Millisecond,
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`event:PB_CONNECT`,
+				`"lastEventId":"test-456"`,
+			},
+			NotExpectedContent: []string{
+				`id:test-456`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                        0,
+				"OnRealtimeConnectRequest": 1,
+				"OnRealtimeMessageSend":    1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRealtimeSubscribeReplay(t *testing.T) {
+	client := subscriptions.NewDefaultClient()
+
+	resetClient := func() {
+		client.Unsubscribe()
+		client.Unset("lastEventId")
+	}
+
+	readMessages := func(t testing.TB, total int) []subscriptions.Message {
+		var messages []subscriptions.Message
+
+		for i := 0; i < total; i++ {
+			select {
+			case msg := <-client.Channel():
+				messages = append(messages, msg)
+			case <-time.After(100 * time.Millisecond):
+				t.Fatalf("Expected %d messages, got %d", total, len(messages))
+			}
+		}
+
+		// no more messages are expected
+		select {
+		case msg := <-client.Channel():
+			t.Fatalf("Unexpected message %q: %s", msg.Name, msg.Data)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		return messages
+	}
+
+	updateRecord := func(t testing.TB, app *tests.TestApp, title string) {
+		record, err := app.FindRecordById("demo2", "achvryl401bhse3")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record.Set("title", title)
+
+		if err := app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "without last event id",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["demo2"]}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readMessages(t, 0)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+		{
+			Name:   "with unknown last event id",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["demo2"]}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				client.Set("lastEventId", "unknown-1")
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				messages := readMessages(t, 1)
+				if messages[0].Name != "PB_RESYNC" {
+					t.Fatalf("Expected PB_RESYNC message, got %q", messages[0].Name)
+				}
+				if string(messages[0].Data) != `{"lastEventId":"unknown-1"}` {
+					t.Fatalf("Unexpected PB_RESYNC data %s", messages[0].Data)
+				}
+				if client.Get("lastEventId") != nil {
+					t.Fatal("Expected the last event id to be cleared")
+				}
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+		{
+			Name:   "with last event id of unrelated topic",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["missing"]}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				client.Set("lastEventId", "unknown-1")
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				readMessages(t, 0)
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+		{
+			Name:   "with valid last event id",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["demo2"]}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				client.Subscribe("demo2")
+				app.SubscriptionsBroker().Register(client)
+
+				updateRecord(t, app, "replay1")
+				first := readMessages(t, 1)[0]
+
+				updateRecord(t, app, "replay2")
+				second := readMessages(t, 1)[0]
+
+				if first.Id == "" || second.Id == "" || first.Id == second.Id {
+					t.Fatalf("Expected unique non-empty event ids, got %q and %q", first.Id, second.Id)
+				}
+
+				// simulate a reconnect after the first message
+				client.Unsubscribe()
+				client.Set("lastEventId", first.Id)
+				client.Set("expectedEventId", second.Id)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				messages := readMessages(t, 1)
+				if messages[0].Name != "demo2" {
+					t.Fatalf("Expected demo2 message, got %q", messages[0].Name)
+				}
+				if messages[0].Id != client.Get("expectedEventId") {
+					t.Fatalf("Expected event id %v, got %q", client.Get("expectedEventId"), messages[0].Id)
+				}
+				if !strings.Contains(string(messages[0].Data), `"title":"replay2"`) {
+					t.Fatalf("Expected the second record update to be replayed, got %s", messages[0].Data)
+				}
+				client.Unset("expectedEventId")
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+		{
+			Name:   "with last event id before an unsubscribed topic change",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["demo2"]}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				client.Subscribe("demo2")
+				app.SubscriptionsBroker().Register(client)
+
+				updateRecord(t, app, "replay1")
+				cursor := readMessages(t, 1)[0]
+
+				// simulate a disconnect (the change is not buffered because there are no subscribers)
+				client.Unsubscribe()
+				updateRecord(t, app, "replay2")
+
+				client.Set("lastEventId", cursor.Id)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				messages := readMessages(t, 1)
+				if messages[0].Name != "PB_RESYNC" {
+					t.Fatalf("Expected PB_RESYNC message, got %q", messages[0].Name)
+				}
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+		{
+			Name:   "with valid last event id and typed (bool) filter",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			Body:   strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["demo2?options={\"query\":{\"filter\":\"active = true\"}}"]}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				client.Subscribe("demo2")
+				app.SubscriptionsBroker().Register(client)
+
+				record, err := app.FindRecordById("demo2", "achvryl401bhse3")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				updateRecord(t, app, "replay0")
+				cursor := readMessages(t, 1)[0]
+
+				for i, active := range []bool{false, true} {
+					record.Set("title", "replay"+strconv.Itoa(i+1))
+					record.Set("active", active)
+					if err := app.Save(record); err != nil {
+						t.Fatal(err)
+					}
+					readMessages(t, 1)
+				}
+
+				// simulate a reconnect after the cursor message
+				client.Unsubscribe()
+				client.Set("lastEventId", cursor.Id)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				messages := readMessages(t, 1)
+				if !strings.Contains(string(messages[0].Data), `"title":"replay2"`) {
+					t.Fatalf("Expected only the active record update to be replayed, got %s", messages[0].Data)
+				}
+				resetClient()
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,558 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/router"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+	"github.com/thewandererbg/pgbase/tools/websocket"
+)
+
+const (
+	// realtimeWSPingInterval is the interval at which the server sends ping frames to the client.
+	realtimeWSPingInterval = 25 * time.Second
+
+	// realtimeWSPongWait is the max allowed time without any
+	// received frame (including pong) before closing the connection.
+	realtimeWSPongWait = 60 * time.Second
+
+	// realtimeWSWriteWait is the max allowed time to write a single frame.
+	realtimeWSWriteWait = 10 * time.Second
+
+	// realtimeWSReadLimit is the max size of a single client message.
+	realtimeWSReadLimit = 512 << 10
+)
+
+// WebSocket message types.
+const (
+	realtimeWSTypeSubscribe   = "subscribe"
+	realtimeWSTypeUnsubscribe = "unsubscribe"
+	realtimeWSTypePing        = "ping"
+	realtimeWSTypePong        = "pong"
+	realtimeWSTypeMessage     = "message"
+	realtimeWSTypeAck         = "ack"
+	realtimeWSTypeError       = "error"
+
+	realtimeWSTypePresenceJoin  = "presence_join"
+	realtimeWSTypePresenceLeave = "presence_leave"
+	realtimeWSTypePresenceList  = "presence_list"
+	realtimeWSTypePresence      = "presence"
+
+	realtimeWSTypeBroadcast = "broadcast"
+)
+
+// realtimeWSMessage is the JSON envelope of every WebSocket text frame
+// exchanged between the client and the server.
+//
+// Client -> server:
+//
+//	{"type":"subscribe",   "id":"1", "subscriptions":["posts/*"], "token":"..."}
+//	{"type":"unsubscribe", "id":"2", "subscriptions":["posts/*"]}
+//	{"type":"ping",        "id":"3"}
+//	{"type":"message",     "id":"4", "name":"custom", "data":{...}}
+//	{"type":"presence_join",  "id":"5", "topic":"posts/RECORD_ID", "data":{...}}
+//	{"type":"presence_leave", "id":"6", "topic":"posts/RECORD_ID"}
+//	{"type":"presence_list",  "id":"7", "topic":"posts/RECORD_ID"}
+//	{"type":"broadcast",      "id":"8", "topic":"rooms:abc", "data":{...}}
+//
+// Server -> client:
+//
+//	{"type":"message",  "name":"posts/*", "data":{...}}
+//	{"type":"ack",      "id":"1"}
+//	{"type":"pong",     "id":"3"}
+//	{"type":"presence", "id":"7", "topic":"posts/RECORD_ID", "data":[...]}
+//	{"type":"error",    "id":"4", "error":{"status":400,"message":"...","data":{}}}
+type realtimeWSMessage struct {
+	Type          string           `json:"type"`
+	Id            string           `json:"id,omitempty"`
+	Name          string           `json:"name,omitempty"`
+	Topic         string           `json:"topic,omitempty"`
+	Data          json.RawMessage  `json:"data,omitempty"`
+	Subscriptions []string         `json:"subscriptions,omitempty"`
+	Token         string           `json:"token,omitempty"`
+	Error         *router.ApiError `json:"error,omitempty"`
+}
+
+// realtimeConnectWS establishes a WebSocket realtime connection.
+//
+// It shares the same subscription client and OnRealtime* hooks as the SSE
+// connection but the subscriptions are managed in-band with
+// "subscribe" and "unsubscribe" messages.
+func realtimeConnectWS(e *core.RequestEvent) error {
+	if !websocket.IsUpgradeRequest(e.Request) {
+		return e.BadRequestError("Missing or invalid WebSocket upgrade request.", nil)
+	}
+
+	// create cancellable request
+	cancelCtx, cancelRequest := context.WithCancel(e.Request.Context())
+	defer cancelRequest()
+	e.Request = e.Request.Clone(cancelCtx)
+
+	connectEvent := new(core.RealtimeConnectRequestEvent)
+	connectEvent.RequestEvent = e
+	connectEvent.Client = newRealtimeClient(e.App)
+	connectEvent.IdleTimeout = 5 * time.Minute
+
+	var upgraded bool
+
+	err := e.App.OnRealtimeConnectRequest().Trigger(connectEvent, func(ce *core.RealtimeConnectRequestEvent) error {
+		conn, err := websocket.Upgrade(ce.Response, ce.Request, nil)
+		if err != nil {
+			return ce.BadRequestError("Failed to upgrade the WebSocket connection.", err)
+		}
+		upgraded = true
+
+		conn.SetReadLimit(realtimeWSReadLimit)
+
+		session := &realtimeWSSession{
+			event:  ce.RequestEvent,
+			client: ce.Client,
+			conn:   conn,
+			cancel: cancelRequest,
+		}
+
+		return session.run(ce.IdleTimeout)
+	})
+
+	// the connection was hijacked and it is no longer possible to write an http error response
+	if upgraded {
+		if err != nil {
+			e.App.Logger().Debug("Realtime WebSocket connection error", slog.String("error", err.Error()))
+		}
+		return nil
+	}
+
+	return err
+}
+
+// realtimeWSSession holds the state of a single WebSocket realtime connection.
+type realtimeWSSession struct {
+	event  *core.RequestEvent
+	client subscriptions.Client
+	conn   *websocket.Conn
+	cancel context.CancelFunc
+}
+
+func (s *realtimeWSSession) run(idleTimeout time.Duration) error {
+	app := s.event.App
+	clientId := s.client.Id()
+
+	// register new subscription client
+	app.SubscriptionsBroker().Register(s.client)
+
+	activity := make(chan struct{}, 1)
+	readerDone := make(chan struct{})
+
+	defer func() {
+		s.conn.CloseWithCode(websocket.CloseGoingAway, "")
+
+		// keep draining the client channel until the reader stops
+		// to prevent blocking any of its hooks that may send a reply
+		for {
+			select {
+			case <-readerDone:
+			case _, ok := <-s.client.Channel():
+				if ok {
+					continue
+				}
+				<-readerDone
+			}
+
+			realtimePresenceUntrackAll(app, clientId)
+			app.SubscriptionsBroker().Unregister(clientId)
+			return
+		}
+	}()
+
+	s.conn.SetReadDeadline(time.Now().Add(realtimeWSPongWait))
+	s.conn.SetPongHandler(func([]byte) {
+		s.conn.SetReadDeadline(time.Now().Add(realtimeWSPongWait))
+	})
+
+	app.Logger().Debug("Realtime WebSocket connection established.", slog.String("clientId", clientId))
+
+	// signalize established connection (aka. fire "connect" message)
+	connectMsgErr := s.sendMessage(&subscriptions.Message{
+		Name: "PB_CONNECT",
+		Data: []byte(`{"clientId":"` + clientId + `"}`),
+	})
+	if connectMsgErr != nil {
+		close(readerDone)
+		app.Logger().Debug(
+			"Realtime WebSocket connection closed (failed to deliver PB_CONNECT)",
+			slog.String("clientId", clientId),
+			slog.String("error", connectMsgErr.Error()),
+		)
+		return nil
+	}
+
+	go s.readLoop(activity, readerDone)
+
+	// start an idle timer to keep track of inactive/forgotten connections
+	idleTimer := time.NewTimer(idleTimeout)
+	defer idleTimer.Stop()
+
+	pingTicker := time.NewTicker(realtimeWSPingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-idleTimer.C:
+			s.cancel()
+		case <-pingTicker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(realtimeWSWriteWait))
+			if err := s.conn.Ping(nil); err != nil {
+				app.Logger().Debug(
+					"Realtime WebSocket connection closed (failed to send ping)",
+					slog.String("clientId", clientId),
+					slog.String("error", err.Error()),
+				)
+				return nil
+			}
+		case <-activity:
+			idleTimer.Stop()
+			idleTimer.Reset(idleTimeout)
+		case msg, ok := <-s.client.Channel():
+			if !ok {
+				// channel is closed
+				app.Logger().Debug(
+					"Realtime WebSocket connection closed (closed channel)",
+					slog.String("clientId", clientId),
+				)
+				return nil
+			}
+
+			if err := s.sendMessage(&msg); err != nil {
+				app.Logger().Debug(
+					"Realtime WebSocket connection closed (failed to deliver message)",
+					slog.String("clientId", clientId),
+					slog.String("error", err.Error()),
+				)
+				return nil
+			}
+
+			idleTimer.Stop()
+			idleTimer.Reset(idleTimeout)
+		case <-s.event.Request.Context().Done():
+			// connection is closed
+			app.Logger().Debug(
+				"Realtime WebSocket connection closed (cancelled request)",
+				slog.String("clientId", clientId),
+			)
+			return nil
+		}
+	}
+}
+
+// readLoop reads and handles the client messages until the connection is closed.
+//
+// The messages are handled sequentially in the order of their arrival.
+func (s *realtimeWSSession) readLoop(activity chan<- struct{}, done chan<- struct{}) {
+	defer close(done)
+	defer s.cancel()
+
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				s.event.App.Logger().Debug(
+					"Realtime WebSocket read failure",
+					slog.String("clientId", s.client.Id()),
+					slog.String("error", err.Error()),
+				)
+			}
+			return
+		}
+
+		s.conn.SetReadDeadline(time.Now().Add(realtimeWSPongWait))
+
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+
+		msg := new(realtimeWSMessage)
+
+		if messageType != websocket.TextMessage {
+			err = s.event.BadRequestError("Only JSON text messages are supported.", nil)
+		} else if jsonErr := json.Unmarshal(data, msg); jsonErr != nil {
+			err = s.event.BadRequestError("Invalid JSON message.", jsonErr)
+		} else {
+			err = s.handle(msg)
+		}
+
+		if err != nil {
+			err = s.write(&realtimeWSMessage{
+				Type:  realtimeWSTypeError,
+				Id:    msg.Id,
+				Error: router.ToApiError(err),
+			})
+		} else if msg.Type != realtimeWSTypePing && msg.Type != realtimeWSTypePresenceList {
+			err = s.write(&realtimeWSMessage{Type: realtimeWSTypeAck, Id: msg.Id})
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *realtimeWSSession) handle(msg *realtimeWSMessage) error {
+	switch msg.Type {
+	case realtimeWSTypeSubscribe:
+		return s.updateSubscriptions(msg, true)
+	case realtimeWSTypeUnsubscribe:
+		return s.updateSubscriptions(msg, false)
+	case realtimeWSTypePing:
+		return s.write(&realtimeWSMessage{Type: realtimeWSTypePong, Id: msg.Id})
+	case realtimeWSTypeMessage:
+		return s.clientMessage(msg)
+	case realtimeWSTypePresenceJoin:
+		return s.presenceJoin(msg)
+	case realtimeWSTypePresenceLeave:
+		realtimePresenceUntrack(s.event.App, s.client.Id(), realtimePresenceNormalizeTopic(s.event.App, msg.Topic))
+		return nil
+	case realtimeWSTypePresenceList:
+		return s.presenceList(msg)
+	case realtimeWSTypeBroadcast:
+		return s.broadcast(msg)
+	default:
+		return s.event.BadRequestError("Unsupported message type.", nil)
+	}
+}
+
+// updateSubscriptions adds (or removes) the message subscriptions
+// to the current client subscriptions.
+//
+// Unsubscribe without subscriptions removes all client subscriptions.
+func (s *realtimeWSSession) updateSubscriptions(msg *realtimeWSMessage, subscribe bool) error {
+	err := validation.Validate(msg.Subscriptions,
+		validation.Length(0, 1000),
+		validation.Each(validation.Length(0, 2500)),
+	)
+	if err != nil {
+		return s.event.BadRequestError("", validation.Errors{"subscriptions": err})
+	}
+
+	auth := s.event.Auth
+	if msg.Token != "" {
+		auth, err = s.event.App.FindAuthRecordByToken(msg.Token, core.TokenTypeAuth)
+		if err != nil {
+			return s.event.UnauthorizedError("The request requires valid record authorization token.", err)
+		}
+	}
+
+	// for now allow only guest->auth upgrades and any other auth change is forbidden
+	clientAuth, _ := s.client.Get(RealtimeClientAuthKey).(*core.Record)
+	if clientAuth != nil && !isSameAuth(clientAuth, auth) {
+		return s.event.ForbiddenError("The current and the previous request authorization don't match.", nil)
+	}
+	s.event.Auth = auth
+
+	newSubscriptions := make([]string, 0, len(msg.Subscriptions))
+	for sub := range s.client.Subscriptions() {
+		if subscribe || (len(msg.Subscriptions) > 0 && !slices.Contains(msg.Subscriptions, sub)) {
+			newSubscriptions = append(newSubscriptions, sub)
+		}
+	}
+	if subscribe {
+		for _, sub := range msg.Subscriptions {
+			if !slices.Contains(newSubscriptions, sub) {
+				newSubscriptions = append(newSubscriptions, sub)
+			}
+		}
+	}
+
+	if len(newSubscriptions) > 1000 {
+		return s.event.BadRequestError("Too many subscriptions.", nil)
+	}
+
+	event := new(core.RealtimeSubscribeRequestEvent)
+	event.RequestEvent = s.event
+	event.Client = s.client
+	event.Subscriptions = newSubscriptions
+
+	return s.event.App.OnRealtimeSubscribeRequest().Trigger(event, func(e *core.RealtimeSubscribeRequestEvent) error {
+		// update auth state
+		e.Client.Set(RealtimeClientAuthKey, e.Auth)
+
+		// replace the previous subscriptions
+		e.Client.Unsubscribe()
+		e.Client.Subscribe(e.Subscriptions...)
+
+		e.App.Logger().Debug(
+			"Realtime subscriptions updated.",
+			slog.String("clientId", e.Client.Id()),
+			slog.Any("subscriptions", e.Subscriptions),
+		)
+
+		// send the initial result set of the new live query subscriptions (if any)
+		realtimeSyncQuerySubscriptions(e.App, e.Client)
+
+		return nil
+	})
+}
+
+// clientMessage forwards a custom client message to the OnRealtimeClientMessage hook.
+func (s *realtimeWSSession) clientMessage(msg *realtimeWSMessage) error {
+	err := validation.Validate(msg.Name, validation.Required, validation.Length(1, 255))
+	if err != nil {
+		return s.event.BadRequestError("", validation.Errors{"name": err})
+	}
+
+	event := new(core.RealtimeClientMessageEvent)
+	event.RequestEvent = s.event
+	event.Client = s.client
+	event.Message = &subscriptions.Message{
+		Name: msg.Name,
+		Data: msg.Data,
+	}
+
+	return s.event.App.OnRealtimeClientMessage().Trigger(event)
+}
+
+// presenceJoin joins (or updates the state of) the client in the message presence topic.
+func (s *realtimeWSSession) presenceJoin(msg *realtimeWSMessage) error {
+	if err := validateRealtimePresenceState(msg.Data); err != nil {
+		return s.event.BadRequestError("", validation.Errors{"data": err})
+	}
+
+	auth := s.currentAuth()
+
+	topic, err := realtimePresenceCheckAccess(s.event.App, msg.Topic, auth)
+	if err != nil {
+		return s.event.ForbiddenError("You are not allowed to join the presence topic.", err)
+	}
+
+	_, err = realtimePresenceTrack(s.event.App, s.client.Id(), topic, auth, msg.Data)
+	if err != nil {
+		return s.event.BadRequestError("The client has reached the max allowed number of presence topics.", err)
+	}
+
+	return nil
+}
+
+// presenceList replies with the current members of the message presence topic.
+func (s *realtimeWSSession) presenceList(msg *realtimeWSMessage) error {
+	topic, err := realtimePresenceCheckAccess(s.event.App, msg.Topic, s.currentAuth())
+	if err != nil {
+		return s.event.ForbiddenError("You are not allowed to access the presence topic.", err)
+	}
+
+	data, err := json.Marshal(s.event.App.SubscriptionsBroker().Presence(topic))
+	if err != nil {
+		return err
+	}
+
+	return s.write(&realtimeWSMessage{
+		Type:  realtimeWSTypePresence,
+		Id:    msg.Id,
+		Topic: topic,
+		Data:  data,
+	})
+}
+
+// broadcast publishes the message data to the message topic broadcast channel
+// (the client itself is excluded from the channel subscribers).
+func (s *realtimeWSSession) broadcast(msg *realtimeWSMessage) error {
+	form := &realtimeBroadcastForm{
+		ClientId: s.client.Id(),
+		Channel:  msg.Topic,
+		Data:     msg.Data,
+	}
+
+	err := form.validate()
+	if err != nil {
+		return s.event.BadRequestError("", err)
+	}
+
+	auth := s.currentAuth()
+
+	connInfo, err := s.event.RequestInfo()
+	if err != nil {
+		return err
+	}
+
+	// mock the publish request data so that the same
+	// channel rules could be used for both transports
+	var data any
+	_ = json.Unmarshal(msg.Data, &data)
+	requestInfo := &core.RequestInfo{
+		Context: core.RequestInfoContextRealtime,
+		Method:  http.MethodPost,
+		Query:   connInfo.Query,
+		Headers: connInfo.Headers,
+		Body: map[string]any{
+			"channel": form.Channel,
+			"data":    data,
+		},
+		Auth: auth,
+	}
+
+	err = realtimeCheckBroadcastPublish(s.event.App, form.Channel, requestInfo)
+	if err != nil {
+		return s.event.ForbiddenError("You are not allowed to publish to the broadcast channel.", err)
+	}
+
+	err = realtimeBroadcast(s.event.App, &realtimeBroadcastMessage{
+		Channel: form.Channel,
+		Data:    form.Data,
+		Sender:  newRealtimeBroadcastSender(auth),
+	}, s.client.Id())
+	if err != nil {
+		return s.event.BadRequestError("Failed to publish the broadcast message.", err)
+	}
+
+	return nil
+}
+
+// currentAuth returns the client auth state (if any) or the connection auth.
+func (s *realtimeWSSession) currentAuth() *core.Record {
+	if clientAuth, _ := s.client.Get(RealtimeClientAuthKey).(*core.Record); clientAuth != nil {
+		return clientAuth
+	}
+
+	return s.event.Auth
+}
+
+// sendMessage delivers a single subscription message to the client.
+func (s *realtimeWSSession) sendMessage(msg *subscriptions.Message) error {
+	msgEvent := new(core.RealtimeMessageEvent)
+	msgEvent.RequestEvent = s.event
+	msgEvent.Client = s.client
+	msgEvent.Message = msg
+
+	return s.event.App.OnRealtimeMessageSend().Trigger(msgEvent, func(me *core.RealtimeMessageEvent) error {
+		data := json.RawMessage(me.Message.Data)
+		if !json.Valid(data) {
+			// wrap non-JSON data as string
+			data, _ = json.Marshal(string(me.Message.Data))
+		}
+
+		return s.write(&realtimeWSMessage{
+			Type: realtimeWSTypeMessage,
+			Name: me.Message.Name,
+			Data: data,
+		})
+	})
+}
+
+func (s *realtimeWSSession) write(msg *realtimeWSMessage) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(realtimeWSWriteWait))
+
+	return s.conn.WriteMessage(websocket.TextMessage, raw)
+}
@@ -36,12 +36,19 @@ func bindRecordAuthApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 		collectionPathRateLimit("", "authWithOAuth2", "auth"),
 	)
 
+	sub.POST("/auth-with-ldap", recordAuthWithLDAP).Bind(
+		collectionPathRateLimit("", "authWithLDAP", "auth"),
+	)
+
 	sub.POST("/request-otp", recordRequestOTP).Bind(
 		collectionPathRateLimit("", "requestOTP"),
 	)
 	sub.POST("/auth-with-otp", recordAuthWithOTP).Bind(
 		collectionPathRateLimit("", "authWithOTP", "auth"),
 	)
+	sub.GET("/auth-with-otp-link", recordAuthWithOTPLink).Bind(
+		collectionPathRateLimit("", "authWithOTP", "auth"),
+	)
 
 	sub.POST("/request-password-reset", recordRequestPasswordReset).Bind(
 		collectionPathRateLimit("", "requestPasswordReset"),
@@ -66,6 +73,9 @@ func bindRecordAuthApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 	)
 
 	sub.POST("/impersonate/{id}", recordAuthImpersonate).Bind(RequireSuperuserAuth())
+
+	sub.GET("/lockout/{id}", recordAuthLockoutView).Bind(RequireSuperuserAuth())
+	sub.DELETE("/lockout/{id}", recordAuthUnlock).Bind(RequireSuperuserAuth())
 }
 
 func findAuthCollection(e *core.RequestEvent) (*core.Collection, error) {
@@ -39,7 +39,7 @@ func recordRequestEmailChange(e *core.RequestEvent) error {
 	event.NewEmail = form.NewEmail
 
 	return e.App.OnRecordRequestEmailChangeRequest().Trigger(event, func(e *core.RecordRequestEmailChangeRequestEvent) error {
-		if err := mails.SendRecordChangeEmail(e.App, e.Record, e.NewEmail); err != nil {
+		if err := mails.SendRecordChangeEmail(e.App, e.Record, e.NewEmail, e.AcceptLanguages()...); err != nil {
 			return firstApiError(err, e.BadRequestError("Failed to request email change.", err))
 		}
 
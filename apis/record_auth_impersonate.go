@@ -32,7 +32,7 @@ func recordAuthImpersonate(e *core.RequestEvent) error {
 		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
 	}
 
-	token, err := record.NewStaticAuthToken(time.Duration(form.Duration) * time.Second)
+	token, err := e.App.NewStaticAuthToken(record, time.Duration(form.Duration)*time.Second)
 	if err != nil {
 		e.InternalServerError("Failed to generate static auth token", err)
 	}
@@ -0,0 +1,66 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+type lockoutResponse struct {
+	LockedUntil types.DateTime `json:"lockedUntil"`
+	Attempts    int            `json:"attempts"`
+	Locked      bool           `json:"locked"`
+}
+
+// recordAuthLockoutView returns the password auth lockout state of the specified auth record.
+func recordAuthLockoutView(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	record, err := e.App.FindRecordById(collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	result := lockoutResponse{}
+
+	if collection.PasswordAuth.LockoutThreshold > 0 {
+		attempts, err := e.App.FindRecentAuthAttempts(collection.Id, record.Id, "", collection.PasswordAuth.LockoutThreshold)
+		if err != nil {
+			return e.InternalServerError("Failed to load the record auth attempts.", err)
+		}
+
+		result.Attempts = len(attempts)
+
+		lockedUntil := collection.PasswordAuth.LockedUntil(attempts)
+		if !lockedUntil.IsZero() {
+			result.Locked = true
+			result.LockedUntil, _ = types.ParseDateTime(lockedUntil)
+		}
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// recordAuthUnlock deletes all stored failed password auth attempts
+// of the specified auth record (aka. unlocks the account).
+func recordAuthUnlock(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	record, err := e.App.FindRecordById(collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	if err := e.App.DeleteAuthAttempts(record); err != nil {
+		return e.BadRequestError("Failed to unlock the record account.", err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
@@ -21,6 +21,10 @@ type mfaResponse struct {
 	Duration int64 `json:"duration"` // in seconds
 }
 
+type ldapResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
 type passwordResponse struct {
 	IdentityFields []string `json:"identityFields"`
 	Enabled        bool     `json:"enabled"`
@@ -54,6 +58,7 @@ type authMethodsResponse struct {
 	OAuth2   oauth2Response   `json:"oauth2"`
 	MFA      mfaResponse      `json:"mfa"`
 	OTP      otpResponse      `json:"otp"`
+	LDAP     ldapResponse     `json:"ldap"`
 
 	// legacy fields
 	// @todo remove after dropping v0.22 support
@@ -91,6 +96,9 @@ func recordAuthMethods(e *core.RequestEvent) error {
 		MFA: mfaResponse{
 			Enabled: collection.MFA.Enabled,
 		},
+		LDAP: ldapResponse{
+			Enabled: collection.LDAP.Enabled,
+		},
 	}
 
 	if collection.PasswordAuth.Enabled {
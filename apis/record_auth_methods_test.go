@@ -38,6 +38,7 @@ func TestRecordAuthMethodsList(t *testing.T) {
 				`"oauth2":{"providers":[],"enabled":false}`,
 				`"mfa":{"enabled":false,"duration":0}`,
 				`"otp":{"enabled":false,"duration":0}`,
+				`"ldap":{"enabled":false}`,
 			},
 			ExpectedEvents: map[string]int{"*": 0},
 		},
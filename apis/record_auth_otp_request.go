@@ -41,14 +41,25 @@ func recordRequestOTP(e *core.RequestEvent) error {
 
 	event := new(core.RecordCreateOTPRequestEvent)
 	event.RequestEvent = e
-	event.Password = security.RandomStringWithAlphabet(collection.OTP.Length, "1234567890")
+	if collection.OTP.MagicLink {
+		// the password is used as the magic link browser binding secret
+		event.Password = security.RandomString(40)
+	} else {
+		event.Password = security.RandomStringWithAlphabet(collection.OTP.Length, "1234567890")
+	}
 	event.Collection = collection
 	event.Record = record
 
 	originalApp := e.App
+	locales := e.AcceptLanguages()
 
 	return e.App.OnRecordRequestOTPRequest().Trigger(event, func(e *core.RecordCreateOTPRequestEvent) error {
 		if e.Record == nil {
+			if collection.OTP.MagicLink {
+				// set a dummy binding cookie for consistency with the regular response
+				setOTPMagicLinkCookie(e.RequestEvent, collection, security.RandomString(40))
+			}
+
 			// write a dummy 200 response as a very rudimentary emails enumeration "protection"
 			e.JSON(http.StatusOK, map[string]string{
 				"otpId": core.GenerateDefaultRandomId(),
@@ -97,15 +108,34 @@ func recordRequestOTP(e *core.RequestEvent) error {
 				return err
 			}
 
-			// send OTP email
-			// (in the background as a very basic timing attacks and emails enumeration protection)
-			// ---
-			routine.FireAndForget(func() {
-				err = mails.SendRecordOTP(originalApp, e.Record, otp.Id, e.Password)
+			if collection.OTP.MagicLink {
+				link, err := otpMagicLink(e.RequestEvent, e.Record, otp.Id)
 				if err != nil {
-					originalApp.Logger().Error("Failed to send OTP email", "error", errors.Join(err, originalApp.Delete(otp)))
+					return firstApiError(err, e.InternalServerError("Failed to generate the OTP magic link.", err))
 				}
-			})
+
+				setOTPMagicLinkCookie(e.RequestEvent, collection, e.Password)
+
+				// send OTP magic link email
+				// (in the background as a very basic timing attacks and emails enumeration protection)
+				// ---
+				routine.FireAndForget(func() {
+					err := mails.SendRecordOTPMagicLink(originalApp, e.Record, otp.Id, link, locales...)
+					if err != nil {
+						originalApp.Logger().Error("Failed to send OTP magic link email", "error", errors.Join(err, originalApp.Delete(otp)))
+					}
+				})
+			} else {
+				// send OTP email
+				// (in the background as a very basic timing attacks and emails enumeration protection)
+				// ---
+				routine.FireAndForget(func() {
+					err = mails.SendRecordOTP(originalApp, e.Record, otp.Id, e.Password, locales...)
+					if err != nil {
+						originalApp.Logger().Error("Failed to send OTP email", "error", errors.Join(err, originalApp.Delete(otp)))
+					}
+				})
+			}
 		}
 
 		return e.JSON(http.StatusOK, map[string]string{
@@ -165,6 +165,70 @@ func TestRecordRequestOTP(t *testing.T) {
 				}
 			},
 		},
+		{
+			Name:   "existing auth record (magic link)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/request-otp",
+			Body:   strings.NewReader(`{"email":"test@example.com"}`),
+			Delay:  100 * time.Millisecond,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableOTPMagicLink(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"otpId":"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRecordRequestOTPRequest":  1,
+				"OnMailerSend":               1,
+				"OnMailerRecordOTPSend":      1,
+				"OnModelCreate":              1,
+				"OnModelCreateExecute":       1,
+				"OnModelAfterCreateSuccess":  1,
+				"OnModelValidate":            2,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+				"OnRecordValidate":           2,
+				"OnModelUpdate":              1,
+				"OnModelUpdateExecute":       1,
+				"OnModelAfterUpdateSuccess":  1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if app.TestMailer.TotalSend() != 1 {
+					t.Fatalf("Expected 1 email, got %d", app.TestMailer.TotalSend())
+				}
+
+				html := app.TestMailer.LastMessage().HTML
+				if !strings.Contains(html, "http://localhost:8090/api/collections/users/auth-with-otp-link?token=") {
+					t.Fatalf("Expected the email to contain the magic link, got\n%s", html)
+				}
+
+				var bindingCookie *http.Cookie
+				for _, c := range res.Cookies() {
+					if c.Name == "pb_otp_link__pb_users_auth_" {
+						bindingCookie = c
+					}
+				}
+				if bindingCookie == nil || bindingCookie.Value == "" || !bindingCookie.HttpOnly {
+					t.Fatalf("Expected a HttpOnly browser binding cookie, got %v", bindingCookie)
+				}
+
+				otps, err := app.FindRecordsByFilter(core.CollectionNameOTPs, "sentTo='test@example.com'", "", 0, 0)
+				if err != nil || len(otps) != 1 {
+					t.Fatalf("Expected to find 1 OTP with sentTo %q, found %d", "test@example.com", len(otps))
+				}
+
+				otp, _ := app.FindOTPById(otps[0].Id)
+				if !otp.ValidatePassword(bindingCookie.Value) {
+					t.Fatal("Expected the OTP password to match the browser binding cookie")
+				}
+			},
+		},
 		{
 			Name:   "existing auth record with intercepted email (with < 9 non-expired)",
 			Method: http.MethodPost,
@@ -291,3 +355,22 @@ func TestRecordRequestOTP(t *testing.T) {
 		}
 	}
 }
+
+func enableOTPMagicLink(t testing.TB, app *tests.TestApp) {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.OTP.Enabled = true
+	collection.OTP.MagicLink = true
+	collection.OTP.MagicLinkRedirectURL = "https://example.com/login"
+	collection.OTP.MagicLinkTemplate = core.EmailTemplate{
+		Subject: "Login link",
+		Body:    `<a href="{MAGIC_LINK}">Login</a>`,
+	}
+
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -53,8 +53,9 @@ func recordRequestPasswordReset(e *core.RequestEvent) error {
 	return e.App.OnRecordRequestPasswordResetRequest().Trigger(event, func(e *core.RecordRequestPasswordResetRequestEvent) error {
 		// run in background because we don't need to show the result to the client
 		app := e.App
+		locales := e.AcceptLanguages()
 		routine.FireAndForget(func() {
-			if err := mails.SendRecordPasswordReset(app, e.Record); err != nil {
+			if err := mails.SendRecordPasswordReset(app, e.Record, locales...); err != nil {
 				app.Logger().Error("Failed to send password reset email", "error", err)
 				return
 			}
@@ -24,7 +24,7 @@ func recordAuthRefresh(e *core.RequestEvent) error {
 		claims, _ := security.ParseUnverifiedJWT(token) //
 		if v, ok := claims[core.TokenClaimRefreshable]; ok && cast.ToBool(v) {
 			var tokenErr error
-			token, tokenErr = e.Record.NewAuthToken()
+			token, tokenErr = e.App.NewAuthToken(e.Record)
 			if tokenErr != nil {
 				return e.InternalServerError("Failed to refresh auth token.", tokenErr)
 			}
@@ -57,8 +57,9 @@ func recordRequestVerification(e *core.RequestEvent) error {
 
 		// run in background because we don't need to show the result to the client
 		app := e.App
+		locales := e.AcceptLanguages()
 		routine.FireAndForget(func() {
-			if err := mails.SendRecordVerification(app, e.Record); err != nil {
+			if err := mails.SendRecordVerification(app, e.Record, locales...); err != nil {
 				app.Logger().Error("Failed to send verification email", "error", err)
 			}
 
@@ -0,0 +1,225 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/auth"
+)
+
+func recordAuthWithLDAP(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.LDAP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow LDAP authentication.", nil)
+	}
+
+	form := &authWithLDAPForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextLDAP)
+
+	ldapUser, err := collection.LDAP.InitProvider().Authenticate(e.Request.Context(), form.Username, form.Password)
+	if err != nil {
+		if !errors.Is(err, auth.ErrLDAPInvalidCredentials) {
+			e.App.Logger().Warn("LDAP authentication failure", "error", err, "collectionName", collection.Name)
+		}
+		return e.BadRequestError("Failed to authenticate.", err)
+	}
+
+	var authRecord *core.Record
+
+	// check for existing relation with the auth collection
+	externalAuthRel, err := e.App.FindFirstExternalAuthByExpr(dbx.HashExp{
+		"collectionRef": collection.Id,
+		"provider":      auth.NameLDAP,
+		"providerId":    ldapUser.Id,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("Failed LDAP relation check.", err)
+	}
+
+	switch {
+	case err == nil && externalAuthRel != nil:
+		authRecord, err = e.App.FindRecordById(collection, externalAuthRel.RecordRef())
+		if err != nil {
+			return err
+		}
+	case ldapUser.Email != "":
+		// look for an existing auth record by the directory entry email
+		authRecord, err = e.App.FindAuthRecordByEmail(collection.Id, ldapUser.Email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return e.InternalServerError("Failed LDAP auth record check.", err)
+		}
+	}
+
+	event := new(core.RecordAuthWithLDAPRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.LDAPUser = ldapUser
+	event.CreateData = form.CreateData
+	event.Record = authRecord
+	event.IsNewRecord = authRecord == nil
+
+	return e.App.OnRecordAuthWithLDAPRequest().Trigger(event, func(e *core.RecordAuthWithLDAPRequestEvent) error {
+		if err := ldapSubmit(e, externalAuthRel); err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to authenticate.", err))
+		}
+
+		meta := map[string]any{
+			"id":      e.LDAPUser.Id,
+			"email":   e.LDAPUser.Email,
+			"rawUser": e.LDAPUser.RawUser,
+			"isNew":   e.IsNewRecord,
+		}
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodLDAP, meta)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithLDAPForm struct {
+	// Additional data that will be used for creating a new auth record
+	// if a linked or matching auth record doesn't exist.
+	CreateData map[string]any `form:"createData" json:"createData"`
+
+	Username string `form:"username" json:"username"`
+	Password string `form:"password" json:"password"`
+}
+
+func (form *authWithLDAPForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Username, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Password, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+func ldapSubmit(e *core.RecordAuthWithLDAPRequestEvent, optExternalAuth *core.ExternalAuth) error {
+	return e.App.RunInTransaction(func(txApp core.App) error {
+		if e.Record == nil {
+			// extra check to prevent creating a superuser record via
+			// LDAP in case the method is used by another action
+			if e.Collection.Name == core.CollectionNameSuperusers {
+				return errors.New("superusers are not allowed to sign-up with LDAP")
+			}
+
+			payload := maps.Clone(e.CreateData)
+			if payload == nil {
+				payload = map[string]any{}
+			}
+
+			// assign the directory email only if the user hasn't submitted one
+			if v, _ := payload[core.FieldNameEmail].(string); v == "" {
+				payload[core.FieldNameEmail] = e.LDAPUser.Email
+			}
+
+			// map the directory attributes (unless the field was explicitly submitted as part of CreateData)
+			for field, attr := range e.Collection.LDAP.MappedFields {
+				if _, ok := payload[field]; ok {
+					continue
+				}
+
+				if v, ok := e.LDAPUser.RawUser[attr]; ok {
+					payload[field] = v
+				}
+			}
+
+			createdRecord, err := sendLDAPRecordCreateRequest(txApp, e, payload)
+			if err != nil {
+				return err
+			}
+
+			e.Record = createdRecord
+
+			if e.Record.Email() == e.LDAPUser.Email && !e.Record.Verified() {
+				// mark as verified as long as it matches the directory data (even if the email is empty)
+				e.Record.SetVerified(true)
+				if err := txApp.Save(e.Record); err != nil {
+					return err
+				}
+			}
+		} else {
+			var needUpdate bool
+
+			// set random password for users with unverified email
+			// (this is in case a malicious actor has registered previously with the user email)
+			if e.Record.Email() != "" && !e.Record.Verified() {
+				e.Record.SetRandomPassword()
+				needUpdate = true
+			}
+
+			// update the existing auth record empty email if the directory entry has one
+			if e.Record.Email() == "" && e.LDAPUser.Email != "" {
+				e.Record.SetEmail(e.LDAPUser.Email)
+				needUpdate = true
+			}
+
+			// update the existing auth record verified state
+			// (only if the auth record doesn't have an email or the auth record email match with the directory one)
+			if !e.Record.Verified() && (e.Record.Email() == "" || e.Record.Email() == e.LDAPUser.Email) {
+				e.Record.SetVerified(true)
+				needUpdate = true
+			}
+
+			if needUpdate {
+				if err := txApp.Save(e.Record); err != nil {
+					return err
+				}
+			}
+		}
+
+		// create ExternalAuth relation if missing
+		if optExternalAuth == nil {
+			optExternalAuth = core.NewExternalAuth(txApp)
+			optExternalAuth.SetCollectionRef(e.Record.Collection().Id)
+			optExternalAuth.SetRecordRef(e.Record.Id)
+			optExternalAuth.SetProvider(auth.NameLDAP)
+			optExternalAuth.SetProviderId(e.LDAPUser.Id)
+
+			if err := txApp.Save(optExternalAuth); err != nil {
+				return fmt.Errorf("failed to save linked rel: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func sendLDAPRecordCreateRequest(txApp core.App, e *core.RecordAuthWithLDAPRequestEvent, payload map[string]any) (*core.Record, error) {
+	ir := &core.InternalRequest{
+		Method: http.MethodPost,
+		URL:    "/api/collections/" + e.Collection.Name + "/records",
+		Body:   payload,
+	}
+
+	var createdRecord *core.Record
+	response, err := processInternalRequest(txApp, e.RequestEvent, ir, core.RequestInfoContextLDAP, func(data any) error {
+		createdRecord, _ = data.(*core.Record)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Status != http.StatusOK || createdRecord == nil {
+		return nil, errors.New("failed to create LDAP auth record")
+	}
+
+	return createdRecord, nil
+}
@@ -0,0 +1,157 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/auth"
+)
+
+func TestRecordAuthWithLDAP(t *testing.T) {
+	t.Parallel()
+
+	server, err := tests.NewTestLDAPServer(
+		tests.TestLDAPEntry{
+			DN:       "uid=john,ou=people,dc=example,dc=com",
+			Password: "john_pass",
+			Attributes: map[string][]string{
+				"uid":         {"john"},
+				"mail":        {"john@example.com"},
+				"displayName": {"John Doe"},
+			},
+		},
+		tests.TestLDAPEntry{
+			DN:       "uid=test,ou=people,dc=example,dc=com",
+			Password: "test_pass",
+			Attributes: map[string][]string{
+				"uid":  {"test"},
+				"mail": {"test@example.com"},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	enableLDAP := func(t testing.TB, app *tests.TestApp) {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users.MFA.Enabled = false
+		users.LDAP.Enabled = true
+		users.LDAP.URL = server.URL()
+		users.LDAP.UserDN = "uid={username},ou=people,dc=example,dc=com"
+		users.LDAP.EmailAttribute = "mail"
+		users.LDAP.MappedFields = map[string]string{"name": "displayName"}
+
+		if err := app.Save(users); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "disabled LDAP auth",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-ldap",
+			Body:            strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"","password":""}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app)
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"username":{"code":"validation_required"`,
+				`"password":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid credentials",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"invalid"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "valid credentials (new record)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"email":"john@example.com"`,
+				`"name":"John Doe"`,
+				`"verified":true`,
+				`"isNew":true`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordCreateRequest":       1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				record, err := app.FindAuthRecordByEmail("users", "john@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rels, err := app.FindAllExternalAuthsByRecord(record)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(rels) != 1 || rels[0].Provider() != auth.NameLDAP || rels[0].ProviderId() != "uid=john,ou=people,dc=example,dc=com" {
+					t.Fatalf("Expected a single LDAP linked external auth, got %v", rels)
+				}
+			},
+		},
+		{
+			Name:   "valid credentials (existing record with matching email)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"test","password":"test_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"id":"4q1xlclmfloku33"`,
+				`"isNew":false`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordCreateRequest":       0,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -65,30 +65,35 @@ func recordAuthWithOTP(e *core.RequestEvent) error {
 	// ---
 
 	return e.App.OnRecordAuthWithOTPRequest().Trigger(event, func(e *core.RecordAuthWithOTPRequestEvent) error {
-		// update the user email verified state in case the OTP originate from an email address matching the current record one
-		//
-		// note: don't wait for success auth response (it could fail because of MFA) and because we already validated the OTP above
-		otpSentTo := e.OTP.SentTo()
-		if !e.Record.Verified() && otpSentTo != "" && e.Record.Email() == otpSentTo {
-			e.Record.SetVerified(true)
-			err = e.App.Save(e.Record)
-			if err != nil {
-				e.App.Logger().Error("Failed to update record verified state after successful OTP validation",
-					"error", err,
-					"otpId", e.OTP.Id,
-					"recordId", e.Record.Id,
-				)
-			}
-		}
+		consumeOTP(e)
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodOTP, nil)
+	})
+}
 
-		// try to delete the used otp
-		err = e.App.Delete(e.OTP)
+// consumeOTP deletes the already validated OTP and updates the auth record verified state.
+func consumeOTP(e *core.RecordAuthWithOTPRequestEvent) {
+	// update the user email verified state in case the OTP originate from an email address matching the current record one
+	//
+	// note: don't wait for success auth response (it could fail because of MFA) and because we already validated the OTP
+	otpSentTo := e.OTP.SentTo()
+	if !e.Record.Verified() && otpSentTo != "" && e.Record.Email() == otpSentTo {
+		e.Record.SetVerified(true)
+		err := e.App.Save(e.Record)
 		if err != nil {
-			e.App.Logger().Error("Failed to delete used OTP", "error", err, "otpId", e.OTP.Id)
+			e.App.Logger().Error("Failed to update record verified state after successful OTP validation",
+				"error", err,
+				"otpId", e.OTP.Id,
+				"recordId", e.Record.Id,
+			)
 		}
+	}
 
-		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodOTP, nil)
-	})
+	// try to delete the used otp
+	err := e.App.Delete(e.OTP)
+	if err != nil {
+		e.App.Logger().Error("Failed to delete used OTP", "error", err, "otpId", e.OTP.Id)
+	}
 }
 
 // -------------------------------------------------------------------
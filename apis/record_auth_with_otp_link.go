@@ -0,0 +1,159 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+const otpMagicLinkCookiePrefix = "pb_otp_link_"
+
+// recordAuthWithOTPLink completes the OTP authentication from an emailed magic link
+// and redirects to the collection OTP.MagicLinkRedirectURL with the auth token (or the MFA id)
+// in the url fragment.
+//
+// On failure (including unsatisfied collection auth rule) it redirects
+// with an "error" fragment parameter.
+func recordAuthWithOTPLink(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.OTP.Enabled || !collection.OTP.MagicLink {
+		return e.ForbiddenError("The collection is not configured to allow OTP magic link authentication.", nil)
+	}
+
+	redirect := func(params url.Values) error {
+		return e.Redirect(http.StatusSeeOther, otpMagicLinkRedirectURL(collection, params))
+	}
+
+	fail := func(reason string, err error) error {
+		e.App.Logger().Debug("Failed OTP magic link authentication", "error", err, "collection", collection.Name)
+		return redirect(url.Values{"error": {reason}})
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextOTP)
+
+	event := new(core.RecordAuthWithOTPRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+
+	// extra validations
+	// ---
+	token := e.Request.URL.Query().Get("token")
+
+	event.Record, err = e.App.FindAuthRecordByToken(token, core.TokenTypeMagicLink)
+	if err != nil {
+		return fail("invalid_link", err)
+	}
+
+	if event.Record.Collection().Id != collection.Id {
+		return fail("invalid_link", errors.New("the magic link is for a different collection"))
+	}
+
+	claims, _ := security.ParseUnverifiedJWT(token)
+	otpId, _ := claims[core.TokenClaimOTPId].(string)
+
+	event.OTP, err = e.App.FindOTPById(otpId)
+	if err != nil {
+		return fail("invalid_link", err)
+	}
+
+	if event.OTP.CollectionRef() != collection.Id || event.OTP.RecordRef() != event.Record.Id {
+		return fail("invalid_link", errors.New("the OTP is for a different auth record"))
+	}
+
+	if event.OTP.HasExpired(collection.OTP.DurationTime()) {
+		return fail("invalid_link", errors.New("the OTP is expired"))
+	}
+
+	// the link must be opened in the same browser from where it was requested
+	cookie, err := e.Request.Cookie(otpMagicLinkCookiePrefix + collection.Id)
+	if err != nil || !event.OTP.ValidatePassword(cookie.Value) {
+		return fail("different_browser", fmt.Errorf("missing or invalid browser binding: %w", err))
+	}
+
+	// check the collection auth rule here (it is checked again by recordAuthResponseWith)
+	// so that the failure could be reported with redirect instead of a json error
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return fail("invalid_request", err)
+	}
+
+	canAuth, err := e.App.CanAccessRecord(event.Record, requestInfo, collection.AuthRule)
+	if !canAuth {
+		return fail("forbidden", fmt.Errorf("the auth rule requirements are not satisfied: %w", err))
+	}
+	// ---
+
+	return e.App.OnRecordAuthWithOTPRequest().Trigger(event, func(e *core.RecordAuthWithOTPRequestEvent) error {
+		consumeOTP(e)
+
+		setOTPMagicLinkCookie(e.RequestEvent, collection, "")
+
+		token, err := e.App.NewAuthToken(e.Record)
+		if err != nil {
+			return e.InternalServerError("Failed to create auth token.", err)
+		}
+
+		return recordAuthResponseWith(e.RequestEvent, e.Record, token, core.MFAMethodOTP, nil, func(e *core.RecordAuthRequestEvent, mfaId string) error {
+			if mfaId != "" {
+				redirect(url.Values{"mfaId": {mfaId}})
+				return ErrMFA
+			}
+
+			return redirect(url.Values{"token": {e.Token}})
+		})
+	})
+}
+
+// otpMagicLink returns the OTP magic link for the provided auth record and OTP id.
+func otpMagicLink(e *core.RequestEvent, authRecord *core.Record, otpId string) (string, error) {
+	token, err := authRecord.NewMagicLinkToken(otpId)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(e.App.Settings().Meta.AppURL, "/") +
+		"/api/collections/" + url.PathEscape(authRecord.Collection().Name) +
+		"/auth-with-otp-link?token=" + url.QueryEscape(token), nil
+}
+
+// setOTPMagicLinkCookie sets (or removes if value is empty) the
+// magic link browser binding cookie for the specified collection.
+func setOTPMagicLinkCookie(e *core.RequestEvent, collection *core.Collection, value string) {
+	cookie := &http.Cookie{
+		Name:     otpMagicLinkCookiePrefix + collection.Id,
+		Value:    value,
+		Path:     "/api/collections/",
+		MaxAge:   int(collection.OTP.Duration),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(e.App.Settings().Meta.AppURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+
+	e.SetCookie(cookie)
+}
+
+// otpMagicLinkRedirectURL returns the collection magic link redirect url
+// with the provided params appended to its fragment.
+func otpMagicLinkRedirectURL(collection *core.Collection, params url.Values) string {
+	redirectURL := collection.OTP.MagicLinkRedirectURL
+
+	separator := "#"
+	if strings.Contains(redirectURL, "#") {
+		separator = "&"
+	}
+
+	return redirectURL + separator + params.Encode()
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"slices"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
@@ -66,6 +67,24 @@ func recordAuthWithPassword(e *core.RequestEvent) error {
 		return e.InternalServerError("", foundErr)
 	}
 
+	// check for temporary locked account
+	// (note: the identity is checked even if the record is not found to prevent accounts enumeration)
+	if collection.PasswordAuth.LockoutThreshold > 0 {
+		var foundRecordId string
+		if foundRecord != nil {
+			foundRecordId = foundRecord.Id
+		}
+
+		lockedUntil, err := passwordAuthLockedUntil(e.App, collection, foundRecordId, form.Identity)
+		if err != nil {
+			return e.InternalServerError("", err)
+		}
+
+		if !lockedUntil.IsZero() {
+			return e.TooManyRequestsError("Too many failed authentication attempts. Please try again later.", nil)
+		}
+	}
+
 	event := new(core.RecordAuthWithPasswordRequestEvent)
 	event.RequestEvent = e
 	event.Collection = collection
@@ -76,9 +95,34 @@ func recordAuthWithPassword(e *core.RequestEvent) error {
 
 	return e.App.OnRecordAuthWithPasswordRequest().Trigger(event, func(e *core.RecordAuthWithPasswordRequestEvent) error {
 		if e.Record == nil || !e.Record.ValidatePassword(e.Password) {
+			if e.Collection.PasswordAuth.LockoutThreshold > 0 {
+				if err := registerFailedPasswordAuth(e.RequestEvent, e.Collection, e.Record, e.Identity); err != nil {
+					e.App.Logger().Warn("Failed to register failed password auth attempt", "error", err)
+				}
+			}
+
 			return e.BadRequestError("Failed to authenticate.", errors.New("invalid login credentials"))
 		}
 
+		if e.Collection.PasswordPolicy.MaxAge > 0 {
+			expired, err := hasPasswordExpired(e.App, e.Record)
+			if err != nil {
+				return e.InternalServerError("", err)
+			}
+
+			if expired {
+				return e.BadRequestError("The password has expired and must be reset.", validation.Errors{
+					"password": validation.NewError("validation_password_expired", "The password has expired."),
+				})
+			}
+		}
+
+		if e.Collection.PasswordAuth.LockoutThreshold > 0 {
+			if err := e.App.DeleteAuthAttempts(e.Record); err != nil {
+				e.App.Logger().Warn("Failed to reset the failed password auth attempts", "error", err)
+			}
+		}
+
 		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodPassword, nil)
 	})
 }
@@ -106,6 +150,56 @@ func (form *authWithPasswordForm) validate(collection *core.Collection) error {
 	)
 }
 
+// passwordAuthLockedUntil returns the time until which the password auth
+// for the specified record and/or identity value is locked
+// (zero time if not locked).
+func passwordAuthLockedUntil(app core.App, collection *core.Collection, recordId string, identity string) (time.Time, error) {
+	attempts, err := app.FindRecentAuthAttempts(collection.Id, recordId, identity, collection.PasswordAuth.LockoutThreshold)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return collection.PasswordAuth.LockedUntil(attempts), nil
+}
+
+// hasPasswordExpired reports whether the last tracked password change
+// of the provided auth record is older than its collection PasswordPolicy.MaxAge.
+func hasPasswordExpired(app core.App, authRecord *core.Record) (bool, error) {
+	history, err := app.FindPasswordHistory(authRecord, 1)
+	if err != nil || len(history) == 0 {
+		return false, err
+	}
+
+	maxAge := authRecord.Collection().PasswordPolicy.MaxAgeTime()
+
+	return time.Since(history[0].Created.Time()) > maxAge, nil
+}
+
+// registerFailedPasswordAuth stores the failed password auth attempt and
+// sends an auth alert email to the record if its account was just locked.
+func registerFailedPasswordAuth(e *core.RequestEvent, collection *core.Collection, authRecord *core.Record, identity string) error {
+	var recordId string
+	if authRecord != nil {
+		recordId = authRecord.Id
+	}
+
+	_, err := e.App.CreateAuthAttempt(collection.Id, recordId, identity, e.RealIP())
+	if err != nil {
+		return err
+	}
+
+	if authRecord == nil || !collection.AuthAlert.Enabled || authRecord.Email() == "" {
+		return nil
+	}
+
+	lockedUntil, err := passwordAuthLockedUntil(e.App, collection, recordId, identity)
+	if err != nil || lockedUntil.IsZero() {
+		return err
+	}
+
+	return sendAuthAlert(e.App, authRecord, e.AcceptLanguages()...)
+}
+
 func findRecordByIdentityField(app core.App, collection *core.Collection, field string, value any) (*core.Record, error) {
 	if !slices.Contains(collection.PasswordAuth.IdentityFields, field) {
 		return nil, errors.New("invalid identity field " + field)
@@ -6,9 +6,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/pocketbase/dbx"
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/tests"
 	"github.com/thewandererbg/pgbase/tools/dbutils"
+	"github.com/thewandererbg/pgbase/tools/types"
 )
 
 func TestRecordAuthWithPassword(t *testing.T) {
@@ -635,6 +637,222 @@ func TestRecordAuthWithPassword(t *testing.T) {
 
 		// rate limit checks
 		// -----------------------------------------------------------
+		{
+			Name:   "lockout - invalid password below the threshold",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"invalid"
+			}`),
+			BeforeTestFunc: enableLockout("clients", 3, 1),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				attempts, err := app.FindRecentAuthAttempts("v851q4r790rhknl", "", "test@example.com", 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(attempts) != 2 {
+					t.Fatalf("Expected 2 auth attempts, got %d", len(attempts))
+				}
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{}`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithPasswordRequest": 1,
+				// auth attempt track
+				"OnModelCreate":             1,
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelValidate":           1,
+			},
+		},
+		{
+			Name:   "lockout - invalid password reaching the threshold",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"invalid"
+			}`),
+			BeforeTestFunc: enableLockout("clients", 3, 2),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if app.TestMailer.TotalSend() != 1 {
+					t.Fatalf("Expected 1 sent lockout alert email, got %d", app.TestMailer.TotalSend())
+				}
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{}`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithPasswordRequest": 1,
+				// auth attempt track
+				"OnModelCreate":             1,
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelValidate":           1,
+				// lockout alert
+				"OnMailerSend":                1,
+				"OnMailerRecordAuthAlertSend": 1,
+			},
+		},
+		{
+			Name:   "lockout - locked account with valid password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc:  enableLockout("clients", 3, 3),
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "lockout - locked nonexisting identity",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"missing@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLockout("clients", 1, 0)(t, app, e)
+
+				if _, err := app.CreateAuthAttempt("v851q4r790rhknl", "", "missing@example.com", ""); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "lockout - valid password below the threshold",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc: enableLockout("clients", 3, 2),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				attempts, err := app.FindRecentAuthAttempts("v851q4r790rhknl", "", "test@example.com", 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(attempts) != 0 {
+					t.Fatalf("Expected the auth attempts to be reset, got %d", len(attempts))
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"email":"test@example.com"`,
+				`"token":`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+				"OnRecordEnrich":                  1,
+				// authOrigin track
+				"OnModelCreate":               1,
+				"OnModelCreateExecute":        1,
+				"OnModelAfterCreateSuccess":   1,
+				"OnModelValidate":             1,
+				"OnRecordCreate":              1,
+				"OnRecordCreateExecute":       1,
+				"OnRecordAfterCreateSuccess":  1,
+				"OnRecordValidate":            1,
+				"OnMailerSend":                1,
+				"OnMailerRecordAuthAlertSend": 1,
+			},
+		},
+		{
+			Name:   "password policy - expired password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection, err := app.FindCollectionByNameOrId("clients")
+				if err != nil {
+					t.Fatal(err)
+				}
+				collection.PasswordPolicy.MaxAge = 86400
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+
+				record, err := app.FindAuthRecordByEmail(collection, "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := app.CreatePasswordHistory(record, record.GetString("password:hash")); err != nil {
+					t.Fatal(err)
+				}
+
+				// mark the last password change as older than the max age
+				_, err = app.AuxDB().NewQuery("UPDATE {{_passwordHistory}} SET [[created]] = {:created}").
+					Bind(dbx.Params{"created": types.NowDateTime().AddDate(0, 0, -2).String()}).
+					Execute()
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{`,
+				`"password":{"code":"validation_password_expired"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithPasswordRequest": 1,
+			},
+		},
+		{
+			Name:   "password policy - non-expired password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/clients/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection, err := app.FindCollectionByNameOrId("clients")
+				if err != nil {
+					t.Fatal(err)
+				}
+				collection.PasswordPolicy.MaxAge = 86400
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+
+				record, err := app.FindAuthRecordByEmail(collection, "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := app.CreatePasswordHistory(record, record.GetString("password:hash")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"email":"test@example.com"`,
+				`"token":`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+		},
 		{
 			Name:   "RateLimit rule - users:authWithPassword",
 			Method: http.MethodPost,
@@ -705,3 +923,33 @@ func TestRecordAuthWithPassword(t *testing.T) {
 		scenario.Test(t)
 	}
 }
+
+// enableLockout enables the password auth lockout for the specified collection
+// and creates totalAttempts failed auth attempts for its "test@example.com" record.
+func enableLockout(collectionIdOrName string, threshold int, totalAttempts int) func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+	return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		collection, err := app.FindCollectionByNameOrId(collectionIdOrName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		collection.PasswordAuth.LockoutThreshold = threshold
+		collection.PasswordAuth.LockoutWindow = 900
+		collection.PasswordAuth.LockoutDuration = 900
+
+		if err := app.Save(collection); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := app.FindAuthRecordByEmail(collection, "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < totalAttempts; i++ {
+			if _, err := app.CreateAuthAttempt(collection.Id, record.Id, record.Email(), ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
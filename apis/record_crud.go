@@ -228,9 +228,9 @@ func recordCreate(optFinalizer func(data any) error) func(e *core.RequestEvent)
 			return firstApiError(err, e.BadRequestError("Failed to read the submitted data.", err))
 		}
 
-		// set a random password for the OAuth2 ignoring its plain password validators
+		// set a random password for the OAuth2 and LDAP auto created records ignoring its plain password validators
 		var skipPlainPasswordRecordValidators bool
-		if requestInfo.Context == core.RequestInfoContextOAuth2 {
+		if requestInfo.Context == core.RequestInfoContextOAuth2 || requestInfo.Context == core.RequestInfoContextLDAP {
 			if _, ok := data[core.FieldNamePassword]; !ok {
 				data[core.FieldNamePassword] = security.RandomString(30)
 				data[core.FieldNamePassword+"Confirm"] = data[core.FieldNamePassword]
@@ -338,6 +338,9 @@ func recordCreate(optFinalizer func(data any) error) func(e *core.RequestEvent)
 				return firstApiError(err, e.BadRequestError("Failed to create record", err))
 			}
 
+			// the attached uploads are no longer needed once the record is saved
+			deleteAttachedUploads(e.RequestEvent)
+
 			err = EnrichRecord(e.RequestEvent, e.Record)
 			if err != nil {
 				return firstApiError(err, e.InternalServerError("Failed to enrich record", err))
@@ -469,6 +472,9 @@ func recordUpdate(optFinalizer func(data any) error) func(e *core.RequestEvent)
 				return firstApiError(err, e.BadRequestError("Failed to update record.", err))
 			}
 
+			// the attached uploads are no longer needed once the record is saved
+			deleteAttachedUploads(e.RequestEvent)
+
 			err = EnrichRecord(e.RequestEvent, e.Record)
 			if err != nil {
 				return firstApiError(err, e.InternalServerError("Failed to enrich record", err))
@@ -602,8 +608,14 @@ func recordDataFromRequest(e *core.RequestEvent, record *core.Record) (map[strin
 		return nil, err
 	}
 
+	// resolve the attached resumable uploads
+	body, err := resolveUploadRefs(e, record.Collection(), info.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	// resolve regular fields
-	result := record.ReplaceModifiers(info.Body)
+	result := record.ReplaceModifiers(body)
 
 	// resolve uploaded files
 	uploadedFiles, err := extractUploadedFiles(e, record.Collection(), "")
@@ -616,14 +628,15 @@ func recordDataFromRequest(e *core.RequestEvent, record *core.Record) (map[strin
 
 			// if not remove/prepend/append -> merge with the submitted
 			// info.Body values to prevent accidental old files deletion
-			if info.Body[k] != nil &&
+			if body[k] != nil &&
 				!strings.HasPrefix(k, "+") &&
 				!strings.HasSuffix(k, "+") &&
 				!strings.HasSuffix(k, "-") {
-				existing := list.ToUniqueStringSlice(info.Body[k])
-				for _, name := range existing {
-					uploaded = append(uploaded, name)
+				existing, ok := body[k].([]any)
+				if !ok {
+					existing = list.ToInterfaceSlice(list.ToUniqueStringSlice(body[k]))
 				}
+				uploaded = append(uploaded, existing...)
 			}
 
 			for _, file := range files {
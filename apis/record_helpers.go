@@ -2,15 +2,19 @@ package apis
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/pocketbase/dbx"
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/mails"
+	"github.com/thewandererbg/pgbase/tools/inflector"
 	"github.com/thewandererbg/pgbase/tools/router"
 	"github.com/thewandererbg/pgbase/tools/routine"
 	"github.com/thewandererbg/pgbase/tools/search"
@@ -33,7 +37,7 @@ var ErrMFA = errors.New("mfa required")
 // Set authMethod to empty string if you want to ignore the MFA checks and the login alerts
 // (can be also adjusted additionally via the OnRecordAuthRequest hook).
 func RecordAuthResponse(e *core.RequestEvent, authRecord *core.Record, authMethod string, meta any) error {
-	token, tokenErr := authRecord.NewAuthToken()
+	token, tokenErr := e.App.NewAuthToken(authRecord)
 	if tokenErr != nil {
 		return e.InternalServerError("Failed to create auth token.", tokenErr)
 	}
@@ -42,6 +46,49 @@ func RecordAuthResponse(e *core.RequestEvent, authRecord *core.Record, authMetho
 }
 
 func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token string, authMethod string, meta any) error {
+	return recordAuthResponseWith(e, authRecord, token, authMethod, meta, writeRecordAuthJSON)
+}
+
+// recordAuthResponseWriter writes the final auth response.
+//
+// mfaId is non-empty if the authentication requires an additional MFA step.
+type recordAuthResponseWriter func(e *core.RecordAuthRequestEvent, mfaId string) error
+
+// writeRecordAuthJSON is the default [recordAuthResponseWriter] that writes the auth response as JSON.
+func writeRecordAuthJSON(e *core.RecordAuthRequestEvent, mfaId string) error {
+	if mfaId != "" {
+		// eagerly write the mfa response and return an err so that
+		// external middlewars are aware that the auth response requires an extra step
+		e.JSON(http.StatusUnauthorized, map[string]string{
+			"mfaId": mfaId,
+		})
+		return ErrMFA
+	}
+
+	result := struct {
+		Meta   any          `json:"meta,omitempty"`
+		Record *core.Record `json:"record"`
+		Token  string       `json:"token"`
+	}{
+		Token:  e.Token,
+		Record: e.Record,
+	}
+
+	if e.Meta != nil {
+		result.Meta = e.Meta
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func recordAuthResponseWith(
+	e *core.RequestEvent,
+	authRecord *core.Record,
+	token string,
+	authMethod string,
+	meta any,
+	write recordAuthResponseWriter,
+) error {
 	originalRequestInfo, err := e.RequestInfo()
 	if err != nil {
 		return err
@@ -74,12 +121,7 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 
 		// require additional authentication
 		if mfaId != "" {
-			// eagerly write the mfa response and return an err so that
-			// external middlewars are aware that the auth response requires an extra step
-			e.JSON(http.StatusUnauthorized, map[string]string{
-				"mfaId": mfaId,
-			})
-			return ErrMFA
+			return write(e, mfaId)
 		}
 		// ---
 
@@ -116,20 +158,7 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 			}
 		}
 
-		result := struct {
-			Meta   any          `json:"meta,omitempty"`
-			Record *core.Record `json:"record"`
-			Token  string       `json:"token"`
-		}{
-			Token:  e.Token,
-			Record: e.Record,
-		}
-
-		if e.Meta != nil {
-			result.Meta = e.Meta
-		}
-
-		return e.JSON(http.StatusOK, result)
+		return write(e, "")
 	})
 }
 
@@ -507,6 +536,99 @@ func checkForSuperuserOnlyRuleFields(requestInfo *core.RequestInfo) error {
 	return nil
 }
 
+// newRecordSnapshotQuery returns a select query factory against a single
+// row CTE table populated with the provided record data (instead of its db state)
+// and the shallow cloned collection that should be used with the query rule resolvers.
+//
+// The snapshot collection has a random suffix so that the CTE table
+// doesn't shadow the real one (e.g. in case of @collection self reference).
+func newRecordSnapshotQuery(app core.App, record *core.Record) (func() *dbx.SelectQuery, *core.Collection, error) {
+	export, err := record.DBExport(app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshotRandomPart := "__pb_snapshot__" + security.PseudorandomString(6)
+
+	params := make(dbx.Params, len(export))
+	selects := make([]string, 0, len(export))
+	for k, v := range export {
+		field := record.Collection().Fields.GetByName(k)
+
+		k = inflector.Columnify(k) // columnify is just as extra measure in case of custom fields
+		param := "__pb_snapshot__" + k
+		params[param] = v
+
+		// cast to the field column type so that the rule
+		// comparisons behave the same as with the real table
+		// (empty values like zero dates are not valid cast input)
+		columnType := ""
+		if field != nil && !isEmptySnapshotValue(v) {
+			columnType = snapshotColumnType(field.ColumnType(app))
+		}
+		if columnType != "" {
+			selects = append(selects, "CAST({:"+param+"} AS "+columnType+") AS [["+k+"]]")
+		} else {
+			selects = append(selects, "{:"+param+"} AS [["+k+"]]")
+		}
+	}
+
+	snapshotCollection := *record.Collection()
+	snapshotCollection.Id += snapshotRandomPart
+	snapshotCollection.Name += inflector.Columnify(snapshotRandomPart)
+
+	withFrom := fmt.Sprintf("WITH {{%s}} as (SELECT %s)", snapshotCollection.Name, strings.Join(selects, ","))
+
+	newQuery := func() *dbx.SelectQuery {
+		return app.DB().Select("(1)").PreFragment(withFrom).From(snapshotCollection.Name).AndBind(params)
+	}
+
+	return newQuery, &snapshotCollection, nil
+}
+
+// isEmptySnapshotValue reports whether v is exported as empty string.
+func isEmptySnapshotValue(v any) bool {
+	if valuer, ok := v.(driver.Valuer); ok {
+		v, _ = valuer.Value()
+	}
+
+	str, ok := v.(string)
+
+	return ok && str == ""
+}
+
+var snapshotColumnTypeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_ ]*(\(\s*\d+(\s*,\s*\d+)?\s*\))?(\[\])?$`)
+
+// snapshotColumnTypeStopWords are the column definition
+// keywords that follow the plain column data type.
+var snapshotColumnTypeStopWords = []string{
+	"DEFAULT", "NOT", "NULL", "PRIMARY", "UNIQUE", "CHECK",
+	"REFERENCES", "COLLATE", "GENERATED", "CONSTRAINT",
+}
+
+// snapshotColumnType extracts the data type from a field column
+// definition (e.g. "INT8 DEFAULT 0 NOT NULL" -> "INT8").
+//
+// Returns empty string if the data type contains unsafe characters.
+func snapshotColumnType(columnDefinition string) string {
+	var parts []string
+
+	for _, word := range strings.Fields(columnDefinition) {
+		if slices.Contains(snapshotColumnTypeStopWords, strings.ToUpper(word)) {
+			break
+		}
+		parts = append(parts, word)
+	}
+
+	columnType := strings.Join(parts, " ")
+
+	if !snapshotColumnTypeRegex.MatchString(columnType) {
+		return ""
+	}
+
+	return columnType
+}
+
 // firstApiError returns the first ApiError from the errors list
 // (this is used usually to prevent unnecessary wraping and to allow bubling ApiError from nested hooks)
 //
@@ -571,28 +693,8 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 	}
 
 	// send email alert for the new origin auth (skip first login)
-	//
-	// Note: The "fake" timeout is a temp solution to avoid blocking
-	//       for too long when the SMTP server is not accessible, due
-	//       to the lack of context cancellation support in the underlying
-	//       mailer and net/smtp package.
-	//       The goroutine technically "leaks" but we assume that the OS will
-	//       terminate the connection after some time (usually after 3-4 mins).
 	if !isFirstLogin && currentOrigin.IsNew() && authRecord.Email() != "" {
-		mailSent := make(chan error, 1)
-
-		timer := time.AfterFunc(15*time.Second, func() {
-			mailSent <- errors.New("auth alert mail send wait timeout reached")
-		})
-
-		routine.FireAndForget(func() {
-			err := mails.SendRecordAuthAlert(e.App, authRecord)
-			timer.Stop()
-			mailSent <- err
-		})
-
-		err = <-mailSent
-		if err != nil {
+		if err := sendAuthAlert(e.App, authRecord, e.AcceptLanguages()...); err != nil {
 			return err
 		}
 	}
@@ -611,3 +713,27 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 	// create/update the origin fingerprint
 	return e.App.Save(currentOrigin)
 }
+
+// sendAuthAlert sends an auth alert email to the specified auth record.
+//
+// Note: The "fake" timeout is a temp solution to avoid blocking
+// for too long when the SMTP server is not accessible, due
+// to the lack of context cancellation support in the underlying
+// mailer and net/smtp package.
+// The goroutine technically "leaks" but we assume that the OS will
+// terminate the connection after some time (usually after 3-4 mins).
+func sendAuthAlert(app core.App, authRecord *core.Record, locales ...string) error {
+	mailSent := make(chan error, 1)
+
+	timer := time.AfterFunc(15*time.Second, func() {
+		mailSent <- errors.New("auth alert mail send wait timeout reached")
+	})
+
+	routine.FireAndForget(func() {
+		err := mails.SendRecordAuthAlert(app, authRecord, locales...)
+		timer.Stop()
+		mailSent <- err
+	})
+
+	return <-mailSent
+}
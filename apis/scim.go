@@ -0,0 +1,460 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/spf13/cast"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/forms"
+	"github.com/thewandererbg/pgbase/tools/router"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+const (
+	scimSchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimSchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimSchemaSPConfig     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaResourceType = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	scimSchemaSchema       = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+	scimContentType = "application/scim+json"
+
+	scimBasePath = "/scim/v2"
+
+	scimDefaultCount = 100
+	scimMaxCount     = 500
+)
+
+// bindSCIMApi registers the SCIM 2.0 user provisioning api endpoints.
+func bindSCIMApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	subGroup := rg.Group(scimBasePath).BindFunc(scimAuth)
+
+	subGroup.GET("/ServiceProviderConfig", scimServiceProviderConfig)
+	subGroup.GET("/ResourceTypes", scimResourceTypes)
+	subGroup.GET("/Schemas", scimSchemas)
+
+	subGroup.GET("/Users", scimUsersList)
+	subGroup.POST("/Users", scimUserCreate)
+	subGroup.GET("/Users/{id}", scimUserView)
+	subGroup.PUT("/Users/{id}", scimUserReplace)
+	subGroup.PATCH("/Users/{id}", scimUserPatch)
+	subGroup.DELETE("/Users/{id}", scimUserDelete)
+
+	subGroup.GET("/Groups", scimGroupsList)
+	subGroup.POST("/Groups", scimGroupCreate)
+	subGroup.GET("/Groups/{id}", scimGroupView)
+	subGroup.PUT("/Groups/{id}", scimGroupReplace)
+	subGroup.PATCH("/Groups/{id}", scimGroupPatch)
+	subGroup.DELETE("/Groups/{id}", scimGroupDelete)
+}
+
+// scimAuth checks whether SCIM is enabled and that the request
+// has a valid SCIM bearer token.
+func scimAuth(e *core.RequestEvent) error {
+	config := e.App.Settings().SCIM
+
+	if !config.Enabled || config.Token == "" {
+		return scimError(e, http.StatusNotFound, "", "SCIM is not enabled.")
+	}
+
+	token, ok := strings.CutPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+	if !ok || !security.Equal(strings.TrimSpace(token), config.Token) {
+		return scimError(e, http.StatusUnauthorized, "", "Missing or invalid SCIM bearer token.")
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextSCIM)
+
+	return e.Next()
+}
+
+// -------------------------------------------------------------------
+
+var errSCIMGroupsDisabled = errors.New("SCIM groups are not enabled")
+
+// scimContext holds the SCIM resources mapping resolved from the app settings.
+type scimContext struct {
+	config           core.SCIMConfig
+	usersCollection  *core.Collection
+	groupsCollection *core.Collection
+	userNameField    string
+	nameField        string
+	groupNameField   string
+}
+
+func resolveSCIMContext(app core.App) (*scimContext, error) {
+	config := app.Settings().SCIM
+
+	usersCollection, err := app.FindCachedCollectionByNameOrId(config.Collection)
+	if err != nil || !usersCollection.IsAuth() {
+		return nil, errors.New("missing or invalid SCIM auth collection")
+	}
+
+	result := &scimContext{
+		config:          config,
+		usersCollection: usersCollection,
+		userNameField:   core.FieldNameEmail,
+	}
+
+	if usersCollection.Fields.GetByName("username") != nil {
+		result.userNameField = "username"
+	}
+
+	if usersCollection.Fields.GetByName("name") != nil {
+		result.nameField = "name"
+	}
+
+	if config.ActiveField != "" {
+		if _, ok := usersCollection.Fields.GetByName(config.ActiveField).(*core.BoolField); !ok {
+			return nil, fmt.Errorf("invalid SCIM active field %q", config.ActiveField)
+		}
+	}
+
+	if config.GroupsField != "" {
+		relField, ok := usersCollection.Fields.GetByName(config.GroupsField).(*core.RelationField)
+		if !ok || !relField.IsMultiple() {
+			return nil, fmt.Errorf("invalid SCIM groups field %q", config.GroupsField)
+		}
+
+		result.groupsCollection, err = app.FindCachedCollectionByNameOrId(relField.CollectionId)
+		if err != nil {
+			return nil, err
+		}
+
+		result.groupNameField = config.GroupNameField
+		if result.groupNameField == "" {
+			result.groupNameField = "name"
+		}
+
+		if result.groupsCollection.Fields.GetByName(result.groupNameField) == nil {
+			return nil, fmt.Errorf("invalid SCIM group name field %q", result.groupNameField)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *scimContext) location(e *core.RequestEvent, resourceType string, id string) string {
+	return strings.TrimRight(e.App.Settings().Meta.AppURL, "/") + "/api" + scimBasePath + "/" + resourceType + "/" + id
+}
+
+// scimContextError writes the SCIM error response for a failed SCIM context resolve.
+func scimContextError(e *core.RequestEvent, err error) error {
+	if errors.Is(err, errSCIMGroupsDisabled) {
+		return scimError(e, http.StatusNotFound, "", "SCIM groups are not enabled.")
+	}
+
+	e.App.Logger().Error("Invalid SCIM settings", "error", err.Error())
+
+	return scimError(e, http.StatusInternalServerError, "", "Invalid SCIM settings.")
+}
+
+// -------------------------------------------------------------------
+
+// scimJSON writes the provided data as "application/scim+json" response.
+func scimJSON(e *core.RequestEvent, status int, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return e.Blob(status, scimContentType, raw)
+}
+
+// scimError writes a SCIM error response.
+func scimError(e *core.RequestEvent, status int, scimType string, detail string) error {
+	data := map[string]any{
+		"schemas": []string{scimSchemaError},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	}
+
+	if scimType != "" {
+		data["scimType"] = scimType
+	}
+
+	return scimJSON(e, status, data)
+}
+
+// scimSaveError converts the provided record save error to a SCIM error response.
+func scimSaveError(e *core.RequestEvent, err error) error {
+	var apiErr *router.ApiError
+	if errors.As(err, &apiErr) {
+		rawErr, ok := apiErr.RawData().(error)
+		if !ok {
+			return scimError(e, apiErr.Status, "", apiErr.Message)
+		}
+		err = rawErr
+	}
+
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		for field, fieldErr := range errs {
+			var validationErr validation.Error
+			if errors.As(fieldErr, &validationErr) && validationErr.Code() == "validation_not_unique" {
+				return scimError(e, http.StatusConflict, "uniqueness", field+": "+validationErr.Message())
+			}
+		}
+
+		return scimError(e, http.StatusBadRequest, "invalidValue", errs.Error())
+	}
+
+	return scimError(e, http.StatusBadRequest, "", err.Error())
+}
+
+// scimReadBody decodes the request JSON body into dst.
+func scimReadBody(e *core.RequestEvent, dst any) error {
+	if err := json.NewDecoder(e.Request.Body).Decode(dst); err != nil {
+		return fmt.Errorf("failed to decode the request body: %w", err)
+	}
+
+	return nil
+}
+
+// scimPagination returns the 1-based SCIM startIndex and count query parameters.
+func scimPagination(e *core.RequestEvent) (int, int) {
+	query := e.Request.URL.Query()
+
+	startIndex := cast.ToInt(query.Get("startIndex"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	count := scimDefaultCount
+	if raw := query.Get("count"); raw != "" {
+		count = min(max(cast.ToInt(raw), 0), scimMaxCount)
+	}
+
+	return startIndex, count
+}
+
+func scimListResponse(e *core.RequestEvent, total int, startIndex int, resources []map[string]any) error {
+	return scimJSON(e, http.StatusOK, map[string]any{
+		"schemas":      []string{scimSchemaListResponse},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	})
+}
+
+// scimUpsertRecord saves the provided record data through the regular
+// record create/update request hooks and form validations.
+//
+// If randomPassword is set, the auth record password is replaced with
+// a random one ignoring its plain password validators.
+func scimUpsertRecord(e *core.RequestEvent, record *core.Record, data map[string]any, randomPassword bool) error {
+	if randomPassword {
+		data[core.FieldNamePassword] = security.RandomString(30)
+		data[core.FieldNamePassword+"Confirm"] = data[core.FieldNamePassword]
+	}
+
+	form := forms.NewRecordUpsert(e.App, record)
+	form.GrantSuperuserAccess()
+	form.Load(data)
+
+	if randomPassword {
+		// unset the plain value to skip the plain password field validators
+		if raw, ok := record.GetRaw(core.FieldNamePassword).(*core.PasswordFieldValue); ok {
+			raw.Plain = ""
+		}
+	}
+
+	event := new(core.RecordRequestEvent)
+	event.RequestEvent = e
+	event.Collection = record.Collection()
+	event.Record = record
+
+	submit := func(e *core.RecordRequestEvent) error {
+		form.SetApp(e.App)
+		form.SetRecord(e.Record)
+
+		return form.Submit()
+	}
+
+	if record.IsNew() {
+		return e.App.OnRecordCreateRequest().Trigger(event, submit)
+	}
+
+	return e.App.OnRecordUpdateRequest().Trigger(event, submit)
+}
+
+// scimDeleteRecord deletes the provided record through the regular record delete request hooks.
+func scimDeleteRecord(e *core.RequestEvent, record *core.Record) error {
+	event := new(core.RecordRequestEvent)
+	event.RequestEvent = e
+	event.Collection = record.Collection()
+	event.Record = record
+
+	return e.App.OnRecordDeleteRequest().Trigger(event, func(e *core.RecordRequestEvent) error {
+		return e.App.Delete(e.Record)
+	})
+}
+
+// scimFindRecords returns the paginated collection records matching the provided
+// filter expression (ordered by their id) and the total number of matching records.
+func scimFindRecords(app core.App, collection *core.Collection, filter dbx.Expression, offset int, limit int) ([]*core.Record, int, error) {
+	query := app.RecordQuery(collection)
+	if filter != nil {
+		query.AndWhere(filter)
+	}
+
+	var total int
+	countQuery := *query // shallow clone
+	err := countQuery.Select("COUNT(DISTINCT [[" + collection.Name + ".id]])").Row(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records := []*core.Record{}
+
+	if limit > 0 && offset < total {
+		err = query.OrderBy(collection.Name + ".id ASC").
+			Offset(int64(offset)).
+			Limit(int64(limit)).
+			All(&records)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return records, total, nil
+}
+
+// scimPatchOperation defines a single SCIM PATCH operation.
+type scimPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// scimPatchRequest defines a SCIM PATCH request body.
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+func (r *scimPatchRequest) validate() error {
+	if len(r.Operations) == 0 {
+		return errors.New("missing PATCH operations")
+	}
+
+	for _, op := range r.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace", "remove":
+		default:
+			return fmt.Errorf("unsupported PATCH operation %q", op.Op)
+		}
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+func scimServiceProviderConfig(e *core.RequestEvent) error {
+	return scimJSON(e, http.StatusOK, map[string]any{
+		"schemas":          []string{scimSchemaSPConfig},
+		"patch":            map[string]any{"supported": true},
+		"bulk":             map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           map[string]any{"supported": true, "maxResults": scimMaxCount},
+		"changePassword":   map[string]any{"supported": true},
+		"sort":             map[string]any{"supported": false},
+		"etag":             map[string]any{"supported": false},
+		"documentationUri": "",
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "Authentication with the static SCIM bearer token from the app settings.",
+			"primary":     true,
+		}},
+		"meta": map[string]any{"resourceType": "ServiceProviderConfig"},
+	})
+}
+
+func scimResourceTypes(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	resources := []map[string]any{{
+		"schemas":  []string{scimSchemaResourceType},
+		"id":       "User",
+		"name":     "User",
+		"endpoint": "/Users",
+		"schema":   scimSchemaUser,
+		"meta":     map[string]any{"resourceType": "ResourceType"},
+	}}
+
+	if c.groupsCollection != nil {
+		resources = append(resources, map[string]any{
+			"schemas":  []string{scimSchemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   scimSchemaGroup,
+			"meta":     map[string]any{"resourceType": "ResourceType"},
+		})
+	}
+
+	return scimListResponse(e, len(resources), 1, resources)
+}
+
+func scimSchemas(e *core.RequestEvent) error {
+	attr := func(name string, typ string, multiValued bool, extra ...map[string]any) map[string]any {
+		result := map[string]any{
+			"name":        name,
+			"type":        typ,
+			"multiValued": multiValued,
+			"required":    false,
+			"mutability":  "readWrite",
+			"returned":    "default",
+		}
+		for _, e := range extra {
+			for k, v := range e {
+				result[k] = v
+			}
+		}
+		return result
+	}
+
+	resources := []map[string]any{
+		{
+			"schemas": []string{scimSchemaSchema},
+			"id":      scimSchemaUser,
+			"name":    "User",
+			"attributes": []map[string]any{
+				attr("userName", "string", false, map[string]any{"required": true, "uniqueness": "server"}),
+				attr("displayName", "string", false),
+				attr("name", "complex", false),
+				attr("emails", "complex", true),
+				attr("active", "boolean", false),
+				attr("password", "string", false, map[string]any{"mutability": "writeOnly", "returned": "never"}),
+				attr("groups", "complex", true, map[string]any{"mutability": "readOnly"}),
+			},
+			"meta": map[string]any{"resourceType": "Schema"},
+		},
+		{
+			"schemas": []string{scimSchemaSchema},
+			"id":      scimSchemaGroup,
+			"name":    "Group",
+			"attributes": []map[string]any{
+				attr("displayName", "string", false, map[string]any{"required": true}),
+				attr("members", "complex", true),
+			},
+			"meta": map[string]any{"resourceType": "Schema"},
+		},
+	}
+
+	return scimListResponse(e, len(resources), 1, resources)
+}
@@ -0,0 +1,337 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pocketbase/dbx"
+)
+
+// scimMaxFilterLength is the max allowed length of a single SCIM filter expression.
+const scimMaxFilterLength = 2000
+
+// scimFilterAttrType defines how a SCIM filter attribute is compared.
+type scimFilterAttrType int
+
+const (
+	scimFilterAttrString scimFilterAttrType = iota
+	scimFilterAttrBool
+	scimFilterAttrArray
+)
+
+// scimFilterAttr describes a filterable SCIM attribute mapped to a db column.
+type scimFilterAttr struct {
+	Column string
+	Type   scimFilterAttrType
+}
+
+// scimFilterParser converts a SCIM filter expression (RFC 7644 section 3.4.2.2)
+// into a db expression.
+//
+// Supported are the "eq", "ne", "co", "sw", "ew" and "pr" operators,
+// the "and", "or" and "not" logical operators and grouping with parenthesis.
+//
+// String comparisons are case-insensitive.
+type scimFilterParser struct {
+	attrs  map[string]scimFilterAttr
+	tokens []string
+	pos    int
+	params dbx.Params
+}
+
+// parseSCIMFilter parses the provided SCIM filter expression using
+// the attrs map (with lowercased SCIM attribute names as keys) to resolve the filter attributes.
+//
+// Returns nil expression if the filter is empty.
+func parseSCIMFilter(filter string, attrs map[string]scimFilterAttr) (dbx.Expression, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, nil
+	}
+
+	if len(filter) > scimMaxFilterLength {
+		return nil, errors.New("the filter expression is too long")
+	}
+
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &scimFilterParser{attrs: attrs, tokens: tokens, params: dbx.Params{}}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", p.tokens[p.pos])
+	}
+
+	return expr, nil
+}
+
+func (p *scimFilterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *scimFilterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *scimFilterParser) parseOr() (dbx.Expression, error) {
+	exprs := []dbx.Expression{}
+
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if !strings.EqualFold(p.peek(), "or") {
+			break
+		}
+		p.next()
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+
+	return dbx.Enclose(dbx.Or(exprs...)), nil
+}
+
+func (p *scimFilterParser) parseAnd() (dbx.Expression, error) {
+	exprs := []dbx.Expression{}
+
+	for {
+		expr, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if !strings.EqualFold(p.peek(), "and") {
+			break
+		}
+		p.next()
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+
+	return dbx.Enclose(dbx.And(exprs...)), nil
+}
+
+func (p *scimFilterParser) parseFactor() (dbx.Expression, error) {
+	token := p.next()
+
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of the filter expression")
+	case strings.EqualFold(token, "not"):
+		if p.peek() != "(" {
+			return nil, errors.New(`"not" must be followed by a parenthesized expression`)
+		}
+
+		expr, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		return dbx.Not(expr), nil
+	case token == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.next() != ")" {
+			return nil, errors.New("missing closing parenthesis")
+		}
+
+		return expr, nil
+	}
+
+	return p.parseComparison(token)
+}
+
+func (p *scimFilterParser) parseComparison(attrPath string) (dbx.Expression, error) {
+	attr, ok := p.attrs[strings.ToLower(normalizeSCIMAttrPath(attrPath))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported filter attribute %q", attrPath)
+	}
+
+	col := "[[" + attr.Column + "]]"
+
+	op := strings.ToLower(p.next())
+
+	if op == "pr" {
+		switch attr.Type {
+		case scimFilterAttrBool:
+			return dbx.NewExp(col + " IS NOT NULL"), nil
+		case scimFilterAttrArray:
+			return dbx.NewExp("jsonb_array_length(" + col + ") > 0"), nil
+		default:
+			return dbx.NewExp("COALESCE(" + col + ", '') != ''"), nil
+		}
+	}
+
+	rawValue := p.next()
+	if rawValue == "" {
+		return nil, fmt.Errorf("missing comparison value for %q", attrPath)
+	}
+
+	value, err := parseSCIMFilterValue(rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	placeholder := "scim" + strconv.Itoa(len(p.params))
+
+	switch attr.Type {
+	case scimFilterAttrBool:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%q expects a boolean value", attrPath)
+		}
+
+		p.params[placeholder] = v
+
+		switch op {
+		case "eq":
+			return dbx.NewExp(col+" = {:"+placeholder+"}", p.params), nil
+		case "ne":
+			return dbx.NewExp(col+" != {:"+placeholder+"}", p.params), nil
+		}
+	case scimFilterAttrArray:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%q expects a string value", attrPath)
+		}
+
+		p.params[placeholder] = v
+
+		switch op {
+		case "eq":
+			return dbx.NewExp(col+" @> jsonb_build_array({:"+placeholder+"}::text)", p.params), nil
+		case "ne":
+			return dbx.NewExp("NOT ("+col+" @> jsonb_build_array({:"+placeholder+"}::text))", p.params), nil
+		}
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%q expects a string value", attrPath)
+		}
+
+		lowerCol := "LOWER(" + col + ")"
+
+		switch op {
+		case "eq":
+			p.params[placeholder] = strings.ToLower(v)
+			return dbx.NewExp(lowerCol+" = {:"+placeholder+"}", p.params), nil
+		case "ne":
+			p.params[placeholder] = strings.ToLower(v)
+			return dbx.NewExp(lowerCol+" != {:"+placeholder+"}", p.params), nil
+		case "co":
+			p.params[placeholder] = "%" + escapeSCIMLike(strings.ToLower(v)) + "%"
+		case "sw":
+			p.params[placeholder] = escapeSCIMLike(strings.ToLower(v)) + "%"
+		case "ew":
+			p.params[placeholder] = "%" + escapeSCIMLike(strings.ToLower(v))
+		}
+
+		if _, ok := p.params[placeholder]; ok {
+			return dbx.NewExp(lowerCol+" LIKE {:"+placeholder+"} ESCAPE '\\'", p.params), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported filter operator %q for %q", op, attrPath)
+}
+
+// normalizeSCIMAttrPath strips the optional schema URN prefix from the attribute path
+// (eg. "urn:ietf:params:scim:schemas:core:2.0:User:userName" -> "userName").
+func normalizeSCIMAttrPath(attrPath string) string {
+	if strings.HasPrefix(strings.ToLower(attrPath), "urn:") {
+		if i := strings.LastIndex(attrPath, ":"); i >= 0 {
+			return attrPath[i+1:]
+		}
+	}
+
+	return attrPath
+}
+
+func parseSCIMFilterValue(raw string) (any, error) {
+	switch strings.ToLower(raw) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, errors.New("null comparisons are not supported")
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		var str string
+		if err := json.Unmarshal([]byte(raw), &str); err != nil {
+			return nil, fmt.Errorf("invalid string value %s", raw)
+		}
+		return str, nil
+	}
+
+	return nil, fmt.Errorf("unsupported filter value %q", raw)
+}
+
+func escapeSCIMLike(str string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(str)
+}
+
+func tokenizeSCIMFilter(filter string) ([]string, error) {
+	tokens := []string{}
+
+	runes := []rune(filter)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"':
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated string value")
+			}
+			i++
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			start := i
+			for ; i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"'; i++ {
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,400 @@
+package apis
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/spf13/cast"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/list"
+)
+
+// scimMembersPathRegex matches the SCIM group members value filter path,
+// eg. `members[value eq "abc"]`.
+var scimMembersPathRegex = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+func scimGroupsList(e *core.RequestEvent) error {
+	c, err := resolveSCIMGroupsContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	table := c.groupsCollection.Name + "."
+
+	filter, err := parseSCIMFilter(e.Request.URL.Query().Get("filter"), map[string]scimFilterAttr{
+		"id":          {Column: table + core.FieldNameId},
+		"displayname": {Column: table + c.groupNameField},
+	})
+	if err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+
+	startIndex, count := scimPagination(e)
+
+	records, total, err := scimFindRecords(e.App, c.groupsCollection, filter, startIndex-1, count)
+	if err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidFilter", "Failed to fetch the groups.")
+	}
+
+	excludeMembers := slices.Contains(
+		strings.Split(strings.ToLower(e.Request.URL.Query().Get("excludedAttributes")), ","),
+		"members",
+	)
+
+	resources := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		resource, err := c.groupResource(e, record, !excludeMembers)
+		if err != nil {
+			return scimError(e, http.StatusInternalServerError, "", "Failed to load the group members.")
+		}
+		resources = append(resources, resource)
+	}
+
+	return scimListResponse(e, total, startIndex, resources)
+}
+
+func scimGroupView(e *core.RequestEvent) error {
+	c, err := resolveSCIMGroupsContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.groupsCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "Group not found.")
+	}
+
+	resource, err := c.groupResource(e, record, true)
+	if err != nil {
+		return scimError(e, http.StatusInternalServerError, "", "Failed to load the group members.")
+	}
+
+	return scimJSON(e, http.StatusOK, resource)
+}
+
+func scimGroupCreate(e *core.RequestEvent) error {
+	c, err := resolveSCIMGroupsContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	payload := map[string]any{}
+	if err := scimReadBody(e, &payload); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	record := core.NewRecord(c.groupsCollection)
+
+	return c.saveGroup(e, record, payload, http.StatusCreated)
+}
+
+func scimGroupReplace(e *core.RequestEvent) error {
+	c, err := resolveSCIMGroupsContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.groupsCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "Group not found.")
+	}
+
+	payload := map[string]any{}
+	if err := scimReadBody(e, &payload); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	// PUT replaces the entire members list
+	if _, ok := payload["members"]; !ok {
+		payload["members"] = []any{}
+	}
+
+	return c.saveGroup(e, record, payload, http.StatusOK)
+}
+
+func scimGroupPatch(e *core.RequestEvent) error {
+	c, err := resolveSCIMGroupsContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.groupsCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "Group not found.")
+	}
+
+	patch := &scimPatchRequest{}
+	if err := scimReadBody(e, patch); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	if err := patch.validate(); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	memberIds, err := c.groupMemberIds(e, record)
+	if err != nil {
+		return scimError(e, http.StatusInternalServerError, "", "Failed to load the group members.")
+	}
+
+	payload := map[string]any{}
+
+	for _, op := range patch.Operations {
+		opName := strings.ToLower(op.Op)
+
+		ops := map[string]any{}
+		if op.Path == "" {
+			values, ok := op.Value.(map[string]any)
+			if !ok {
+				return scimError(e, http.StatusBadRequest, "invalidValue", "The operation value must be an object when no path is specified.")
+			}
+			for k, v := range values {
+				ops[strings.ToLower(k)] = v
+			}
+		} else {
+			ops[strings.ToLower(normalizeSCIMAttrPath(op.Path))] = op.Value
+		}
+
+		for path, value := range ops {
+			if match := scimMembersPathRegex.FindStringSubmatch(op.Path); match != nil {
+				if opName != "remove" {
+					return scimError(e, http.StatusBadRequest, "invalidPath", "Member value filters are supported only for remove operations.")
+				}
+				memberIds = slices.DeleteFunc(memberIds, func(id string) bool { return id == match[1] })
+				continue
+			}
+
+			switch path {
+			case "displayname":
+				if opName == "remove" {
+					value = ""
+				}
+				payload["displayName"] = value
+			case "members":
+				ids := scimMemberIds(value)
+				switch opName {
+				case "add":
+					memberIds = list.ToUniqueStringSlice(append(memberIds, ids...))
+				case "replace":
+					memberIds = list.ToUniqueStringSlice(ids)
+				case "remove":
+					if value == nil {
+						memberIds = []string{}
+					} else {
+						memberIds = slices.DeleteFunc(memberIds, func(id string) bool { return slices.Contains(ids, id) })
+					}
+				}
+			default:
+				return scimError(e, http.StatusBadRequest, "invalidPath", "Unsupported group attribute "+op.Path+".")
+			}
+		}
+	}
+
+	members := make([]any, len(memberIds))
+	for i, id := range memberIds {
+		members[i] = map[string]any{"value": id}
+	}
+	payload["members"] = members
+
+	return c.saveGroup(e, record, payload, http.StatusOK)
+}
+
+func scimGroupDelete(e *core.RequestEvent) error {
+	c, err := resolveSCIMGroupsContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.groupsCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "Group not found.")
+	}
+
+	if err := scimDeleteRecord(e, record); err != nil {
+		return scimSaveError(e, err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+// resolveSCIMGroupsContext resolves the SCIM context and checks
+// whether the groups mapping is configured.
+func resolveSCIMGroupsContext(app core.App) (*scimContext, error) {
+	c, err := resolveSCIMContext(app)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.groupsCollection == nil {
+		return nil, errSCIMGroupsDisabled
+	}
+
+	return c, nil
+}
+
+// saveGroup saves the provided group record with the SCIM Group payload
+// and syncs its members (if present in the payload).
+func (c *scimContext) saveGroup(e *core.RequestEvent, record *core.Record, payload map[string]any, status int) error {
+	data := map[string]any{}
+	if v, ok := payload["displayName"]; ok {
+		data[c.groupNameField] = cast.ToString(v)
+	}
+
+	rawMembers, syncMembers := payload["members"]
+
+	originalApp := e.App
+
+	err := e.App.RunInTransaction(func(txApp core.App) error {
+		e.App = txApp
+		defer func() { e.App = originalApp }()
+
+		if err := scimUpsertRecord(e, record, data, false); err != nil {
+			return err
+		}
+
+		if syncMembers {
+			return c.syncGroupMembers(e, record, scimMemberIds(rawMembers))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return scimSaveError(e, err)
+	}
+
+	resource, err := c.groupResource(e, record, true)
+	if err != nil {
+		return scimError(e, http.StatusInternalServerError, "", "Failed to load the group members.")
+	}
+
+	return scimJSON(e, status, resource)
+}
+
+// syncGroupMembers updates the groups relation field of the users
+// so that only the ones with the provided ids are members of the group.
+func (c *scimContext) syncGroupMembers(e *core.RequestEvent, group *core.Record, memberIds []string) error {
+	currentIds, err := c.groupMemberIds(e, group)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range currentIds {
+		if slices.Contains(memberIds, id) {
+			continue
+		}
+
+		user, err := e.App.FindRecordById(c.usersCollection, id)
+		if err != nil {
+			return err
+		}
+
+		user.Set(c.config.GroupsField+"-", group.Id)
+
+		if err := scimUpsertRecord(e, user, map[string]any{}, false); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range memberIds {
+		if slices.Contains(currentIds, id) {
+			continue
+		}
+
+		user, err := e.App.FindRecordById(c.usersCollection, id)
+		if err != nil {
+			return err
+		}
+
+		user.Set(c.config.GroupsField+"+", group.Id)
+
+		if err := scimUpsertRecord(e, user, map[string]any{}, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// groupMemberIds returns the ids of the users that are members of the provided group.
+func (c *scimContext) groupMemberIds(e *core.RequestEvent, group *core.Record) ([]string, error) {
+	ids := []string{}
+
+	if group.IsNew() {
+		return ids, nil
+	}
+
+	err := e.App.RecordQuery(c.usersCollection).
+		Select(c.usersCollection.Name + ".id").
+		AndWhere(dbx.NewExp(
+			"[["+c.usersCollection.Name+"."+c.config.GroupsField+"]] @> jsonb_build_array({:groupId}::text)",
+			dbx.Params{"groupId": group.Id},
+		)).
+		OrderBy(c.usersCollection.Name + ".id ASC").
+		Column(&ids)
+
+	return ids, err
+}
+
+// groupResource exports the provided group record as SCIM Group resource.
+func (c *scimContext) groupResource(e *core.RequestEvent, record *core.Record, withMembers bool) (map[string]any, error) {
+	result := map[string]any{
+		"schemas":     []string{scimSchemaGroup},
+		"id":          record.Id,
+		"displayName": record.GetString(c.groupNameField),
+		"meta": map[string]any{
+			"resourceType": "Group",
+			"created":      record.GetDateTime("created").Time(),
+			"lastModified": record.GetDateTime("updated").Time(),
+			"location":     c.location(e, "Groups", record.Id),
+		},
+	}
+
+	if withMembers {
+		ids, err := c.groupMemberIds(e, record)
+		if err != nil {
+			return nil, err
+		}
+
+		members := make([]map[string]any, len(ids))
+		for i, id := range ids {
+			members[i] = map[string]any{
+				"value": id,
+				"$ref":  c.location(e, "Users", id),
+			}
+		}
+
+		result["members"] = members
+	}
+
+	return result, nil
+}
+
+// scimMemberIds extracts the member ids from the provided SCIM "members" attribute value.
+func scimMemberIds(rawMembers any) []string {
+	ids := []string{}
+
+	members, ok := rawMembers.([]any)
+	if !ok {
+		if rawMembers != nil {
+			members = []any{rawMembers}
+		}
+	}
+
+	for _, raw := range members {
+		member, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if id := cast.ToString(member["value"]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return list.ToUniqueStringSlice(ids)
+}
@@ -0,0 +1,341 @@
+package apis
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/list"
+)
+
+func scimUsersList(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	filter, err := parseSCIMFilter(e.Request.URL.Query().Get("filter"), c.userFilterAttrs())
+	if err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+
+	startIndex, count := scimPagination(e)
+
+	records, total, err := scimFindRecords(e.App, c.usersCollection, filter, startIndex-1, count)
+	if err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidFilter", "Failed to fetch the users.")
+	}
+
+	resources := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		resources = append(resources, c.userResource(e, record))
+	}
+
+	return scimListResponse(e, total, startIndex, resources)
+}
+
+func scimUserView(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.usersCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "User not found.")
+	}
+
+	return scimJSON(e, http.StatusOK, c.userResource(e, record))
+}
+
+func scimUserCreate(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	payload := map[string]any{}
+	if err := scimReadBody(e, &payload); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	data := c.userRecordData(payload)
+
+	// users provisioned by the identity provider are considered verified
+	data[core.FieldNameVerified] = true
+
+	_, hasPassword := data[core.FieldNamePassword]
+
+	record := core.NewRecord(c.usersCollection)
+
+	if err := scimUpsertRecord(e, record, data, !hasPassword); err != nil {
+		return scimSaveError(e, err)
+	}
+
+	return scimJSON(e, http.StatusCreated, c.userResource(e, record))
+}
+
+func scimUserReplace(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.usersCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "User not found.")
+	}
+
+	payload := map[string]any{}
+	if err := scimReadBody(e, &payload); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	if err := scimUpsertRecord(e, record, c.userRecordData(payload), false); err != nil {
+		return scimSaveError(e, err)
+	}
+
+	return scimJSON(e, http.StatusOK, c.userResource(e, record))
+}
+
+func scimUserPatch(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.usersCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "User not found.")
+	}
+
+	patch := &scimPatchRequest{}
+	if err := scimReadBody(e, patch); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	if err := patch.validate(); err != nil {
+		return scimError(e, http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	// merge all operations into a single partial user payload
+	payload := map[string]any{}
+	for _, op := range patch.Operations {
+		if op.Path == "" {
+			values, ok := op.Value.(map[string]any)
+			if !ok {
+				return scimError(e, http.StatusBadRequest, "invalidValue", "The operation value must be an object when no path is specified.")
+			}
+
+			for k, v := range values {
+				if strings.EqualFold(op.Op, "remove") {
+					v = nil
+				}
+				payload[k] = v
+			}
+
+			continue
+		}
+
+		attr := strings.ToLower(normalizeSCIMAttrPath(op.Path))
+
+		// eg. emails[type eq "work"].value
+		if strings.HasPrefix(attr, "emails") {
+			attr = "emails"
+			if value, ok := op.Value.(string); ok {
+				op.Value = []any{map[string]any{"value": value, "primary": true}}
+			}
+		}
+
+		if strings.EqualFold(op.Op, "remove") {
+			op.Value = nil
+		}
+
+		payload[attr] = op.Value
+	}
+
+	if err := scimUpsertRecord(e, record, c.userRecordData(payload), false); err != nil {
+		return scimSaveError(e, err)
+	}
+
+	return scimJSON(e, http.StatusOK, c.userResource(e, record))
+}
+
+func scimUserDelete(e *core.RequestEvent) error {
+	c, err := resolveSCIMContext(e.App)
+	if err != nil {
+		return scimContextError(e, err)
+	}
+
+	record, err := e.App.FindRecordById(c.usersCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return scimError(e, http.StatusNotFound, "", "User not found.")
+	}
+
+	if err := scimDeleteRecord(e, record); err != nil {
+		return scimSaveError(e, err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+// userFilterAttrs returns the filterable SCIM User attributes.
+func (c *scimContext) userFilterAttrs() map[string]scimFilterAttr {
+	table := c.usersCollection.Name + "."
+
+	attrs := map[string]scimFilterAttr{
+		"id":           {Column: table + core.FieldNameId},
+		"username":     {Column: table + c.userNameField},
+		"emails":       {Column: table + core.FieldNameEmail},
+		"emails.value": {Column: table + core.FieldNameEmail},
+	}
+
+	if c.nameField != "" {
+		attrs["displayname"] = scimFilterAttr{Column: table + c.nameField}
+		attrs["name.formatted"] = scimFilterAttr{Column: table + c.nameField}
+	}
+
+	if c.config.ActiveField != "" {
+		attrs["active"] = scimFilterAttr{Column: table + c.config.ActiveField, Type: scimFilterAttrBool}
+	}
+
+	if c.groupsCollection != nil {
+		attrs["groups"] = scimFilterAttr{Column: table + c.config.GroupsField, Type: scimFilterAttrArray}
+		attrs["groups.value"] = scimFilterAttr{Column: table + c.config.GroupsField, Type: scimFilterAttrArray}
+	}
+
+	return attrs
+}
+
+// userRecordData maps the provided SCIM User payload to auth record data.
+//
+// Attributes that don't have a corresponding record field are ignored.
+func (c *scimContext) userRecordData(payload map[string]any) map[string]any {
+	data := map[string]any{}
+
+	// normalize the payload keys
+	attrs := make(map[string]any, len(payload))
+	for k, v := range payload {
+		attrs[strings.ToLower(normalizeSCIMAttrPath(k))] = v
+	}
+
+	if v, ok := attrs["username"]; ok {
+		data[c.userNameField] = cast.ToString(v)
+	}
+
+	if v, ok := attrs["emails"]; ok {
+		data[core.FieldNameEmail] = scimPrimaryEmail(v)
+	}
+
+	if c.nameField != "" {
+		if v, ok := attrs["name"].(map[string]any); ok {
+			formatted := cast.ToString(v["formatted"])
+			if formatted == "" {
+				formatted = strings.TrimSpace(cast.ToString(v["givenName"]) + " " + cast.ToString(v["familyName"]))
+			}
+			data[c.nameField] = formatted
+		}
+
+		if v, ok := attrs["name.formatted"]; ok {
+			data[c.nameField] = cast.ToString(v)
+		}
+
+		if v, ok := attrs["displayname"]; ok {
+			data[c.nameField] = cast.ToString(v)
+		}
+	}
+
+	if v, ok := attrs["active"]; ok && c.config.ActiveField != "" {
+		data[c.config.ActiveField] = cast.ToBool(v)
+	}
+
+	if v, ok := attrs["password"]; ok && cast.ToString(v) != "" {
+		data[core.FieldNamePassword] = cast.ToString(v)
+		data[core.FieldNamePassword+"Confirm"] = data[core.FieldNamePassword]
+	}
+
+	return data
+}
+
+// userResource exports the provided auth record as SCIM User resource.
+func (c *scimContext) userResource(e *core.RequestEvent, record *core.Record) map[string]any {
+	result := map[string]any{
+		"schemas":  []string{scimSchemaUser},
+		"id":       record.Id,
+		"userName": record.GetString(c.userNameField),
+		"active":   true,
+		"meta": map[string]any{
+			"resourceType": "User",
+			"created":      record.GetDateTime("created").Time(),
+			"lastModified": record.GetDateTime("updated").Time(),
+			"location":     c.location(e, "Users", record.Id),
+		},
+	}
+
+	if email := record.Email(); email != "" {
+		result["emails"] = []map[string]any{{"value": email, "primary": true}}
+	}
+
+	if c.nameField != "" {
+		name := record.GetString(c.nameField)
+		result["displayName"] = name
+		result["name"] = map[string]any{"formatted": name}
+	}
+
+	if c.config.ActiveField != "" {
+		result["active"] = record.GetBool(c.config.ActiveField)
+	}
+
+	if c.groupsCollection != nil {
+		groups := []map[string]any{}
+
+		ids := list.ToUniqueStringSlice(record.GetStringSlice(c.config.GroupsField))
+		if len(ids) > 0 {
+			groupRecords, _ := e.App.FindRecordsByIds(c.groupsCollection, ids)
+			for _, group := range groupRecords {
+				groups = append(groups, map[string]any{
+					"value":   group.Id,
+					"display": group.GetString(c.groupNameField),
+					"$ref":    c.location(e, "Groups", group.Id),
+				})
+			}
+		}
+
+		result["groups"] = groups
+	}
+
+	return result
+}
+
+// scimPrimaryEmail returns the primary (or the first one if there is no primary)
+// email address from the provided SCIM "emails" attribute value.
+func scimPrimaryEmail(rawEmails any) string {
+	emails, ok := rawEmails.([]any)
+	if !ok || len(emails) == 0 {
+		return ""
+	}
+
+	var first string
+
+	for _, raw := range emails {
+		email, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		value := cast.ToString(email["value"])
+
+		if cast.ToBool(email["primary"]) {
+			return value
+		}
+
+		if first == "" {
+			first = value
+		}
+	}
+
+	return first
+}
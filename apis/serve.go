@@ -75,6 +75,8 @@ func Serve(app core.App, config ServeConfig) error {
 	pbRouter.Bind(CORS(CORSConfig{
 		AllowOrigins: config.AllowedOrigins,
 		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		// expose the resumable uploads (tus) response headers
+		ExposeHeaders: []string{"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires"},
 	}))
 
 	pbRouter.GET("/_/{path...}", Static(ui.DistDirFS, false)).
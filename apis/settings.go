@@ -17,6 +17,7 @@ func bindSettingsApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	subGroup.POST("/test/s3", settingsTestS3)
 	subGroup.POST("/test/email", settingsTestEmail)
 	subGroup.POST("/apple/generate-client-secret", settingsGenerateAppleClientSecret)
+	subGroup.POST("/signing-keys/rotate", settingsRotateSigningKey)
 }
 
 func settingsList(e *core.RequestEvent) error {
@@ -110,6 +111,12 @@ func settingsTestEmail(e *core.RequestEvent) error {
 		return e.BadRequestError("Failed to send the test email. Raw error: \n"+err.Error(), nil)
 	}
 
+	if form.DKIM {
+		return e.JSON(http.StatusOK, map[string]string{
+			"dkimSignature": form.DKIMSignature(),
+		})
+	}
+
 	return e.NoContent(http.StatusNoContent)
 }
 
@@ -137,3 +144,26 @@ func settingsGenerateAppleClientSecret(e *core.RequestEvent) error {
 		"secret": secret,
 	})
 }
+
+func settingsRotateSigningKey(e *core.RequestEvent) error {
+	form := forms.NewSigningKeyRotate(e.App)
+
+	// load request
+	if err := e.BindBody(form); err != nil {
+		return e.BadRequestError("An error occurred while loading the submitted data.", err)
+	}
+
+	// rotate
+	jwk, err := form.Submit()
+	if err != nil {
+		// form error
+		if fErr, ok := err.(validation.Errors); ok {
+			return e.BadRequestError("Invalid signing key data.", fErr)
+		}
+
+		// key generation or settings save error
+		return e.BadRequestError("Failed to rotate the signing key. Raw error: \n"+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, jwk)
+}
@@ -0,0 +1,535 @@
+package apis
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
+	"github.com/thewandererbg/pgbase/tools/list"
+	"github.com/thewandererbg/pgbase/tools/router"
+)
+
+// UploadRefPrefix is the file field value prefix that could be used
+// to attach a completed resumable upload to a record (e.g. "@upload:abc123").
+const UploadRefPrefix = "@upload:"
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,expiration,termination"
+	tusContentType = "application/offset+octet-stream"
+)
+
+const requestEventKeyAttachedUploads = "__attachedUploads" // the value must be []*core.Upload
+
+// bindUploadApi registers the resumable uploads api endpoints.
+//
+// The endpoints implement the core tus protocol v1.0.0 (https://tus.io/protocols/resumable-upload)
+// with the creation, expiration and termination extensions.
+func bindUploadApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/uploads").Bind(RequireAuth()).BindFunc(tusResumableCheck)
+	sub.POST("", uploadCreate)
+	sub.HEAD("/{id}", uploadHead)
+	sub.PATCH("/{id}", uploadPatch).Bind(BodyLimit(0))
+	sub.DELETE("/{id}", uploadDelete)
+}
+
+func tusResumableCheck(e *core.RequestEvent) error {
+	e.Response.Header().Set("Tus-Resumable", tusVersion)
+
+	if e.Request.Header.Get("Tus-Resumable") != tusVersion {
+		e.Response.Header().Set("Tus-Version", tusVersion)
+		e.Response.Header().Set("Tus-Extension", tusExtensions)
+		return e.Error(http.StatusPreconditionFailed, "Unsupported or missing Tus-Resumable header.", nil)
+	}
+
+	return e.Next()
+}
+
+func uploadCreate(e *core.RequestEvent) error {
+	if e.Request.Header.Get("Upload-Defer-Length") != "" {
+		return e.BadRequestError("Deferred upload length is not supported.", nil)
+	}
+
+	size, err := strconv.ParseInt(e.Request.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size <= 0 {
+		return e.BadRequestError("Missing or invalid Upload-Length header.", err)
+	}
+
+	maxSize, err := uploadsMaxSize(e.App)
+	if err != nil {
+		return e.InternalServerError("Failed to load the max allowed upload size.", err)
+	}
+	if size > maxSize {
+		e.Response.Header().Set("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+		return ErrRequestEntityTooLarge
+	}
+
+	metadata := parseUploadMetadata(e.Request.Header.Get("Upload-Metadata"))
+
+	name := metadata["filename"]
+	if name == "" {
+		name = metadata["name"]
+	}
+
+	upload, err := e.App.CreateUpload(e.Auth.Collection().Id, e.Auth.Id, name, size)
+	if err != nil {
+		return e.InternalServerError("Failed to create upload.", err)
+	}
+
+	e.Response.Header().Set("Location", "/api/uploads/"+upload.Id)
+	e.Response.Header().Set("Upload-Expires", upload.Expires.Time().UTC().Format(http.TimeFormat))
+
+	return e.NoContent(http.StatusCreated)
+}
+
+func uploadHead(e *core.RequestEvent) error {
+	upload, err := findRequestUpload(e)
+	if err != nil {
+		return err
+	}
+
+	e.Response.Header().Set("Cache-Control", "no-store")
+	e.Response.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	e.Response.Header().Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	e.Response.Header().Set("Upload-Expires", upload.Expires.Time().UTC().Format(http.TimeFormat))
+
+	return e.NoContent(http.StatusOK)
+}
+
+func uploadPatch(e *core.RequestEvent) error {
+	if e.Request.Header.Get("Content-Type") != tusContentType {
+		return e.Error(http.StatusUnsupportedMediaType, "Content-Type must be "+tusContentType+".", nil)
+	}
+
+	offset, err := strconv.ParseInt(e.Request.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return e.BadRequestError("Missing or invalid Upload-Offset header.", err)
+	}
+
+	id := e.Request.PathValue("id")
+	if !uploadLocks.lock(id) {
+		return e.Error(http.StatusLocked, "The upload is currently being modified by another request.", nil)
+	}
+	defer uploadLocks.unlock(id)
+
+	upload, err := findRequestUpload(e)
+	if err != nil {
+		return err
+	}
+
+	if offset != upload.Offset {
+		return e.Error(http.StatusConflict, "Upload-Offset doesn't match with the current upload offset.", nil)
+	}
+
+	if !upload.IsCompleted() {
+		err = writeUploadChunk(e.App, upload, e.Request.Body)
+		if err != nil {
+			if errors.Is(err, core.ErrUploadOffsetMismatch) {
+				return e.Error(http.StatusConflict, "The upload offset was modified by another request.", nil)
+			}
+			return e.InternalServerError("Failed to store the upload chunk.", err)
+		}
+	}
+
+	e.Response.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	e.Response.Header().Set("Upload-Expires", upload.Expires.Time().UTC().Format(http.TimeFormat))
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func uploadDelete(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	if !uploadLocks.lock(id) {
+		return e.Error(http.StatusLocked, "The upload is currently being modified by another request.", nil)
+	}
+	defer uploadLocks.unlock(id)
+
+	upload, err := findRequestUpload(e)
+	if err != nil {
+		return err
+	}
+
+	if err := e.App.AuxDelete(upload); err != nil {
+		return e.InternalServerError("Failed to delete upload.", err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+// findRequestUpload returns the non-expired upload matching the "id"
+// path param and accessible by the current request auth.
+func findRequestUpload(e *core.RequestEvent) (*core.Upload, error) {
+	upload, err := e.App.FindUploadById(e.Request.PathValue("id"))
+	if err != nil || !canAccessUpload(e, upload) {
+		return nil, e.NotFoundError("", err)
+	}
+
+	return upload, nil
+}
+
+// canAccessUpload checks whether the upload is non-expired
+// and owned by the current request auth (or a superuser).
+func canAccessUpload(e *core.RequestEvent, upload *core.Upload) bool {
+	if e.Auth == nil || upload.Expires.Time().Before(time.Now()) {
+		return false
+	}
+
+	if e.HasSuperuserAuth() {
+		return true
+	}
+
+	return upload.CollectionRef == e.Auth.Collection().Id && upload.RecordRef == e.Auth.Id
+}
+
+// uploadsMaxSize returns the largest allowed single file size
+// across all file fields of the non-view collections.
+func uploadsMaxSize(app core.App) (int64, error) {
+	collections, err := app.FindAllCollections(core.CollectionTypeBase, core.CollectionTypeAuth)
+	if err != nil {
+		return 0, err
+	}
+
+	var result int64
+	for _, c := range collections {
+		for _, f := range c.Fields {
+			fileField, ok := f.(*core.FileField)
+			if !ok {
+				continue
+			}
+
+			maxSize := fileField.MaxSize
+			if maxSize <= 0 {
+				maxSize = core.DefaultFileFieldMaxSize
+			}
+
+			result = max(result, maxSize)
+		}
+	}
+
+	return result, nil
+}
+
+// parseUploadMetadata parses the tus Upload-Metadata header value
+// (comma separated "key base64value" pairs).
+//
+// Invalid pairs are ignored.
+func parseUploadMetadata(header string) map[string]string {
+	result := map[string]string{}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+
+		result[key] = string(value)
+	}
+
+	return result
+}
+
+// writeUploadChunk stores the content of r as a new upload chunk
+// starting from the current upload offset and advances the offset.
+//
+// If reading from r fails in the middle (e.g. because of a dropped connection)
+// the already received part is still stored so that the upload could be resumed.
+//
+// Once the upload is completed, all stored chunks are composed into
+// a single file located at [core.Upload.FileKey].
+func writeUploadChunk(app core.App, upload *core.Upload, r io.Reader) error {
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	chunkKey := upload.ChunkKey(upload.Offset)
+
+	chunk := &uploadChunkReader{r: io.LimitReader(r, upload.Size-upload.Offset)}
+
+	err = fsys.UploadReader(chunk, chunkKey)
+	if err != nil {
+		return err
+	}
+
+	if chunk.n == 0 {
+		return fsys.Delete(chunkKey)
+	}
+
+	newOffset := upload.Offset + chunk.n
+
+	// compose the chunks before marking the upload as completed
+	// so that the final file is always available for completed uploads
+	if newOffset >= upload.Size {
+		chunks, err := fsys.List(upload.BaseFilesPath() + "/chunk_")
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(chunks))
+		for _, c := range chunks {
+			keys = append(keys, c.Key)
+		}
+		slices.Sort(keys)
+
+		if err := fsys.Compose(upload.FileKey(), keys...); err != nil {
+			return err
+		}
+	}
+
+	err = app.AdvanceUploadOffset(upload, newOffset)
+	if err != nil {
+		return errors.Join(err, fsys.Delete(chunkKey))
+	}
+
+	if upload.IsCompleted() {
+		if errs := fsys.DeletePrefix(upload.BaseFilesPath() + "/chunk_"); len(errs) > 0 {
+			app.Logger().Warn(
+				"Failed to delete the composed upload chunks",
+				slog.String("uploadId", upload.Id),
+				slog.Any("errors", errs),
+			)
+		}
+	}
+
+	return nil
+}
+
+// uploadChunkReader counts the read bytes and converts
+// any read error into io.EOF so that the partially received
+// chunk could still be stored.
+type uploadChunkReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *uploadChunkReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, io.EOF
+	}
+
+	return n, err
+}
+
+// -------------------------------------------------------------------
+
+var uploadLocks = &uploadLocker{ids: map[string]struct{}{}}
+
+// uploadLocker guards against concurrent modifications of the same upload
+// within the current app instance.
+type uploadLocker struct {
+	ids map[string]struct{}
+	mu  sync.Mutex
+}
+
+func (l *uploadLocker) lock(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.ids[id]; ok {
+		return false
+	}
+
+	l.ids[id] = struct{}{}
+
+	return true
+}
+
+func (l *uploadLocker) unlock(id string) {
+	l.mu.Lock()
+	delete(l.ids, id)
+	l.mu.Unlock()
+}
+
+// -------------------------------------------------------------------
+
+// resolveUploadRefs returns a shallow copy of the provided request body
+// with the collection file fields "@upload:ID" values replaced with
+// the file of the related completed upload.
+//
+// The resolved uploads are stored in the request event store so that
+// they could be deleted after the record is successfully saved.
+func resolveUploadRefs(e *core.RequestEvent, collection *core.Collection, body map[string]any) (map[string]any, error) {
+	var result map[string]any
+	var attached []*core.Upload
+	var fsys *filesystem.System
+
+	defer func() {
+		if fsys != nil {
+			fsys.Close()
+		}
+	}()
+
+	for _, field := range collection.Fields {
+		if field.Type() != core.FieldTypeFile {
+			continue
+		}
+
+		name := field.GetName()
+
+		for _, k := range []string{name, "+" + name, name + "+"} {
+			raw, ok := body[k]
+			if !ok {
+				continue
+			}
+
+			values := list.ToUniqueStringSlice(raw)
+			if !slices.ContainsFunc(values, func(v string) bool { return strings.HasPrefix(v, UploadRefPrefix) }) {
+				continue
+			}
+
+			resolved := make([]any, 0, len(values))
+
+			for _, v := range values {
+				id, isRef := strings.CutPrefix(v, UploadRefPrefix)
+				if !isRef {
+					resolved = append(resolved, v)
+					continue
+				}
+
+				upload, err := e.App.FindUploadById(id)
+				if err != nil || !canAccessUpload(e, upload) {
+					return nil, validation.Errors{k: validation.NewError("validation_invalid_upload", "Missing or invalid upload "+id+".")}
+				}
+
+				if !upload.IsCompleted() {
+					return nil, validation.Errors{k: validation.NewError("validation_incomplete_upload", "Upload "+id+" is not completed yet.")}
+				}
+
+				if !upload.CanAttachTo(collection.Id, name) {
+					return nil, validation.Errors{k: validation.NewError("validation_upload_field_mismatch", "Upload "+id+" is bound to a different file field.")}
+				}
+
+				if fsys == nil {
+					fsys, err = e.App.NewFilesystem()
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				// the file is checked and attached using only its stored
+				// object attributes (aka. without downloading its content)
+				attrs, err := fsys.Attributes(upload.FileKey())
+				if err != nil {
+					return nil, validation.Errors{k: validation.NewError("validation_invalid_upload", "Missing upload "+id+" file.")}
+				}
+
+				reader := &uploadFileReader{app: e.App, key: upload.FileKey(), contentType: attrs.ContentType}
+
+				file, err := filesystem.NewFileFromReader(reader, attrs.Size, upload.OriginalName)
+				if err != nil {
+					return nil, err
+				}
+
+				resolved = append(resolved, file)
+				attached = append(attached, upload)
+			}
+
+			if result == nil {
+				result = make(map[string]any, len(body))
+				for bk, bv := range body {
+					result[bk] = bv
+				}
+			}
+
+			result[k] = resolved
+		}
+	}
+
+	if result == nil {
+		return body, nil
+	}
+
+	e.Set(requestEventKeyAttachedUploads, attached)
+
+	return result, nil
+}
+
+// deleteAttachedUploads deletes the uploads resolved with [resolveUploadRefs].
+//
+// The uploads are not deleted if the request is part of a transaction (e.g. batch request)
+// because the record changes could be still rolled back (they will be deleted on expiration).
+func deleteAttachedUploads(e *core.RequestEvent) {
+	attached, _ := e.Get(requestEventKeyAttachedUploads).([]*core.Upload)
+	if len(attached) == 0 || e.App.IsTransactional() {
+		return
+	}
+
+	for _, upload := range attached {
+		if err := e.App.AuxDelete(upload); err != nil {
+			e.App.Logger().Warn(
+				"Failed to delete attached upload",
+				slog.String("uploadId", upload.Id),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// uploadFileReader defines a [filesystem.StorageFileReader] for a completed upload file.
+//
+// When the upload is attached to a record, its file is copied directly
+// within the storage and Open is used only if the content is needed
+// (e.g. to calculate the file hash of a dedupe field).
+//
+// A new filesystem instance is created on every Open call
+// and it is closed together with the returned reader.
+type uploadFileReader struct {
+	app         core.App
+	key         string
+	contentType string
+}
+
+// StorageKey implements [filesystem.StorageFileReader] interface method.
+func (r *uploadFileReader) StorageKey() string {
+	return r.key
+}
+
+// ContentType implements [filesystem.StorageFileReader] interface method.
+func (r *uploadFileReader) ContentType() string {
+	return r.contentType
+}
+
+func (r *uploadFileReader) Open() (io.ReadSeekCloser, error) {
+	fsys, err := r.app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+
+	br, err := fsys.GetReader(r.key)
+	if err != nil {
+		fsys.Close()
+		return nil, err
+	}
+
+	return &uploadFileReadCloser{ReadSeekCloser: br, fsys: fsys}, nil
+}
+
+type uploadFileReadCloser struct {
+	io.ReadSeekCloser
+	fsys *filesystem.System
+}
+
+func (r *uploadFileReadCloser) Close() error {
+	return errors.Join(r.ReadSeekCloser.Close(), r.fsys.Close())
+}
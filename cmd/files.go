@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/list"
+)
+
+var fileStorages = []string{core.StorageLocal, core.StorageS3, core.StoragePostgres}
+
+// NewFilesCommand creates and returns new command for managing
+// the app files storage (migrate, gc).
+func NewFilesCommand(app core.App) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "files",
+		Short: "Manage the app files storage",
+	}
+
+	command.AddCommand(filesMigrateCommand(app))
+	command.AddCommand(filesGCCommand(app))
+
+	return command
+}
+
+func filesMigrateCommand(app core.App) *cobra.Command {
+	var overwrite bool
+
+	command := &cobra.Command{
+		Use:          "migrate",
+		Example:      "files migrate local postgres",
+		Short:        "Copies all app files from one storage to another (local, s3 or postgres)",
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("missing source and destination storage arguments")
+			}
+
+			for _, storage := range args {
+				if !list.ExistInSlice(storage, fileStorages) {
+					return fmt.Errorf("invalid storage %q (expected one of %v)", storage, fileStorages)
+				}
+			}
+
+			if args[0] == args[1] {
+				return errors.New("the source and destination storages must be different")
+			}
+
+			src, err := app.NewStorageFilesystem(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to initialize the %q storage: %w", args[0], err)
+			}
+			defer src.Close()
+
+			dst, err := app.NewStorageFilesystem(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to initialize the %q storage: %w", args[1], err)
+			}
+			defer dst.Close()
+
+			objects, err := src.List("")
+			if err != nil {
+				return fmt.Errorf("failed to list the %q storage files: %w", args[0], err)
+			}
+
+			var copied, skipped, failed int
+
+			for _, obj := range objects {
+				if obj.IsDir {
+					continue
+				}
+
+				if !overwrite {
+					exists, err := dst.Exists(obj.Key)
+					if err != nil {
+						color.Red("Failed to check %q: %v", obj.Key, err)
+						failed++
+						continue
+					}
+
+					if exists {
+						skipped++
+						continue
+					}
+				}
+
+				if err := src.Transfer(dst, obj.Key); err != nil {
+					color.Red("Failed to copy %q: %v", obj.Key, err)
+					failed++
+					continue
+				}
+
+				copied++
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to copy %d file(s) (copied %d, skipped %d)", failed, copied, skipped)
+			}
+
+			color.Green("Successfully migrated the %q files to %q (copied %d, skipped %d)!", args[0], args[1], copied, skipped)
+			return nil
+		},
+	}
+
+	command.Flags().BoolVar(&overwrite, "overwrite", false, "overwrite the already existing destination files")
+
+	return command
+}
+
+func filesGCCommand(app core.App) *cobra.Command {
+	var dryRun bool
+	var gracePeriod time.Duration
+
+	command := &cobra.Command{
+		Use:          "gc",
+		Example:      "files gc --dry-run --grace=48h",
+		Short:        "Deletes the storage files that are no longer referenced by any record",
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			if gracePeriod < 0 {
+				return errors.New("the grace period must be non-negative")
+			}
+
+			result, err := app.FilesGC(context.Background(), core.FilesGCOptions{
+				GracePeriod: gracePeriod,
+				DryRun:      dryRun,
+			})
+			if result != nil {
+				for _, group := range []struct {
+					label string
+					keys  []string
+				}{
+					{"orphan", result.Orphans},
+					{"thumb without original", result.Thumbs},
+					{"unreferenced blob", result.Blobs},
+				} {
+					for _, key := range group.keys {
+						fmt.Printf("%s: %s\n", group.label, key)
+					}
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				color.Yellow("Found %d orphaned file(s) (%d bytes). Dry run - nothing was deleted.", result.Total(), result.Size)
+			} else {
+				color.Green("Successfully deleted %d orphaned file(s) (%d bytes)!", result.Deleted, result.Size)
+			}
+
+			return nil
+		},
+	}
+
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "only report the orphaned files without deleting them")
+	command.Flags().DurationVar(&gracePeriod, "grace", core.DefaultFilesGCGracePeriod, "min age of an orphaned file before it is collected")
+
+	return command
+}
@@ -0,0 +1,164 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/thewandererbg/pgbase/cmd"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestFilesMigrateCommandInvalidArgs(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	scenarios := []struct {
+		name string
+		args []string
+	}{
+		{"no args", []string{"migrate"}},
+		{"missing destination", []string{"migrate", "local"}},
+		{"invalid source", []string{"migrate", "invalid", "postgres"}},
+		{"invalid destination", []string{"migrate", "local", "invalid"}},
+		{"same storage", []string{"migrate", "local", "local"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			command := cmd.NewFilesCommand(app)
+			command.SetArgs(s.args)
+
+			if err := command.Execute(); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestFilesMigrateCommand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	local, err := app.NewStorageFilesystem(core.StorageLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+
+	localFiles, err := local.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(localFiles) == 0 {
+		t.Fatal("Expected the test app to have local files")
+	}
+
+	// local -> postgres
+	command := cmd.NewFilesCommand(app)
+	command.SetArgs([]string{"migrate", core.StorageLocal, core.StoragePostgres})
+	if err := command.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	pg, err := app.NewStorageFilesystem(core.StoragePostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+
+	for _, file := range localFiles {
+		localAttrs, err := local.Attributes(file.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pgAttrs, err := pg.Attributes(file.Key)
+		if err != nil {
+			t.Fatalf("Missing migrated file %q: %v", file.Key, err)
+		}
+
+		if pgAttrs.Size != localAttrs.Size || pgAttrs.ContentType != localAttrs.ContentType {
+			t.Fatalf("Expected %q attributes to match, got %d %q vs %d %q",
+				file.Key, pgAttrs.Size, pgAttrs.ContentType, localAttrs.Size, localAttrs.ContentType)
+		}
+	}
+
+	// change a migrated file to check the overwrite behavior
+	key := localFiles[0].Key
+	if err := pg.Upload([]byte("changed"), key); err != nil {
+		t.Fatal(err)
+	}
+
+	// existing files are skipped by default
+	command = cmd.NewFilesCommand(app)
+	command.SetArgs([]string{"migrate", core.StorageLocal, core.StoragePostgres})
+	if err := command.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if attrs, _ := pg.Attributes(key); attrs == nil || attrs.Size != int64(len("changed")) {
+		t.Fatalf("Expected %q to be skipped", key)
+	}
+
+	// --overwrite
+	command = cmd.NewFilesCommand(app)
+	command.SetArgs([]string{"migrate", core.StorageLocal, core.StoragePostgres, "--overwrite"})
+	if err := command.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if attrs, _ := pg.Attributes(key); attrs == nil || attrs.Size != localFiles[0].Size {
+		t.Fatalf("Expected %q to be overwritten", key)
+	}
+}
+
+func TestFilesGCCommand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	orphan := "_pb_users_auth_/4q1xlclmfloku33/orphan.txt"
+	if err := fsys.Upload([]byte("test"), orphan); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		args           []string
+		expectError    bool
+		expectedExists bool
+	}{
+		{"negative grace period", []string{"gc", "--grace=-1h"}, true, true},
+		{"within the grace period", []string{"gc"}, false, true},
+		{"dry run", []string{"gc", "--dry-run", "--grace=0"}, false, true},
+		{"delete", []string{"gc", "--grace=0"}, false, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			command := cmd.NewFilesCommand(app)
+			command.SetArgs(s.args)
+
+			err := command.Execute()
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			exists, _ := fsys.Exists(orphan)
+			if exists != s.expectedExists {
+				t.Fatalf("Expected the orphan file exists to be %v, got %v", s.expectedExists, exists)
+			}
+		})
+	}
+}
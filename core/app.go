@@ -77,6 +77,13 @@ type App interface {
 	// EnsureSettingsCacheFresh reloads locally or broadcasts invalidation (multi instances).
 	EnsureSettingsCacheFresh()
 
+	// PublishPubSubMessage broadcasts the JSON serialized payload
+	// to the other app instances under the specified topic
+	// (see also [App.OnPubSubMessage]).
+	//
+	// It is no-op if the multi-instance pub/sub is not running.
+	PublishPubSubMessage(topic string, payload any) error
+
 	// Settings returns the loaded app settings.
 	Settings() *Settings
 
@@ -89,11 +96,14 @@ type App interface {
 	// SubscriptionsBroker returns the app realtime subscriptions broker instance.
 	SubscriptionsBroker() *subscriptions.Broker
 
+	// MailCatcher returns the app in-memory dev mail catcher instance.
+	MailCatcher() *mailer.Catcher
+
 	// NewMailClient creates and returns a new SMTP or Sendmail client
 	// based on the current app settings.
 	NewMailClient() mailer.Mailer
 
-	// NewFilesystem creates a new local or S3 filesystem instance
+	// NewFilesystem creates a new local, S3 or Postgres filesystem instance
 	// for managing regular app files (ex. record uploads)
 	// based on the current app settings.
 	//
@@ -101,6 +111,14 @@ type App interface {
 	// after you are done working with it.
 	NewFilesystem() (*filesystem.System, error)
 
+	// NewStorageFilesystem creates a new filesystem instance for the
+	// specified app files storage (StorageLocal, StorageS3 or StoragePostgres)
+	// regardless of the currently active one (ex. to migrate files between storages).
+	//
+	// NB! Make sure to call Close() on the returned result
+	// after you are done working with it.
+	NewStorageFilesystem(storage string) (*filesystem.System, error)
+
 	// NewBackupsFilesystem creates a new local or S3 filesystem instance
 	// for managing app backups based on the current app settings.
 	//
@@ -341,6 +359,129 @@ type App interface {
 
 	// ---------------------------------------------------------------
 
+	// AuthAttemptQuery returns a new AuthAttempt select query.
+	AuthAttemptQuery() *dbx.SelectQuery
+
+	// CreateAuthAttempt stores a new failed auth attempt for the specified
+	// auth collection, record (could be empty) and identity value.
+	CreateAuthAttempt(collectionId string, recordId string, identity string, ip string) (*AuthAttempt, error)
+
+	// FindRecentAuthAttempts returns up to limit most recent failed auth attempts
+	// of the specified auth collection matching either the record id or the identity value.
+	//
+	// The result is sorted by the attempts created date in DESC order.
+	FindRecentAuthAttempts(collectionId string, recordId string, identity string, limit int) ([]*AuthAttempt, error)
+
+	// DeleteAuthAttempts deletes all failed auth attempts of the specified auth record
+	// (aka. unlocks the account) including the ones matching its identity field values.
+	DeleteAuthAttempts(authRecord *Record) error
+
+	// DeleteOldAuthAttempts deletes all failed auth attempts that are created before createdBefore.
+	DeleteOldAuthAttempts(createdBefore time.Time) error
+
+	// ---------------------------------------------------------------
+
+	// UploadQuery returns a new Upload select query.
+	UploadQuery() *dbx.SelectQuery
+
+	// FindUploadById returns a single Upload model by its id.
+	FindUploadById(id string) (*Upload, error)
+
+	// CreateUpload stores a new empty resumable upload with the specified
+	// total size for the provided auth collection and record owner.
+	CreateUpload(collectionId string, recordId string, originalName string, size int64) (*Upload, error)
+
+	// CreateFieldUpload is similar to [App.CreateUpload] but the new upload
+	// is bound to the specified collection file field and it can't be
+	// attached to a different one.
+	CreateFieldUpload(collectionId string, recordId string, fieldCollectionId string, fieldName string, originalName string, size int64) (*Upload, error)
+
+	// AdvanceUploadOffset atomically changes the offset of the provided upload
+	// to newOffset and extends its expiration date.
+	//
+	// Returns ErrUploadOffsetMismatch if the stored upload offset was
+	// modified in the meantime (aka. it is different from upload.Offset).
+	AdvanceUploadOffset(upload *Upload, newOffset int64) error
+
+	// DeleteExpiredUploads deletes all uploads (and their stored files) with past expiration date.
+	DeleteExpiredUploads() error
+
+	// ---------------------------------------------------------------
+
+	// CronRunQuery returns a new CronRun select query.
+	CronRunQuery() *dbx.SelectQuery
+
+	// FindCronRunById returns a single CronRun model by its id.
+	FindCronRunById(id string) (*CronRun, error)
+
+	// DeleteOldCronRuns deletes all cron job runs that are started before startedBefore.
+	DeleteOldCronRuns(startedBefore time.Time) error
+
+	// ---------------------------------------------------------------
+
+	// FindRecordFileKey returns the storage key of the specified record file.
+	//
+	// For the files of deduplicated file fields (see [FileField.Dedupe])
+	// the key of the referenced content blob is returned, otherwise
+	// fallbacks to the regular record file path (record.BaseFilesPath()+"/"+filename).
+	FindRecordFileKey(record *Record, filename string) (string, error)
+
+	// FilesGC walks the storage files of all non-view collections and collects
+	// (and deletes, unless opts.DryRun is set) the files that are no longer
+	// referenced in the database - record files that are not part of any
+	// file field value, thumbs without original and unreferenced deduplicated blobs.
+	FilesGC(ctx context.Context, opts FilesGCOptions) (*FilesGCResult, error)
+
+	// ---------------------------------------------------------------
+
+	// MailOutboxQuery returns a new MailOutboxMessage select query.
+	MailOutboxQuery() *dbx.SelectQuery
+
+	// FindMailOutboxMessageById returns a single MailOutboxMessage model by its id.
+	FindMailOutboxMessageById(id string) (*MailOutboxMessage, error)
+
+	// EnqueueMail stores the provided mail message in the mail outbox
+	// for background delivery.
+	//
+	// When called with a transactional app instance the message is
+	// enqueued as part of the transaction, aka. it will be delivered
+	// only if the transaction is committed.
+	EnqueueMail(message *mailer.Message) (*MailOutboxMessage, error)
+
+	// RetryMailOutboxMessage resets the attempts of the provided
+	// outbox message and schedules it for immediate delivery.
+	RetryMailOutboxMessage(message *MailOutboxMessage) error
+
+	// PurgeMailOutbox deletes all sent and failed outbox messages
+	// matching the optional expr and returns the number of the deleted messages.
+	//
+	// Pending messages are never purged.
+	PurgeMailOutbox(expr dbx.Expression) (int64, error)
+
+	// ProcessMailOutbox claims and delivers up to limit due pending
+	// outbox messages and returns the number of the processed messages
+	// (regardless of their delivery result).
+	ProcessMailOutbox(limit int) (int, error)
+
+	// ---------------------------------------------------------------
+
+	// PasswordHistoryQuery returns a new PasswordHistory select query.
+	PasswordHistoryQuery() *dbx.SelectQuery
+
+	// FindPasswordHistory returns up to limit most recent password changes
+	// of the provided auth record (in DESC order).
+	FindPasswordHistory(authRecord *Record, limit int) ([]*PasswordHistory, error)
+
+	// CreatePasswordHistory stores the provided password hash as the latest
+	// password change of the auth record and deletes the older entries
+	// exceeding the record collection PasswordPolicy.HistorySize.
+	CreatePasswordHistory(authRecord *Record, hash string) error
+
+	// DeleteAllPasswordHistoryByRecord deletes all password history entries of the provided auth record.
+	DeleteAllPasswordHistoryByRecord(authRecord *Record) error
+
+	// ---------------------------------------------------------------
+
 	// CollectionQuery returns a new Collection select query.
 	CollectionQuery() *dbx.SelectQuery
 
@@ -520,6 +661,25 @@ type App interface {
 
 	// ---------------------------------------------------------------
 
+	// FindOAuth2ClientById returns a single OAuth2Client model by its id.
+	FindOAuth2ClientById(id string) (*OAuth2Client, error)
+
+	// FindOAuth2CodeByCode returns a single OAuth2Code model by its plain code value.
+	FindOAuth2CodeByCode(code string) (*OAuth2Code, error)
+
+	// DeleteExpiredOAuth2Codes deletes all expired OAuth2 authorization codes.
+	DeleteExpiredOAuth2Codes() error
+
+	// ---------------------------------------------------------------
+
+	// FindMailTemplateByName returns a single MailTemplate model by its name.
+	FindMailTemplateByName(name string) (*MailTemplate, error)
+
+	// FindAllMailTemplatesByType returns all MailTemplate models with the specified type.
+	FindAllMailTemplatesByType(templateType string) ([]*MailTemplate, error)
+
+	// ---------------------------------------------------------------
+
 	// RecordQuery returns a new Record select query from a collection model, id or name.
 	//
 	// In case a collection id or name is provided and that collection doesn't
@@ -617,6 +777,26 @@ type App interface {
 	// Returns an error if the JWT is invalid, expired or not associated to an auth collection record.
 	FindAuthRecordByToken(token string, validTypes ...string) (*Record, error)
 
+	// NewAuthToken generates and returns a new authentication token for the provided auth record.
+	//
+	// If the record collection has an active signing key in the app settings,
+	// the token is signed with it (RS256 or EdDSA), otherwise it fallbacks
+	// to the default HS256 [Record.NewAuthToken].
+	NewAuthToken(record *Record) (string, error)
+
+	// NewStaticAuthToken generates and returns a new static (non-refreshable)
+	// authentication token for the provided auth record.
+	//
+	// Zero or negative duration will fallback to the duration from the auth collection settings.
+	NewStaticAuthToken(record *Record, duration time.Duration) (string, error)
+
+	// NewOAuth2AccessToken generates and returns a new access token issued
+	// to a third-party OAuth2 client on behalf of the provided auth record.
+	//
+	// The token is bound to the client id and the granted scopes
+	// and it is not accepted by the regular record APIs.
+	NewOAuth2AccessToken(record *Record, clientId string, scopes []string) (string, error)
+
 	// FindAuthRecordByEmail finds the auth record associated with the provided email.
 	//
 	// Returns an error if it is not an auth collection or the record is not found.
@@ -677,6 +857,14 @@ type App interface {
 	// Note that the app could be terminated abruptly without awaiting the hook completion.
 	OnTerminate() *hook.Hook[*TerminateEvent]
 
+	// OnPubSubMessage hook is triggered when a message published by
+	// another app instance with [App.PublishPubSubMessage] is received.
+	//
+	// If the optional "tags" list (message topics) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnPubSubMessage(tags ...string) *hook.TaggedHook[*PubSubMessageEvent]
+
 	// OnBackupCreate hook is triggered on each [App.CreateBackup] call.
 	OnBackupCreate() *hook.Hook[*BackupEvent]
 
@@ -1219,7 +1407,8 @@ type App interface {
 	// Realtime API event hooks
 	// ---------------------------------------------------------------
 
-	// OnRealtimeConnectRequest hook is triggered when establishing the SSE client connection.
+	// OnRealtimeConnectRequest hook is triggered when establishing the SSE
+	// (or WebSocket) client connection.
 	//
 	// Any execution after e.Next() of a hook handler happens after the client disconnects.
 	OnRealtimeConnectRequest() *hook.Hook[*RealtimeConnectRequestEvent]
@@ -1232,6 +1421,12 @@ type App interface {
 	// modify the submitted change.
 	OnRealtimeSubscribeRequest() *hook.Hook[*RealtimeSubscribeRequestEvent]
 
+	// OnRealtimeClientMessage hook is triggered when a WebSocket client
+	// sends a custom (non-subscription) message to the server.
+	//
+	// Replies could be sent back to the client with e.Client.Send(...).
+	OnRealtimeClientMessage() *hook.Hook[*RealtimeClientMessageEvent]
+
 	// ---------------------------------------------------------------
 	// Settings API event hooks
 	// ---------------------------------------------------------------
@@ -1406,6 +1601,45 @@ type App interface {
 	// triggered and called only if their event data origin matches the tags.
 	OnRecordAuthWithOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithOTPRequestEvent]
 
+	// OnRecordAuthWithLDAPRequest hook is triggered on each Record
+	// auth with LDAP API request (after the directory credentials verification).
+	//
+	// [RecordAuthWithLDAPRequestEvent.Record] could be nil if no linked or matching auth record is found,
+	// allowing you to manually locate a different Record model (by reassigning [RecordAuthWithLDAPRequestEvent.Record]).
+	// If Record is still nil after the hook, a new auth record will be created.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithLDAPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithLDAPRequestEvent]
+
+	// ---------------------------------------------------------------
+	// OAuth2 server API event hooks
+	// ---------------------------------------------------------------
+
+	// OnOAuth2AuthorizeRequest hook is triggered on each OAuth2 server
+	// authorize (aka. consent submit) API request.
+	//
+	// Could be used to implement custom consent rules, e.g. by
+	// changing [OAuth2AuthorizeRequestEvent.Approved] or by
+	// restricting [OAuth2AuthorizeRequestEvent.Scopes].
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnOAuth2AuthorizeRequest(tags ...string) *hook.TaggedHook[*OAuth2AuthorizeRequestEvent]
+
+	// OnOAuth2TokenRequest hook is triggered on each OAuth2 server
+	// authorization code exchange API request.
+	//
+	// Could be used to additionally validate the exchange or to modify
+	// the ID token claims before signing them.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnOAuth2TokenRequest(tags ...string) *hook.TaggedHook[*OAuth2TokenRequestEvent]
+
 	// ---------------------------------------------------------------
 	// Record CRUD API event hooks
 	// ---------------------------------------------------------------
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+var (
+	_ Model = (*AuthAttempt)(nil)
+)
+
+const AuthAttemptsTableName = "_authAttempts"
+
+// authAttemptsMaxAge is the max age of the stored failed auth attempts
+// (aka. the sum of the max allowed lockout window and duration).
+const authAttemptsMaxAge = 48 * time.Hour
+
+// AuthAttempt defines a single failed password auth attempt
+// stored in the auxiliary app database.
+type AuthAttempt struct {
+	BaseModel
+
+	Created       types.DateTime `db:"created" json:"created"`
+	CollectionRef string         `db:"collectionRef" json:"collectionRef"`
+	RecordRef     string         `db:"recordRef" json:"recordRef"`
+	Identity      string         `db:"identity" json:"identity"`
+	IP            string         `db:"ip" json:"ip"`
+}
+
+func (m *AuthAttempt) TableName() string {
+	return AuthAttemptsTableName
+}
+
+func (app *BaseApp) registerAuthAttemptHooks() {
+	// run on every hour to cleanup the no longer relevant failed auth attempts
+	app.Cron().Add("__pbAuthAttemptsCleanup__", "0 * * * *", func() {
+		if err := app.DeleteOldAuthAttempts(time.Now().Add(-authAttemptsMaxAge)); err != nil {
+			app.Logger().Warn("Failed to delete old auth attempts", "error", err)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// AuthAttemptQuery returns a new AuthAttempt select query.
+func (app *BaseApp) AuthAttemptQuery() *dbx.SelectQuery {
+	return app.AuxModelQuery(&AuthAttempt{})
+}
+
+// CreateAuthAttempt stores a new failed auth attempt for the specified
+// auth collection, record (could be empty) and identity value.
+//
+// The identity value is stored in lowercase.
+func (app *BaseApp) CreateAuthAttempt(collectionId string, recordId string, identity string, ip string) (*AuthAttempt, error) {
+	model := &AuthAttempt{}
+	model.Id = GenerateDefaultRandomId()
+	model.Created = types.NowDateTime()
+	model.CollectionRef = collectionId
+	model.RecordRef = recordId
+	model.Identity = strings.ToLower(identity)
+	model.IP = ip
+
+	if err := app.AuxSave(model); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// FindRecentAuthAttempts returns up to limit most recent failed auth attempts
+// of the specified auth collection matching either the record id or the identity value.
+//
+// The result is sorted by the attempts created date in DESC order.
+func (app *BaseApp) FindRecentAuthAttempts(collectionId string, recordId string, identity string, limit int) ([]*AuthAttempt, error) {
+	result := []*AuthAttempt{}
+
+	matchExprs := []dbx.Expression{}
+	if recordId != "" {
+		matchExprs = append(matchExprs, dbx.HashExp{"recordRef": recordId})
+	}
+	if identity != "" {
+		matchExprs = append(matchExprs, dbx.HashExp{"identity": strings.ToLower(identity)})
+	}
+	if len(matchExprs) == 0 {
+		return result, nil
+	}
+
+	err := app.AuthAttemptQuery().
+		AndWhere(dbx.HashExp{"collectionRef": collectionId}).
+		AndWhere(dbx.Or(matchExprs...)).
+		OrderBy("created DESC").
+		Limit(int64(limit)).
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteAuthAttempts deletes all failed auth attempts of the specified auth record
+// (aka. unlocks the account) including the ones matching its identity field values.
+func (app *BaseApp) DeleteAuthAttempts(authRecord *Record) error {
+	collection := authRecord.Collection()
+
+	matchExprs := []dbx.Expression{dbx.HashExp{"recordRef": authRecord.Id}}
+	for _, name := range collection.PasswordAuth.IdentityFields {
+		identity := authRecord.GetString(name)
+		if identity != "" {
+			matchExprs = append(matchExprs, dbx.HashExp{"identity": strings.ToLower(identity)})
+		}
+	}
+
+	_, err := app.AuxNonconcurrentDB().Delete(AuthAttemptsTableName, dbx.And(
+		dbx.HashExp{"collectionRef": collection.Id},
+		dbx.Or(matchExprs...),
+	)).Execute()
+
+	return err
+}
+
+// DeleteOldAuthAttempts deletes all failed auth attempts that are created before createdBefore.
+//
+// For better performance the delete is executed as plain SQL statement,
+// aka. no delete model hook events will be fired.
+func (app *BaseApp) DeleteOldAuthAttempts(createdBefore time.Time) error {
+	formattedDate := createdBefore.UTC().Format(types.DefaultDateLayout)
+	expr := dbx.NewExp("[[created]] <= {:date}", dbx.Params{"date": formattedDate})
+
+	_, err := app.AuxNonconcurrentDB().Delete(AuthAttemptsTableName, expr).Execute()
+
+	return err
+}
@@ -0,0 +1,158 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestCreateAuthAttempt(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	attempt, err := app.CreateAuthAttempt("_pb_users_auth_", "4q1xlclmfloku33", "Test@Example.com", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempt.Id == "" {
+		t.Fatal("Expected the attempt id to be set")
+	}
+
+	if attempt.Identity != "test@example.com" {
+		t.Fatalf("Expected lowercased identity, got %q", attempt.Identity)
+	}
+
+	var count int
+	err = app.AuthAttemptQuery().Select("count(*)").Row(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 auth attempt, got %d", count)
+	}
+}
+
+func TestFindRecentAuthAttempts(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	stubs := []struct {
+		collectionId string
+		recordId     string
+		identity     string
+	}{
+		{"_pb_users_auth_", "4q1xlclmfloku33", "test@example.com"},
+		{"_pb_users_auth_", "", "test@example.com"},
+		{"_pb_users_auth_", "4q1xlclmfloku33", "test"},
+		{"_pb_users_auth_", "", "test2@example.com"},
+		{"v851q4r790rhknl", "", "test@example.com"},
+	}
+	for _, s := range stubs {
+		if _, err := app.CreateAuthAttempt(s.collectionId, s.recordId, s.identity, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []struct {
+		name     string
+		recordId string
+		identity string
+		limit    int
+		expected int
+	}{
+		{"empty record and identity", "", "", 10, 0},
+		{"missing record", "missing", "", 10, 0},
+		{"by record", "4q1xlclmfloku33", "", 10, 2},
+		{"by identity", "", "TEST@example.com", 10, 2},
+		{"by record or identity", "4q1xlclmfloku33", "test@example.com", 10, 3},
+		{"with limit", "4q1xlclmfloku33", "test@example.com", 2, 2},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := app.FindRecentAuthAttempts("_pb_users_auth_", s.recordId, s.identity, s.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != s.expected {
+				t.Fatalf("Expected %d attempts, got %d", s.expected, len(result))
+			}
+
+			for i := 1; i < len(result); i++ {
+				if result[i].Created.After(result[i-1].Created) {
+					t.Fatalf("Expected the attempts to be sorted by created DESC, got %v", result)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteAuthAttempts(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.CreateAuthAttempt(user.Collection().Id, user.Id, "", "")
+	app.CreateAuthAttempt(user.Collection().Id, "", user.Email(), "")
+	app.CreateAuthAttempt(user.Collection().Id, "", "test2@example.com", "")
+	app.CreateAuthAttempt("v851q4r790rhknl", user.Id, "", "")
+
+	if err := app.DeleteAuthAttempts(user); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = app.AuthAttemptQuery().Select("count(*)").Row(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 remaining auth attempts, got %d", count)
+	}
+}
+
+func TestDeleteOldAuthAttempts(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	for i := 0; i < 3; i++ {
+		attempt, err := app.CreateAuthAttempt("_pb_users_auth_", "", "test@example.com", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		attempt.Created = attempt.Created.Add(time.Duration(-i) * time.Hour)
+		if err := app.AuxSave(attempt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.DeleteOldAuthAttempts(time.Now().Add(-30 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := []*core.AuthAttempt{}
+	if err := app.AuthAttemptQuery().All(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 remaining auth attempt, got %d", len(result))
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/thewandererbg/pgbase/tools/logger"
 	"github.com/thewandererbg/pgbase/tools/mailer"
 	"github.com/thewandererbg/pgbase/tools/routine"
+	"github.com/thewandererbg/pgbase/tools/security"
 	"github.com/thewandererbg/pgbase/tools/store"
 	"github.com/thewandererbg/pgbase/tools/subscriptions"
 	"github.com/thewandererbg/pgbase/tools/types"
@@ -41,6 +42,13 @@ const (
 	LocalAutocertCacheDirName string = ".autocert_cache"
 )
 
+// App files storages.
+const (
+	StorageLocal    string = "local"
+	StorageS3       string = "s3"
+	StoragePostgres string = "postgres"
+)
+
 // FilesManager defines an interface with common methods that files manager models should implement.
 type FilesManager interface {
 	// BaseFilesPath returns the storage dir path used by the interface instance.
@@ -76,6 +84,8 @@ type BaseApp struct {
 	cron                *cron.Cron
 	settings            *Settings
 	subscriptionsBroker *subscriptions.Broker
+	mailCatcher         *mailer.Catcher
+	instanceId          string // used to skip the self published pub/sub messages
 	logger              *slog.Logger
 	concurrentDB        dbx.Builder
 	nonconcurrentDB     dbx.Builder
@@ -86,6 +96,7 @@ type BaseApp struct {
 	onBootstrap     *hook.Hook[*BootstrapEvent]
 	onServe         *hook.Hook[*ServeEvent]
 	onTerminate     *hook.Hook[*TerminateEvent]
+	onPubSubMessage *hook.Hook[*PubSubMessageEvent]
 	onBackupCreate  *hook.Hook[*BackupEvent]
 	onBackupRestore *hook.Hook[*BackupEvent]
 
@@ -147,6 +158,7 @@ type BaseApp struct {
 	onRealtimeConnectRequest   *hook.Hook[*RealtimeConnectRequestEvent]
 	onRealtimeMessageSend      *hook.Hook[*RealtimeMessageEvent]
 	onRealtimeSubscribeRequest *hook.Hook[*RealtimeSubscribeRequestEvent]
+	onRealtimeClientMessage    *hook.Hook[*RealtimeClientMessageEvent]
 
 	// settings event hooks
 	onSettingsListRequest   *hook.Hook[*SettingsListRequestEvent]
@@ -170,6 +182,11 @@ type BaseApp struct {
 	onRecordConfirmEmailChangeRequest   *hook.Hook[*RecordConfirmEmailChangeRequestEvent]
 	onRecordRequestOTPRequest           *hook.Hook[*RecordCreateOTPRequestEvent]
 	onRecordAuthWithOTPRequest          *hook.Hook[*RecordAuthWithOTPRequestEvent]
+	onRecordAuthWithLDAPRequest         *hook.Hook[*RecordAuthWithLDAPRequestEvent]
+
+	// oauth2 server API event hooks
+	onOAuth2AuthorizeRequest *hook.Hook[*OAuth2AuthorizeRequestEvent]
+	onOAuth2TokenRequest     *hook.Hook[*OAuth2TokenRequestEvent]
 
 	// record crud API event hooks
 	onRecordsListRequest  *hook.Hook[*RecordsListRequestEvent]
@@ -199,6 +216,8 @@ func NewBaseApp(config BaseAppConfig) *BaseApp {
 		store:               store.New[string, any](nil),
 		cron:                cron.New(),
 		subscriptionsBroker: subscriptions.NewBroker(),
+		mailCatcher:         mailer.NewCatcher(0),
+		instanceId:          security.RandomString(15),
 		config:              &config,
 	}
 
@@ -234,6 +253,7 @@ func (app *BaseApp) initHooks() {
 	app.onBootstrap = &hook.Hook[*BootstrapEvent]{}
 	app.onServe = &hook.Hook[*ServeEvent]{}
 	app.onTerminate = &hook.Hook[*TerminateEvent]{}
+	app.onPubSubMessage = &hook.Hook[*PubSubMessageEvent]{}
 	app.onBackupCreate = &hook.Hook[*BackupEvent]{}
 	app.onBackupRestore = &hook.Hook[*BackupEvent]{}
 
@@ -295,6 +315,7 @@ func (app *BaseApp) initHooks() {
 	app.onRealtimeConnectRequest = &hook.Hook[*RealtimeConnectRequestEvent]{}
 	app.onRealtimeMessageSend = &hook.Hook[*RealtimeMessageEvent]{}
 	app.onRealtimeSubscribeRequest = &hook.Hook[*RealtimeSubscribeRequestEvent]{}
+	app.onRealtimeClientMessage = &hook.Hook[*RealtimeClientMessageEvent]{}
 
 	// settings event hooks
 	app.onSettingsListRequest = &hook.Hook[*SettingsListRequestEvent]{}
@@ -318,6 +339,11 @@ func (app *BaseApp) initHooks() {
 	app.onRecordConfirmEmailChangeRequest = &hook.Hook[*RecordConfirmEmailChangeRequestEvent]{}
 	app.onRecordRequestOTPRequest = &hook.Hook[*RecordCreateOTPRequestEvent]{}
 	app.onRecordAuthWithOTPRequest = &hook.Hook[*RecordAuthWithOTPRequestEvent]{}
+	app.onRecordAuthWithLDAPRequest = &hook.Hook[*RecordAuthWithLDAPRequestEvent]{}
+
+	// oauth2 server API event hooks
+	app.onOAuth2AuthorizeRequest = &hook.Hook[*OAuth2AuthorizeRequestEvent]{}
+	app.onOAuth2TokenRequest = &hook.Hook[*OAuth2TokenRequestEvent]{}
 
 	// record crud API event hooks
 	app.onRecordsListRequest = &hook.Hook[*RecordsListRequestEvent]{}
@@ -581,9 +607,45 @@ func (app *BaseApp) SubscriptionsBroker() *subscriptions.Broker {
 	return app.subscriptionsBroker
 }
 
+// MailCatcher returns the app in-memory dev mail catcher instance.
+//
+// The catcher receives the app mail messages only when the app
+// is in dev mode and the mail catcher setting is enabled.
+func (app *BaseApp) MailCatcher() *mailer.Catcher {
+	return app.mailCatcher
+}
+
 // NewMailClient creates and returns a new SMTP or Sendmail client
 // based on the current app settings.
+//
+// If the mail outbox is enabled, the returned client enqueues the
+// messages for background delivery instead of sending them directly.
+//
+// If the app is in dev mode and the mail catcher is enabled, the returned
+// client stores the messages in the [BaseApp.MailCatcher] instead.
 func (app *BaseApp) NewMailClient() mailer.Mailer {
+	if app.Settings().MailOutbox.Enabled && !app.isMailCatcherActive() {
+		return &mailOutboxClient{app: app}
+	}
+
+	return app.newDirectMailClient()
+}
+
+// isMailCatcherActive reports whether the app mail messages should be
+// stored in the in-memory mail catcher instead of being sent.
+func (app *BaseApp) isMailCatcherActive() bool {
+	return app.IsDev() && app.Settings().MailCatcher.Enabled
+}
+
+// newDirectMailClient creates and returns a new SMTP or Sendmail client
+// based on the current app settings (regardless of the mail outbox).
+func (app *BaseApp) newDirectMailClient() mailer.Mailer {
+	// note: the catcher OnSend hook is registered only once with the base hooks
+	if app.isMailCatcherActive() {
+		app.mailCatcher.SetMaxMessages(app.Settings().MailCatcher.GetMaxMessages())
+		return app.mailCatcher
+	}
+
 	var client mailer.Mailer
 
 	// init mailer client
@@ -601,85 +663,133 @@ func (app *BaseApp) NewMailClient() mailer.Mailer {
 		client = &mailer.Sendmail{}
 	}
 
+	// init the DKIM signer (if enabled)
+	if dkim := app.Settings().SMTP.DKIM; dkim.Enabled {
+		signer, err := dkim.Signer()
+		if err != nil {
+			app.Logger().Error("Failed to init the DKIM signer, the mails will be sent unsigned", "error", err)
+		} else {
+			switch c := client.(type) {
+			case *mailer.SMTPClient:
+				c.DKIM = signer
+			case *mailer.Sendmail:
+				c.DKIM = signer
+			}
+		}
+	}
+
 	// register the app level hook
 	if h, ok := client.(mailer.SendInterceptor); ok {
-		h.OnSend().Bind(&hook.Handler[*mailer.SendEvent]{
-			Id: "__pbMailerOnSend__",
-			Func: func(e *mailer.SendEvent) error {
-				appEvent := new(MailerEvent)
-				appEvent.App = app
-				appEvent.Mailer = client
-				appEvent.Message = e.Message
-
-				return app.OnMailerSend().Trigger(appEvent, func(ae *MailerEvent) error {
-					e.Message = ae.Message
-
-					// print the mail in the console to assist with the debugging
-					if app.IsDev() {
-						logDate := new(strings.Builder)
-						log.New(logDate, "", log.LstdFlags).Print()
-
-						mailLog := new(strings.Builder)
-						mailLog.WriteString(strings.TrimSpace(logDate.String()))
-						mailLog.WriteString(" Mail sent\n")
-						fmt.Fprintf(mailLog, "├─ From: %v\n", ae.Message.From)
-						fmt.Fprintf(mailLog, "├─ To: %v\n", ae.Message.To)
-						fmt.Fprintf(mailLog, "├─ Cc: %v\n", ae.Message.Cc)
-						fmt.Fprintf(mailLog, "├─ Bcc: %v\n", ae.Message.Bcc)
-						fmt.Fprintf(mailLog, "├─ Subject: %v\n", ae.Message.Subject)
-
-						if len(ae.Message.Attachments) > 0 {
-							attachmentKeys := make([]string, 0, len(ae.Message.Attachments))
-							for k := range ae.Message.Attachments {
-								attachmentKeys = append(attachmentKeys, k)
-							}
-							fmt.Fprintf(mailLog, "├─ Attachments: %v\n", attachmentKeys)
-						}
+		h.OnSend().Bind(app.mailerOnSendHandler(client))
+	}
 
-						if len(ae.Message.InlineAttachments) > 0 {
-							attachmentKeys := make([]string, 0, len(ae.Message.InlineAttachments))
-							for k := range ae.Message.InlineAttachments {
-								attachmentKeys = append(attachmentKeys, k)
-							}
-							fmt.Fprintf(mailLog, "├─ InlineAttachments: %v\n", attachmentKeys)
-						}
+	return client
+}
 
-						const indentation = "        "
-						if ae.Message.Text != "" {
-							textParts := strings.Split(strings.TrimSpace(ae.Message.Text), "\n")
-							textIndented := indentation + strings.Join(textParts, "\n"+indentation)
-							fmt.Fprintf(mailLog, "└─ Text:\n%s", textIndented)
-						} else {
-							htmlParts := strings.Split(strings.TrimSpace(ae.Message.HTML), "\n")
-							htmlIndented := indentation + strings.Join(htmlParts, "\n"+indentation)
-							fmt.Fprintf(mailLog, "└─ HTML:\n%s", htmlIndented)
+// mailerOnSendHandler returns the mailer client OnSend hook handler that
+// triggers the app OnMailerSend hook.
+func (app *BaseApp) mailerOnSendHandler(client mailer.Mailer) *hook.Handler[*mailer.SendEvent] {
+	return &hook.Handler[*mailer.SendEvent]{
+		Id: "__pbMailerOnSend__",
+		Func: func(e *mailer.SendEvent) error {
+			appEvent := new(MailerEvent)
+			appEvent.App = app
+			appEvent.Mailer = client
+			appEvent.Message = e.Message
+
+			return app.OnMailerSend().Trigger(appEvent, func(ae *MailerEvent) error {
+				e.Message = ae.Message
+
+				// print the mail in the console to assist with the debugging
+				if app.IsDev() {
+					logDate := new(strings.Builder)
+					log.New(logDate, "", log.LstdFlags).Print()
+
+					mailLog := new(strings.Builder)
+					mailLog.WriteString(strings.TrimSpace(logDate.String()))
+					mailLog.WriteString(" Mail sent\n")
+					fmt.Fprintf(mailLog, "├─ From: %v\n", ae.Message.From)
+					fmt.Fprintf(mailLog, "├─ To: %v\n", ae.Message.To)
+					fmt.Fprintf(mailLog, "├─ Cc: %v\n", ae.Message.Cc)
+					fmt.Fprintf(mailLog, "├─ Bcc: %v\n", ae.Message.Bcc)
+					fmt.Fprintf(mailLog, "├─ Subject: %v\n", ae.Message.Subject)
+
+					if len(ae.Message.Attachments) > 0 {
+						attachmentKeys := make([]string, 0, len(ae.Message.Attachments))
+						for k := range ae.Message.Attachments {
+							attachmentKeys = append(attachmentKeys, k)
 						}
+						fmt.Fprintf(mailLog, "├─ Attachments: %v\n", attachmentKeys)
+					}
 
-						color.HiBlack("%s", mailLog.String())
+					if len(ae.Message.InlineAttachments) > 0 {
+						attachmentKeys := make([]string, 0, len(ae.Message.InlineAttachments))
+						for k := range ae.Message.InlineAttachments {
+							attachmentKeys = append(attachmentKeys, k)
+						}
+						fmt.Fprintf(mailLog, "├─ InlineAttachments: %v\n", attachmentKeys)
 					}
 
-					// send the email with the new mailer in case it was replaced
-					if client != ae.Mailer {
-						return ae.Mailer.Send(e.Message)
+					const indentation = "        "
+					if ae.Message.Text != "" {
+						textParts := strings.Split(strings.TrimSpace(ae.Message.Text), "\n")
+						textIndented := indentation + strings.Join(textParts, "\n"+indentation)
+						fmt.Fprintf(mailLog, "└─ Text:\n%s", textIndented)
+					} else {
+						htmlParts := strings.Split(strings.TrimSpace(ae.Message.HTML), "\n")
+						htmlIndented := indentation + strings.Join(htmlParts, "\n"+indentation)
+						fmt.Fprintf(mailLog, "└─ HTML:\n%s", htmlIndented)
 					}
 
-					return e.Next()
-				})
-			},
-		})
-	}
+					color.HiBlack("%s", mailLog.String())
+				}
 
-	return client
+				// send the email with the new mailer in case it was replaced
+				if client != ae.Mailer {
+					return ae.Mailer.Send(e.Message)
+				}
+
+				return e.Next()
+			})
+		},
+	}
 }
 
-// NewFilesystem creates a new local or S3 filesystem instance
+// NewFilesystem creates a new local, S3 or Postgres filesystem instance
 // for managing regular app files (ex. record uploads)
 // based on the current app settings.
 //
 // NB! Make sure to call Close() on the returned result
 // after you are done working with it.
 func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
-	if app.settings != nil && app.settings.S3.Enabled {
+	storage := StorageLocal
+
+	if app.settings != nil {
+		if app.settings.S3.Enabled {
+			storage = StorageS3
+		} else if app.settings.PostgresStorage.Enabled {
+			storage = StoragePostgres
+		}
+	}
+
+	return app.NewStorageFilesystem(storage)
+}
+
+// NewStorageFilesystem creates a new filesystem instance for the
+// specified app files storage (StorageLocal, StorageS3 or StoragePostgres)
+// regardless of the currently active one (ex. to migrate files between storages).
+//
+// NB! Make sure to call Close() on the returned result
+// after you are done working with it.
+func (app *BaseApp) NewStorageFilesystem(storage string) (*filesystem.System, error) {
+	switch storage {
+	case StorageLocal:
+		return filesystem.NewLocal(filepath.Join(app.DataDir(), LocalStorageDirName))
+	case StorageS3:
+		if app.settings == nil {
+			return nil, errors.New("missing app settings")
+		}
+
 		return filesystem.NewS3(
 			app.settings.S3.Bucket,
 			app.settings.S3.Region,
@@ -688,10 +798,16 @@ func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
 			app.settings.S3.Secret,
 			app.settings.S3.ForcePathStyle,
 		)
-	}
+	case StoragePostgres:
+		var chunkSize int
+		if app.settings != nil {
+			chunkSize = app.settings.PostgresStorage.ChunkSize
+		}
 
-	// fallback to local filesystem
-	return filesystem.NewLocal(filepath.Join(app.DataDir(), LocalStorageDirName))
+		return filesystem.NewPostgres(app.DB(), chunkSize)
+	default:
+		return nil, fmt.Errorf("unknown files storage %q", storage)
+	}
 }
 
 // NewBackupsFilesystem creates a new local or S3 filesystem instance
@@ -784,6 +900,10 @@ func (app *BaseApp) OnTerminate() *hook.Hook[*TerminateEvent] {
 	return app.onTerminate
 }
 
+func (app *BaseApp) OnPubSubMessage(tags ...string) *hook.TaggedHook[*PubSubMessageEvent] {
+	return hook.NewTaggedHook(app.onPubSubMessage, tags...)
+}
+
 func (app *BaseApp) OnBackupCreate() *hook.Hook[*BackupEvent] {
 	return app.onBackupCreate
 }
@@ -998,6 +1118,10 @@ func (app *BaseApp) OnRealtimeSubscribeRequest() *hook.Hook[*RealtimeSubscribeRe
 	return app.onRealtimeSubscribeRequest
 }
 
+func (app *BaseApp) OnRealtimeClientMessage() *hook.Hook[*RealtimeClientMessageEvent] {
+	return app.onRealtimeClientMessage
+}
+
 // -------------------------------------------------------------------
 // Settings API event hooks
 // -------------------------------------------------------------------
@@ -1078,6 +1202,22 @@ func (app *BaseApp) OnRecordAuthWithOTPRequest(tags ...string) *hook.TaggedHook[
 	return hook.NewTaggedHook(app.onRecordAuthWithOTPRequest, tags...)
 }
 
+func (app *BaseApp) OnRecordAuthWithLDAPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithLDAPRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithLDAPRequest, tags...)
+}
+
+// -------------------------------------------------------------------
+// OAuth2 server API event hooks
+// -------------------------------------------------------------------
+
+func (app *BaseApp) OnOAuth2AuthorizeRequest(tags ...string) *hook.TaggedHook[*OAuth2AuthorizeRequestEvent] {
+	return hook.NewTaggedHook(app.onOAuth2AuthorizeRequest, tags...)
+}
+
+func (app *BaseApp) OnOAuth2TokenRequest(tags ...string) *hook.TaggedHook[*OAuth2TokenRequestEvent] {
+	return hook.NewTaggedHook(app.onOAuth2TokenRequest, tags...)
+}
+
 // -------------------------------------------------------------------
 // Record CRUD API event hooks
 // -------------------------------------------------------------------
@@ -1248,6 +1388,10 @@ func (app *BaseApp) registerBaseHooks() {
 		Priority: -99,
 	})
 
+	// bind the dev mail catcher to the base app once
+	// (it is a shared instance, unlike the other mail clients)
+	app.mailCatcher.OnSend().Bind(app.mailerOnSendHandler(app.mailCatcher))
+
 	app.OnServe().Bind(&hook.Handler[*ServeEvent]{
 		Id: "__pbCronStart__",
 		Func: func(e *ServeEvent) error {
@@ -1267,6 +1411,16 @@ func (app *BaseApp) registerBaseHooks() {
 	app.registerMFAHooks()
 	app.registerOTPHooks()
 	app.registerAuthOriginHooks()
+	app.registerOAuth2ClientHooks()
+	app.registerOAuth2CodeHooks()
+	app.registerMailTemplateHooks()
+	app.registerAuthAttemptHooks()
+	app.registerPasswordHistoryHooks()
+	app.registerUploadHooks()
+	app.registerFileDedupeHooks()
+	app.registerFilesGCHooks()
+	app.registerMailOutboxHooks()
+	app.registerCronRunHooks()
 }
 
 // getLoggerMinLevel returns the logger min level based on the
@@ -232,6 +232,62 @@ func TestBaseAppNewMailClient(t *testing.T) {
 	if m2.OnSend() == nil || m2.OnSend().Length() == 0 {
 		t.Fatal("Expected OnSend hook to be registered")
 	}
+
+	// the mail catcher has effect only in dev mode
+	app.Settings().MailCatcher.Enabled = true
+
+	client3 := app.NewMailClient()
+	if _, ok := client3.(*mailer.SMTPClient); !ok {
+		t.Fatalf("Expected mailer.SMTPClient instance, got %v", client3)
+	}
+}
+
+func TestBaseAppNewMailClientMailCatcher(t *testing.T) {
+	const testDataDir = "/tmp/pb_base_app_test_data_dir/"
+	defer os.RemoveAll(testDataDir)
+
+	app := core.NewBaseApp(core.BaseAppConfig{
+		DataDir: testDataDir,
+		IsDev:   true,
+	})
+
+	app.Settings().SMTP.Enabled = true
+	app.Settings().MailOutbox.Enabled = true
+	app.Settings().MailCatcher.Enabled = true
+	app.Settings().MailCatcher.MaxMessages = 1
+
+	var hookCalls int
+	app.OnMailerSend().BindFunc(func(e *core.MailerEvent) error {
+		if e.App != app {
+			t.Fatalf("Expected the base app instance, got %v", e.App)
+		}
+		hookCalls++
+		return e.Next()
+	})
+
+	for _, subject := range []string{"a", "b"} {
+		client := app.NewMailClient()
+		if client != app.MailCatcher() {
+			t.Fatalf("Expected the app mail catcher instance, got %v", client)
+		}
+
+		if err := client.Send(&mailer.Message{Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if hookCalls != 2 {
+		t.Fatalf("Expected 2 OnMailerSend calls, got %d", hookCalls)
+	}
+
+	if total := app.MailCatcher().OnSend().Length(); total != 1 {
+		t.Fatalf("Expected a single mail catcher OnSend handler, got %d", total)
+	}
+
+	messages := app.MailCatcher().Messages()
+	if len(messages) != 1 || messages[0].Subject != "b" {
+		t.Fatalf("Expected only the last message to be stored, got %v", messages)
+	}
 }
 
 func TestBaseAppNewFilesystem(t *testing.T) {
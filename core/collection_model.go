@@ -573,6 +573,7 @@ func (m Collection) MarshalJSON() ([]byte, error) {
 		for i := range alias.OAuth2.Providers {
 			alias.OAuth2.Providers[i].ClientSecret = ""
 		}
+		alias.LDAP.BindPassword = ""
 
 		return json.Marshal(alias)
 	default:
@@ -807,6 +808,8 @@ func onCollectionSave(e *CollectionEvent) error {
 
 	if e.Collection.IsAuth() {
 		e.Collection.unsetMissingOAuth2MappedFields()
+		e.Collection.unsetMissingLDAPMappedFields()
+		e.Collection.unsetMissingEmailLocaleField()
 	}
 
 	e.Collection.updateGeneratedIdIfExists(e.App)
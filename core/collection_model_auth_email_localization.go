@@ -0,0 +1,226 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Auth collection email template kinds.
+const (
+	EmailTemplateVerification       = "verification"
+	EmailTemplateResetPassword      = "resetPassword"
+	EmailTemplateConfirmEmailChange = "confirmEmailChange"
+	EmailTemplateOTP                = "otp"
+	EmailTemplateOTPMagicLink       = "otpMagicLink"
+	EmailTemplateAuthAlert          = "authAlert"
+)
+
+var localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,8}([-_][a-zA-Z0-9]{1,8})*$`)
+
+// EmailTemplate returns the default (non-localized) collection email template of the specified kind.
+//
+// Returns false if kind is not a known email template kind.
+func (m *Collection) EmailTemplate(kind string) (EmailTemplate, bool) {
+	switch kind {
+	case EmailTemplateVerification:
+		return m.VerificationTemplate, true
+	case EmailTemplateResetPassword:
+		return m.ResetPasswordTemplate, true
+	case EmailTemplateConfirmEmailChange:
+		return m.ConfirmEmailChangeTemplate, true
+	case EmailTemplateOTP:
+		return m.OTP.EmailTemplate, true
+	case EmailTemplateOTPMagicLink:
+		return m.OTP.MagicLinkTemplate, true
+	case EmailTemplateAuthAlert:
+		return m.AuthAlert.EmailTemplate, true
+	}
+
+	return EmailTemplate{}, false
+}
+
+// LocalizedEmailTemplate returns the collection email template of the
+// specified kind for the first matching locale from the provided
+// preference list followed by the configured default locale.
+//
+// A locale matches a localized variant using the following rules:
+//   - exact match (case-insensitive, "_" and "-" are treated the same), eg. "pt-BR" => "pt-BR"
+//   - base language match, eg. "pt-BR" => "pt"
+//   - any other region of the same language, eg. "pt-BR" => "pt-PT"
+//
+// Localized variants with empty subject and body are ignored.
+// If none of the locales matches, fallbacks to the default (non-localized) template.
+func (m *Collection) LocalizedEmailTemplate(kind string, locales ...string) EmailTemplate {
+	if t, ok := m.EmailLocalization.Find(kind, locales...); ok {
+		return t
+	}
+
+	t, _ := m.EmailTemplate(kind)
+
+	return t
+}
+
+// -------------------------------------------------------------------
+
+type EmailLocalizationConfig struct {
+	// LocaleField is the optional name of the auth collection field
+	// holding the record preferred locale (eg. "de" or "pt-BR").
+	//
+	// The record locale takes precedence over the request Accept-Language header.
+	LocaleField string `form:"localeField" json:"localeField"`
+
+	// DefaultLocale is the optional fallback locale used when none of
+	// the record or request locales matches a localized variant.
+	DefaultLocale string `form:"defaultLocale" json:"defaultLocale"`
+
+	// Templates holds the localized email template variants indexed by their locale.
+	Templates map[string]LocalizedEmailTemplates `form:"templates" json:"templates"`
+}
+
+// Validate makes EmailLocalizationConfig validatable by implementing [validation.Validatable] interface.
+func (c EmailLocalizationConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.DefaultLocale, validation.Match(localeRegex).Error("Must be a valid locale, eg. en or pt-BR.")),
+		validation.Field(&c.Templates, validation.By(checkLocalizedEmailTemplates)),
+	)
+}
+
+func checkLocalizedEmailTemplates(value any) error {
+	templates, _ := value.(map[string]LocalizedEmailTemplates)
+
+	existing := map[string]struct{}{}
+
+	errs := validation.Errors{}
+	for locale, t := range templates {
+		if !localeRegex.MatchString(locale) {
+			errs[locale] = validation.NewError("validation_invalid_locale", "Invalid locale {{.locale}}.").
+				SetParams(map[string]any{"locale": locale})
+			continue
+		}
+
+		normalized := normalizeLocale(locale)
+		if _, ok := existing[normalized]; ok {
+			errs[locale] = validation.NewError("validation_duplicated_locale", "The locale {{.locale}} is already defined.").
+				SetParams(map[string]any{"locale": locale})
+			continue
+		}
+		existing[normalized] = struct{}{}
+
+		if err := t.Validate(); err != nil {
+			errs[locale] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Find returns the localized template variant of the specified kind
+// for the first matching locale from the provided preference list
+// followed by c.DefaultLocale (see [Collection.LocalizedEmailTemplate]).
+//
+// Returns false if there is no matching localized variant.
+func (c EmailLocalizationConfig) Find(kind string, locales ...string) (EmailTemplate, bool) {
+	if len(c.Templates) == 0 {
+		return EmailTemplate{}, false
+	}
+
+	// sort the available locales for deterministic "same language" matches
+	available := make([]string, 0, len(c.Templates))
+	for locale := range c.Templates {
+		available = append(available, locale)
+	}
+	slices.Sort(available)
+
+	candidates := locales
+	if c.DefaultLocale != "" {
+		candidates = append(slices.Clone(locales), c.DefaultLocale)
+	}
+
+	for _, candidate := range candidates {
+		candidate = normalizeLocale(candidate)
+		if candidate == "" {
+			continue
+		}
+
+		language, _, _ := strings.Cut(candidate, "-")
+
+		matchers := []func(locale string) bool{
+			func(locale string) bool { return locale == candidate },
+			func(locale string) bool { return locale == language },
+			func(locale string) bool { return strings.HasPrefix(locale, language+"-") },
+		}
+
+		for _, match := range matchers {
+			for _, locale := range available {
+				if !match(normalizeLocale(locale)) {
+					continue
+				}
+
+				if t := c.Templates[locale].Get(kind); !t.IsEmpty() {
+					return t, true
+				}
+			}
+		}
+	}
+
+	return EmailTemplate{}, false
+}
+
+// normalizeLocale returns the lowercased locale with "-" as separator.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// -------------------------------------------------------------------
+
+// LocalizedEmailTemplates defines the email template variants of a single locale.
+//
+// Leave a template subject and body empty to fallback to the next matching locale.
+type LocalizedEmailTemplates struct {
+	VerificationTemplate       EmailTemplate `form:"verificationTemplate" json:"verificationTemplate"`
+	ResetPasswordTemplate      EmailTemplate `form:"resetPasswordTemplate" json:"resetPasswordTemplate"`
+	ConfirmEmailChangeTemplate EmailTemplate `form:"confirmEmailChangeTemplate" json:"confirmEmailChangeTemplate"`
+	OTPTemplate                EmailTemplate `form:"otpTemplate" json:"otpTemplate"`
+	OTPMagicLinkTemplate       EmailTemplate `form:"otpMagicLinkTemplate" json:"otpMagicLinkTemplate"`
+	AuthAlertTemplate          EmailTemplate `form:"authAlertTemplate" json:"authAlertTemplate"`
+}
+
+// Validate makes LocalizedEmailTemplates validatable by implementing [validation.Validatable] interface.
+func (t LocalizedEmailTemplates) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.VerificationTemplate, validation.Skip.When(t.VerificationTemplate.IsEmpty())),
+		validation.Field(&t.ResetPasswordTemplate, validation.Skip.When(t.ResetPasswordTemplate.IsEmpty())),
+		validation.Field(&t.ConfirmEmailChangeTemplate, validation.Skip.When(t.ConfirmEmailChangeTemplate.IsEmpty())),
+		validation.Field(&t.OTPTemplate, validation.Skip.When(t.OTPTemplate.IsEmpty())),
+		validation.Field(&t.OTPMagicLinkTemplate, validation.Skip.When(t.OTPMagicLinkTemplate.IsEmpty())),
+		validation.Field(&t.AuthAlertTemplate, validation.Skip.When(t.AuthAlertTemplate.IsEmpty())),
+	)
+}
+
+// Get returns the template variant of the specified kind
+// (returns zero EmailTemplate for unknown kind).
+func (t LocalizedEmailTemplates) Get(kind string) EmailTemplate {
+	switch kind {
+	case EmailTemplateVerification:
+		return t.VerificationTemplate
+	case EmailTemplateResetPassword:
+		return t.ResetPasswordTemplate
+	case EmailTemplateConfirmEmailChange:
+		return t.ConfirmEmailChangeTemplate
+	case EmailTemplateOTP:
+		return t.OTPTemplate
+	case EmailTemplateOTPMagicLink:
+		return t.OTPMagicLinkTemplate
+	case EmailTemplateAuthAlert:
+		return t.AuthAlertTemplate
+	}
+
+	return EmailTemplate{}
+}
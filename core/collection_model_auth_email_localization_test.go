@@ -0,0 +1,182 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestCollectionEmailTemplate(t *testing.T) {
+	t.Parallel()
+
+	c := core.NewAuthCollection("test")
+
+	scenarios := []struct {
+		kind     string
+		expected core.EmailTemplate
+		exists   bool
+	}{
+		{"missing", core.EmailTemplate{}, false},
+		{core.EmailTemplateVerification, c.VerificationTemplate, true},
+		{core.EmailTemplateResetPassword, c.ResetPasswordTemplate, true},
+		{core.EmailTemplateConfirmEmailChange, c.ConfirmEmailChangeTemplate, true},
+		{core.EmailTemplateOTP, c.OTP.EmailTemplate, true},
+		{core.EmailTemplateOTPMagicLink, c.OTP.MagicLinkTemplate, true},
+		{core.EmailTemplateAuthAlert, c.AuthAlert.EmailTemplate, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.kind, func(t *testing.T) {
+			template, exists := c.EmailTemplate(s.kind)
+
+			if exists != s.exists {
+				t.Fatalf("Expected exists %v, got %v", s.exists, exists)
+			}
+
+			if template != s.expected {
+				t.Fatalf("Expected template %v, got %v", s.expected, template)
+			}
+		})
+	}
+}
+
+func TestCollectionLocalizedEmailTemplate(t *testing.T) {
+	t.Parallel()
+
+	c := core.NewAuthCollection("test")
+	c.EmailLocalization.DefaultLocale = "de"
+	c.EmailLocalization.Templates = map[string]core.LocalizedEmailTemplates{
+		"de": {
+			VerificationTemplate: core.EmailTemplate{Subject: "de_verification", Body: "de_verification"},
+			OTPTemplate:          core.EmailTemplate{Subject: "de_otp", Body: "de_otp"},
+		},
+		"pt": {
+			VerificationTemplate: core.EmailTemplate{Subject: "pt_verification", Body: "pt_verification"},
+		},
+		"pt_BR": {
+			VerificationTemplate: core.EmailTemplate{Subject: "pt-BR_verification", Body: "pt-BR_verification"},
+			AuthAlertTemplate:    core.EmailTemplate{Subject: "pt-BR_alert", Body: "pt-BR_alert"},
+		},
+		"fr-CA": {
+			VerificationTemplate: core.EmailTemplate{Subject: "fr-CA_verification", Body: "fr-CA_verification"},
+		},
+	}
+
+	scenarios := []struct {
+		name            string
+		kind            string
+		locales         []string
+		expectedSubject string
+	}{
+		{"unknown kind", "missing", []string{"pt"}, ""},
+		{"no locales (default locale)", core.EmailTemplateVerification, nil, "de_verification"},
+		{"no matching locale (default locale)", core.EmailTemplateVerification, []string{"bg"}, "de_verification"},
+		{"missing default locale variant", core.EmailTemplateResetPassword, []string{"pt-BR"}, c.ResetPasswordTemplate.Subject},
+		{"exact match", core.EmailTemplateVerification, []string{"pt-BR"}, "pt-BR_verification"},
+		{"case-insensitive exact match", core.EmailTemplateVerification, []string{"PT_br"}, "pt-BR_verification"},
+		{"base language match", core.EmailTemplateVerification, []string{"pt-PT"}, "pt_verification"},
+		{"same language region match", core.EmailTemplateVerification, []string{"fr"}, "fr-CA_verification"},
+		{"locales order", core.EmailTemplateVerification, []string{"bg", "fr-FR", "pt"}, "fr-CA_verification"},
+		{"empty variant fallback to the next locale", core.EmailTemplateOTP, []string{"pt-BR"}, "de_otp"},
+		{"empty variant fallback to the same language region", core.EmailTemplateAuthAlert, []string{"pt", "en"}, "pt-BR_alert"},
+		{"empty variants fallback to the base template", core.EmailTemplateAuthAlert, []string{"fr", "en"}, c.AuthAlert.EmailTemplate.Subject},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			template := c.LocalizedEmailTemplate(s.kind, s.locales...)
+
+			if template.Subject != s.expectedSubject {
+				t.Fatalf("Expected subject %q, got %q", s.expectedSubject, template.Subject)
+			}
+		})
+	}
+}
+
+func TestEmailLocalizationConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name           string
+		config         core.EmailLocalizationConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.EmailLocalizationConfig{},
+			[]string{},
+		},
+		{
+			"invalid default locale",
+			core.EmailLocalizationConfig{DefaultLocale: "e"},
+			[]string{"defaultLocale"},
+		},
+		{
+			"invalid templates locale",
+			core.EmailLocalizationConfig{
+				Templates: map[string]core.LocalizedEmailTemplates{"en US": {}},
+			},
+			[]string{"templates"},
+		},
+		{
+			"duplicated templates locale",
+			core.EmailLocalizationConfig{
+				Templates: map[string]core.LocalizedEmailTemplates{"pt-BR": {}, "pt_br": {}},
+			},
+			[]string{"templates"},
+		},
+		{
+			"partially filled template variant",
+			core.EmailLocalizationConfig{
+				Templates: map[string]core.LocalizedEmailTemplates{
+					"de": {OTPTemplate: core.EmailTemplate{Subject: "test"}},
+				},
+			},
+			[]string{"templates"},
+		},
+		{
+			"valid data",
+			core.EmailLocalizationConfig{
+				LocaleField:   "locale",
+				DefaultLocale: "en",
+				Templates: map[string]core.LocalizedEmailTemplates{
+					"de":    {OTPTemplate: core.EmailTemplate{Subject: "test", Body: "test"}},
+					"pt-BR": {},
+				},
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestLocalizedEmailTemplatesValidate(t *testing.T) {
+	t.Parallel()
+
+	templates := core.LocalizedEmailTemplates{
+		VerificationTemplate: core.EmailTemplate{Subject: "test", Body: "test"},
+		AuthAlertTemplate:    core.EmailTemplate{Body: "test"},
+		OTPTemplate:          core.EmailTemplate{Subject: "test"},
+	}
+
+	err := templates.Validate()
+
+	for _, key := range []string{"authAlertTemplate", "otpTemplate"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Fatalf("Expected %q validation error, got %v", key, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "verificationTemplate") {
+		t.Fatalf("Expected no verificationTemplate validation error, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -44,6 +45,30 @@ func (m *Collection) unsetMissingOAuth2MappedFields() {
 	}
 }
 
+func (m *Collection) unsetMissingLDAPMappedFields() {
+	if !m.IsAuth() {
+		return
+	}
+
+	for field := range m.LDAP.MappedFields {
+		if m.Fields.GetByName(field) == nil {
+			delete(m.LDAP.MappedFields, field)
+		}
+	}
+}
+
+func (m *Collection) unsetMissingEmailLocaleField() {
+	if !m.IsAuth() {
+		return
+	}
+
+	if m.EmailLocalization.LocaleField != "" {
+		if m.Fields.GetByName(m.EmailLocalization.LocaleField) == nil {
+			m.EmailLocalization.LocaleField = ""
+		}
+	}
+}
+
 func (m *Collection) setDefaultAuthOptions() {
 	m.collectionAuthOptions = collectionAuthOptions{
 		VerificationTemplate:       defaultVerificationTemplate,
@@ -55,8 +80,10 @@ func (m *Collection) setDefaultAuthOptions() {
 			EmailTemplate: defaultAuthAlertTemplate,
 		},
 		PasswordAuth: PasswordAuthConfig{
-			Enabled:        true,
-			IdentityFields: []string{FieldNameEmail},
+			Enabled:         true,
+			IdentityFields:  []string{FieldNameEmail},
+			LockoutWindow:   900, // 15min
+			LockoutDuration: 900, // 15min
 		},
 		MFA: MFAConfig{
 			Enabled:  false,
@@ -67,6 +94,12 @@ func (m *Collection) setDefaultAuthOptions() {
 			Duration:      180, // 3min
 			Length:        8,
 			EmailTemplate: defaultOTPTemplate,
+
+			MagicLinkTemplate: defaultOTPMagicLinkTemplate,
+		},
+		LDAP: LDAPConfig{
+			Enabled:        false,
+			EmailAttribute: "mail",
 		},
 		AuthToken: TokenConfig{
 			Secret:   security.RandomString(50),
@@ -125,12 +158,18 @@ type collectionAuthOptions struct {
 	// PasswordAuth defines options related to the collection password authentication.
 	PasswordAuth PasswordAuthConfig `form:"passwordAuth" json:"passwordAuth"`
 
+	// PasswordPolicy defines extra password strength and rotation requirements.
+	PasswordPolicy PasswordPolicyConfig `form:"passwordPolicy" json:"passwordPolicy"`
+
 	// MFA defines options related to the Multi-factor authentication (MFA).
 	MFA MFAConfig `form:"mfa" json:"mfa"`
 
 	// OTP defines options related to the One-time password authentication (OTP).
 	OTP OTPConfig `form:"otp" json:"otp"`
 
+	// LDAP defines options related to the LDAP/Active Directory password authentication.
+	LDAP LDAPConfig `form:"ldap" json:"ldap"`
+
 	// Various token configurations
 	// ---
 	AuthToken          TokenConfig `form:"authToken" json:"authToken"`
@@ -144,6 +183,9 @@ type collectionAuthOptions struct {
 	VerificationTemplate       EmailTemplate `form:"verificationTemplate" json:"verificationTemplate"`
 	ResetPasswordTemplate      EmailTemplate `form:"resetPasswordTemplate" json:"resetPasswordTemplate"`
 	ConfirmEmailChangeTemplate EmailTemplate `form:"confirmEmailChangeTemplate" json:"confirmEmailChangeTemplate"`
+
+	// EmailLocalization defines the localized variants of the collection email templates.
+	EmailLocalization EmailLocalizationConfig `form:"emailLocalization" json:"emailLocalization"`
 }
 
 func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
@@ -161,8 +203,10 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		),
 		validation.Field(&o.AuthAlert),
 		validation.Field(&o.PasswordAuth),
+		validation.Field(&o.PasswordPolicy),
 		validation.Field(&o.OAuth2),
 		validation.Field(&o.OTP),
+		validation.Field(&o.LDAP),
 		validation.Field(&o.MFA),
 		validation.Field(&o.AuthToken),
 		validation.Field(&o.PasswordResetToken),
@@ -172,6 +216,7 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		validation.Field(&o.VerificationTemplate, validation.Required),
 		validation.Field(&o.ResetPasswordTemplate, validation.Required),
 		validation.Field(&o.ConfirmEmailChangeTemplate, validation.Required),
+		validation.Field(&o.EmailLocalization),
 	)
 	if err != nil {
 		return err
@@ -192,6 +237,9 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		if o.OTP.Enabled {
 			authsEnabled++
 		}
+		if o.LDAP.Enabled {
+			authsEnabled++
+		}
 		if authsEnabled < 2 {
 			return validation.Errors{
 				"mfa": validation.Errors{
@@ -249,6 +297,11 @@ func (t EmailTemplate) Validate() error {
 	)
 }
 
+// IsEmpty reports whether both the template subject and body are empty.
+func (t EmailTemplate) IsEmpty() bool {
+	return t.Subject == "" && t.Body == ""
+}
+
 // Resolve replaces the placeholder parameters in the current email
 // template and returns its components as ready-to-use strings.
 func (t EmailTemplate) Resolve(placeholders map[string]any) (subject, body string) {
@@ -322,16 +375,35 @@ type OTPConfig struct {
 	// In addition to the system placeholders you can also make use of
 	// [core.EmailPlaceholderOTPId] and [core.EmailPlaceholderOTP].
 	EmailTemplate EmailTemplate `form:"emailTemplate" json:"emailTemplate"`
+
+	// MagicLink specifies whether to email a single-use login link
+	// instead of the numeric one-time password.
+	//
+	// The link works only in the same browser from where the OTP was requested
+	// and on success redirects to MagicLinkRedirectURL with the auth token
+	// (or the MFA id) in the url fragment, eg. "https://example.com/login#token=...".
+	MagicLink bool `form:"magicLink" json:"magicLink"`
+
+	// MagicLinkRedirectURL is the app url to redirect to after opening the magic link.
+	MagicLinkRedirectURL string `form:"magicLinkRedirectURL" json:"magicLinkRedirectURL"`
+
+	// MagicLinkTemplate is the default magic link email template that will be send to the auth record.
+	//
+	// In addition to the system placeholders you can also make use of
+	// [core.EmailPlaceholderOTPId] and [core.EmailPlaceholderMagicLink].
+	MagicLinkTemplate EmailTemplate `form:"magicLinkTemplate" json:"magicLinkTemplate"`
 }
 
 // Validate makes OTPConfig validatable by implementing [validation.Validatable] interface.
 func (c OTPConfig) Validate() error {
 	return validation.ValidateStruct(&c,
 		validation.Field(&c.Duration, validation.When(c.Enabled, validation.Required, validation.Min(10), validation.Max(86400))),
-		validation.Field(&c.Length, validation.When(c.Enabled, validation.Required, validation.Min(4))),
+		validation.Field(&c.Length, validation.When(c.Enabled && !c.MagicLink, validation.Required, validation.Min(4))),
+		validation.Field(&c.MagicLinkRedirectURL, validation.When(c.Enabled && c.MagicLink, validation.Required), is.URL),
 		// note: for now always run the email template validations even
 		// if not enabled since it could be used separately
 		validation.Field(&c.EmailTemplate),
+		validation.Field(&c.MagicLinkTemplate, validation.Skip.When(!c.MagicLink)),
 	)
 }
 
@@ -342,6 +414,60 @@ func (c OTPConfig) DurationTime() time.Duration {
 
 // -------------------------------------------------------------------
 
+type PasswordPolicyConfig struct {
+	// RequireLowercase, RequireUppercase, RequireDigit and RequireSymbol
+	// specify the character classes that the password must contain.
+	RequireLowercase bool `form:"requireLowercase" json:"requireLowercase"`
+	RequireUppercase bool `form:"requireUppercase" json:"requireUppercase"`
+	RequireDigit     bool `form:"requireDigit" json:"requireDigit"`
+	RequireSymbol    bool `form:"requireSymbol" json:"requireSymbol"`
+
+	// DisallowIdentity disallows passwords containing the record
+	// email, username or any of the other password auth identity field values.
+	DisallowIdentity bool `form:"disallowIdentity" json:"disallowIdentity"`
+
+	// HistorySize specifies the number of the last record passwords
+	// that are not allowed to be reused (0 means no restriction).
+	HistorySize int `form:"historySize" json:"historySize"`
+
+	// MaxAge specifies the max password age (in seconds) after which
+	// the password auth is rejected until the password is reset (0 means no restriction).
+	//
+	// Records without tracked password change (eg. created before enabling
+	// the policy) are not affected until their next password change.
+	MaxAge int64 `form:"maxAge" json:"maxAge"`
+
+	// BreachedListFile is an optional path to a local file with known
+	// breached password SHA-1 hashes (one per line, optionally followed
+	// by ":count" as in the HIBP downloadable lists).
+	//
+	// Split hashes are also accepted in the "PREFIX:SUFFIX[:count]" format
+	// but only if together they form the full hash (partial hashes are ignored).
+	BreachedListFile string `form:"breachedListFile" json:"breachedListFile"`
+}
+
+// Validate makes PasswordPolicyConfig validatable by implementing [validation.Validatable] interface.
+func (c PasswordPolicyConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.HistorySize, validation.Min(0), validation.Max(24)),
+		validation.Field(&c.MaxAge, validation.Min(0), validation.Max(int64(10*365*86400))),
+		validation.Field(&c.BreachedListFile, validation.Length(0, 1000)),
+	)
+}
+
+// MaxAgeTime returns the current MaxAge as [time.Duration].
+func (c PasswordPolicyConfig) MaxAgeTime() time.Duration {
+	return time.Duration(c.MaxAge) * time.Second
+}
+
+// TracksHistory reports whether the password changes of the
+// collection records need to be tracked.
+func (c PasswordPolicyConfig) TracksHistory() bool {
+	return c.HistorySize > 0 || c.MaxAge > 0
+}
+
+// -------------------------------------------------------------------
+
 type MFAConfig struct {
 	Enabled bool `form:"enabled" json:"enabled"`
 
@@ -376,6 +502,21 @@ type PasswordAuthConfig struct {
 	//
 	// Usually only fields that has single column UNIQUE index are accepted as values.
 	IdentityFields []string `form:"identityFields" json:"identityFields"`
+
+	// LockoutThreshold specifies the max allowed failed password auth attempts
+	// (per auth record and identity value) within LockoutWindow
+	// before temporary locking the account.
+	//
+	// Set it to 0 to disable the account lockout.
+	LockoutThreshold int `form:"lockoutThreshold" json:"lockoutThreshold"`
+
+	// LockoutWindow specifies the time period (in seconds) in which
+	// the failed auth attempts are counted.
+	LockoutWindow int64 `form:"lockoutWindow" json:"lockoutWindow"`
+
+	// LockoutDuration specifies the cooldown period (in seconds) after
+	// the last failed auth attempt during which the account remains locked.
+	LockoutDuration int64 `form:"lockoutDuration" json:"lockoutDuration"`
 }
 
 // Validate makes PasswordAuthConfig validatable by implementing [validation.Validatable] interface.
@@ -389,9 +530,49 @@ func (c PasswordAuthConfig) Validate() error {
 
 	return validation.ValidateStruct(&c,
 		validation.Field(&c.IdentityFields, validation.Required),
+		validation.Field(&c.LockoutThreshold, validation.Min(0), validation.Max(1000)),
+		validation.Field(&c.LockoutWindow, validation.When(c.LockoutThreshold > 0, validation.Required, validation.Min(1), validation.Max(86400))),
+		validation.Field(&c.LockoutDuration, validation.When(c.LockoutThreshold > 0, validation.Required, validation.Min(1), validation.Max(86400))),
 	)
 }
 
+// LockoutWindowTime returns the current LockoutWindow as [time.Duration].
+func (c PasswordAuthConfig) LockoutWindowTime() time.Duration {
+	return time.Duration(c.LockoutWindow) * time.Second
+}
+
+// LockoutDurationTime returns the current LockoutDuration as [time.Duration].
+func (c PasswordAuthConfig) LockoutDurationTime() time.Duration {
+	return time.Duration(c.LockoutDuration) * time.Second
+}
+
+// LockedUntil returns the time until which the account related to the
+// provided failed auth attempts is locked.
+//
+// The attempts are expected to be the most recent failed auth attempts
+// sorted by their created date in DESC order.
+//
+// Returns zero time if the account is not locked or the lockout is disabled.
+func (c PasswordAuthConfig) LockedUntil(attempts []*AuthAttempt) time.Time {
+	if c.LockoutThreshold <= 0 || len(attempts) < c.LockoutThreshold {
+		return time.Time{}
+	}
+
+	latest := attempts[0].Created.Time()
+	oldest := attempts[c.LockoutThreshold-1].Created.Time()
+
+	if latest.Sub(oldest) > c.LockoutWindowTime() {
+		return time.Time{}
+	}
+
+	until := latest.Add(c.LockoutDurationTime())
+	if !until.After(time.Now()) {
+		return time.Time{}
+	}
+
+	return until
+}
+
 // -------------------------------------------------------------------
 
 type OAuth2KnownFields struct {
@@ -541,3 +722,100 @@ func (c OAuth2ProviderConfig) InitProvider() (auth.Provider, error) {
 
 	return provider, nil
 }
+
+// -------------------------------------------------------------------
+
+type LDAPConfig struct {
+	// URL is the LDAP server url, eg. "ldaps://ldap.example.com:636".
+	URL string `form:"url" json:"url"`
+
+	// BindDN and BindPassword are the optional service account credentials
+	// used for the user entry search (anonymous bind is used if empty).
+	BindDN       string `form:"bindDN" json:"bindDN"`
+	BindPassword string `form:"bindPassword" json:"bindPassword,omitempty"`
+
+	// UserDN is the direct bind DN template, eg. "uid={username},ou=people,dc=example,dc=com".
+	//
+	// It is used only when SearchFilter is not set.
+	UserDN string `form:"userDN" json:"userDN"`
+
+	// BaseDN is the user entry search base, eg. "ou=people,dc=example,dc=com".
+	BaseDN string `form:"baseDN" json:"baseDN"`
+
+	// SearchFilter is the user entry search filter template,
+	// eg. "(&(objectClass=person)(sAMAccountName={username}))".
+	SearchFilter string `form:"searchFilter" json:"searchFilter"`
+
+	// IdAttribute is the attribute with the unique user identifier
+	// used for linking the directory entry with the auth record (default to the entry DN).
+	IdAttribute string `form:"idAttribute" json:"idAttribute"`
+
+	// EmailAttribute is the attribute with the user email address.
+	EmailAttribute string `form:"emailAttribute" json:"emailAttribute"`
+
+	// MappedFields defines which directory entry attribute value
+	// should be assigned to the auto created auth record fields (field name => attribute).
+	MappedFields map[string]string `form:"mappedFields" json:"mappedFields"`
+
+	Enabled       bool `form:"enabled" json:"enabled"`
+	StartTLS      bool `form:"startTLS" json:"startTLS"`
+	TLSSkipVerify bool `form:"tlsSkipVerify" json:"tlsSkipVerify"`
+}
+
+// Validate makes LDAPConfig validatable by implementing [validation.Validatable] interface.
+func (c LDAPConfig) Validate() error {
+	if !c.Enabled {
+		return nil // no need to validate
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(
+			&c.URL,
+			validation.Required,
+			validation.Match(regexp.MustCompile(`^ldaps?://`)).Error("Must be a valid ldap:// or ldaps:// url."),
+		),
+		validation.Field(
+			&c.UserDN,
+			validation.When(c.SearchFilter == "", validation.Required),
+			validation.By(checkLDAPUsernamePlaceholder),
+		),
+		validation.Field(&c.BaseDN, validation.When(c.SearchFilter != "", validation.Required)),
+		validation.Field(&c.SearchFilter, validation.By(checkLDAPUsernamePlaceholder)),
+	)
+}
+
+func checkLDAPUsernamePlaceholder(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	if !strings.Contains(v, auth.LDAPUsernamePlaceholder) {
+		return validation.NewError("validation_missing_username_placeholder", "Missing required {{.placeholder}} placeholder.").
+			SetParams(map[string]any{"placeholder": auth.LDAPUsernamePlaceholder})
+	}
+
+	return nil
+}
+
+// InitProvider returns a new auth.LDAP instance loaded with the current LDAPConfig options.
+func (c LDAPConfig) InitProvider() *auth.LDAP {
+	provider := &auth.LDAP{
+		URL:            c.URL,
+		StartTLS:       c.StartTLS,
+		TLSSkipVerify:  c.TLSSkipVerify,
+		BindDN:         c.BindDN,
+		BindPassword:   c.BindPassword,
+		UserDN:         c.UserDN,
+		BaseDN:         c.BaseDN,
+		SearchFilter:   c.SearchFilter,
+		IdAttribute:    c.IdAttribute,
+		EmailAttribute: c.EmailAttribute,
+	}
+
+	for _, attr := range c.MappedFields {
+		provider.Attributes = append(provider.Attributes, attr)
+	}
+
+	return provider
+}
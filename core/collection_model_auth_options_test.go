@@ -317,6 +317,15 @@ func TestCollectionAuthOptionsValidate(t *testing.T) {
 			},
 			expectedErrors: []string{"confirmEmailChangeTemplate"},
 		},
+		{
+			name: "trigger emailLocalization validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.EmailLocalization.DefaultLocale = "invalid locale"
+				return c, nil
+			},
+			expectedErrors: []string{"emailLocalization"},
+		},
 	}
 
 	for _, s := range scenarios {
@@ -582,6 +591,113 @@ func TestOTPConfigValidate(t *testing.T) {
 			},
 			[]string{},
 		},
+		{
+			"magic link (zero value)",
+			core.OTPConfig{
+				Enabled:       true,
+				EmailTemplate: core.EmailTemplate{Body: "a", Subject: "b"},
+				Duration:      100,
+				MagicLink:     true,
+			},
+			[]string{"magicLinkRedirectURL", "magicLinkTemplate"},
+		},
+		{
+			"magic link (invalid redirect url)",
+			core.OTPConfig{
+				Enabled:              true,
+				EmailTemplate:        core.EmailTemplate{Body: "a", Subject: "b"},
+				Duration:             100,
+				MagicLink:            true,
+				MagicLinkRedirectURL: "invalid",
+				MagicLinkTemplate:    core.EmailTemplate{Body: "a", Subject: "b"},
+			},
+			[]string{"magicLinkRedirectURL"},
+		},
+		{
+			"magic link (valid data)",
+			core.OTPConfig{
+				Enabled:              true,
+				EmailTemplate:        core.EmailTemplate{Body: "a", Subject: "b"},
+				Duration:             100,
+				MagicLink:            true,
+				MagicLinkRedirectURL: "https://example.com/login",
+				MagicLinkTemplate:    core.EmailTemplate{Body: "a", Subject: "b"},
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestLDAPConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.LDAPConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.LDAPConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.LDAPConfig{Enabled: true},
+			[]string{"url", "userDN"},
+		},
+		{
+			"invalid url scheme",
+			core.LDAPConfig{
+				Enabled: true,
+				URL:     "https://example.com",
+				UserDN:  "uid={username},dc=example,dc=com",
+			},
+			[]string{"url"},
+		},
+		{
+			"userDN without placeholder",
+			core.LDAPConfig{
+				Enabled: true,
+				URL:     "ldap://example.com",
+				UserDN:  "uid=test,dc=example,dc=com",
+			},
+			[]string{"userDN"},
+		},
+		{
+			"searchFilter without placeholder and baseDN",
+			core.LDAPConfig{
+				Enabled:      true,
+				URL:          "ldap://example.com",
+				SearchFilter: "(uid=test)",
+			},
+			[]string{"baseDN", "searchFilter"},
+		},
+		{
+			"valid direct bind",
+			core.LDAPConfig{
+				Enabled: true,
+				URL:     "ldaps://example.com:636",
+				UserDN:  "uid={username},dc=example,dc=com",
+			},
+			[]string{},
+		},
+		{
+			"valid search bind",
+			core.LDAPConfig{
+				Enabled:      true,
+				URL:          "ldap://example.com",
+				BaseDN:       "dc=example,dc=com",
+				SearchFilter: "(sAMAccountName={username})",
+			},
+			[]string{},
+		},
 	}
 
 	for _, s := range scenarios {
@@ -701,6 +817,21 @@ func TestPasswordAuthConfigValidate(t *testing.T) {
 			core.PasswordAuthConfig{Enabled: true, IdentityFields: []string{"abc"}},
 			[]string{},
 		},
+		{
+			"lockout with zero window and duration",
+			core.PasswordAuthConfig{Enabled: true, IdentityFields: []string{"abc"}, LockoutThreshold: 5},
+			[]string{"lockoutWindow", "lockoutDuration"},
+		},
+		{
+			"lockout with invalid values",
+			core.PasswordAuthConfig{Enabled: true, IdentityFields: []string{"abc"}, LockoutThreshold: 1001, LockoutWindow: 86401, LockoutDuration: -1},
+			[]string{"lockoutThreshold", "lockoutWindow", "lockoutDuration"},
+		},
+		{
+			"lockout with valid values",
+			core.PasswordAuthConfig{Enabled: true, IdentityFields: []string{"abc"}, LockoutThreshold: 5, LockoutWindow: 60, LockoutDuration: 86400},
+			[]string{},
+		},
 	}
 
 	for _, s := range scenarios {
@@ -712,6 +843,101 @@ func TestPasswordAuthConfigValidate(t *testing.T) {
 	}
 }
 
+func TestPasswordPolicyConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.PasswordPolicyConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.PasswordPolicyConfig{},
+			[]string{},
+		},
+		{
+			"invalid values",
+			core.PasswordPolicyConfig{HistorySize: 25, MaxAge: -1, BreachedListFile: strings.Repeat("a", 1001)},
+			[]string{"historySize", "maxAge", "breachedListFile"},
+		},
+		{
+			"valid values",
+			core.PasswordPolicyConfig{RequireDigit: true, HistorySize: 24, MaxAge: 86400, BreachedListFile: "/tmp/test.txt"},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestPasswordAuthConfigLockedUntil(t *testing.T) {
+	now := time.Now()
+
+	attempts := func(ago ...time.Duration) []*core.AuthAttempt {
+		result := make([]*core.AuthAttempt, len(ago))
+		for i, d := range ago {
+			result[i] = &core.AuthAttempt{}
+			result[i].Created, _ = types.ParseDateTime(now.Add(-d))
+		}
+		return result
+	}
+
+	config := core.PasswordAuthConfig{LockoutThreshold: 3, LockoutWindow: 60, LockoutDuration: 120}
+
+	scenarios := []struct {
+		name     string
+		config   core.PasswordAuthConfig
+		attempts []*core.AuthAttempt
+		expected time.Time
+	}{
+		{
+			"disabled lockout",
+			core.PasswordAuthConfig{LockoutWindow: 60, LockoutDuration: 120},
+			attempts(0, 0, 0),
+			time.Time{},
+		},
+		{
+			"less attempts than the threshold",
+			config,
+			attempts(0, 10*time.Second),
+			time.Time{},
+		},
+		{
+			"attempts outside of the window",
+			config,
+			attempts(0, 10*time.Second, 61*time.Second),
+			time.Time{},
+		},
+		{
+			"attempts within the window but with elapsed cooldown",
+			config,
+			attempts(121*time.Second, 130*time.Second, 140*time.Second),
+			time.Time{},
+		},
+		{
+			"locked",
+			config,
+			attempts(10*time.Second, 20*time.Second, 30*time.Second),
+			now.Add(110 * time.Second),
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.LockedUntil(s.attempts)
+
+			if !result.Equal(s.expected) {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+		})
+	}
+}
+
 func TestOAuth2ConfigGetProviderConfig(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -7,6 +7,8 @@ const (
 	EmailPlaceholderToken   string = "{TOKEN}"
 	EmailPlaceholderOTP     string = "{OTP}"
 	EmailPlaceholderOTPId   string = "{OTP_ID}"
+
+	EmailPlaceholderMagicLink string = "{MAGIC_LINK}"
 )
 
 var defaultVerificationTemplate = EmailTemplate{
@@ -62,6 +64,20 @@ var defaultOTPTemplate = EmailTemplate{
 </p>`,
 }
 
+var defaultOTPMagicLinkTemplate = EmailTemplate{
+	Subject: "Login link for " + EmailPlaceholderAppName,
+	Body: `<p>Hello,</p>
+<p>Click on the button below to login to your ` + EmailPlaceholderAppName + ` account.</p>
+<p>
+  <a class="btn" href="` + EmailPlaceholderMagicLink + `" target="_blank" rel="noopener">Login</a>
+</p>
+<p><i>The link works only once and only in the browser where the login was requested. If you didn't ask for the login link, you can ignore this email.</i></p>
+<p>
+  Thanks,<br/>
+  ` + EmailPlaceholderAppName + ` team
+</p>`,
+}
+
 var defaultAuthAlertTemplate = EmailTemplate{
 	Subject: "Login from a new location",
 	Body: `<p>Hello,</p>
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/cron"
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+var (
+	_ Model = (*CronRun)(nil)
+)
+
+const CronRunsTableName = "_cronRuns"
+
+const (
+	CronRunStatusSuccess = "success"
+	CronRunStatusError   = "error"
+	CronRunStatusPanic   = "panic"
+	CronRunStatusTimeout = "timeout"
+)
+
+// CronRun defines a single cron job run stored in the auxiliary app database.
+type CronRun struct {
+	BaseModel
+
+	Started  types.DateTime `db:"started" json:"started"`
+	Ended    types.DateTime `db:"ended" json:"ended"`
+	Job      string         `db:"job" json:"job"`
+	Trigger  string         `db:"trigger" json:"trigger"`
+	Instance string         `db:"instance" json:"instance"`
+	Status   string         `db:"status" json:"status"`
+	Error    string         `db:"error" json:"error"`
+
+	// Duration is the run execution time in milliseconds.
+	Duration float64 `db:"duration" json:"duration"`
+}
+
+func (m *CronRun) TableName() string {
+	return CronRunsTableName
+}
+
+func (app *BaseApp) registerCronRunHooks() {
+	// store each job run in the history
+	app.Cron().OnRun().Bind(&hook.Handler[*cron.JobRunEvent]{
+		Id: "__pbCronRunHistory__",
+		Func: func(e *cron.JobRunEvent) error {
+			started := time.Now()
+
+			runErr := e.Next()
+
+			run := &CronRun{}
+			run.Id = GenerateDefaultRandomId()
+			run.Job = e.Job.Id()
+			run.Trigger = e.Trigger
+			run.Instance = app.instanceId
+			run.Started, _ = types.ParseDateTime(started)
+			run.Ended = types.NowDateTime()
+			run.Duration = float64(time.Since(started).Microseconds()) / 1000
+			run.Status = CronRunStatusSuccess
+
+			if runErr != nil {
+				run.Error = runErr.Error()
+
+				switch {
+				case errors.Is(runErr, cron.ErrJobPanic):
+					run.Status = CronRunStatusPanic
+				case errors.Is(runErr, context.DeadlineExceeded):
+					run.Status = CronRunStatusTimeout
+				default:
+					run.Status = CronRunStatusError
+				}
+
+				app.Logger().Error(
+					"Cron job failed",
+					"jobId", run.Job,
+					"trigger", run.Trigger,
+					"status", run.Status,
+					"error", runErr,
+				)
+			}
+
+			if app.IsBootstrapped() {
+				if err := app.AuxSave(run); err != nil {
+					app.Logger().Warn("Failed to store the cron job run", "jobId", run.Job, "error", err)
+				}
+			}
+
+			return runErr
+		},
+		Priority: -99,
+	})
+
+	// cleanup old cron job runs
+	app.Cron().Add("__pbCronRunsCleanup__", "0 */6 * * *", func() {
+		maxDays := app.Settings().CronHistory.GetMaxDays()
+		if err := app.DeleteOldCronRuns(time.Now().AddDate(0, 0, -maxDays)); err != nil {
+			app.Logger().Warn("Failed to delete old cron job runs", "error", err)
+		}
+	})
+}
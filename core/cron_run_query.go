@@ -0,0 +1,39 @@
+package core
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// CronRunQuery returns a new CronRun select query.
+func (app *BaseApp) CronRunQuery() *dbx.SelectQuery {
+	return app.AuxModelQuery(&CronRun{})
+}
+
+// FindCronRunById returns a single CronRun model by its id.
+func (app *BaseApp) FindCronRunById(id string) (*CronRun, error) {
+	model := &CronRun{}
+
+	err := app.CronRunQuery().
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// DeleteOldCronRuns deletes all cron job runs that are started before startedBefore.
+func (app *BaseApp) DeleteOldCronRuns(startedBefore time.Time) error {
+	formattedDate := startedBefore.UTC().Format(types.DefaultDateLayout)
+	expr := dbx.NewExp("[[started]] <= {:date}", dbx.Params{"date": formattedDate})
+
+	_, err := app.AuxNonconcurrentDB().Delete(CronRunsTableName, expr).Execute()
+
+	return err
+}
@@ -0,0 +1,149 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/cron"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+func TestCronRunHistory(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	jobs := map[string]func(ctx context.Context) error{
+		"test_success": func(ctx context.Context) error { return nil },
+		"test_error":   func(ctx context.Context) error { return errors.New("test") },
+		"test_panic":   func(ctx context.Context) error { panic("test") },
+		"test_timeout": func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	expectedStatuses := map[string]string{
+		"test_success": core.CronRunStatusSuccess,
+		"test_error":   core.CronRunStatusError,
+		"test_panic":   core.CronRunStatusPanic,
+		"test_timeout": core.CronRunStatusTimeout,
+	}
+
+	for id, fn := range jobs {
+		err := app.Cron().AddWithOptions(id, "* * * * *", cron.JobOptions{Timeout: 10 * time.Millisecond}, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, j := range app.Cron().Jobs() {
+		if _, ok := jobs[j.Id()]; ok {
+			j.Run()
+		}
+	}
+
+	for id, status := range expectedStatuses {
+		t.Run(id, func(t *testing.T) {
+			runs := []*core.CronRun{}
+
+			err := app.CronRunQuery().AndWhere(dbx.HashExp{"job": id}).All(&runs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(runs) != 1 {
+				t.Fatalf("Expected 1 run, got %d", len(runs))
+			}
+
+			run := runs[0]
+
+			if run.Status != status {
+				t.Fatalf("Expected status %q, got %q", status, run.Status)
+			}
+
+			if (status == core.CronRunStatusSuccess) != (run.Error == "") {
+				t.Fatalf("Unexpected run error %q", run.Error)
+			}
+
+			if run.Trigger != cron.JobTriggerManual {
+				t.Fatalf("Expected trigger %q, got %q", cron.JobTriggerManual, run.Trigger)
+			}
+
+			if run.Instance == "" || run.Started.IsZero() || run.Ended.Before(run.Started) {
+				t.Fatalf("Expected instance, started and ended to be set, got %v", run)
+			}
+		})
+	}
+}
+
+func TestFindCronRunById(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	run := &core.CronRun{}
+	run.Id = "test_run"
+	run.Job = "test"
+	run.Status = core.CronRunStatusSuccess
+	if err := app.AuxSave(run); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindCronRunById("missing"); err == nil {
+		t.Fatal("Expected error for missing cron run, got nil")
+	}
+
+	found, err := app.FindCronRunById(run.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Job != "test" {
+		t.Fatalf("Expected job %q, got %q", "test", found.Job)
+	}
+}
+
+func TestDeleteOldCronRuns(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := time.Now()
+
+	for i, started := range []time.Time{now.AddDate(0, 0, -10), now.AddDate(0, 0, -2), now} {
+		run := &core.CronRun{}
+		run.Id = "test_run_" + string(rune('a'+i))
+		run.Job = "test"
+		run.Started, _ = types.ParseDateTime(started)
+		run.Ended = run.Started
+		if err := app.AuxSave(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.DeleteOldCronRuns(now.AddDate(0, 0, -5)); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	if err := app.CronRunQuery().Select("count(*)").AndWhere(dbx.HashExp{"job": "test"}).Row(&total); err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 2 {
+		t.Fatalf("Expected 2 remaining runs, got %d", total)
+	}
+
+	if _, err := app.FindCronRunById("test_run_a"); err == nil {
+		t.Fatal("Expected the oldest run to be deleted")
+	}
+}
@@ -158,6 +158,8 @@ const (
 	RequestInfoContextOAuth2        = "oauth2"
 	RequestInfoContextOTP           = "otp"
 	RequestInfoContextPasswordAuth  = "password"
+	RequestInfoContextLDAP          = "ldap"
+	RequestInfoContextSCIM          = "scim"
 )
 
 // RequestInfo defines a HTTP request data struct, usually used
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -89,6 +90,19 @@ type TerminateEvent struct {
 	IsRestart bool
 }
 
+type PubSubMessageEvent struct {
+	hook.Event
+	App        App
+	InstanceId string // the id of the publisher app instance
+	Topic      string
+	Payload    json.RawMessage
+}
+
+// Tags implements the [hook.Tagger] interface.
+func (e *PubSubMessageEvent) Tags() []string {
+	return []string{e.Topic}
+}
+
 type BackupEvent struct {
 	hook.Event
 	App     App
@@ -436,6 +450,14 @@ type RealtimeSubscribeRequestEvent struct {
 	Subscriptions []string
 }
 
+type RealtimeClientMessageEvent struct {
+	hook.Event
+	*RequestEvent
+
+	Client  subscriptions.Client
+	Message *subscriptions.Message
+}
+
 // -------------------------------------------------------------------
 // Record CRUD API events data
 // -------------------------------------------------------------------
@@ -488,6 +510,41 @@ type RecordAuthWithOTPRequestEvent struct {
 	OTP    *OTP
 }
 
+type OAuth2AuthorizeRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Client      *OAuth2Client
+	Record      *Record
+	Scopes      []string
+	RedirectURI string
+	State       string
+	Approved    bool
+}
+
+type OAuth2TokenRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Client        *OAuth2Client
+	Code          *OAuth2Code
+	Record        *Record
+	IdTokenClaims map[string]any
+}
+
+type RecordAuthWithLDAPRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record      *Record
+	LDAPUser    *auth.AuthUser
+	CreateData  map[string]any
+	IsNewRecord bool
+}
+
 type RecordAuthRequestEvent struct {
 	hook.Event
 	*RequestEvent
@@ -133,6 +133,13 @@ type FileField struct {
 
 	// Required will require the field value to have at least one file.
 	Required bool `form:"required" json:"required"`
+
+	// Dedupe stores the new uploaded files by their content hash so that
+	// identical files attached to multiple records are stored only once.
+	//
+	// The record file names and urls remain the same and the shared
+	// content is deleted when the last referencing record file is removed.
+	Dedupe bool `form:"dedupe" json:"dedupe"`
 }
 
 // Type implements [Field.Type] interface method.
@@ -385,6 +392,15 @@ func (f *FileField) Intercept(
 			}
 		}
 
+		return actionFunc()
+	case InterceptorActionAfterDelete:
+		// note: the record dir files are deleted by the app FileManager hook
+		// but the shared deduplicated blobs are not part of it
+		err := f.releaseDeletedRecordFileRefs(newContextIfInvalid(ctx), app, record)
+		if err != nil {
+			app.Logger().Warn("Failed to release the deleted record file refs", "error", err)
+		}
+
 		return actionFunc()
 	case InterceptorActionAfterCreate, InterceptorActionAfterUpdate:
 		record.SetRaw(uploadedFilesPrefix+f.Name, nil)
@@ -427,6 +443,12 @@ func (f *FileField) afterRecordExecuteSuccess(ctx context.Context, app App, reco
 }
 
 func (f *FileField) afterRecordExecuteFailure(ctx context.Context, app App, record *Record) error {
+	// the new deduplicated file refs are reverted together with the transaction
+	// (the blobs are not deleted because they could be shared with other records)
+	if _, insideTransaction := app.DB().(*dbx.Tx); insideTransaction && f.Dedupe {
+		return nil
+	}
+
 	uploaded := f.extractUploadableFiles(f.toSliceValue(record.GetRaw(f.Name)))
 
 	toDelete := make([]string, len(uploaded))
@@ -523,6 +545,10 @@ func (f *FileField) processFilesToUpload(ctx context.Context, app App, record *R
 	defer fsys.Close()
 	fsys.SetContext(ctx)
 
+	if f.Dedupe {
+		return f.processDedupedFilesToUpload(app, fsys, record, uploads)
+	}
+
 	var failed []error     // list of upload errors
 	var succeeded []string // list of uploaded file names
 
@@ -548,6 +574,43 @@ func (f *FileField) processFilesToUpload(ctx context.Context, app App, record *R
 	return nil
 }
 
+// processDedupedFilesToUpload stores the new files by their content hash
+// (skipping the already existing blobs) and references them to the record.
+//
+// Successfully uploaded blobs are not deleted on failure because they
+// could be shared with other records.
+func (f *FileField) processDedupedFilesToUpload(app App, fsys *filesystem.System, record *Record, uploads []*filesystem.File) error {
+	deduped := make([]dedupedFile, 0, len(uploads))
+
+	for _, upload := range uploads {
+		hash, err := hashFileContent(upload)
+		if err != nil {
+			return fmt.Errorf("failed to hash file %q: %w", upload.Name, err)
+		}
+
+		key := FileBlobKey(hash)
+
+		exists, err := fsys.Exists(key)
+		if err != nil {
+			return fmt.Errorf("failed to check file %q blob: %w", upload.Name, err)
+		}
+
+		if !exists {
+			if err := fsys.UploadFile(upload, key); err != nil {
+				return fmt.Errorf("failed to upload file %q: %w", upload.Name, err)
+			}
+		}
+
+		deduped = append(deduped, dedupedFile{name: upload.Name, hash: hash, size: upload.Size})
+	}
+
+	if err := addRecordFileRefs(app, record, deduped); err != nil {
+		return fmt.Errorf("failed to store the file refs: %w", err)
+	}
+
+	return nil
+}
+
 func (f *FileField) deleteNewlyUploadedFiles(ctx context.Context, app App, record *Record) ([]string, error) {
 	uploaded, _ := record.GetRaw(uploadedFilesPrefix + f.Name).([]*filesystem.File)
 	if len(uploaded) == 0 {
@@ -587,6 +650,15 @@ func (f *FileField) deleteFilesByNamesList(ctx context.Context, app App, record
 	defer fsys.Close()
 	fsys.SetContext(ctx)
 
+	// release the deduplicated files blobs (if any)
+	//
+	// note: checked regardless of the Dedupe option because it could have been
+	// changed after the files upload (the record path delete below is no-op for them)
+	err = releaseRecordFileRefs(app, fsys, record, filenames)
+	if err != nil {
+		return filenames, fmt.Errorf("failed to release the file refs: %w", err)
+	}
+
 	var failures []error
 
 	for i := len(filenames) - 1; i >= 0; i-- {
@@ -620,6 +692,23 @@ func (f *FileField) deleteFilesByNamesList(ctx context.Context, app App, record
 	return nil, nil
 }
 
+// releaseDeletedRecordFileRefs releases the deduplicated file blobs of a deleted record.
+func (f *FileField) releaseDeletedRecordFileRefs(ctx context.Context, app App, record *Record) error {
+	filenames := f.extractPlainStrings(f.toSliceValue(record.GetRaw(f.Name)))
+	if len(filenames) == 0 || record.Collection().IsView() {
+		return nil
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+	fsys.SetContext(ctx)
+
+	return releaseRecordFileRefs(app, fsys, record, filenames)
+}
+
 // newContextIfInvalid returns a new Background context if the provided one was cancelled.
 func newContextIfInvalid(ctx context.Context) context.Context {
 	if ctx.Err() == nil {
@@ -201,6 +201,11 @@ func (f *PasswordField) ValidateValue(ctx context.Context, app App, record *Reco
 		}
 	}
 
+	// apply the auth collection password policy (if any)
+	if f.Name == FieldNamePassword && record.Collection().IsAuth() {
+		return validatePasswordPolicy(app, record, fp.Plain)
+	}
+
 	return nil
 }
 
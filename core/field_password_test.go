@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/tests"
 	"golang.org/x/crypto/bcrypt"
@@ -284,6 +287,144 @@ func TestPasswordFieldValidateValue(t *testing.T) {
 	}
 }
 
+func TestPasswordFieldValidateValuePasswordPolicy(t *testing.T) {
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	breachedFile := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(breachedFile, []byte(
+		"# sha1 hashes\n"+
+			"7C4A8D09CA3762AF61E59520943DC26494F8941B:123\n"+ // "123456"
+			"1234\n", // partial hash (ignored)
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name          string
+		policy        core.PasswordPolicyConfig
+		password      string
+		expectedError string
+	}{
+		{
+			"no policy",
+			core.PasswordPolicyConfig{},
+			"1234567890",
+			"",
+		},
+		{
+			"missing lowercase",
+			core.PasswordPolicyConfig{RequireLowercase: true},
+			"ABC123456!",
+			"validation_password_lowercase_required",
+		},
+		{
+			"missing uppercase",
+			core.PasswordPolicyConfig{RequireUppercase: true},
+			"abc123456!",
+			"validation_password_uppercase_required",
+		},
+		{
+			"missing digit",
+			core.PasswordPolicyConfig{RequireDigit: true},
+			"abcDEFGHI!",
+			"validation_password_digit_required",
+		},
+		{
+			"missing symbol",
+			core.PasswordPolicyConfig{RequireSymbol: true},
+			"abcDEF1234",
+			"validation_password_symbol_required",
+		},
+		{
+			"all char classes",
+			core.PasswordPolicyConfig{RequireLowercase: true, RequireUppercase: true, RequireDigit: true, RequireSymbol: true},
+			"abcDEF123!",
+			"",
+		},
+		{
+			"containing the email local part",
+			core.PasswordPolicyConfig{DisallowIdentity: true},
+			"abc_TEST123",
+			"validation_password_contains_identity",
+		},
+		{
+			"containing the username",
+			core.PasswordPolicyConfig{DisallowIdentity: true},
+			"abc_Users75657",
+			"validation_password_contains_identity",
+		},
+		{
+			"not containing identity values",
+			core.PasswordPolicyConfig{DisallowIdentity: true},
+			"1234567890",
+			"",
+		},
+		{
+			"reused password",
+			core.PasswordPolicyConfig{HistorySize: 2},
+			"1234567890",
+			"validation_password_reused",
+		},
+		{
+			"not reused password",
+			core.PasswordPolicyConfig{HistorySize: 2},
+			"1234567891",
+			"",
+		},
+		{
+			"breached password",
+			core.PasswordPolicyConfig{BreachedListFile: breachedFile},
+			"123456",
+			"validation_password_breached",
+		},
+		{
+			"not breached password",
+			core.PasswordPolicyConfig{BreachedListFile: breachedFile},
+			"1234567",
+			"",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			record, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			record.Collection().PasswordPolicy = s.policy
+
+			if err := app.CreatePasswordHistory(record, record.GetString("password:hash")); err != nil {
+				t.Fatal(err)
+			}
+
+			record.SetPassword(s.password)
+
+			field := record.Collection().Fields.GetByName(core.FieldNamePassword)
+
+			err = field.ValidateValue(context.Background(), app, record)
+
+			if s.expectedError == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr validation.Error
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected validation.Error, got %v", err)
+			}
+
+			if validationErr.Code() != s.expectedError {
+				t.Fatalf("Expected error code %q, got %q", s.expectedError, validationErr.Code())
+			}
+		})
+	}
+}
+
 func TestPasswordFieldValidateSettings(t *testing.T) {
 	testDefaultFieldIdValidation(t, core.FieldTypePassword)
 	testDefaultFieldNameValidation(t, core.FieldTypePassword)
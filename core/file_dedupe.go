@@ -0,0 +1,331 @@
+package core
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+const (
+	FileBlobsTableName = "_fileBlobs"
+	FileRefsTableName  = "_fileRefs"
+)
+
+// fileRefsStoreKeyPrefix is the app store key prefix of the cached collection file refs check.
+const fileRefsStoreKeyPrefix = "@fileRefs_"
+
+// fileBlobsStorageDir is the storage dir where the deduplicated file blobs are stored.
+//
+// The dir starts with a dot to prevent collisions with the collections storage dirs.
+const fileBlobsStorageDir = ".blobs"
+
+// FileBlobKey returns the storage key of the deduplicated file blob with the specified content hash.
+func FileBlobKey(hash string) string {
+	prefix := hash
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+
+	return fileBlobsStorageDir + "/" + prefix + "/" + hash
+}
+
+// FindRecordFileKey returns the storage key of the specified record file.
+//
+// For the files of deduplicated file fields (see [FileField.Dedupe])
+// the key of the referenced content blob is returned, otherwise
+// fallbacks to the regular record file path (record.BaseFilesPath()+"/"+filename).
+//
+// The file references are looked up only for collections that have
+// (or had in the past) a deduplicated file field.
+func (app *BaseApp) FindRecordFileKey(record *Record, filename string) (string, error) {
+	hasRefs, err := app.collectionHasFileRefs(record.Collection())
+	if err != nil {
+		return "", err
+	}
+
+	if !hasRefs {
+		return record.BaseFilesPath() + "/" + filename, nil
+	}
+
+	var hash string
+
+	err = app.DB().Select("hash").
+		From(FileRefsTableName).
+		Where(dbx.HashExp{
+			"collectionRef": record.Collection().Id,
+			"recordRef":     record.Id,
+			"filename":      filename,
+		}).
+		Limit(1).
+		Row(&hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record.BaseFilesPath() + "/" + filename, nil
+		}
+		return "", err
+	}
+
+	return FileBlobKey(hash), nil
+}
+
+// collectionFileRefsState is the cached result of a collection file refs check.
+type collectionFileRefsState struct {
+	updated types.DateTime
+	hasRefs bool
+}
+
+// collectionHasFileRefs reports whether the collection records could have
+// deduplicated files, aka. whether the collection has a deduplicated file
+// field or it has stored file refs from a previously deduplicated field.
+//
+// The file refs check result is cached until the next collection change
+// (the refs could be created only while the Dedupe option is enabled).
+func (app *BaseApp) collectionHasFileRefs(collection *Collection) (bool, error) {
+	for _, f := range collection.Fields {
+		if ff, ok := f.(*FileField); ok && ff.Dedupe {
+			return true, nil
+		}
+	}
+
+	storeKey := fileRefsStoreKeyPrefix + collection.Id
+
+	state, ok := app.Store().Get(storeKey).(collectionFileRefsState)
+	if ok && state.updated.Equal(collection.Updated) {
+		return state.hasRefs, nil
+	}
+
+	var exists int
+	err := app.DB().Select("(1)").
+		From(FileRefsTableName).
+		Where(dbx.HashExp{"collectionRef": collection.Id}).
+		Limit(1).
+		Row(&exists)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	state = collectionFileRefsState{updated: collection.Updated, hasRefs: exists > 0}
+	app.Store().Set(storeKey, state)
+
+	return state.hasRefs, nil
+}
+
+// hashFileContent returns the hex encoded SHA-256 hash of the file content.
+func hashFileContent(file *filesystem.File) (string, error) {
+	r, err := file.Reader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dedupedFile holds the content hash of a new deduplicated record file.
+type dedupedFile struct {
+	name string
+	hash string
+	size int64
+}
+
+// addRecordFileRefs stores the references of the specified record files
+// to their content blobs and increments the blobs reference counters.
+//
+// All references are inserted with a single statement so that
+// a failure doesn't leave partially stored references.
+func addRecordFileRefs(app App, record *Record, files []dedupedFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	params := dbx.Params{
+		"collectionRef": record.Collection().Id,
+		"recordRef":     record.Id,
+	}
+
+	// note: the same content could be uploaded more than once and
+	// ON CONFLICT cannot update the same row twice in a single statement
+	counts := map[string]int{}
+	sizes := map[string]int64{}
+	hashes := []string{}
+	for _, f := range files {
+		if _, ok := counts[f.hash]; !ok {
+			hashes = append(hashes, f.hash)
+		}
+		counts[f.hash]++
+		sizes[f.hash] = f.size
+	}
+
+	blobValues := make([]string, len(hashes))
+	for i, hash := range hashes {
+		suffix := strconv.Itoa(i)
+		blobValues[i] = fmt.Sprintf("({:bh%s}, {:bs%s}, {:bc%s})", suffix, suffix, suffix)
+		params["bh"+suffix] = hash
+		params["bs"+suffix] = sizes[hash]
+		params["bc"+suffix] = counts[hash]
+	}
+
+	refValues := make([]string, len(files))
+	for i, f := range files {
+		suffix := strconv.Itoa(i)
+		refValues[i] = fmt.Sprintf("({:collectionRef}, {:recordRef}, {:rn%s}, {:rh%s})", suffix, suffix)
+		params["rn"+suffix] = f.name
+		params["rh"+suffix] = f.hash
+	}
+
+	_, err := app.NonconcurrentDB().NewQuery(fmt.Sprintf(`
+		WITH blobs AS (
+			INSERT INTO {{%s}} ([[hash]], [[size]], [[refs]]) VALUES %s
+			ON CONFLICT ([[hash]]) DO UPDATE SET
+				[[refs]] = {{%s}}.[[refs]] + EXCLUDED.[[refs]],
+				[[updated]] = CURRENT_TIMESTAMP
+		)
+		INSERT INTO {{%s}} ([[collectionRef]], [[recordRef]], [[filename]], [[hash]]) VALUES %s
+	`,
+		FileBlobsTableName, strings.Join(blobValues, ", "), FileBlobsTableName,
+		FileRefsTableName, strings.Join(refValues, ", "),
+	)).Bind(params).Execute()
+
+	return err
+}
+
+// releaseRecordFileRefs releases the blob references of the specified
+// record files (if any) and deletes the no longer referenced blobs.
+func releaseRecordFileRefs(app App, fsys *filesystem.System, record *Record, filenames []string) error {
+	if len(filenames) == 0 {
+		return nil
+	}
+
+	params := dbx.Params{
+		"collectionRef": record.Collection().Id,
+		"recordRef":     record.Id,
+	}
+
+	placeholders := make([]string, len(filenames))
+	for i, name := range filenames {
+		key := "f" + strconv.Itoa(i)
+		placeholders[i] = "{:" + key + "}"
+		params[key] = name
+	}
+
+	return releaseFileRefs(
+		app,
+		fsys,
+		"[[collectionRef]] = {:collectionRef} AND [[recordRef]] = {:recordRef} AND [[filename]] IN ("+strings.Join(placeholders, ", ")+")",
+		params,
+	)
+}
+
+// releaseCollectionFileRefs releases all blob references of the
+// specified collection records and deletes the no longer referenced blobs.
+func releaseCollectionFileRefs(app App, fsys *filesystem.System, collectionId string) error {
+	return releaseFileRefs(app, fsys, "[[collectionRef]] = {:collectionRef}", dbx.Params{"collectionRef": collectionId})
+}
+
+// releaseFileRefs deletes the file references matching the provided
+// raw where condition, decrements the related blobs reference counters
+// and deletes the blobs that are no longer referenced.
+func releaseFileRefs(app App, fsys *filesystem.System, where string, params dbx.Params) error {
+	unreferenced := []string{}
+
+	err := app.NonconcurrentDB().NewQuery(fmt.Sprintf(`
+		WITH refs AS (
+			DELETE FROM {{%s}} WHERE %s RETURNING [[hash]]
+		), counts AS (
+			SELECT [[hash]], COUNT(*) AS [[n]] FROM refs GROUP BY [[hash]]
+		), blobs AS (
+			UPDATE {{%s}} SET [[refs]] = {{%s}}.[[refs]] - counts.[[n]]
+			FROM counts
+			WHERE {{%s}}.[[hash]] = counts.[[hash]]
+			RETURNING {{%s}}.[[hash]], {{%s}}.[[refs]]
+		)
+		SELECT [[hash]] FROM blobs WHERE [[refs]] <= 0
+	`,
+		FileRefsTableName, where,
+		FileBlobsTableName, FileBlobsTableName, FileBlobsTableName, FileBlobsTableName, FileBlobsTableName,
+	)).Bind(params).Column(&unreferenced)
+	if err != nil {
+		return err
+	}
+
+	return deleteUnreferencedFileBlobs(app, fsys, unreferenced)
+}
+
+// deleteUnreferencedFileBlobs deletes the blobs with the specified
+// hashes that have no more references (aka. refs <= 0).
+//
+// Blobs that were referenced again in the meantime are skipped.
+//
+// Note that similar to the other storage operations this is not atomic,
+// aka. a blob that is concurrently referenced again right after its
+// row deletion could still have its storage object deleted.
+func deleteUnreferencedFileBlobs(app App, fsys *filesystem.System, hashes []string) error {
+	var errs []error
+
+	for _, hash := range hashes {
+		var deleted string
+
+		err := app.NonconcurrentDB().NewQuery(fmt.Sprintf(
+			"DELETE FROM {{%s}} WHERE [[hash]] = {:hash} AND [[refs]] <= 0 RETURNING [[hash]]",
+			FileBlobsTableName,
+		)).Bind(dbx.Params{"hash": hash}).Row(&deleted)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				errs = append(errs, err)
+			}
+			continue // still referenced
+		}
+
+		err = fsys.Delete(FileBlobKey(hash))
+		if err != nil && !errors.Is(err, filesystem.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("blob %q: %w", hash, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (app *BaseApp) registerFileDedupeHooks() {
+	// release the deduplicated file blobs of the deleted collection records
+	// (the records are not deleted one by one with the collection table)
+	app.OnCollectionAfterDeleteSuccess().Bind(&hook.Handler[*CollectionEvent]{
+		Id: "__pbFileDedupeCollectionDelete__",
+		Func: func(e *CollectionEvent) error {
+			if e.Collection.IsView() {
+				return e.Next()
+			}
+
+			fsys, err := e.App.NewFilesystem()
+			if err != nil {
+				return err
+			}
+			defer fsys.Close()
+
+			if err := releaseCollectionFileRefs(e.App, fsys, e.Collection.Id); err != nil {
+				e.App.Logger().Warn(
+					"Failed to release the deleted collection file refs",
+					"collectionId", e.Collection.Id,
+					"error", err,
+				)
+			}
+
+			return e.Next()
+		},
+		Priority: -99,
+	})
+}
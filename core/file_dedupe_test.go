@@ -0,0 +1,202 @@
+package core_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
+)
+
+func TestFileBlobKey(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		hash     string
+		expected string
+	}{
+		{"", ".blobs//"},
+		{"a", ".blobs/a/a"},
+		{"abcdef", ".blobs/ab/abcdef"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.hash, func(t *testing.T) {
+			if key := core.FileBlobKey(s.hash); key != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, key)
+			}
+		})
+	}
+}
+
+func TestFileFieldDedupe(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	demo1, err := testApp.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	demo1.Fields.GetByName("file_many").(*core.FileField).Dedupe = true
+	if err := testApp.Save(demo1); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("dedupe test content")
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	blobKey := core.FileBlobKey(hash)
+
+	newFile := func(name string) *filesystem.File {
+		f, err := filesystem.NewFileFromBytes(content, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	// create 2 records with the same file content
+	f1 := newFile("a.txt")
+	f2 := newFile("b.txt")
+	f3 := newFile("c.txt")
+
+	record1 := core.NewRecord(demo1)
+	record1.Set("text", "test1")
+	record1.Set("file_many", []any{f1, f2}) // duplicated content in the same record
+	if err := testApp.Save(record1); err != nil {
+		t.Fatal(err)
+	}
+
+	record2 := core.NewRecord(demo1)
+	record2.Set("text", "test2")
+	record2.Set("file_many", []any{f3})
+	if err := testApp.Save(record2); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFileBlobRefs(t, testApp, hash, 3)
+	assertStorageFileExists(t, testApp, blobKey, true)
+
+	// the record files should not be stored separately
+	assertStorageFileExists(t, testApp, record1.BaseFilesPath()+"/"+f1.Name, false)
+
+	for _, name := range []string{f1.Name, f2.Name} {
+		key, err := testApp.FindRecordFileKey(record1, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != blobKey {
+			t.Fatalf("Expected %q key %q, got %q", name, blobKey, key)
+		}
+	}
+
+	// non-deduped file
+	key, err := testApp.FindRecordFileKey(record1, "missing.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := record1.BaseFilesPath() + "/missing.txt"; key != expected {
+		t.Fatalf("Expected the regular record file key %q, got %q", expected, key)
+	}
+
+	// disabled dedupe option after the files upload
+	demo1.Fields.GetByName("file_many").(*core.FileField).Dedupe = false
+	if err := testApp.Save(demo1); err != nil {
+		t.Fatal(err)
+	}
+	key, err = testApp.FindRecordFileKey(record1, f1.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != blobKey {
+		t.Fatalf("Expected the blob key %q after disabling the dedupe option, got %q", blobKey, key)
+	}
+
+	// collection that never had a deduplicated file field
+	demo2Record, err := testApp.FindRecordById("demo2", "0yxhwia2amd8gec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err = testApp.FindRecordFileKey(demo2Record, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := demo2Record.BaseFilesPath() + "/test.txt"; key != expected {
+		t.Fatalf("Expected the regular record file key %q, got %q", expected, key)
+	}
+
+	// remove a single record file
+	record1.Set("file_many-", f1.Name)
+	if err := testApp.Save(record1); err != nil {
+		t.Fatal(err)
+	}
+	assertFileBlobRefs(t, testApp, hash, 2)
+	assertStorageFileExists(t, testApp, blobKey, true)
+
+	// delete the first record
+	if err := testApp.Delete(record1); err != nil {
+		t.Fatal(err)
+	}
+	assertFileBlobRefs(t, testApp, hash, 1)
+	assertStorageFileExists(t, testApp, blobKey, true)
+
+	// remove the last referencing record file
+	record2.Set("file_many", nil)
+	if err := testApp.Save(record2); err != nil {
+		t.Fatal(err)
+	}
+	assertFileBlobRefs(t, testApp, hash, 0)
+	assertStorageFileExists(t, testApp, blobKey, false)
+}
+
+func assertFileBlobRefs(t *testing.T, app core.App, hash string, expected int) {
+	var refs int
+
+	err := app.DB().Select("refs").
+		From(core.FileBlobsTableName).
+		Where(dbx.HashExp{"hash": hash}).
+		Row(&refs)
+	if err != nil && expected != 0 {
+		t.Fatalf("Failed to fetch the blob refs: %v", err)
+	}
+
+	if refs != expected {
+		t.Fatalf("Expected %d blob refs, got %d", expected, refs)
+	}
+
+	var total int
+
+	err = app.DB().Select("count(*)").
+		From(core.FileRefsTableName).
+		Where(dbx.HashExp{"hash": hash}).
+		Row(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != expected {
+		t.Fatalf("Expected %d stored file refs, got %d", expected, total)
+	}
+}
+
+func assertStorageFileExists(t *testing.T, app core.App, key string, expected bool) {
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	exists, err := fsys.Exists(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exists != expected {
+		t.Fatalf("Expected %q exists to be %v, got %v", key, expected, exists)
+	}
+}
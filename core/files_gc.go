@@ -0,0 +1,354 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
+	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
+	"github.com/thewandererbg/pgbase/tools/list"
+)
+
+// DefaultFilesGCGracePeriod is the default min age of an orphaned
+// storage file before it is deleted by the scheduled files gc.
+const DefaultFilesGCGracePeriod = 24 * time.Hour
+
+// filesGCBatchSize is the max number of records (or blobs) to check with a single query.
+const filesGCBatchSize = 200
+
+// FilesGCOptions defines the options of a single files garbage collection run.
+type FilesGCOptions struct {
+	// GracePeriod is the min age of an orphaned file before it is reported and deleted.
+	//
+	// It prevents deleting the files of in-progress record saves
+	// (the new files are uploaded before the record is persisted).
+	//
+	// Zero value means that all orphaned files are collected regardless of their age.
+	GracePeriod time.Duration
+
+	// DryRun reports the orphaned files without deleting them.
+	DryRun bool
+}
+
+// FilesGCResult holds the result of a single files garbage collection run.
+type FilesGCResult struct {
+	// Orphans lists the storage keys of the record files that
+	// are not part of any record file field value.
+	Orphans []string `json:"orphans"`
+
+	// Thumbs lists the storage keys of the thumbs (and transformed images)
+	// whose original file is no longer part of the record.
+	Thumbs []string `json:"thumbs"`
+
+	// Blobs lists the storage keys of the deduplicated file blobs
+	// that are no longer referenced by any record file.
+	Blobs []string `json:"blobs"`
+
+	// Size is the total size in bytes of all collected files.
+	Size int64 `json:"size"`
+
+	// Deleted is the number of the successfully deleted files (always 0 for dry runs).
+	Deleted int `json:"deleted"`
+}
+
+// Total returns the total number of the collected files.
+func (r *FilesGCResult) Total() int {
+	return len(r.Orphans) + len(r.Thumbs) + len(r.Blobs)
+}
+
+// FilesGC walks the storage files of all non-view collections and collects
+// (and deletes, unless opts.DryRun is set) the files that are no longer
+// referenced in the database - record files that are not part of any
+// file field value, thumbs without original and unreferenced deduplicated blobs.
+//
+// The files of unknown (e.g. deleted) collections are not walked.
+func (app *BaseApp) FilesGC(ctx context.Context, opts FilesGCOptions) (*FilesGCResult, error) {
+	collections, err := app.FindAllCollections(CollectionTypeBase, CollectionTypeAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+	defer fsys.Close()
+	fsys.SetContext(ctx)
+
+	result := &FilesGCResult{
+		Orphans: []string{},
+		Thumbs:  []string{},
+		Blobs:   []string{},
+	}
+
+	var cutoff time.Time
+	if opts.GracePeriod > 0 {
+		cutoff = time.Now().Add(-opts.GracePeriod)
+	}
+
+	for _, collection := range collections {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if err := app.collectCollectionOrphanedFiles(fsys, collection, cutoff, result); err != nil {
+			return result, fmt.Errorf("failed to collect the %q orphaned files: %w", collection.Name, err)
+		}
+	}
+
+	if err := app.collectOrphanedFileBlobs(fsys, cutoff, result); err != nil {
+		return result, fmt.Errorf("failed to collect the orphaned file blobs: %w", err)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	var errs []error
+
+	for _, keys := range [][]string{result.Orphans, result.Thumbs, result.Blobs} {
+		for _, key := range keys {
+			err := fsys.Delete(key)
+			if err != nil && !errors.Is(err, filesystem.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("%q: %w", key, err))
+				continue
+			}
+
+			result.Deleted++
+		}
+	}
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("failed to delete %d orphaned file(s): %w", len(errs), errors.Join(errs...))
+	}
+
+	return result, nil
+}
+
+// gcRecordFile represents a single record storage file.
+type gcRecordFile struct {
+	obj      *blob.ListObject
+	recordId string
+	filename string // the original file name (for thumbs it is the name of the thumb original)
+	isThumb  bool
+}
+
+func (app *BaseApp) collectCollectionOrphanedFiles(
+	fsys *filesystem.System,
+	collection *Collection,
+	cutoff time.Time,
+	result *FilesGCResult,
+) error {
+	prefix := collection.BaseFilesPath() + "/"
+
+	objects, err := fsys.List(prefix)
+	if err != nil {
+		return err
+	}
+
+	files := make([]*gcRecordFile, 0, len(objects))
+	recordIds := []string{}
+
+	for _, obj := range objects {
+		if obj.IsDir || isWithinGracePeriod(obj, cutoff) {
+			continue
+		}
+
+		// <recordId>/<filename> or <recordId>/thumbs_<filename>/<thumb>
+		parts := strings.Split(strings.TrimPrefix(obj.Key, prefix), "/")
+
+		file := &gcRecordFile{obj: obj, recordId: parts[0]}
+
+		switch {
+		case len(parts) == 2:
+			file.filename = parts[1]
+		case len(parts) == 3 && strings.HasPrefix(parts[1], "thumbs_"):
+			file.filename = strings.TrimPrefix(parts[1], "thumbs_")
+			file.isThumb = true
+		default:
+			// unknown file location
+			result.Orphans = append(result.Orphans, obj.Key)
+			result.Size += obj.Size
+			continue
+		}
+
+		files = append(files, file)
+		recordIds = append(recordIds, file.recordId)
+	}
+
+	referenced, err := app.findRecordsFilenames(collection, list.ToUniqueStringSlice(recordIds))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if _, ok := referenced[file.recordId][file.filename]; ok {
+			continue
+		}
+
+		if file.isThumb {
+			result.Thumbs = append(result.Thumbs, file.obj.Key)
+		} else {
+			result.Orphans = append(result.Orphans, file.obj.Key)
+		}
+		result.Size += file.obj.Size
+	}
+
+	return nil
+}
+
+// findRecordsFilenames returns the file field values of the
+// specified collection records grouped by their record id.
+func (app *BaseApp) findRecordsFilenames(collection *Collection, recordIds []string) (map[string]map[string]struct{}, error) {
+	result := make(map[string]map[string]struct{}, len(recordIds))
+
+	var fileFields []string
+	for _, field := range collection.Fields {
+		if field.Type() == FieldTypeFile {
+			fileFields = append(fileFields, field.GetName())
+		}
+	}
+
+	if len(fileFields) == 0 {
+		return result, nil // no record could have files
+	}
+
+	for i := 0; i < len(recordIds); i += filesGCBatchSize {
+		batch := recordIds[i:min(i+filesGCBatchSize, len(recordIds))]
+
+		records, err := app.FindRecordsByIds(collection, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			filenames := map[string]struct{}{}
+
+			for _, name := range fileFields {
+				for _, filename := range record.GetStringSlice(name) {
+					filenames[filename] = struct{}{}
+				}
+			}
+
+			result[record.Id] = filenames
+		}
+	}
+
+	return result, nil
+}
+
+func (app *BaseApp) collectOrphanedFileBlobs(fsys *filesystem.System, cutoff time.Time, result *FilesGCResult) error {
+	objects, err := fsys.List(fileBlobsStorageDir + "/")
+	if err != nil {
+		return err
+	}
+
+	blobs := make(map[string]*blob.ListObject, len(objects))
+	hashes := make([]string, 0, len(objects))
+
+	for _, obj := range objects {
+		if obj.IsDir || isWithinGracePeriod(obj, cutoff) {
+			continue
+		}
+
+		hash := obj.Key[strings.LastIndex(obj.Key, "/")+1:]
+		if FileBlobKey(hash) != obj.Key {
+			// unknown file location
+			result.Blobs = append(result.Blobs, obj.Key)
+			result.Size += obj.Size
+			continue
+		}
+
+		blobs[hash] = obj
+		hashes = append(hashes, hash)
+	}
+
+	for i := 0; i < len(hashes); i += filesGCBatchSize {
+		batch := hashes[i:min(i+filesGCBatchSize, len(hashes))]
+
+		referenced := []string{}
+
+		err := app.DB().Select("hash").
+			From(FileBlobsTableName).
+			Where(dbx.In("hash", list.ToInterfaceSlice(batch)...)).
+			AndWhere(dbx.NewExp("[[refs]] > 0")).
+			Column(&referenced)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range batch {
+			if !list.ExistInSlice(hash, referenced) {
+				result.Blobs = append(result.Blobs, blobs[hash].Key)
+				result.Size += blobs[hash].Size
+			}
+		}
+	}
+
+	return nil
+}
+
+// isWithinGracePeriod reports whether the object was modified after the cutoff time.
+func isWithinGracePeriod(obj *blob.ListObject, cutoff time.Time) bool {
+	return !cutoff.IsZero() && obj.ModTime.After(cutoff)
+}
+
+func (app *BaseApp) registerFilesGCHooks() {
+	const jobId = "__pbFilesGC__"
+
+	loadJob := func() {
+		rawSchedule := app.Settings().FilesGC.Cron
+		if rawSchedule == "" {
+			app.Cron().Remove(jobId)
+			return
+		}
+
+		app.Cron().Add(jobId, rawSchedule, func() {
+			result, err := app.FilesGC(context.Background(), FilesGCOptions{
+				GracePeriod: app.Settings().FilesGC.GetGracePeriod(),
+			})
+			if err != nil {
+				app.Logger().Error(
+					"[Files GC cron] Failed to collect the orphaned files",
+					slog.String("error", err.Error()),
+				)
+			}
+
+			if result != nil && result.Total() > 0 {
+				app.Logger().Info(
+					"[Files GC cron] Deleted orphaned files",
+					slog.Int("orphans", len(result.Orphans)),
+					slog.Int("thumbs", len(result.Thumbs)),
+					slog.Int("blobs", len(result.Blobs)),
+					slog.Int("deleted", result.Deleted),
+					slog.Int64("size", result.Size),
+				)
+			}
+		})
+	}
+
+	app.OnBootstrap().BindFunc(func(e *BootstrapEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		loadJob()
+
+		return nil
+	})
+
+	app.OnSettingsReload().BindFunc(func(e *SettingsReloadEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		loadJob()
+
+		return nil
+	})
+}
@@ -0,0 +1,131 @@
+package core_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestFilesGC(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	referencedBlob := core.FileBlobKey("referenced")
+
+	expectedOrphans := []string{
+		"_pb_users_auth_/4q1xlclmfloku33/orphan.txt",
+		"_pb_users_auth_/missing_record/orphan.txt",
+	}
+	expectedThumbs := []string{
+		"_pb_users_auth_/4q1xlclmfloku33/thumbs_missing.png/100x100_missing.png",
+	}
+	expectedBlobs := []string{
+		core.FileBlobKey("unreferenced"),
+	}
+	referenced := []string{
+		"_pb_users_auth_/4q1xlclmfloku33/300_1SEi6Q6U72.png",
+		"_pb_users_auth_/4q1xlclmfloku33/thumbs_300_1SEi6Q6U72.png/100x100_300_1SEi6Q6U72.png",
+		referencedBlob,
+	}
+
+	for _, keys := range [][]string{expectedOrphans, expectedThumbs, expectedBlobs, referenced[1:]} {
+		for _, key := range keys {
+			if err := fsys.Upload([]byte("test"), key); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	_, err = app.DB().Insert(core.FileBlobsTableName, dbx.Params{"hash": "referenced", "size": 4, "refs": 1}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("grace period", func(t *testing.T) {
+		result, err := app.FilesGC(context.Background(), core.FilesGCOptions{
+			GracePeriod: time.Hour,
+			DryRun:      true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, key := range slices.Concat(expectedOrphans, expectedThumbs, expectedBlobs) {
+			if slices.Contains(slices.Concat(result.Orphans, result.Thumbs, result.Blobs), key) {
+				t.Fatalf("Expected the recent %q file to be skipped", key)
+			}
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		result, err := app.FilesGC(context.Background(), core.FilesGCOptions{DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertContainsAll(t, "orphans", result.Orphans, expectedOrphans)
+		assertContainsAll(t, "thumbs", result.Thumbs, expectedThumbs)
+		assertContainsAll(t, "blobs", result.Blobs, expectedBlobs)
+
+		all := slices.Concat(result.Orphans, result.Thumbs, result.Blobs)
+		for _, key := range referenced {
+			if slices.Contains(all, key) {
+				t.Fatalf("Expected the referenced %q file to not be collected", key)
+			}
+		}
+
+		if result.Deleted != 0 {
+			t.Fatalf("Expected no deleted files, got %d", result.Deleted)
+		}
+
+		for _, key := range all {
+			if exists, _ := fsys.Exists(key); !exists {
+				t.Fatalf("Expected %q to not be deleted", key)
+			}
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		result, err := app.FilesGC(context.Background(), core.FilesGCOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Deleted != result.Total() {
+			t.Fatalf("Expected %d deleted files, got %d", result.Total(), result.Deleted)
+		}
+
+		for _, key := range slices.Concat(expectedOrphans, expectedThumbs, expectedBlobs) {
+			if exists, _ := fsys.Exists(key); exists {
+				t.Fatalf("Expected %q to be deleted", key)
+			}
+		}
+
+		for _, key := range referenced {
+			if exists, _ := fsys.Exists(key); !exists {
+				t.Fatalf("Expected the referenced %q file to remain", key)
+			}
+		}
+	})
+}
+
+func assertContainsAll(t *testing.T, name string, values []string, expected []string) {
+	for _, v := range expected {
+		if !slices.Contains(values, v) {
+			t.Fatalf("Expected %s to contain %q, got %v", name, v, values)
+		}
+	}
+}
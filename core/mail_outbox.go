@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/mailer"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// mailOutboxPollInterval is the interval at which the idle
+// outbox workers check for new due messages.
+const mailOutboxPollInterval = 3 * time.Second
+
+var _ mailer.Mailer = (*mailOutboxClient)(nil)
+
+// mailOutboxClient is a [mailer.Mailer] implementation that
+// enqueues the messages in the mail outbox instead of sending them.
+type mailOutboxClient struct {
+	app App
+}
+
+// Send implements [mailer.Mailer] interface.
+func (c *mailOutboxClient) Send(message *mailer.Message) error {
+	_, err := c.app.EnqueueMail(message)
+	return err
+}
+
+// deliverNextMailOutboxMessage claims the next due pending outbox message,
+// tries to send it and updates its status based on the delivery result.
+//
+// The message row stays locked until the delivery completes so that
+// other workers (including from other app instances) skip it.
+//
+// Returns false if there are no due pending messages.
+func (app *BaseApp) deliverNextMailOutboxMessage() (bool, error) {
+	var found bool
+
+	err := app.RunInTransaction(func(txApp App) error {
+		m := &MailOutboxMessage{}
+
+		err := txApp.DB().NewQuery(fmt.Sprintf(`
+			SELECT * FROM {{%s}}
+			WHERE [[status]] = {:status} AND [[nextAttempt]] <= {:now}
+			ORDER BY [[nextAttempt]] ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		`, MailOutboxTableName)).Bind(dbx.Params{
+			"status": MailOutboxStatusPending,
+			"now":    types.NowDateTime().String(),
+		}).One(m)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		found = true
+
+		sendErr := app.sendMailOutboxMessage(m)
+
+		now := time.Now()
+		params := dbx.Params{
+			"attempts": m.Attempts + 1,
+			"updated":  types.NowDateTime().String(),
+		}
+
+		switch {
+		case sendErr == nil:
+			params["status"] = MailOutboxStatusSent
+			params["lastError"] = ""
+		case m.Attempts+1 >= app.Settings().MailOutbox.GetMaxAttempts():
+			params["status"] = MailOutboxStatusFailed
+			params["lastError"] = sendErr.Error()
+		default:
+			nextAttempt, _ := types.ParseDateTime(now.Add(mailOutboxBackoff(m.Attempts + 1)))
+			params["status"] = MailOutboxStatusPending
+			params["lastError"] = sendErr.Error()
+			params["nextAttempt"] = nextAttempt.String()
+		}
+
+		if sendErr != nil {
+			app.Logger().Warn(
+				"Failed to deliver mail outbox message",
+				"id", m.Id,
+				"attempts", m.Attempts+1,
+				"error", sendErr,
+			)
+		}
+
+		_, err = txApp.DB().Update(MailOutboxTableName, params, dbx.HashExp{"id": m.Id}).Execute()
+
+		return err
+	})
+
+	return found, err
+}
+
+// sendMailOutboxMessage sends the outbox message with the direct (non-outbox) mail client.
+func (app *BaseApp) sendMailOutboxMessage(m *MailOutboxMessage) error {
+	message, err := m.MailerMessage()
+	if err != nil {
+		return err
+	}
+
+	return app.newDirectMailClient().Send(message)
+}
+
+// runMailOutboxWorker delivers the due outbox messages until ctx is canceled.
+func (app *BaseApp) runMailOutboxWorker(ctx context.Context) {
+	for {
+		found, err := app.deliverNextMailOutboxMessage()
+		if err != nil {
+			app.Logger().Warn("Failed to process the mail outbox", "error", err)
+		}
+
+		// continue with the next message without waiting
+		if found && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(mailOutboxPollInterval):
+		}
+	}
+}
+
+func (app *BaseApp) registerMailOutboxHooks() {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		cancel  context.CancelFunc
+		serving bool
+	)
+
+	// (re)starts the outbox workers based on the current app settings
+	//
+	// note: the previous workers are stopped without waiting for their
+	// current delivery to complete (the message rows remain locked until then)
+	loadWorkers := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+			cancel = nil
+		}
+
+		if !serving || !app.Settings().MailOutbox.Enabled {
+			return
+		}
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+
+		for range app.Settings().MailOutbox.GetWorkers() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				app.runMailOutboxWorker(ctx)
+			}()
+		}
+	}
+
+	app.OnServe().BindFunc(func(e *ServeEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		serving = true
+		mu.Unlock()
+
+		loadWorkers()
+
+		return nil
+	})
+
+	app.OnSettingsReload().BindFunc(func(e *SettingsReloadEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		loadWorkers()
+
+		return nil
+	})
+
+	app.OnTerminate().BindFunc(func(e *TerminateEvent) error {
+		mu.Lock()
+		serving = false
+		mu.Unlock()
+
+		loadWorkers()
+
+		wg.Wait()
+
+		return e.Next()
+	})
+
+	// cleanup the old sent and failed messages
+	app.Cron().Add("__pbMailOutboxCleanup__", "0 */6 * * *", func() {
+		maxDays := app.Settings().MailOutbox.MaxDays
+		if maxDays <= 0 {
+			return
+		}
+
+		createdBefore := time.Now().AddDate(0, 0, -1*maxDays).UTC().Format(types.DefaultDateLayout)
+
+		_, err := app.PurgeMailOutbox(dbx.NewExp("[[created]] < {:date}", dbx.Params{"date": createdBefore}))
+		if err != nil {
+			app.Logger().Warn("Failed to delete old mail outbox messages", "error", err)
+		}
+	})
+}
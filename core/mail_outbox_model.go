@@ -0,0 +1,208 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/mailer"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+var (
+	_ Model = (*MailOutboxMessage)(nil)
+)
+
+const MailOutboxTableName = "_mailOutbox"
+
+const (
+	MailOutboxStatusPending = "pending"
+	MailOutboxStatusSent    = "sent"
+	MailOutboxStatusFailed  = "failed"
+)
+
+// MailOutboxMessage defines a single mail message stored in the mail outbox
+// and waiting to be (or already) delivered by the outbox workers.
+type MailOutboxMessage struct {
+	BaseModel
+
+	Created     types.DateTime `db:"created" json:"created"`
+	Updated     types.DateTime `db:"updated" json:"updated"`
+	NextAttempt types.DateTime `db:"nextAttempt" json:"nextAttempt"`
+	Status      string         `db:"status" json:"status"`
+	LastError   string         `db:"lastError" json:"lastError"`
+	Attempts    int            `db:"attempts" json:"attempts"`
+
+	// Message is the serialized mail message without the attachments content
+	// (only the attachments names are listed).
+	Message types.JSONRaw `db:"message" json:"message"`
+
+	// Attachments is the serialized attachments content of the mail message.
+	Attachments types.JSONRaw `db:"attachments" json:"-"`
+}
+
+func (m *MailOutboxMessage) TableName() string {
+	return MailOutboxTableName
+}
+
+// outboxMessageData is the stored mail message data.
+type outboxMessageData struct {
+	From              mail.Address      `json:"from"`
+	To                []mail.Address    `json:"to"`
+	Bcc               []mail.Address    `json:"bcc"`
+	Cc                []mail.Address    `json:"cc"`
+	Subject           string            `json:"subject"`
+	HTML              string            `json:"html"`
+	Text              string            `json:"text"`
+	Headers           map[string]string `json:"headers"`
+	Attachments       []string          `json:"attachments"`
+	InlineAttachments []string          `json:"inlineAttachments"`
+}
+
+// outboxAttachmentsData is the stored mail message attachments content.
+type outboxAttachmentsData struct {
+	Attachments       map[string][]byte `json:"attachments"`
+	InlineAttachments map[string][]byte `json:"inlineAttachments"`
+}
+
+// NewMailOutboxMessage creates a new pending outbox message from the provided mail message.
+//
+// Note that the message attachments readers are fully consumed.
+func NewMailOutboxMessage(message *mailer.Message) (*MailOutboxMessage, error) {
+	data := outboxMessageData{
+		From:    message.From,
+		To:      message.To,
+		Bcc:     message.Bcc,
+		Cc:      message.Cc,
+		Subject: message.Subject,
+		HTML:    message.HTML,
+		Text:    message.Text,
+		Headers: message.Headers,
+	}
+
+	attachments := outboxAttachmentsData{}
+
+	var err error
+
+	attachments.Attachments, data.Attachments, err = readOutboxAttachments(message.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments.InlineAttachments, data.InlineAttachments, err = readOutboxAttachments(message.InlineAttachments)
+	if err != nil {
+		return nil, err
+	}
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAttachments, err := json.Marshal(attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	now := types.NowDateTime()
+
+	m := &MailOutboxMessage{}
+	m.Id = GenerateDefaultRandomId()
+	m.Created = now
+	m.Updated = now
+	m.NextAttempt = now
+	m.Status = MailOutboxStatusPending
+	m.Message = rawData
+	m.Attachments = rawAttachments
+
+	return m, nil
+}
+
+// MailerMessage decodes and returns the stored mail message (including its attachments).
+func (m *MailOutboxMessage) MailerMessage() (*mailer.Message, error) {
+	data := outboxMessageData{}
+	if err := json.Unmarshal(m.Message, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode the outbox message: %w", err)
+	}
+
+	attachments := outboxAttachmentsData{}
+	if len(m.Attachments) > 0 {
+		if err := json.Unmarshal(m.Attachments, &attachments); err != nil {
+			return nil, fmt.Errorf("failed to decode the outbox message attachments: %w", err)
+		}
+	}
+
+	message := &mailer.Message{
+		From:    data.From,
+		To:      data.To,
+		Bcc:     data.Bcc,
+		Cc:      data.Cc,
+		Subject: data.Subject,
+		HTML:    data.HTML,
+		Text:    data.Text,
+		Headers: data.Headers,
+	}
+
+	if len(attachments.Attachments) > 0 {
+		message.Attachments = make(map[string]io.Reader, len(attachments.Attachments))
+		for name, content := range attachments.Attachments {
+			message.Attachments[name] = bytes.NewReader(content)
+		}
+	}
+
+	if len(attachments.InlineAttachments) > 0 {
+		message.InlineAttachments = make(map[string]io.Reader, len(attachments.InlineAttachments))
+		for name, content := range attachments.InlineAttachments {
+			message.InlineAttachments[name] = bytes.NewReader(content)
+		}
+	}
+
+	return message, nil
+}
+
+// readOutboxAttachments reads the content of the provided attachments
+// and returns it together with the sorted attachments names.
+func readOutboxAttachments(attachments map[string]io.Reader) (map[string][]byte, []string, error) {
+	contents := make(map[string][]byte, len(attachments))
+	names := make([]string, 0, len(attachments))
+
+	for name, r := range attachments {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read attachment %q: %w", name, err)
+		}
+
+		contents[name] = content
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return contents, names, nil
+}
+
+// mailOutboxBackoff returns the delay before the next delivery
+// attempt of a message that failed attempts number of times.
+//
+// The delay doubles with every failed attempt starting from
+// 30 seconds and it is capped to 1 hour.
+func mailOutboxBackoff(attempts int) time.Duration {
+	const (
+		base     = 30 * time.Second
+		maxDelay = time.Hour
+	)
+
+	if attempts <= 1 {
+		return base
+	}
+
+	if attempts > 8 {
+		return maxDelay // 30s*2^7 is already >1h
+	}
+
+	return min(base<<(attempts-1), maxDelay)
+}
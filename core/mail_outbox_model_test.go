@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/mailer"
+)
+
+func TestMailOutboxMessageEncoding(t *testing.T) {
+	t.Parallel()
+
+	original := &mailer.Message{
+		From:    mail.Address{Name: "Sender", Address: "sender@example.com"},
+		To:      []mail.Address{{Address: "to@example.com"}},
+		Cc:      []mail.Address{{Address: "cc@example.com"}},
+		Bcc:     []mail.Address{{Address: "bcc@example.com"}},
+		Subject: "test subject",
+		HTML:    "<p>test html</p>",
+		Text:    "test text",
+		Headers: map[string]string{"X-Test": "1"},
+		Attachments: map[string]io.Reader{
+			"b.txt": strings.NewReader("b content"),
+			"a.txt": strings.NewReader("a content"),
+		},
+		InlineAttachments: map[string]io.Reader{
+			"logo.png": strings.NewReader("logo content"),
+		},
+	}
+
+	m, err := NewMailOutboxMessage(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Id == "" {
+		t.Fatal("Expected the outbox message id to be set")
+	}
+
+	if m.Status != MailOutboxStatusPending {
+		t.Fatalf("Expected status %q, got %q", MailOutboxStatusPending, m.Status)
+	}
+
+	if m.NextAttempt.IsZero() || m.Created.IsZero() {
+		t.Fatalf("Expected created and nextAttempt to be set, got %v and %v", m.Created, m.NextAttempt)
+	}
+
+	// the serialized message should contain only the attachments names
+	rawMessage := string(m.Message)
+	for _, s := range []string{`"attachments":["a.txt","b.txt"]`, `"inlineAttachments":["logo.png"]`} {
+		if !strings.Contains(rawMessage, s) {
+			t.Fatalf("Expected %s in the serialized message, got\n%s", s, rawMessage)
+		}
+	}
+	if strings.Contains(rawMessage, "content") {
+		t.Fatalf("Expected the attachments content to not be part of the serialized message, got\n%s", rawMessage)
+	}
+
+	// the attachments content shouldn't be exported
+	rawJSON, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(rawJSON), `"attachments":{`) {
+		t.Fatalf("Expected the attachments field to be hidden, got\n%s", rawJSON)
+	}
+
+	decoded, err := m.MailerMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.From.String() != original.From.String() {
+		t.Fatalf("Expected from %q, got %q", original.From.String(), decoded.From.String())
+	}
+
+	if len(decoded.To) != 1 || decoded.To[0].Address != "to@example.com" ||
+		len(decoded.Cc) != 1 || decoded.Cc[0].Address != "cc@example.com" ||
+		len(decoded.Bcc) != 1 || decoded.Bcc[0].Address != "bcc@example.com" {
+		t.Fatalf("Unexpected decoded recipients: %v, %v, %v", decoded.To, decoded.Cc, decoded.Bcc)
+	}
+
+	if decoded.Subject != original.Subject || decoded.HTML != original.HTML || decoded.Text != original.Text {
+		t.Fatalf("Unexpected decoded content: %q, %q, %q", decoded.Subject, decoded.HTML, decoded.Text)
+	}
+
+	if decoded.Headers["X-Test"] != "1" {
+		t.Fatalf("Expected X-Test header, got %v", decoded.Headers)
+	}
+
+	expectedAttachments := map[string]string{"a.txt": "a content", "b.txt": "b content"}
+	if len(decoded.Attachments) != len(expectedAttachments) {
+		t.Fatalf("Expected %d attachments, got %d", len(expectedAttachments), len(decoded.Attachments))
+	}
+	for name, content := range expectedAttachments {
+		raw, err := io.ReadAll(decoded.Attachments[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != content {
+			t.Fatalf("Expected attachment %q content %q, got %q", name, content, raw)
+		}
+	}
+
+	logo, err := io.ReadAll(decoded.InlineAttachments["logo.png"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(logo) != "logo content" {
+		t.Fatalf("Expected inline attachment content %q, got %q", "logo content", logo)
+	}
+}
+
+func TestMailOutboxBackoff(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{-1, 30 * time.Second},
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprint(s.attempts), func(t *testing.T) {
+			if v := mailOutboxBackoff(s.attempts); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/mailer"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// MailOutboxQuery returns a new MailOutboxMessage select query.
+func (app *BaseApp) MailOutboxQuery() *dbx.SelectQuery {
+	return app.ModelQuery(&MailOutboxMessage{})
+}
+
+// FindMailOutboxMessageById returns a single MailOutboxMessage model by its id.
+func (app *BaseApp) FindMailOutboxMessageById(id string) (*MailOutboxMessage, error) {
+	model := &MailOutboxMessage{}
+
+	err := app.MailOutboxQuery().
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// EnqueueMail stores the provided mail message in the mail outbox
+// for background delivery.
+//
+// When called with a transactional app instance the message is
+// enqueued as part of the transaction, aka. it will be delivered
+// only if the transaction is committed.
+func (app *BaseApp) EnqueueMail(message *mailer.Message) (*MailOutboxMessage, error) {
+	model, err := NewMailOutboxMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.Save(model); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// RetryMailOutboxMessage resets the attempts of the provided
+// outbox message and schedules it for immediate delivery.
+func (app *BaseApp) RetryMailOutboxMessage(message *MailOutboxMessage) error {
+	now := types.NowDateTime()
+
+	_, err := app.NonconcurrentDB().Update(
+		MailOutboxTableName,
+		dbx.Params{
+			"status":      MailOutboxStatusPending,
+			"attempts":    0,
+			"nextAttempt": now.String(),
+			"updated":     now.String(),
+		},
+		dbx.HashExp{"id": message.Id},
+	).Execute()
+	if err != nil {
+		return err
+	}
+
+	message.Status = MailOutboxStatusPending
+	message.Attempts = 0
+	message.NextAttempt = now
+	message.Updated = now
+
+	return nil
+}
+
+// PurgeMailOutbox deletes all sent and failed outbox messages
+// matching the optional expr and returns the number of the deleted messages.
+//
+// Pending messages are never purged.
+//
+// For better performance the messages delete is executed as plain SQL statement,
+// aka. no delete model hook events will be fired.
+func (app *BaseApp) PurgeMailOutbox(expr dbx.Expression) (int64, error) {
+	where := dbx.And(
+		dbx.In("status", MailOutboxStatusSent, MailOutboxStatusFailed),
+		expr,
+	)
+
+	result, err := app.NonconcurrentDB().Delete(MailOutboxTableName, where).Execute()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ProcessMailOutbox claims and delivers up to limit due pending
+// outbox messages and returns the number of the processed messages
+// (regardless of their delivery result).
+//
+// The messages are claimed with "FOR UPDATE SKIP LOCKED" so it is safe
+// to be called concurrently (including from different app instances).
+func (app *BaseApp) ProcessMailOutbox(limit int) (int, error) {
+	var processed int
+
+	for processed < limit {
+		found, err := app.deliverNextMailOutboxMessage()
+		if err != nil {
+			return processed, fmt.Errorf("failed to process the mail outbox: %w", err)
+		}
+
+		if !found {
+			break
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
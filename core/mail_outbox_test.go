@@ -0,0 +1,256 @@
+package core_test
+
+import (
+	"errors"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/mailer"
+)
+
+func newTestOutboxMessage(subject string) *mailer.Message {
+	return &mailer.Message{
+		From:    mail.Address{Address: "sender@example.com"},
+		To:      []mail.Address{{Address: "to@example.com"}},
+		Subject: subject,
+		HTML:    "<p>" + subject + "</p>",
+	}
+}
+
+func TestMailOutboxEnqueueAndProcess(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().MailOutbox.Enabled = true
+
+	if err := app.NewMailClient().Send(newTestOutboxMessage("test1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if total := app.TestMailer.TotalSend(); total != 0 {
+		t.Fatalf("Expected the message to be only enqueued, got %d sent messages", total)
+	}
+
+	messages := []*core.MailOutboxMessage{}
+	if err := app.MailOutboxQuery().All(&messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].Status != core.MailOutboxStatusPending {
+		t.Fatalf("Expected 1 pending outbox message, got %v", messages)
+	}
+
+	processed, err := app.ProcessMailOutbox(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != 1 {
+		t.Fatalf("Expected 1 processed message, got %d", processed)
+	}
+
+	if total := app.TestMailer.TotalSend(); total != 1 {
+		t.Fatalf("Expected 1 sent message, got %d", total)
+	}
+
+	if subject := app.TestMailer.LastMessage().Subject; subject != "test1" {
+		t.Fatalf("Expected subject %q, got %q", "test1", subject)
+	}
+
+	message, err := app.FindMailOutboxMessageById(messages[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != core.MailOutboxStatusSent || message.Attempts != 1 || message.LastError != "" {
+		t.Fatalf("Expected sent message with 1 attempt, got %#v", message)
+	}
+
+	// nothing else to process
+	processed, err = app.ProcessMailOutbox(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != 0 {
+		t.Fatalf("Expected 0 processed messages, got %d", processed)
+	}
+}
+
+func TestMailOutboxTransactionalEnqueue(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().MailOutbox.Enabled = true
+
+	rollbackErr := errors.New("rollback")
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		if err := txApp.NewMailClient().Send(newTestOutboxMessage("rolled back")); err != nil {
+			return err
+		}
+		return rollbackErr
+	})
+	if !errors.Is(err, rollbackErr) {
+		t.Fatalf("Expected the rollback error, got %v", err)
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		return txApp.NewMailClient().Send(newTestOutboxMessage("committed"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []*core.MailOutboxMessage{}
+	if err := app.MailOutboxQuery().All(&messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 outbox message, got %d", len(messages))
+	}
+
+	decoded, err := messages[0].MailerMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Subject != "committed" {
+		t.Fatalf("Expected the committed message, got %q", decoded.Subject)
+	}
+}
+
+func TestMailOutboxDeliveryFailure(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().MailOutbox.Enabled = true
+	app.Settings().MailOutbox.MaxAttempts = 2
+
+	app.OnMailerSend().BindFunc(func(e *core.MailerEvent) error {
+		return errors.New("smtp unavailable")
+	})
+
+	enqueued, err := app.EnqueueMail(newTestOutboxMessage("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first attempt
+	// ---
+	if _, err := app.ProcessMailOutbox(10); err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := app.FindMailOutboxMessageById(enqueued.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != core.MailOutboxStatusPending || message.Attempts != 1 || message.LastError != "smtp unavailable" {
+		t.Fatalf("Expected pending message with 1 failed attempt, got %#v", message)
+	}
+	if !message.NextAttempt.Time().After(time.Now()) {
+		t.Fatalf("Expected the next attempt to be in the future, got %v", message.NextAttempt)
+	}
+
+	// not due yet
+	processed, err := app.ProcessMailOutbox(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != 0 {
+		t.Fatalf("Expected 0 processed messages, got %d", processed)
+	}
+
+	// last attempt
+	// ---
+	_, err = app.DB().Update(
+		core.MailOutboxTableName,
+		dbx.Params{"nextAttempt": time.Now().Add(-time.Minute)},
+		dbx.HashExp{"id": enqueued.Id},
+	).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.ProcessMailOutbox(10); err != nil {
+		t.Fatal(err)
+	}
+
+	message, err = app.FindMailOutboxMessageById(enqueued.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != core.MailOutboxStatusFailed || message.Attempts != 2 {
+		t.Fatalf("Expected failed message with 2 attempts, got %#v", message)
+	}
+
+	// retry
+	// ---
+	if err := app.RetryMailOutboxMessage(message); err != nil {
+		t.Fatal(err)
+	}
+
+	message, err = app.FindMailOutboxMessageById(enqueued.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != core.MailOutboxStatusPending || message.Attempts != 0 {
+		t.Fatalf("Expected pending message with reset attempts, got %#v", message)
+	}
+}
+
+func TestPurgeMailOutbox(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	statuses := []string{
+		core.MailOutboxStatusPending,
+		core.MailOutboxStatusSent,
+		core.MailOutboxStatusSent,
+		core.MailOutboxStatusFailed,
+	}
+
+	for _, status := range statuses {
+		m, err := app.EnqueueMail(newTestOutboxMessage(status))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = app.DB().Update(core.MailOutboxTableName, dbx.Params{"status": status}, dbx.HashExp{"id": m.Id}).Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := app.PurgeMailOutbox(dbx.HashExp{"status": core.MailOutboxStatusFailed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Fatalf("Expected 1 deleted message, got %d", deleted)
+	}
+
+	deleted, err = app.PurgeMailOutbox(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("Expected 2 deleted messages, got %d", deleted)
+	}
+
+	var total int
+	if err := app.MailOutboxQuery().Select("count(*)").Row(&total); err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Fatalf("Expected only the pending message to remain, got %d messages", total)
+	}
+}
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"regexp"
+	"strconv"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+const CollectionNameMailTemplates = "_mailTemplates"
+
+// Mail template types.
+const (
+	// MailTemplateTypeTemplate is a regular sendable mail template.
+	MailTemplateTypeTemplate = "template"
+
+	// MailTemplateTypeLayout is a mail template layout wrapping the
+	// rendered template content via {{template "content" .}}.
+	MailTemplateTypeLayout = "layout"
+
+	// MailTemplateTypePartial is a reusable mail template fragment
+	// that could be included via {{template "partialName" .}}.
+	MailTemplateTypePartial = "partial"
+)
+
+// Mail template placeholder types.
+const (
+	MailTemplatePlaceholderText   = "text"
+	MailTemplatePlaceholderNumber = "number"
+	MailTemplatePlaceholderBool   = "bool"
+	MailTemplatePlaceholderDate   = "date"
+	MailTemplatePlaceholderURL    = "url"
+
+	// MailTemplatePlaceholderHTML is an unescaped trusted HTML placeholder.
+	MailTemplatePlaceholderHTML = "html"
+)
+
+var (
+	_ Model        = (*MailTemplate)(nil)
+	_ PreValidator = (*MailTemplate)(nil)
+	_ RecordProxy  = (*MailTemplate)(nil)
+)
+
+// MailTemplate defines a Record proxy for working with the mailTemplates collection.
+//
+// Mail templates are named, DB stored [html/template] mail templates
+// with optional layout, reusable partials and typed placeholders.
+type MailTemplate struct {
+	*Record
+}
+
+// NewMailTemplate instantiates and returns a new blank *MailTemplate model.
+//
+// Example usage:
+//
+//	t := core.NewMailTemplate(app)
+//	t.SetName("welcome")
+//	t.SetType(core.MailTemplateTypeTemplate)
+//	t.SetSubject("Welcome to {{.APP_NAME}}")
+//	t.SetHTML(`<p>Hello {{.name}}</p>{{template "footer" .}}`)
+//	t.SetPlaceholders([]core.MailTemplatePlaceholder{{Name: "name", Type: core.MailTemplatePlaceholderText, Required: true}})
+//	app.Save(t)
+func NewMailTemplate(app App) *MailTemplate {
+	m := &MailTemplate{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameMailTemplates)
+	if err != nil {
+		// this is just to make tests easier since mailTemplates is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on MailTemplate.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *MailTemplate) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameMailTemplates {
+		return errors.New("missing or invalid MailTemplate ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *MailTemplate) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *MailTemplate) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// Name returns the "name" record field value.
+func (m *MailTemplate) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *MailTemplate) SetName(name string) {
+	m.Set("name", name)
+}
+
+// Type returns the "type" record field value.
+func (m *MailTemplate) Type() string {
+	return m.GetString("type")
+}
+
+// SetType updates the "type" record field value.
+func (m *MailTemplate) SetType(templateType string) {
+	m.Set("type", templateType)
+}
+
+// Subject returns the "subject" record field value.
+//
+// The subject is rendered as [text/template].
+func (m *MailTemplate) Subject() string {
+	return m.GetString("subject")
+}
+
+// SetSubject updates the "subject" record field value.
+func (m *MailTemplate) SetSubject(subject string) {
+	m.Set("subject", subject)
+}
+
+// HTML returns the "html" record field value.
+func (m *MailTemplate) HTML() string {
+	return m.GetString("html")
+}
+
+// SetHTML updates the "html" record field value.
+func (m *MailTemplate) SetHTML(html string) {
+	m.Set("html", html)
+}
+
+// Text returns the optional plain text body "text" record field value.
+//
+// The text body is rendered as [text/template].
+func (m *MailTemplate) Text() string {
+	return m.GetString("text")
+}
+
+// SetText updates the "text" record field value.
+func (m *MailTemplate) SetText(text string) {
+	m.Set("text", text)
+}
+
+// Layout returns the "layout" record field value.
+//
+// It is the name of the layout mail template to use.
+// If empty, the default system mail layout is used.
+func (m *MailTemplate) Layout() string {
+	return m.GetString("layout")
+}
+
+// SetLayout updates the "layout" record field value.
+func (m *MailTemplate) SetLayout(layout string) {
+	m.Set("layout", layout)
+}
+
+// Placeholders returns the "placeholders" record field value.
+func (m *MailTemplate) Placeholders() []MailTemplatePlaceholder {
+	result := []MailTemplatePlaceholder{}
+
+	_ = m.UnmarshalJSONField("placeholders", &result)
+
+	return result
+}
+
+// SetPlaceholders updates the "placeholders" record field value.
+func (m *MailTemplate) SetPlaceholders(placeholders []MailTemplatePlaceholder) {
+	m.Set("placeholders", placeholders)
+}
+
+// Created returns the "created" record field value.
+func (m *MailTemplate) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *MailTemplate) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// -------------------------------------------------------------------
+
+// MailTemplatePlaceholder defines a single typed mail template placeholder.
+//
+// The placeholder value is available in the template as {{.name}}.
+type MailTemplatePlaceholder struct {
+	// Name is the placeholder data key.
+	Name string `form:"name" json:"name"`
+
+	// Type is the placeholder value type (text, number, bool, date, url or html).
+	//
+	// If empty, fallbacks to "text".
+	Type string `form:"type" json:"type"`
+
+	// Required reports whether the placeholder value must be provided when rendering the template.
+	Required bool `form:"required" json:"required"`
+
+	// Sample is an optional sample value used when previewing the template.
+	Sample any `form:"sample" json:"sample"`
+
+	// Description is an optional short description of the placeholder.
+	Description string `form:"description" json:"description"`
+}
+
+var mailTemplatePlaceholderNameRegex = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+
+// Validate makes MailTemplatePlaceholder validatable by implementing [validation.Validatable] interface.
+func (p MailTemplatePlaceholder) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 100), validation.Match(mailTemplatePlaceholderNameRegex)),
+		validation.Field(
+			&p.Type,
+			validation.In(
+				MailTemplatePlaceholderText,
+				MailTemplatePlaceholderNumber,
+				MailTemplatePlaceholderBool,
+				MailTemplatePlaceholderDate,
+				MailTemplatePlaceholderURL,
+				MailTemplatePlaceholderHTML,
+			),
+		),
+		validation.Field(&p.Description, validation.Length(0, 255)),
+	)
+}
+
+// -------------------------------------------------------------------
+
+var mailTemplateContentRegex = regexp.MustCompile(`\{\{-?\s*template\s+"content"`)
+
+func (app *BaseApp) registerMailTemplateHooks() {
+	app.OnRecordValidate(CollectionNameMailTemplates).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			mt := &MailTemplate{Record: e.Record}
+
+			errs := validation.Errors{}
+
+			if mt.Type() == MailTemplateTypeTemplate && mt.Subject() == "" {
+				errs["subject"] = validation.ErrRequired
+			}
+
+			if mt.Type() != MailTemplateTypeTemplate && mt.Layout() != "" {
+				errs["layout"] = validation.NewError("validation_layout_not_allowed", "Only regular mail templates could have a layout.")
+			} else if err := checkMailTemplateLayout(e.App, mt.Layout()); err != nil {
+				errs["layout"] = err
+			}
+
+			if _, err := template.New("").Parse(mt.HTML()); err != nil {
+				errs["html"] = validation.NewError("validation_invalid_template", "Invalid template: {{.error}}.").
+					SetParams(map[string]any{"error": err.Error()})
+			} else if mt.Type() == MailTemplateTypeLayout && !mailTemplateContentRegex.MatchString(mt.HTML()) {
+				errs["html"] = validation.NewError("validation_missing_content_block", `The layout must include the {{template "content" .}} block.`)
+			}
+
+			if err := validateMailTemplatePlaceholders(mt.Placeholders()); err != nil {
+				errs["placeholders"] = err
+			}
+
+			if len(errs) > 0 {
+				return errs
+			}
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+}
+
+func checkMailTemplateLayout(app App, layout string) error {
+	if layout == "" {
+		return nil // default system layout
+	}
+
+	found, err := app.FindMailTemplateByName(layout)
+	if err != nil || found.Type() != MailTemplateTypeLayout {
+		return validation.NewError("validation_missing_layout", "Missing or invalid layout mail template.")
+	}
+
+	return nil
+}
+
+func validateMailTemplatePlaceholders(placeholders []MailTemplatePlaceholder) error {
+	existing := map[string]struct{}{}
+
+	for i, p := range placeholders {
+		if err := p.Validate(); err != nil {
+			return validation.Errors{strconv.Itoa(i): err}
+		}
+
+		if _, ok := existing[p.Name]; ok {
+			return validation.Errors{
+				strconv.Itoa(i): validation.Errors{
+					"name": validation.NewError("validation_duplicated_placeholder", "Duplicated placeholder name."),
+				},
+			}
+		}
+		existing[p.Name] = struct{}{}
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestNewMailTemplate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	mt := core.NewMailTemplate(app)
+
+	if mt.Collection().Name != core.CollectionNameMailTemplates {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameMailTemplates, mt.Collection().Name)
+	}
+}
+
+func TestMailTemplateProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	record := core.NewRecord(core.NewBaseCollection("test"))
+	record.Id = "test_id"
+
+	mt := core.MailTemplate{}
+	mt.SetProxyRecord(record)
+
+	if mt.ProxyRecord() == nil || mt.ProxyRecord().Id != record.Id {
+		t.Fatalf("Expected proxy record with id %q, got %v", record.Id, mt.ProxyRecord())
+	}
+}
+
+func TestMailTemplateFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	mt := core.NewMailTemplate(app)
+
+	mt.SetName("test_name")
+	if v := mt.Name(); v != "test_name" {
+		t.Fatalf("Expected name %q, got %q", "test_name", v)
+	}
+
+	mt.SetType(core.MailTemplateTypeLayout)
+	if v := mt.Type(); v != core.MailTemplateTypeLayout {
+		t.Fatalf("Expected type %q, got %q", core.MailTemplateTypeLayout, v)
+	}
+
+	mt.SetSubject("test_subject")
+	if v := mt.Subject(); v != "test_subject" {
+		t.Fatalf("Expected subject %q, got %q", "test_subject", v)
+	}
+
+	mt.SetHTML("test_html")
+	if v := mt.HTML(); v != "test_html" {
+		t.Fatalf("Expected html %q, got %q", "test_html", v)
+	}
+
+	mt.SetText("test_text")
+	if v := mt.Text(); v != "test_text" {
+		t.Fatalf("Expected text %q, got %q", "test_text", v)
+	}
+
+	mt.SetLayout("test_layout")
+	if v := mt.Layout(); v != "test_layout" {
+		t.Fatalf("Expected layout %q, got %q", "test_layout", v)
+	}
+
+	if v := mt.Placeholders(); len(v) != 0 {
+		t.Fatalf("Expected no placeholders, got %v", v)
+	}
+
+	mt.SetPlaceholders([]core.MailTemplatePlaceholder{
+		{Name: "a", Type: core.MailTemplatePlaceholderNumber, Required: true, Sample: 123},
+		{Name: "b"},
+	})
+	placeholders := mt.Placeholders()
+	if len(placeholders) != 2 ||
+		placeholders[0].Name != "a" ||
+		placeholders[0].Type != core.MailTemplatePlaceholderNumber ||
+		!placeholders[0].Required ||
+		placeholders[0].Sample != float64(123) ||
+		placeholders[1].Name != "b" {
+		t.Fatalf("Unexpected placeholders %v", placeholders)
+	}
+}
+
+func TestMailTemplatePlaceholderValidate(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name           string
+		placeholder    core.MailTemplatePlaceholder
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.MailTemplatePlaceholder{},
+			[]string{"name"},
+		},
+		{
+			"invalid data",
+			core.MailTemplatePlaceholder{Name: "a b", Type: "missing"},
+			[]string{"name", "type"},
+		},
+		{
+			"valid data",
+			core.MailTemplatePlaceholder{Name: "a_b1", Type: core.MailTemplatePlaceholderDate},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			tests.TestValidationErrors(t, s.placeholder.Validate(), s.expectedErrors)
+		})
+	}
+}
+
+func TestMailTemplateValidateHook(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	layout := core.NewMailTemplate(app)
+	layout.SetName("test_layout")
+	layout.SetType(core.MailTemplateTypeLayout)
+	layout.SetHTML(`<div>{{template "content" .}}</div>`)
+	if err := app.Save(layout); err != nil {
+		t.Fatal(err)
+	}
+
+	partial := core.NewMailTemplate(app)
+	partial.SetName("test_partial")
+	partial.SetType(core.MailTemplateTypePartial)
+	partial.SetHTML(`<footer>{{.APP_NAME}}</footer>`)
+	if err := app.Save(partial); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name         string
+		mailTemplate func() *core.MailTemplate
+		expectErrors []string
+	}{
+		{
+			"template without subject",
+			func() *core.MailTemplate {
+				mt := core.NewMailTemplate(app)
+				mt.SetName("test")
+				mt.SetType(core.MailTemplateTypeTemplate)
+				mt.SetHTML("test")
+				return mt
+			},
+			[]string{"subject"},
+		},
+		{
+			"invalid html template and layout",
+			func() *core.MailTemplate {
+				mt := core.NewMailTemplate(app)
+				mt.SetName("test")
+				mt.SetType(core.MailTemplateTypeTemplate)
+				mt.SetSubject("test")
+				mt.SetHTML("{{.missing")
+				mt.SetLayout(partial.Name())
+				return mt
+			},
+			[]string{"html", "layout"},
+		},
+		{
+			"layout without content block",
+			func() *core.MailTemplate {
+				mt := core.NewMailTemplate(app)
+				mt.SetName("test")
+				mt.SetType(core.MailTemplateTypeLayout)
+				mt.SetHTML(`<div>{{template "test_partial" .}}</div>`)
+				return mt
+			},
+			[]string{"html"},
+		},
+		{
+			"partial with layout",
+			func() *core.MailTemplate {
+				mt := core.NewMailTemplate(app)
+				mt.SetName("test")
+				mt.SetType(core.MailTemplateTypePartial)
+				mt.SetHTML("test")
+				mt.SetLayout(layout.Name())
+				return mt
+			},
+			[]string{"layout"},
+		},
+		{
+			"duplicated placeholders",
+			func() *core.MailTemplate {
+				mt := core.NewMailTemplate(app)
+				mt.SetName("test")
+				mt.SetType(core.MailTemplateTypeTemplate)
+				mt.SetSubject("test")
+				mt.SetHTML("test")
+				mt.SetPlaceholders([]core.MailTemplatePlaceholder{{Name: "a"}, {Name: "a"}})
+				return mt
+			},
+			[]string{"placeholders"},
+		},
+		{
+			"valid template",
+			func() *core.MailTemplate {
+				mt := core.NewMailTemplate(app)
+				mt.SetName("test")
+				mt.SetType(core.MailTemplateTypeTemplate)
+				mt.SetSubject("Hello {{.name}}")
+				mt.SetHTML(`<p>Hello {{.name}}</p>{{template "test_partial" .}}`)
+				mt.SetLayout(layout.Name())
+				mt.SetPlaceholders([]core.MailTemplatePlaceholder{{Name: "name", Required: true}})
+				return mt
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			tests.TestValidationErrors(t, app.Validate(s.mailTemplate()), s.expectErrors)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// FindMailTemplateByName returns a single MailTemplate model by its name.
+func (app *BaseApp) FindMailTemplateByName(name string) (*MailTemplate, error) {
+	result := &MailTemplate{}
+
+	err := app.RecordQuery(CollectionNameMailTemplates).
+		AndWhere(dbx.HashExp{"name": name}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAllMailTemplatesByType returns all MailTemplate models with the specified type.
+func (app *BaseApp) FindAllMailTemplatesByType(templateType string) ([]*MailTemplate, error) {
+	result := []*MailTemplate{}
+
+	err := app.RecordQuery(CollectionNameMailTemplates).
+		AndWhere(dbx.HashExp{"type": templateType}).
+		OrderBy("name ASC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sync"
@@ -20,8 +21,19 @@ const (
 	pubSubReconnectInterval = 5 * time.Second
 	chMetaCollections       = "pb:meta:collections"
 	chMetaSettings          = "pb:meta:settings"
+	chMessages              = "pb:messages"
+
+	// pubSubMaxPayloadSize is the max allowed pg_notify payload size (the Postgres limit is 8000 bytes).
+	pubSubMaxPayloadSize = 7900
 )
 
+// pubSubEnvelope defines the [App.PublishPubSubMessage] notification payload.
+type pubSubEnvelope struct {
+	InstanceId string          `json:"instanceId"`
+	Topic      string          `json:"topic"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
 type PubSubState struct {
 	mu         sync.Mutex
 	Conn       *pgx.Conn
@@ -60,7 +72,7 @@ func (app *BaseApp) startPubSub() error {
 			return nil, err
 		}
 
-		for _, ch := range []string{chMetaCollections, chMetaSettings} {
+		for _, ch := range []string{chMetaCollections, chMetaSettings, chMessages} {
 			if _, err := conn.Exec(ctx, `LISTEN `+pgx.Identifier{ch}.Sanitize()); err != nil {
 				_ = conn.Close(ctx)
 				return nil, fmt.Errorf("LISTEN %s: %w", ch, err)
@@ -129,6 +141,9 @@ func (app *BaseApp) subscribeToEvents(ctx context.Context, conn *pgx.Conn) {
 			app.Logger().Info("reloading settings")
 			app.ReloadSettings()
 
+		case chMessages:
+			app.handlePubSubMessage(n.Payload)
+
 		default:
 			// ignore unknown channels
 			continue
@@ -190,6 +205,68 @@ func (app *BaseApp) PublishSettingsReload() error {
 	return err
 }
 
+// PublishPubSubMessage broadcasts the JSON serialized payload
+// to the other app instances under the specified topic.
+//
+// It is no-op if the pub/sub listener is not running.
+func (app *BaseApp) PublishPubSubMessage(topic string, payload any) error {
+	if app.PubSubState() == nil {
+		return nil
+	}
+
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	rawEnvelope, err := json.Marshal(pubSubEnvelope{
+		InstanceId: app.instanceId,
+		Topic:      topic,
+		Payload:    rawPayload,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(rawEnvelope) > pubSubMaxPayloadSize {
+		return fmt.Errorf("the pub/sub message exceeds the max allowed size of %d bytes", pubSubMaxPayloadSize)
+	}
+
+	_, err = app.DB().NewQuery("SELECT pg_notify({:channel}, {:payload})").
+		Bind(dbx.Params{
+			"channel": chMessages,
+			"payload": string(rawEnvelope),
+		}).
+		Execute()
+
+	return err
+}
+
+// handlePubSubMessage triggers the OnPubSubMessage hook for the
+// raw notification payload published by another app instance.
+func (app *BaseApp) handlePubSubMessage(rawEnvelope string) {
+	envelope := pubSubEnvelope{}
+	if err := json.Unmarshal([]byte(rawEnvelope), &envelope); err != nil {
+		app.Logger().Warn("invalid pubsub message", "error", err)
+		return
+	}
+
+	// skip self published messages
+	if envelope.InstanceId == app.instanceId {
+		return
+	}
+
+	event := new(PubSubMessageEvent)
+	event.App = app
+	event.InstanceId = envelope.InstanceId
+	event.Topic = envelope.Topic
+	event.Payload = envelope.Payload
+
+	if err := app.OnPubSubMessage().Trigger(event); err != nil {
+		app.Logger().Warn("pubsub message handler failure", "topic", envelope.Topic, "error", err)
+	}
+}
+
 // EnsureCollectionsCacheFresh reloads collections locally when pubsub is disabled
 // or not running, otherwise broadcasts a reload signal.
 func (app *BaseApp) EnsureCollectionsCacheFresh() {
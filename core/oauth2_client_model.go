@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+const CollectionNameOAuth2Clients = "_oauth2Clients"
+
+var (
+	_ Model        = (*OAuth2Client)(nil)
+	_ PreValidator = (*OAuth2Client)(nil)
+	_ RecordProxy  = (*OAuth2Client)(nil)
+)
+
+// OAuth2Client defines a Record proxy for working with the oauth2Clients collection.
+//
+// OAuth2 clients are the registered applications that are allowed to
+// use the app as OAuth2/OpenID Connect authorization server.
+type OAuth2Client struct {
+	*Record
+}
+
+// NewOAuth2Client instantiates and returns a new blank *OAuth2Client model.
+//
+// Example usage:
+//
+//	client := core.NewOAuth2Client(app)
+//	client.SetName("My app")
+//	client.SetCollectionRef(users.Id)
+//	client.SetSecret("...")
+//	client.SetRedirectURIs([]string{"https://example.com/callback"})
+//	app.Save(client)
+func NewOAuth2Client(app App) *OAuth2Client {
+	m := &OAuth2Client{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOAuth2Clients)
+	if err != nil {
+		// this is just to make tests easier since oauth2Clients is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on OAuth2Client.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OAuth2Client) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOAuth2Clients {
+		return errors.New("missing or invalid OAuth2Client ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OAuth2Client) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OAuth2Client) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// Name returns the "name" record field value.
+func (m *OAuth2Client) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *OAuth2Client) SetName(name string) {
+	m.Set("name", name)
+}
+
+// CollectionRef returns the "collectionRef" field value.
+//
+// It is the id of the auth collection whose records the client could authenticate.
+func (m *OAuth2Client) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *OAuth2Client) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// SetSecret updates the (hashed) "secret" record field value.
+//
+// Clients without a secret are considered public and are required
+// to use PKCE during the authorization code flow.
+func (m *OAuth2Client) SetSecret(secret string) {
+	m.Set("secret", secret)
+}
+
+// IsPublic reports whether the client doesn't have a secret.
+func (m *OAuth2Client) IsPublic() bool {
+	return m.GetString("secret:hash") == ""
+}
+
+// ValidateSecret validates a plain client secret against the stored hash.
+//
+// Always returns false for public clients.
+func (m *OAuth2Client) ValidateSecret(secret string) bool {
+	pv, ok := m.GetRaw("secret").(*PasswordFieldValue)
+	if !ok {
+		return false
+	}
+
+	return pv.Validate(secret)
+}
+
+// RedirectURIs returns the "redirectURIs" record field value.
+func (m *OAuth2Client) RedirectURIs() []string {
+	return m.GetStringSlice("redirectURIs")
+}
+
+// SetRedirectURIs updates the "redirectURIs" record field value.
+func (m *OAuth2Client) SetRedirectURIs(uris []string) {
+	m.Set("redirectURIs", uris)
+}
+
+// HasRedirectURI reports whether uri exactly matches one of the registered client redirect URIs.
+func (m *OAuth2Client) HasRedirectURI(uri string) bool {
+	return uri != "" && slices.Contains(m.RedirectURIs(), uri)
+}
+
+// Created returns the "created" record field value.
+func (m *OAuth2Client) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OAuth2Client) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+func (app *BaseApp) registerOAuth2ClientHooks() {
+	app.OnRecordValidate(CollectionNameOAuth2Clients).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			err := validation.Validate(
+				e.Record.GetString("collectionRef"),
+				validation.Required,
+				validation.By(validateCollectionId(e.App, CollectionTypeAuth)),
+			)
+			if err != nil {
+				return validation.Errors{"collectionRef": err}
+			}
+
+			err = validation.Validate(
+				e.Record.GetStringSlice("redirectURIs"),
+				validation.Required,
+				validation.Each(validation.Required, is.URL),
+			)
+			if err != nil {
+				return validation.Errors{"redirectURIs": err}
+			}
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+
+	// delete on collection ref delete
+	app.OnCollectionDeleteExecute().Bind(&hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if !e.Collection.IsAuth() {
+				return e.Next()
+			}
+
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				clients, err := txApp.FindAllRecords(CollectionNameOAuth2Clients, dbx.HashExp{"collectionRef": e.Collection.Id})
+				if err != nil {
+					return err
+				}
+
+				for _, client := range clients {
+					if err := txApp.Delete(client); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+}
@@ -0,0 +1,180 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestNewOAuth2Client(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	client := core.NewOAuth2Client(app)
+
+	if client.Collection().Name != core.CollectionNameOAuth2Clients {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameOAuth2Clients, client.Collection().Name)
+	}
+}
+
+func TestOAuth2ClientSecret(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	client := core.NewOAuth2Client(app)
+
+	if !client.IsPublic() {
+		t.Fatal("Expected client without secret to be public")
+	}
+
+	if client.ValidateSecret("") {
+		t.Fatal("Expected empty secret validation to fail for public client")
+	}
+
+	client.SetSecret("1234567890")
+
+	if client.IsPublic() {
+		t.Fatal("Expected client with secret to not be public")
+	}
+
+	if client.ValidateSecret("123456789") {
+		t.Fatal("Expected invalid secret validation to fail")
+	}
+
+	if !client.ValidateSecret("1234567890") {
+		t.Fatal("Expected valid secret validation to succeed")
+	}
+}
+
+func TestOAuth2ClientHasRedirectURI(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	client := core.NewOAuth2Client(app)
+	client.SetRedirectURIs([]string{"https://example.com/a", "https://example.com/b"})
+
+	scenarios := []struct {
+		uri      string
+		expected bool
+	}{
+		{"", false},
+		{"https://example.com", false},
+		{"https://example.com/a/", false},
+		{"https://example.com/a", true},
+		{"https://example.com/b", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.uri, func(t *testing.T) {
+			if v := client.HasRedirectURI(s.uri); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestOAuth2ClientValidateHook(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	demo1, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name         string
+		client       func() *core.OAuth2Client
+		expectErrors []string
+	}{
+		{
+			"empty",
+			func() *core.OAuth2Client {
+				return core.NewOAuth2Client(app)
+			},
+			[]string{"collectionRef"},
+		},
+		{
+			"non-auth collectionRef and invalid redirect URI",
+			func() *core.OAuth2Client {
+				client := core.NewOAuth2Client(app)
+				client.SetName("test")
+				client.SetCollectionRef(demo1.Id)
+				client.SetRedirectURIs([]string{"invalid"})
+				return client
+			},
+			[]string{"collectionRef"},
+		},
+		{
+			"invalid redirect URI",
+			func() *core.OAuth2Client {
+				client := core.NewOAuth2Client(app)
+				client.SetName("test")
+				client.SetCollectionRef(user.Collection().Id)
+				client.SetRedirectURIs([]string{"https://example.com", "invalid"})
+				return client
+			},
+			[]string{"redirectURIs"},
+		},
+		{
+			"valid",
+			func() *core.OAuth2Client {
+				client := core.NewOAuth2Client(app)
+				client.SetName("test")
+				client.SetCollectionRef(user.Collection().Id)
+				client.SetRedirectURIs([]string{"https://example.com/callback"})
+				return client
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			errs := app.Validate(s.client())
+			tests.TestValidationErrors(t, errs, s.expectErrors)
+		})
+	}
+}
+
+func TestOAuth2ClientDeleteOnCollectionDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := core.NewOAuth2Client(app)
+	client.SetName("test")
+	client.SetCollectionRef(collection.Id)
+	client.SetRedirectURIs([]string{"https://example.com/callback"})
+	if err := app.Save(client); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindOAuth2ClientById(client.Id); err == nil {
+		t.Fatal("Expected the OAuth2 client to be deleted")
+	}
+}
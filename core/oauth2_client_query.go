@@ -0,0 +1,21 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// FindOAuth2ClientById returns a single OAuth2Client model by its id.
+func (app *BaseApp) FindOAuth2ClientById(id string) (*OAuth2Client, error) {
+	result := &OAuth2Client{}
+
+	err := app.RecordQuery(CollectionNameOAuth2Clients).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+const CollectionNameOAuth2Codes = "_oauth2Codes"
+
+var (
+	_ Model        = (*OAuth2Code)(nil)
+	_ PreValidator = (*OAuth2Code)(nil)
+	_ RecordProxy  = (*OAuth2Code)(nil)
+)
+
+// OAuth2Code defines a Record proxy for working with the oauth2Codes collection.
+//
+// It represents a single-use authorization code issued by the app
+// OAuth2/OpenID Connect authorization server.
+type OAuth2Code struct {
+	*Record
+}
+
+// NewOAuth2Code instantiates and returns a new blank *OAuth2Code model.
+//
+// Example usage:
+//
+//	code := core.NewOAuth2Code(app)
+//	code.SetRecordRef(user.Id)
+//	code.SetCollectionRef(user.Collection().Id)
+//	code.SetClientRef(client.Id)
+//	plainCode := code.RefreshCode()
+//	app.Save(code)
+func NewOAuth2Code(app App) *OAuth2Code {
+	m := &OAuth2Code{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOAuth2Codes)
+	if err != nil {
+		// this is just to make tests easier since oauth2Codes is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on OAuth2Code.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OAuth2Code) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOAuth2Codes {
+		return errors.New("missing or invalid OAuth2Code ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OAuth2Code) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OAuth2Code) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *OAuth2Code) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *OAuth2Code) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *OAuth2Code) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *OAuth2Code) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// ClientRef returns the "clientRef" record field value.
+func (m *OAuth2Code) ClientRef() string {
+	return m.GetString("clientRef")
+}
+
+// SetClientRef updates the "clientRef" record field value.
+func (m *OAuth2Code) SetClientRef(clientId string) {
+	m.Set("clientRef", clientId)
+}
+
+// RefreshCode generates and assigns a new random authorization code.
+//
+// Only the code hash is stored so make sure to use the returned
+// plain code value before it is discarded.
+func (m *OAuth2Code) RefreshCode() string {
+	code := security.RandomString(40)
+
+	m.Set("code", security.SHA256(code))
+
+	return code
+}
+
+// RedirectURI returns the "redirectURI" record field value.
+func (m *OAuth2Code) RedirectURI() string {
+	return m.GetString("redirectURI")
+}
+
+// SetRedirectURI updates the "redirectURI" record field value.
+func (m *OAuth2Code) SetRedirectURI(uri string) {
+	m.Set("redirectURI", uri)
+}
+
+// Scopes returns the space separated "scope" record field value as slice.
+func (m *OAuth2Code) Scopes() []string {
+	return strings.Fields(m.GetString("scope"))
+}
+
+// SetScopes updates the "scope" record field value.
+func (m *OAuth2Code) SetScopes(scopes []string) {
+	m.Set("scope", strings.Join(scopes, " "))
+}
+
+// Nonce returns the "nonce" record field value.
+func (m *OAuth2Code) Nonce() string {
+	return m.GetString("nonce")
+}
+
+// SetNonce updates the "nonce" record field value.
+func (m *OAuth2Code) SetNonce(nonce string) {
+	m.Set("nonce", nonce)
+}
+
+// CodeChallenge returns the "codeChallenge" (S256 PKCE) record field value.
+func (m *OAuth2Code) CodeChallenge() string {
+	return m.GetString("codeChallenge")
+}
+
+// SetCodeChallenge updates the "codeChallenge" (S256 PKCE) record field value.
+func (m *OAuth2Code) SetCodeChallenge(challenge string) {
+	m.Set("codeChallenge", challenge)
+}
+
+// ValidateCodeVerifier checks whether the provided PKCE verifier
+// matches with the stored S256 code challenge.
+//
+// Returns true if the code doesn't have a code challenge and the verifier is empty.
+func (m *OAuth2Code) ValidateCodeVerifier(verifier string) bool {
+	challenge := m.CodeChallenge()
+	if challenge == "" {
+		return verifier == ""
+	}
+
+	return verifier != "" && security.Equal(security.S256Challenge(verifier), challenge)
+}
+
+// Created returns the "created" record field value.
+func (m *OAuth2Code) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OAuth2Code) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// HasExpired checks if the code is expired, aka. whether it has been
+// more than maxElapsed time since its creation.
+func (m *OAuth2Code) HasExpired(maxElapsed time.Duration) bool {
+	return time.Since(m.Created().Time()) > maxElapsed
+}
+
+func (app *BaseApp) registerOAuth2CodeHooks() {
+	recordRefHooks[*OAuth2Code](app, CollectionNameOAuth2Codes, CollectionTypeAuth)
+
+	// run on every hour to cleanup expired authorization codes
+	app.Cron().Add("__pbOAuth2CodesCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredOAuth2Codes(); err != nil {
+			app.Logger().Warn("Failed to delete expired OAuth2 authorization codes", "error", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package core_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+func TestNewOAuth2Code(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	code := core.NewOAuth2Code(app)
+
+	if code.Collection().Name != core.CollectionNameOAuth2Codes {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameOAuth2Codes, code.Collection().Name)
+	}
+}
+
+func TestOAuth2CodeScopes(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	code := core.NewOAuth2Code(app)
+	code.SetScopes([]string{"openid", "email"})
+
+	if v := code.GetString("scope"); v != "openid email" {
+		t.Fatalf("Expected scope field value %q, got %q", "openid email", v)
+	}
+
+	if v := code.Scopes(); !slices.Equal(v, []string{"openid", "email"}) {
+		t.Fatalf("Expected scopes %v, got %v", []string{"openid", "email"}, v)
+	}
+}
+
+func TestOAuth2CodeValidateCodeVerifier(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	verifier := security.RandomString(50)
+
+	scenarios := []struct {
+		name      string
+		challenge string
+		verifier  string
+		expected  bool
+	}{
+		{"no challenge and empty verifier", "", "", true},
+		{"no challenge and nonempty verifier", "", verifier, false},
+		{"challenge and empty verifier", security.S256Challenge(verifier), "", false},
+		{"challenge and invalid verifier", security.S256Challenge(verifier), verifier + "a", false},
+		{"challenge and valid verifier", security.S256Challenge(verifier), verifier, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			code := core.NewOAuth2Code(app)
+			code.SetCodeChallenge(s.challenge)
+
+			if v := code.ValidateCodeVerifier(s.verifier); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestOAuth2CodeRefreshCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := core.NewOAuth2Code(app)
+	code.SetCollectionRef(user.Collection().Id)
+	code.SetRecordRef(user.Id)
+	code.SetClientRef("test")
+	code.SetRedirectURI("https://example.com/callback")
+	plainCode := code.RefreshCode()
+
+	if v := code.GetString("code"); v == plainCode || v != security.SHA256(plainCode) {
+		t.Fatalf("Expected the stored code to be the plain code hash, got %q", v)
+	}
+
+	if err := app.Save(code); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := app.FindOAuth2CodeByCode(plainCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Id != code.Id {
+		t.Fatalf("Expected code %q, got %q", code.Id, found.Id)
+	}
+
+	if _, err := app.FindOAuth2CodeByCode(code.GetString("code")); err == nil {
+		t.Fatal("Expected the code hash to not be usable as code")
+	}
+}
+
+func TestOAuth2CodeHasExpired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := types.NowDateTime()
+
+	code := core.NewOAuth2Code(app)
+	code.SetRaw("created", now.Add(-5*time.Minute))
+
+	scenarios := []struct {
+		maxElapsed time.Duration
+		expected   bool
+	}{
+		{0 * time.Minute, true},
+		{3 * time.Minute, true},
+		{5*time.Minute + 1*time.Second, false},
+		{6 * time.Minute, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.maxElapsed.String(), func(t *testing.T) {
+			if v := code.HasExpired(s.maxElapsed); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// FindOAuth2CodeByCode returns a single OAuth2Code model by its plain code value.
+func (app *BaseApp) FindOAuth2CodeByCode(code string) (*OAuth2Code, error) {
+	result := &OAuth2Code{}
+
+	err := app.RecordQuery(CollectionNameOAuth2Codes).
+		AndWhere(dbx.HashExp{"code": security.SHA256(code)}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteExpiredOAuth2Codes deletes all expired OAuth2 authorization codes.
+func (app *BaseApp) DeleteExpiredOAuth2Codes() error {
+	minValidDate, err := types.ParseDateTime(time.Now().Add(-1 * app.Settings().OAuth2Server.CodeDurationTime()))
+	if err != nil {
+		return err
+	}
+
+	items := []*Record{}
+
+	err = app.RecordQuery(CollectionNameOAuth2Codes).
+		AndWhere(dbx.NewExp("[[created]] < {:date}", dbx.Params{"date": minValidDate})).
+		All(&items)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		err = app.Delete(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
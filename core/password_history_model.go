@@ -0,0 +1,89 @@
+package core
+
+import (
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+var (
+	_ Model = (*PasswordHistory)(nil)
+)
+
+const PasswordHistoryTableName = "_passwordHistory"
+
+// PasswordHistory defines a single auth record password change
+// stored in the auxiliary app database.
+type PasswordHistory struct {
+	BaseModel
+
+	Created       types.DateTime `db:"created" json:"created"`
+	CollectionRef string         `db:"collectionRef" json:"collectionRef"`
+	RecordRef     string         `db:"recordRef" json:"recordRef"`
+	Hash          string         `db:"hash" json:"-"`
+}
+
+func (m *PasswordHistory) TableName() string {
+	return PasswordHistoryTableName
+}
+
+func (app *BaseApp) registerPasswordHistoryHooks() {
+	trackPasswordChange := func(e *RecordEvent, isNew bool) {
+		collection := e.Record.Collection()
+		if !collection.IsAuth() || !collection.PasswordPolicy.TracksHistory() {
+			return
+		}
+
+		hash := e.Record.GetString(FieldNamePassword + ":hash")
+		if hash == "" || (!isNew && hash == e.Record.Original().GetString(FieldNamePassword+":hash")) {
+			return
+		}
+
+		err := e.App.CreatePasswordHistory(e.Record, hash)
+		if err != nil {
+			e.App.Logger().Warn(
+				"Failed to track the password change",
+				"error", err,
+				"recordId", e.Record.Id,
+				"collectionId", collection.Id,
+			)
+		}
+	}
+
+	// note: track the change only after the successful record save
+	// commit to avoid storing password hashes of rolled back changes
+	app.OnRecordAfterCreateSuccess().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			trackPasswordChange(e, true)
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+
+	app.OnRecordAfterUpdateSuccess().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			trackPasswordChange(e, false)
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+
+	app.OnRecordAfterDeleteSuccess().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			if e.Record.Collection().IsAuth() {
+				if err := e.App.DeleteAllPasswordHistoryByRecord(e.Record); err != nil {
+					e.App.Logger().Warn(
+						"Failed to delete the record password history",
+						"error", err,
+						"recordId", e.Record.Id,
+						"collectionId", e.Record.Collection().Id,
+					)
+				}
+			}
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// PasswordHistoryQuery returns a new PasswordHistory select query.
+func (app *BaseApp) PasswordHistoryQuery() *dbx.SelectQuery {
+	return app.AuxModelQuery(&PasswordHistory{})
+}
+
+// FindPasswordHistory returns up to limit most recent password changes
+// of the provided auth record (in DESC order).
+func (app *BaseApp) FindPasswordHistory(authRecord *Record, limit int) ([]*PasswordHistory, error) {
+	result := []*PasswordHistory{}
+
+	err := app.PasswordHistoryQuery().
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC").
+		Limit(int64(limit)).
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CreatePasswordHistory stores the provided password hash as the latest
+// password change of the auth record and deletes the older entries
+// exceeding the record collection PasswordPolicy.HistorySize.
+func (app *BaseApp) CreatePasswordHistory(authRecord *Record, hash string) error {
+	model := &PasswordHistory{}
+	model.Id = GenerateDefaultRandomId()
+	model.Created = types.NowDateTime()
+	model.CollectionRef = authRecord.Collection().Id
+	model.RecordRef = authRecord.Id
+	model.Hash = hash
+
+	if err := app.AuxSave(model); err != nil {
+		return err
+	}
+
+	// keep at least the latest entry for the max age check
+	keep := max(authRecord.Collection().PasswordPolicy.HistorySize, 1)
+
+	_, err := app.AuxNonconcurrentDB().NewQuery(
+		"DELETE FROM {{" + PasswordHistoryTableName + "}} WHERE [[collectionRef]] = {:collectionRef} AND [[recordRef]] = {:recordRef} AND [[id]] NOT IN (" +
+			"SELECT [[id]] FROM {{" + PasswordHistoryTableName + "}} WHERE [[collectionRef]] = {:collectionRef} AND [[recordRef]] = {:recordRef} ORDER BY [[created]] DESC LIMIT {:keep}" +
+			")",
+	).Bind(dbx.Params{
+		"collectionRef": model.CollectionRef,
+		"recordRef":     model.RecordRef,
+		"keep":          keep,
+	}).Execute()
+
+	return err
+}
+
+// DeleteAllPasswordHistoryByRecord deletes all password history entries of the provided auth record.
+func (app *BaseApp) DeleteAllPasswordHistoryByRecord(authRecord *Record) error {
+	_, err := app.AuxNonconcurrentDB().Delete(PasswordHistoryTableName, dbx.HashExp{
+		"collectionRef": authRecord.Collection().Id,
+		"recordRef":     authRecord.Id,
+	}).Execute()
+
+	return err
+}
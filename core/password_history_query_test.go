@@ -0,0 +1,133 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestCreateAndFindPasswordHistory(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.Collection().PasswordPolicy.HistorySize = 2
+
+	hashes := []string{"hash1", "hash2", "hash3"}
+	for _, h := range hashes {
+		if err := app.CreatePasswordHistory(user, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := app.FindPasswordHistory(user, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("Expected only the last 2 history entries to be kept, got %d", len(history))
+	}
+
+	if history[0].Hash != "hash3" || history[1].Hash != "hash2" {
+		t.Fatalf("Expected [hash3, hash2], got [%s, %s]", history[0].Hash, history[1].Hash)
+	}
+
+	if err := app.DeleteAllPasswordHistoryByRecord(user); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = app.FindPasswordHistory(user, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 0 {
+		t.Fatalf("Expected all history entries to be deleted, got %d", len(history))
+	}
+}
+
+func TestPasswordHistoryTracking(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection.PasswordPolicy.HistorySize = 3
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail(collection, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// non-password change
+	user.Set("name", "new_name")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	history, _ := app.FindPasswordHistory(user, 10)
+	if len(history) != 0 {
+		t.Fatalf("Expected no tracked password changes, got %d", len(history))
+	}
+
+	// password change
+	user.SetPassword("1234567891")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	history, _ = app.FindPasswordHistory(user, 10)
+	if len(history) != 1 || history[0].Hash != user.GetString("password:hash") {
+		t.Fatalf("Expected the new password hash to be tracked, got %v", history)
+	}
+
+	// reusing the same password
+	user.SetPassword("1234567891")
+	if err := app.Save(user); err == nil {
+		t.Fatal("Expected the reused password to be rejected")
+	}
+
+	// rolled back password change
+	err = app.RunInTransaction(func(txApp core.App) error {
+		user.SetPassword("1234567892")
+		if err := txApp.Save(user); err != nil {
+			return err
+		}
+
+		return errors.New("rollback")
+	})
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+
+	history, _ = app.FindPasswordHistory(user, 10)
+	if len(history) != 1 {
+		t.Fatalf("Expected the rolled back password change to not be tracked, got %d", len(history))
+	}
+
+	// delete
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	history, _ = app.FindPasswordHistory(user, 10)
+	if len(history) != 0 {
+		t.Fatalf("Expected the password history to be deleted, got %d", len(history))
+	}
+}
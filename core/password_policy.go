@@ -0,0 +1,251 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// breachedHashLength is the length of a hex encoded SHA-1 hash.
+const breachedHashLength = 40
+
+// minIdentityPartLength is the min length of a record identity value
+// to be checked in the [PasswordPolicyConfig.DisallowIdentity] rule.
+const minIdentityPartLength = 3
+
+// validatePasswordPolicy checks the plain password of the specified
+// auth record against its collection [PasswordPolicyConfig].
+func validatePasswordPolicy(app App, record *Record, plain string) error {
+	collection := record.Collection()
+	policy := collection.PasswordPolicy
+
+	if err := checkPasswordCharClasses(policy, plain); err != nil {
+		return err
+	}
+
+	if policy.DisallowIdentity && passwordContainsIdentity(record, plain) {
+		return validation.NewError("validation_password_contains_identity", "The password must not contain your email, username or other identity values.")
+	}
+
+	if policy.HistorySize > 0 && !record.IsNew() {
+		history, err := app.FindPasswordHistory(record, policy.HistorySize)
+		if err != nil {
+			return err
+		}
+
+		for _, h := range history {
+			if bcrypt.CompareHashAndPassword([]byte(h.Hash), []byte(plain)) == nil {
+				return validation.NewError("validation_password_reused", "The password was recently used. Please choose a different one.")
+			}
+		}
+	}
+
+	if policy.BreachedListFile != "" {
+		breached, err := isBreachedPassword(app, policy.BreachedListFile, plain)
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			return validation.NewError("validation_password_breached", "The password was found in a known data breach. Please choose a different one.")
+		}
+	}
+
+	return nil
+}
+
+func checkPasswordCharClasses(policy PasswordPolicyConfig, plain string) error {
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+
+	for _, r := range plain {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireLowercase && !hasLower {
+		return validation.NewError("validation_password_lowercase_required", "Must contain at least one lowercase letter.")
+	}
+
+	if policy.RequireUppercase && !hasUpper {
+		return validation.NewError("validation_password_uppercase_required", "Must contain at least one uppercase letter.")
+	}
+
+	if policy.RequireDigit && !hasDigit {
+		return validation.NewError("validation_password_digit_required", "Must contain at least one digit.")
+	}
+
+	if policy.RequireSymbol && !hasSymbol {
+		return validation.NewError("validation_password_symbol_required", "Must contain at least one symbol.")
+	}
+
+	return nil
+}
+
+func passwordContainsIdentity(record *Record, plain string) bool {
+	collection := record.Collection()
+
+	names := append([]string{FieldNameEmail, "username"}, collection.PasswordAuth.IdentityFields...)
+
+	values := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		if collection.Fields.GetByName(name) == nil {
+			continue
+		}
+
+		v := record.GetString(name)
+		values = append(values, v)
+
+		// check also the email local part
+		if name == FieldNameEmail {
+			if at := strings.LastIndex(v, "@"); at > 0 {
+				values = append(values, v[:at])
+			}
+		}
+	}
+
+	plain = strings.ToLower(plain)
+
+	for _, v := range values {
+		if len([]rune(v)) >= minIdentityPartLength && strings.Contains(plain, strings.ToLower(v)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBreachedPassword reports whether the SHA-1 hash of the provided
+// password matches with any of the hashes in the listFile.
+//
+// The list file is parsed once and cached in memory until its
+// modification time or size changes.
+func isBreachedPassword(app App, listFile string, plain string) (bool, error) {
+	list, err := loadBreachedList(listFile)
+	if err != nil {
+		app.Logger().Error("Failed to load the breached passwords list file", "file", listFile, "error", err)
+		return false, err
+	}
+
+	sum := sha1.Sum([]byte(plain))
+
+	return list.contains(strings.ToUpper(hex.EncodeToString(sum[:]))), nil
+}
+
+// breachedList is a parsed breached passwords list file.
+type breachedList struct {
+	modTime time.Time
+	size    int64
+
+	// hashes is the set of the uppercased full list hashes.
+	hashes map[string]struct{}
+}
+
+// contains reports whether the provided uppercased hash
+// matches with any of the list hashes.
+func (l *breachedList) contains(hash string) bool {
+	_, ok := l.hashes[hash]
+	return ok
+}
+
+var (
+	breachedListsMu sync.Mutex
+	breachedLists   = map[string]*breachedList{}
+)
+
+// loadBreachedList returns the cached parsed listFile or
+// (re)loads it if it wasn't loaded yet or it was modified.
+func loadBreachedList(listFile string) (*breachedList, error) {
+	info, err := os.Stat(listFile)
+	if err != nil {
+		return nil, err
+	}
+
+	breachedListsMu.Lock()
+	defer breachedListsMu.Unlock()
+
+	if l, ok := breachedLists[listFile]; ok && l.modTime.Equal(info.ModTime()) && l.size == info.Size() {
+		return l, nil
+	}
+
+	l, err := parseBreachedList(listFile)
+	if err != nil {
+		return nil, err
+	}
+	l.modTime = info.ModTime()
+	l.size = info.Size()
+
+	breachedLists[listFile] = l
+
+	return l, nil
+}
+
+func parseBreachedList(listFile string) (*breachedList, error) {
+	f, err := os.Open(listFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l := &breachedList{hashes: map[string]struct{}{}}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if hash, ok := parseBreachedListLine(line); ok {
+			l.hashes[hash] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// parseBreachedListLine extracts the full uppercased SHA-1 hash from
+// a single breached list line in one of the following formats:
+//
+//	HASH[:COUNT]
+//	PREFIX:SUFFIX[:COUNT] (eg. the HIBP range files with their prefix)
+//
+// Lines that don't resolve to a full length hex hash are ignored
+// to avoid rejecting unrelated passwords because of a partial match.
+func parseBreachedListLine(line string) (string, bool) {
+	parts := strings.Split(line, ":")
+
+	hash := parts[0]
+	if len(hash) != breachedHashLength && len(parts) > 1 {
+		hash += parts[1]
+	}
+
+	if len(hash) != breachedHashLength {
+		return "", false
+	}
+
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+
+	return strings.ToUpper(hash), true
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadBreachedList(t *testing.T) {
+	t.Parallel()
+
+	listFile := filepath.Join(t.TempDir(), "breached.txt")
+
+	if _, err := loadBreachedList(listFile); err == nil {
+		t.Fatal("Expected missing list file error")
+	}
+
+	err := os.WriteFile(listFile, []byte(
+		"# sha1 hashes\n"+
+			"7c4a8d09ca3762af61e59520943dc26494f8941b:123\n"+ // "123456"
+			"20EAB:E5D64B0E216796E834F52D61FD0B70332FC:5\n"+ // "1234567" split as prefix:suffix
+			"12345\n"+ // short line (ignored)
+			"8CB2237D0679CA88DB6464EAC60DA96345513964:1\n"+ // "12345"
+			"ZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ\n", // non-hex (ignored)
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := loadBreachedList(listFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		hash     string
+		expected bool
+	}{
+		{"7C4A8D09CA3762AF61E59520943DC26494F8941B", true},
+		{"20EABE5D64B0E216796E834F52D61FD0B70332FC", true},
+		{"8CB2237D0679CA88DB6464EAC60DA96345513964", true},
+		{"1234567890123456789012345678901234567890", false},
+		{"1234500000000000000000000000000000000000", false},
+		{"7C4A8D09CA3762AF61E59520943DC26494F8941C", false},
+		{"ZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ", false},
+	}
+
+	for _, s := range scenarios {
+		if v := list.contains(s.hash); v != s.expected {
+			t.Fatalf("Expected %s contains to be %v, got %v", s.hash, s.expected, v)
+		}
+	}
+
+	cached, err := loadBreachedList(listFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached != list {
+		t.Fatal("Expected the cached list to be returned")
+	}
+
+	// modified list file
+	if err := os.WriteFile(listFile, []byte("1234567890123456789012345678901234567890"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(listFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := loadBreachedList(listFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded == list {
+		t.Fatal("Expected the list to be reloaded after modification")
+	}
+	if !reloaded.contains("1234567890123456789012345678901234567890") ||
+		reloaded.contains("7C4A8D09CA3762AF61E59520943DC26494F8941B") {
+		t.Fatalf("Expected the reloaded list hashes, got %v", reloaded.hashes)
+	}
+}
@@ -2,12 +2,14 @@ package core
 
 import (
 	"context"
+	"crypto"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/thewandererbg/pgbase/tools/dbutils"
 	"github.com/thewandererbg/pgbase/tools/inflector"
@@ -499,6 +501,20 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 		return nil, errors.New("the token is not associated to an auth collection record")
 	}
 
+	// asymmetric signed auth token
+	header, err := security.JWTHeader(token)
+	if err != nil {
+		return nil, err
+	}
+	if alg, _ := header["alg"].(string); alg != "" && alg != "HS256" {
+		err = app.verifySignedAuthToken(record, token, tokenType, unverifiedClaims)
+		if err != nil {
+			return nil, err
+		}
+
+		return record, nil
+	}
+
 	var baseTokenKey string
 	switch tokenType {
 	case TokenTypeAuth:
@@ -511,6 +527,10 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 		baseTokenKey = record.Collection().PasswordResetToken.Secret
 	case TokenTypeEmailChange:
 		baseTokenKey = record.Collection().EmailChangeToken.Secret
+	case TokenTypeMagicLink:
+		baseTokenKey = record.Collection().AuthToken.Secret
+	case TokenTypeOAuth2Access:
+		baseTokenKey = record.Collection().AuthToken.Secret
 	default:
 		return nil, errors.New("unknown token type " + tokenType)
 	}
@@ -526,6 +546,38 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 	return record, nil
 }
 
+// verifySignedAuthToken verifies the signature and the token key hash
+// of an asymmetric signed auth token issued for the provided record.
+func (app *BaseApp) verifySignedAuthToken(record *Record, token string, tokenType string, unverifiedClaims jwt.MapClaims) error {
+	if tokenType != TokenTypeAuth && tokenType != TokenTypeOAuth2Access {
+		return errors.New("only auth and OAuth2 access tokens could be signed with asymmetric key")
+	}
+
+	_, err := security.ParseSignedJWT(token, func(kid string) (crypto.PublicKey, error) {
+		signingKey, ok := app.Settings().SigningKeys.FindKey(kid)
+		if !ok || signingKey.CollectionId != record.Collection().Id {
+			return nil, fmt.Errorf("missing or invalid signing key %q", kid)
+		}
+
+		signer, err := signingKey.Signer()
+		if err != nil {
+			return nil, err
+		}
+
+		return signer.Public(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	keyHash, _ := unverifiedClaims[TokenClaimKeyHash].(string)
+	if keyHash == "" || !security.Equal(keyHash, record.tokenKeyHash()) {
+		return errors.New("the token key has been changed")
+	}
+
+	return nil
+}
+
 // FindAuthRecordByEmail finds the auth record associated with the provided email.
 //
 // The email check would be case-insensitive if the related collection
@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +16,8 @@ const (
 	TokenTypeVerification  = "verification"
 	TokenTypePasswordReset = "passwordReset"
 	TokenTypeEmailChange   = "emailChange"
+	TokenTypeMagicLink     = "magicLink"
+	TokenTypeOAuth2Access  = "oauth2Access"
 )
 
 // List with commonly used record token claims
@@ -25,6 +28,10 @@ const (
 	TokenClaimEmail        = "email"
 	TokenClaimNewEmail     = "newEmail"
 	TokenClaimRefreshable  = "refreshable"
+	TokenClaimKeyHash      = "keyHash"
+	TokenClaimOTPId        = "otpId"
+	TokenClaimAudience     = "aud"
+	TokenClaimScope        = "scope"
 )
 
 // Common token related errors
@@ -72,6 +79,115 @@ func (m *Record) newAuthToken(duration time.Duration, refreshable bool) (string,
 	return security.NewJWT(claims, key, duration)
 }
 
+// newSignedAuthToken generates and returns a new record authentication
+// token signed with the provided asymmetric signing key.
+//
+// Because the record token key can't be part of the signature, its
+// hash is stored as separate claim so that the token could be still
+// invalidated on password or token key change.
+func (m *Record) newSignedAuthToken(signingKey SigningKeyConfig, duration time.Duration, refreshable bool) (string, error) {
+	if !m.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	signer, err := signingKey.Signer()
+	if err != nil {
+		return "", errors.Join(ErrMissingSigningKey, err)
+	}
+
+	claims := jwt.MapClaims{
+		TokenClaimType:         TokenTypeAuth,
+		TokenClaimId:           m.Id,
+		TokenClaimCollectionId: m.Collection().Id,
+		TokenClaimRefreshable:  refreshable,
+		TokenClaimKeyHash:      m.tokenKeyHash(),
+	}
+
+	if duration <= 0 {
+		duration = m.Collection().AuthToken.DurationTime()
+	}
+
+	return security.NewSignedJWT(claims, signer, signingKey.Id, duration)
+}
+
+// tokenKeyHash returns a keyed hash of the record token key that is
+// used to bind the asymmetric signed auth tokens to the current token key.
+func (m *Record) tokenKeyHash() string {
+	return security.HS256(m.TokenKey(), m.Collection().AuthToken.Secret)
+}
+
+// NewAuthToken generates and returns a new authentication token for the provided auth record.
+//
+// If the record collection has an active signing key in the app settings,
+// the token is signed with it (RS256 or EdDSA), otherwise it fallbacks
+// to the default HS256 [Record.NewAuthToken].
+func (app *BaseApp) NewAuthToken(record *Record) (string, error) {
+	return app.newAuthToken(record, 0, true)
+}
+
+// NewStaticAuthToken generates and returns a new static authentication token for the provided auth record.
+//
+// Similar to [BaseApp.NewAuthToken], the token is signed with the
+// record collection active signing key (if any).
+//
+// Zero or negative duration will fallback to the duration from the auth collection settings.
+func (app *BaseApp) NewStaticAuthToken(record *Record, duration time.Duration) (string, error) {
+	return app.newAuthToken(record, duration, false)
+}
+
+func (app *BaseApp) newAuthToken(record *Record, duration time.Duration, refreshable bool) (string, error) {
+	signingKey, ok := app.Settings().SigningKeys.ActiveKey(record.Collection().Id)
+	if !ok {
+		return record.newAuthToken(duration, refreshable)
+	}
+
+	return record.newSignedAuthToken(signingKey, duration, refreshable)
+}
+
+// NewOAuth2AccessToken generates and returns a new access token issued
+// to a third-party OAuth2 client on behalf of the provided auth record.
+//
+// The token is bound to the client id ("aud" claim) and the granted
+// space separated scopes ("scope" claim) and it is not accepted
+// as regular auth token by the record APIs.
+//
+// Similar to [BaseApp.NewAuthToken], the token is signed with the
+// record collection active signing key (if any).
+func (app *BaseApp) NewOAuth2AccessToken(record *Record, clientId string, scopes []string) (string, error) {
+	if !record.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	claims := jwt.MapClaims{
+		TokenClaimType:         TokenTypeOAuth2Access,
+		TokenClaimId:           record.Id,
+		TokenClaimCollectionId: record.Collection().Id,
+		TokenClaimAudience:     clientId,
+		TokenClaimScope:        strings.Join(scopes, " "),
+	}
+
+	duration := record.Collection().AuthToken.DurationTime()
+
+	signingKey, ok := app.Settings().SigningKeys.ActiveKey(record.Collection().Id)
+	if !ok {
+		key := (record.TokenKey() + record.Collection().AuthToken.Secret)
+		if key == "" {
+			return "", ErrMissingSigningKey
+		}
+
+		return security.NewJWT(claims, key, duration)
+	}
+
+	signer, err := signingKey.Signer()
+	if err != nil {
+		return "", errors.Join(ErrMissingSigningKey, err)
+	}
+
+	claims[TokenClaimKeyHash] = record.tokenKeyHash()
+
+	return security.NewSignedJWT(claims, signer, signingKey.Id, duration)
+}
+
 // NewVerificationToken generates and returns a new record verification token.
 func (m *Record) NewVerificationToken() (string, error) {
 	if !m.Collection().IsAuth() {
@@ -163,3 +279,28 @@ func (m *Record) NewFileToken() (string, error) {
 		m.Collection().FileToken.DurationTime(),
 	)
 }
+
+// NewMagicLinkToken generates and returns a new OTP magic link token for the specified OTP id.
+//
+// The token is valid for the duration of the collection OTP.
+func (m *Record) NewMagicLinkToken(otpId string) (string, error) {
+	if !m.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	key := (m.TokenKey() + m.Collection().AuthToken.Secret)
+	if key == "" {
+		return "", ErrMissingSigningKey
+	}
+
+	return security.NewJWT(
+		jwt.MapClaims{
+			TokenClaimType:         TokenTypeMagicLink,
+			TokenClaimId:           m.Id,
+			TokenClaimCollectionId: m.Collection().Id,
+			TokenClaimOTPId:        otpId,
+		},
+		key,
+		m.Collection().OTP.DurationTime(),
+	)
+}
@@ -83,6 +83,161 @@ func TestNewAuthToken(t *testing.T) {
 	})
 }
 
+func TestAppNewAuthTokenWithSigningKey(t *testing.T) {
+	t.Parallel()
+
+	algs := []string{security.JWTAlgRS256, security.JWTAlgEdDSA}
+
+	for _, alg := range algs {
+		t.Run(alg, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// no signing key -> fallback to HS256
+			hsToken, err := app.NewAuthToken(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header, _ := security.JWTHeader(hsToken); header["alg"] != "HS256" {
+				t.Fatalf("Expected HS256 token, got %v", header)
+			}
+
+			key, err := app.Settings().SigningKeys.Rotate(user.Collection().Id, alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := app.NewAuthToken(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header, err := security.JWTHeader(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header["alg"] != alg || header["kid"] != key.Id {
+				t.Fatalf("Expected alg %q and kid %q headers, got %v", alg, key.Id, header)
+			}
+
+			staticToken, err := app.NewStaticAuthToken(user, 100*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, _ := security.ParseUnverifiedJWT(staticToken)
+			if claims[core.TokenClaimRefreshable] != false {
+				t.Fatalf("Expected non-refreshable static token, got %v", claims)
+			}
+
+			for _, tk := range []string{token, staticToken} {
+				tokenRecord, err := app.FindAuthRecordByToken(tk, core.TokenTypeAuth)
+				if err != nil || tokenRecord.Id != user.Id {
+					t.Fatalf("Expected auth record %q, got %v (%v)", user.Id, tokenRecord, err)
+				}
+			}
+
+			// the previously issued HS256 tokens should remain valid
+			if _, err := app.FindAuthRecordByToken(hsToken); err != nil {
+				t.Fatalf("Expected the HS256 token to remain valid, got %v", err)
+			}
+
+			// rotated keys should still verify the previously issued tokens
+			if _, err := app.Settings().SigningKeys.Rotate(user.Collection().Id, alg); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.FindAuthRecordByToken(token); err != nil {
+				t.Fatalf("Expected the token to remain valid after key rotation, got %v", err)
+			}
+
+			// removed keys should invalidate the token
+			app.Settings().SigningKeys.Keys = app.Settings().SigningKeys.Keys[1:]
+			if _, err := app.FindAuthRecordByToken(token); err == nil {
+				t.Fatal("Expected the token to be invalid after its signing key removal")
+			}
+
+			// token key change should invalidate the token
+			newToken, err := app.NewAuthToken(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			user.RefreshTokenKey()
+			if err := app.Save(user); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.FindAuthRecordByToken(newToken); err == nil {
+				t.Fatal("Expected the token to be invalid after the token key change")
+			}
+		})
+	}
+}
+
+func TestAppNewOAuth2AccessToken(t *testing.T) {
+	t.Parallel()
+
+	algs := []string{"HS256", security.JWTAlgRS256, security.JWTAlgEdDSA}
+
+	for _, alg := range algs {
+		t.Run(alg, func(t *testing.T) {
+			app, err := tests.NewTestApp()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer app.Cleanup()
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if alg != "HS256" {
+				if _, err := app.Settings().SigningKeys.Rotate(user.Collection().Id, alg); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			token, err := app.NewOAuth2AccessToken(user, "test_client", []string{"openid", "email"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if header, _ := security.JWTHeader(token); header["alg"] != alg {
+				t.Fatalf("Expected %s token, got %v", alg, header)
+			}
+
+			claims, _ := security.ParseUnverifiedJWT(token)
+			if claims[core.TokenClaimType] != core.TokenTypeOAuth2Access ||
+				claims[core.TokenClaimAudience] != "test_client" ||
+				claims[core.TokenClaimScope] != "openid email" {
+				t.Fatalf("Invalid OAuth2 access token claims %v", claims)
+			}
+
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err == nil {
+				t.Fatal("Expected the OAuth2 access token to be rejected as auth token")
+			}
+
+			tokenRecord, err := app.FindAuthRecordByToken(token, core.TokenTypeOAuth2Access)
+			if err != nil || tokenRecord.Id != user.Id {
+				t.Fatalf("Expected auth record %q, got %v (%v)", user.Id, tokenRecord, err)
+			}
+
+			// token key change should invalidate the token
+			user.RefreshTokenKey()
+			if err := app.Save(user); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeOAuth2Access); err == nil {
+				t.Fatal("Expected the token to be invalid after the token key change")
+			}
+		})
+	}
+}
+
 func TestNewVerificationToken(t *testing.T) {
 	t.Parallel()
 
@@ -115,6 +270,16 @@ func TestNewFileToken(t *testing.T) {
 	}, nil)
 }
 
+func TestNewMagicLinkToken(t *testing.T) {
+	t.Parallel()
+
+	testRecordToken(t, core.TokenTypeMagicLink, func(record *core.Record) (string, error) {
+		return record.NewMagicLinkToken("test_otp_id")
+	}, map[string]any{
+		core.TokenClaimOTPId: "test_otp_id",
+	})
+}
+
 func testRecordToken(
 	t *testing.T,
 	tokenType string,
@@ -2,9 +2,11 @@ package core
 
 import (
 	"context"
+	"crypto"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"slices"
@@ -13,13 +15,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ganigeorgiev/fexpr"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/thewandererbg/pgbase/core/validators"
 	"github.com/thewandererbg/pgbase/tools/cron"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
 	"github.com/thewandererbg/pgbase/tools/hook"
 	"github.com/thewandererbg/pgbase/tools/mailer"
 	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
 	"github.com/thewandererbg/pgbase/tools/types"
 )
 
@@ -128,6 +133,18 @@ type settings struct {
 	TrustedProxy TrustedProxyConfig `form:"trustedProxy" json:"trustedProxy"`
 	Batch        BatchConfig        `form:"batch" json:"batch"`
 	Logs         LogsConfig         `form:"logs" json:"logs"`
+	SigningKeys  SigningKeysConfig  `form:"signingKeys" json:"signingKeys"`
+	OAuth2Server OAuth2ServerConfig `form:"oauth2Server" json:"oauth2Server"`
+	SCIM         SCIMConfig         `form:"scim" json:"scim"`
+	Broadcast    BroadcastConfig    `form:"broadcast" json:"broadcast"`
+	Realtime     RealtimeConfig     `form:"realtime" json:"realtime"`
+
+	ImageTransforms ImageTransformsConfig `form:"imageTransforms" json:"imageTransforms"`
+	PostgresStorage PostgresStorageConfig `form:"postgresStorage" json:"postgresStorage"`
+	FilesGC         FilesGCConfig         `form:"filesGC" json:"filesGC"`
+	MailOutbox      MailOutboxConfig      `form:"mailOutbox" json:"mailOutbox"`
+	MailCatcher     MailCatcherConfig     `form:"mailCatcher" json:"mailCatcher"`
+	CronHistory     CronHistoryConfig     `form:"cronHistory" json:"cronHistory"`
 }
 
 // Settings defines the PocketBase app settings.
@@ -169,6 +186,15 @@ func newDefaultSettings() *Settings {
 				MaxRequests: 50,
 				Timeout:     3,
 			},
+			OAuth2Server: OAuth2ServerConfig{
+				Enabled:         false,
+				CodeDuration:    60,
+				IdTokenDuration: 3600,
+			},
+			Realtime: RealtimeConfig{
+				QueueSize:      subscriptions.DefaultQueueSize,
+				OverflowPolicy: string(subscriptions.OverflowDropOldest),
+			},
 			RateLimits: RateLimitsConfig{
 				Enabled: false, // @todo once tested enough enable by default for new installations
 				Rules: []RateLimitRule{
@@ -224,6 +250,10 @@ func (s *Settings) MarkAsNotNew() {
 // PostScan implements [Model.PostScan] interface method.
 func (s *Settings) PostScan() error {
 	s.MarkAsNotNew()
+
+	// the signing keys could have been changed
+	resetSigningKeysCache()
+
 	return nil
 }
 
@@ -287,6 +317,17 @@ func (s *Settings) PostValidate(ctx context.Context, app App) error {
 		validation.Field(&s.Batch),
 		validation.Field(&s.RateLimits),
 		validation.Field(&s.TrustedProxy),
+		validation.Field(&s.SigningKeys),
+		validation.Field(&s.OAuth2Server),
+		validation.Field(&s.SCIM),
+		validation.Field(&s.Broadcast),
+		validation.Field(&s.Realtime),
+		validation.Field(&s.ImageTransforms),
+		validation.Field(&s.PostgresStorage),
+		validation.Field(&s.FilesGC),
+		validation.Field(&s.MailOutbox),
+		validation.Field(&s.MailCatcher),
+		validation.Field(&s.CronHistory),
 	)
 }
 
@@ -331,6 +372,15 @@ func (s *Settings) MarshalJSON() ([]byte, error) {
 		&copy.SMTP.Password,
 		&copy.S3.Secret,
 		&copy.Backups.S3.Secret,
+		&copy.SCIM.Token,
+		&copy.ImageTransforms.Secret,
+		&copy.SMTP.DKIM.PrivateKey,
+	}
+
+	// note: clone the slice to avoid modifying the original keys
+	copy.SigningKeys.Keys = slices.Clone(copy.SigningKeys.Keys)
+	for i := range copy.SigningKeys.Keys {
+		sensitiveFields = append(sensitiveFields, &copy.SigningKeys.Keys[i].PrivateKey)
 	}
 
 	// mask all sensitive fields
@@ -366,6 +416,10 @@ type SMTPConfig struct {
 	//
 	// This is required only by some SMTP servers, such as Gmail SMTP-relay.
 	LocalName string `form:"localName" json:"localName"`
+
+	// DKIM configures the DKIM signing of the outgoing mails
+	// (applied for both the SMTP and sendmail clients).
+	DKIM DKIMConfig `form:"dkim" json:"dkim"`
 }
 
 // Validate makes SMTPConfig validatable by implementing [validation.Validatable] interface.
@@ -389,9 +443,85 @@ func (c SMTPConfig) Validate() error {
 			validation.In(mailer.SMTPAuthLogin, mailer.SMTPAuthPlain),
 		),
 		validation.Field(&c.LocalName, is.Host),
+		validation.Field(&c.DKIM),
 	)
 }
 
+var dkimSelectorRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-\.]*[a-zA-Z0-9])?$`)
+
+var dkimHeaderRegex = regexp.MustCompile(`^[\x21-\x39\x3b-\x7e]+$`)
+
+type DKIMConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Domain is the signing domain ("d=" tag), eg. "example.com".
+	Domain string `form:"domain" json:"domain"`
+
+	// Selector is the DNS key record selector ("s=" tag), eg. "mail"
+	// for a public key published at "mail._domainkey.example.com".
+	Selector string `form:"selector" json:"selector"`
+
+	// PrivateKey is the PEM encoded RSA or Ed25519 signing private key.
+	//
+	// Similar to the other sensitive settings fields it is never exposed
+	// and it is stored encrypted if the app encryption env is set.
+	PrivateKey string `form:"privateKey" json:"privateKey,omitempty"`
+
+	// Headers is an optional list of the header fields to sign.
+	//
+	// If empty, fallbacks to [mailer.DefaultDKIMHeaders].
+	Headers []string `form:"headers" json:"headers"`
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (c DKIMConfig) MarshalJSON() ([]byte, error) {
+	type alias DKIMConfig
+
+	// serialize as empty array
+	if c.Headers == nil {
+		c.Headers = []string{}
+	}
+
+	return json.Marshal(alias(c))
+}
+
+// Validate makes DKIMConfig validatable by implementing [validation.Validatable] interface.
+func (c DKIMConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Domain, validation.When(c.Enabled, validation.Required), is.Domain),
+		validation.Field(
+			&c.Selector,
+			validation.When(c.Enabled, validation.Required),
+			validation.Length(0, 63),
+			validation.Match(dkimSelectorRegex),
+		),
+		validation.Field(
+			&c.PrivateKey,
+			validation.When(c.Enabled, validation.Required),
+			validation.By(checkDKIMPrivateKey),
+		),
+		validation.Field(&c.Headers, validation.Each(validation.Required, validation.Match(dkimHeaderRegex))),
+	)
+}
+
+func checkDKIMPrivateKey(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	if _, err := security.ParseSigningKey(v); err != nil {
+		return validation.NewError("validation_invalid_dkim_key", "Invalid or unsupported PEM private key (must be RSA or Ed25519).")
+	}
+
+	return nil
+}
+
+// Signer creates a new DKIM signer from the current config.
+func (c DKIMConfig) Signer() (*mailer.DKIMSigner, error) {
+	return mailer.NewDKIMSigner(c.Domain, c.Selector, c.PrivateKey, c.Headers)
+}
+
 // -------------------------------------------------------------------
 
 type S3Config struct {
@@ -402,6 +532,12 @@ type S3Config struct {
 	AccessKey      string `form:"accessKey" json:"accessKey"`
 	Secret         string `form:"secret" json:"secret,omitempty"`
 	ForcePathStyle bool   `form:"forcePathStyle" json:"forcePathStyle"`
+
+	// PresignedDownloads redirects the file downloads to short-lived
+	// presigned S3 urls instead of proxying them through the app.
+	//
+	// It is used only for the main files storage.
+	PresignedDownloads bool `form:"presignedDownloads" json:"presignedDownloads"`
 }
 
 // Validate makes S3Config validatable by implementing [validation.Validatable] interface.
@@ -417,6 +553,186 @@ func (c S3Config) Validate() error {
 
 // -------------------------------------------------------------------
 
+// MaxPostgresStorageChunkSize is the max allowed Postgres files storage chunk size in bytes.
+const MaxPostgresStorageChunkSize = 16 << 20
+
+type PostgresStorageConfig struct {
+	// Enabled stores the app files in the main Postgres database
+	// (could be useful for deployments without persistent volumes).
+	//
+	// Note that the S3 storage takes precedence if both are enabled.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// ChunkSize is the size in bytes of the stored file content chunks.
+	//
+	// If not set, fallbacks to 1MB.
+	ChunkSize int `form:"chunkSize" json:"chunkSize"`
+}
+
+// Validate makes PostgresStorageConfig validatable by implementing [validation.Validatable] interface.
+func (c PostgresStorageConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.ChunkSize, validation.Min(0), validation.Max(MaxPostgresStorageChunkSize)),
+	)
+}
+
+// -------------------------------------------------------------------
+
+type FilesGCConfig struct {
+	// Cron is a cron expression to schedule the orphaned storage files cleanup, eg. "0 3 * * *".
+	//
+	// Leave it empty to disable the scheduled cleanup.
+	Cron string `form:"cron" json:"cron"`
+
+	// GracePeriod is the min age in seconds of an orphaned file before it is deleted.
+	//
+	// If not set, fallbacks to 24 hours.
+	GracePeriod int64 `form:"gracePeriod" json:"gracePeriod"`
+}
+
+// Validate makes FilesGCConfig validatable by implementing [validation.Validatable] interface.
+func (c FilesGCConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Cron, validation.By(checkCronExpression)),
+		validation.Field(&c.GracePeriod, validation.Min(0)),
+	)
+}
+
+// GetGracePeriod returns the orphaned files grace period duration
+// (fallbacks to [DefaultFilesGCGracePeriod] if not set).
+func (c FilesGCConfig) GetGracePeriod() time.Duration {
+	if c.GracePeriod <= 0 {
+		return DefaultFilesGCGracePeriod
+	}
+
+	return time.Duration(c.GracePeriod) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+const (
+	// DefaultMailOutboxWorkers is the default number of mail outbox delivery workers per app instance.
+	DefaultMailOutboxWorkers = 2
+
+	// DefaultMailOutboxMaxAttempts is the default max number of delivery attempts of a single outbox message.
+	DefaultMailOutboxMaxAttempts = 5
+)
+
+type MailOutboxConfig struct {
+	// Enabled enqueues the app mail messages in the database
+	// (as part of the current transaction if any) instead of sending
+	// them directly, and delivers them in the background with retries.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Workers is the number of the concurrent delivery workers per app instance.
+	//
+	// If not set, fallbacks to 2.
+	Workers int `form:"workers" json:"workers"`
+
+	// MaxAttempts is the max number of delivery attempts of a single
+	// message before it is marked as failed.
+	//
+	// If not set, fallbacks to 5.
+	MaxAttempts int `form:"maxAttempts" json:"maxAttempts"`
+
+	// MaxDays is the number of days to keep the sent and failed messages.
+	//
+	// Set to 0 to keep them forever.
+	MaxDays int `form:"maxDays" json:"maxDays"`
+}
+
+// Validate makes MailOutboxConfig validatable by implementing [validation.Validatable] interface.
+func (c MailOutboxConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Workers, validation.Min(0), validation.Max(100)),
+		validation.Field(&c.MaxAttempts, validation.Min(0), validation.Max(100)),
+		validation.Field(&c.MaxDays, validation.Min(0)),
+	)
+}
+
+// GetWorkers returns the number of the delivery workers
+// (fallbacks to [DefaultMailOutboxWorkers] if not set).
+func (c MailOutboxConfig) GetWorkers() int {
+	if c.Workers <= 0 {
+		return DefaultMailOutboxWorkers
+	}
+
+	return c.Workers
+}
+
+// GetMaxAttempts returns the max number of delivery attempts
+// (fallbacks to [DefaultMailOutboxMaxAttempts] if not set).
+func (c MailOutboxConfig) GetMaxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return DefaultMailOutboxMaxAttempts
+	}
+
+	return c.MaxAttempts
+}
+
+// -------------------------------------------------------------------
+
+type MailCatcherConfig struct {
+	// Enabled stores the app mail messages in memory instead of sending
+	// them so that they could be inspected with the mail catcher api.
+	//
+	// It has effect only when the app is running in dev mode.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// MaxMessages is the max number of the stored messages.
+	//
+	// If not set, fallbacks to 100.
+	MaxMessages int `form:"maxMessages" json:"maxMessages"`
+}
+
+// Validate makes MailCatcherConfig validatable by implementing [validation.Validatable] interface.
+func (c MailCatcherConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxMessages, validation.Min(0), validation.Max(10000)),
+	)
+}
+
+// GetMaxMessages returns the max number of the stored messages
+// (fallbacks to [mailer.DefaultCatcherMaxMessages] if not set).
+func (c MailCatcherConfig) GetMaxMessages() int {
+	if c.MaxMessages <= 0 {
+		return mailer.DefaultCatcherMaxMessages
+	}
+
+	return c.MaxMessages
+}
+
+// -------------------------------------------------------------------
+
+// DefaultCronHistoryMaxDays is the default number of days to keep the cron job runs history.
+const DefaultCronHistoryMaxDays = 7
+
+type CronHistoryConfig struct {
+	// MaxDays is the number of days to keep the cron job runs history.
+	//
+	// If not set, fallbacks to 7.
+	MaxDays int `form:"maxDays" json:"maxDays"`
+}
+
+// Validate makes CronHistoryConfig validatable by implementing [validation.Validatable] interface.
+func (c CronHistoryConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxDays, validation.Min(0)),
+	)
+}
+
+// GetMaxDays returns the number of days to keep the cron job runs history
+// (fallbacks to [DefaultCronHistoryMaxDays] if not set).
+func (c CronHistoryConfig) GetMaxDays() int {
+	if c.MaxDays <= 0 {
+		return DefaultCronHistoryMaxDays
+	}
+
+	return c.MaxDays
+}
+
+// -------------------------------------------------------------------
+
 type BatchConfig struct {
 	Enabled bool `form:"enabled" json:"enabled"`
 
@@ -704,3 +1020,624 @@ func (c RateLimitRule) Validate() error {
 func (c RateLimitRule) DurationTime() time.Duration {
 	return time.Duration(c.Duration) * time.Second
 }
+
+// -------------------------------------------------------------------
+
+// SigningKeysConfig defines the optional asymmetric auth token signing keys.
+//
+// When an auth collection has an active signing key, its auth tokens
+// are signed with it (RS256 or EdDSA) instead of the collection HS256 secret
+// so that external services could verify them offline using the public
+// keys from the "/.well-known/jwks.json" endpoint.
+type SigningKeysConfig struct {
+	Keys []SigningKeyConfig `form:"keys" json:"keys"`
+}
+
+// ActiveKey returns the active signing key of the specified auth collection.
+func (c SigningKeysConfig) ActiveKey(collectionId string) (SigningKeyConfig, bool) {
+	for _, k := range c.Keys {
+		if k.Active && k.CollectionId == collectionId {
+			return k, true
+		}
+	}
+
+	return SigningKeyConfig{}, false
+}
+
+// FindKey returns the signing key with the specified id (aka. "kid").
+func (c SigningKeysConfig) FindKey(id string) (SigningKeyConfig, bool) {
+	for _, k := range c.Keys {
+		if k.Id == id {
+			return k, true
+		}
+	}
+
+	return SigningKeyConfig{}, false
+}
+
+// Rotate generates a new active signing key for the specified auth
+// collection and deactivates its previous one (if any).
+//
+// Note that the deactivated keys are still kept and used for
+// verifying the already issued tokens until they are explicitly removed.
+func (c *SigningKeysConfig) Rotate(collectionId string, algorithm string) (SigningKeyConfig, error) {
+	privateKey, err := security.GenerateSigningKey(algorithm)
+	if err != nil {
+		return SigningKeyConfig{}, err
+	}
+
+	key := SigningKeyConfig{
+		Id:           security.RandomString(20),
+		CollectionId: collectionId,
+		Algorithm:    algorithm,
+		PrivateKey:   privateKey,
+		Active:       true,
+	}
+
+	for i := range c.Keys {
+		if c.Keys[i].CollectionId == collectionId {
+			c.Keys[i].Active = false
+		}
+	}
+
+	c.Keys = append(c.Keys, key)
+
+	resetSigningKeysCache()
+
+	return key, nil
+}
+
+// JWKS returns the public JWK set of all configured signing keys.
+//
+// Keys that fail to load are skipped.
+func (c SigningKeysConfig) JWKS() []security.JWK {
+	result := make([]security.JWK, 0, len(c.Keys))
+
+	for _, k := range c.Keys {
+		jwk, err := k.JWK()
+		if err != nil {
+			continue
+		}
+		result = append(result, jwk)
+	}
+
+	return result
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (c SigningKeysConfig) MarshalJSON() ([]byte, error) {
+	type alias SigningKeysConfig
+
+	// serialize as empty array
+	if c.Keys == nil {
+		c.Keys = []SigningKeyConfig{}
+	}
+
+	return json.Marshal(alias(c))
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+//
+// Since the private keys are masked on serialization, keys with
+// empty PrivateKey inherit the existing private key with the same id (if any).
+func (c *SigningKeysConfig) UnmarshalJSON(data []byte) error {
+	type alias SigningKeysConfig
+
+	decoded := alias{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	for i, k := range decoded.Keys {
+		if k.PrivateKey != "" {
+			continue
+		}
+
+		if existing, ok := c.FindKey(k.Id); ok {
+			decoded.Keys[i].PrivateKey = existing.PrivateKey
+		}
+	}
+
+	*c = SigningKeysConfig(decoded)
+
+	return nil
+}
+
+// Validate makes SigningKeysConfig validatable by implementing [validation.Validatable] interface.
+func (c SigningKeysConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Keys, validation.By(checkSigningKeys)),
+	)
+}
+
+func checkSigningKeys(value any) error {
+	keys, ok := value.([]SigningKeyConfig)
+	if !ok {
+		return validators.ErrUnsupportedValueType
+	}
+
+	ids := make(map[string]struct{}, len(keys))
+	activeCollections := make(map[string]struct{}, len(keys))
+
+	for i, k := range keys {
+		if _, ok := ids[k.Id]; ok {
+			return validation.Errors{
+				strconv.Itoa(i): validation.Errors{
+					"id": validation.NewError("validation_duplicated_signing_key", "Signing key with id {{.id}} already exists.").
+						SetParams(map[string]any{"id": k.Id}),
+				},
+			}
+		}
+		ids[k.Id] = struct{}{}
+
+		if !k.Active {
+			continue
+		}
+
+		if _, ok := activeCollections[k.CollectionId]; ok {
+			return validation.Errors{
+				strconv.Itoa(i): validation.Errors{
+					"active": validation.NewError("validation_multiple_active_signing_keys", "Only one active signing key per collection is allowed."),
+				},
+			}
+		}
+		activeCollections[k.CollectionId] = struct{}{}
+	}
+
+	return nil
+}
+
+// SigningKeyConfig defines a single asymmetric auth token signing key.
+type SigningKeyConfig struct {
+	// Id is the unique key identifier used as token "kid" header.
+	Id string `form:"id" json:"id"`
+
+	// CollectionId is the id of the auth collection the key belongs to.
+	CollectionId string `form:"collectionId" json:"collectionId"`
+
+	// Algorithm is the JWT signing algorithm (RS256 or EdDSA).
+	Algorithm string `form:"algorithm" json:"algorithm"`
+
+	// PrivateKey is the PEM encoded private key of the signing key.
+	PrivateKey string `form:"privateKey" json:"privateKey,omitempty"`
+
+	// Active indicates whether the key is used for signing new tokens.
+	//
+	// Inactive keys are used only for verifying the already issued tokens.
+	Active bool `form:"active" json:"active"`
+}
+
+// Validate makes SigningKeyConfig validatable by implementing [validation.Validatable] interface.
+func (c SigningKeyConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Id, validation.Required, validation.Length(1, 100), validation.Match(DefaultIdRegex)),
+		validation.Field(&c.CollectionId, validation.Required),
+		validation.Field(&c.Algorithm, validation.Required, validation.In(security.JWTAlgRS256, security.JWTAlgEdDSA)),
+		validation.Field(&c.PrivateKey, validation.Required, validation.By(c.checkPrivateKey)),
+	)
+}
+
+func (c SigningKeyConfig) checkPrivateKey(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	key, err := security.ParseSigningKey(v)
+	if err != nil {
+		return validation.NewError("validation_invalid_signing_key", "Invalid or unsupported PEM private key.")
+	}
+
+	alg, _ := security.SigningKeyAlg(key)
+	if alg != c.Algorithm {
+		return validation.NewError("validation_signing_key_algorithm_mismatch", "The private key doesn't match with the selected algorithm.")
+	}
+
+	return nil
+}
+
+// Signer parses and returns the key PrivateKey as [crypto.Signer].
+//
+// The parsed signer is cached per key id until the settings are
+// reloaded, the keys are rotated or the key PrivateKey is changed.
+func (c SigningKeyConfig) Signer() (crypto.Signer, error) {
+	signingKeysCacheMu.RLock()
+	cached, ok := signingKeysCache[c.Id]
+	signingKeysCacheMu.RUnlock()
+
+	if ok && cached.privateKey == c.PrivateKey {
+		return cached.signer, nil
+	}
+
+	signer, err := security.ParseSigningKey(c.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKeysCacheMu.Lock()
+	signingKeysCache[c.Id] = &parsedSigningKey{privateKey: c.PrivateKey, signer: signer}
+	signingKeysCacheMu.Unlock()
+
+	return signer, nil
+}
+
+// parsedSigningKey is a single cached [SigningKeyConfig.Signer] result.
+type parsedSigningKey struct {
+	signer     crypto.Signer
+	privateKey string
+}
+
+var (
+	signingKeysCacheMu sync.RWMutex
+	signingKeysCache   = map[string]*parsedSigningKey{}
+)
+
+// resetSigningKeysCache clears all cached parsed signing keys.
+func resetSigningKeysCache() {
+	signingKeysCacheMu.Lock()
+	defer signingKeysCacheMu.Unlock()
+
+	clear(signingKeysCache)
+}
+
+// JWK returns the public JWK representation of the current key.
+func (c SigningKeyConfig) JWK() (security.JWK, error) {
+	signer, err := c.Signer()
+	if err != nil {
+		return security.JWK{}, err
+	}
+
+	return security.NewJWK(signer.Public(), c.Id)
+}
+
+// -------------------------------------------------------------------
+
+type OAuth2ServerConfig struct {
+	// Enabled allows the app to act as OAuth2/OpenID Connect authorization server.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// LoginURL is the url of the login and consent page where the
+	// authorize requests will be redirected (with their original query parameters).
+	LoginURL string `form:"loginURL" json:"loginURL"`
+
+	// CodeDuration is the max duration in seconds of an authorization code.
+	CodeDuration int64 `form:"codeDuration" json:"codeDuration"`
+
+	// IdTokenDuration is the duration in seconds of the issued ID tokens.
+	IdTokenDuration int64 `form:"idTokenDuration" json:"idTokenDuration"`
+}
+
+// Validate makes OAuth2ServerConfig validatable by implementing [validation.Validatable] interface.
+func (c OAuth2ServerConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.LoginURL, validation.When(c.Enabled, validation.Required), is.URL),
+		validation.Field(&c.CodeDuration, validation.When(c.Enabled, validation.Required), validation.Min(0), validation.Max(600)),
+		validation.Field(&c.IdTokenDuration, validation.When(c.Enabled, validation.Required), validation.Min(0)),
+	)
+}
+
+// CodeDurationTime returns the authorization code duration as [time.Duration].
+func (c OAuth2ServerConfig) CodeDurationTime() time.Duration {
+	return time.Duration(c.CodeDuration) * time.Second
+}
+
+// IdTokenDurationTime returns the ID token duration as [time.Duration].
+func (c OAuth2ServerConfig) IdTokenDurationTime() time.Duration {
+	return time.Duration(c.IdTokenDuration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type SCIMConfig struct {
+	// Enabled enables the SCIM 2.0 user provisioning endpoints.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Token is the static bearer token that the identity provider
+	// must send with every SCIM request.
+	Token string `form:"token" json:"token,omitempty"`
+
+	// Collection is the name or id of the auth collection
+	// where the SCIM Users are provisioned.
+	Collection string `form:"collection" json:"collection"`
+
+	// GroupsField is the optional name of the Collection multiple relation field
+	// used for the SCIM Groups membership (the related collection records are the Groups).
+	//
+	// Leave it empty to disable the SCIM Groups endpoints.
+	GroupsField string `form:"groupsField" json:"groupsField"`
+
+	// GroupNameField is the name of the groups collection field
+	// used as SCIM Group "displayName" (default to "name").
+	GroupNameField string `form:"groupNameField" json:"groupNameField"`
+
+	// ActiveField is the optional name of the Collection bool field
+	// used as SCIM User "active" state.
+	//
+	// If empty, all users are reported as active and deactivation requests are ignored.
+	ActiveField string `form:"activeField" json:"activeField"`
+}
+
+// Validate makes SCIMConfig validatable by implementing [validation.Validatable] interface.
+func (c SCIMConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Token, validation.When(c.Enabled, validation.Required, validation.Length(32, 512))),
+		validation.Field(&c.Collection, validation.When(c.Enabled, validation.Required)),
+	)
+}
+
+// -------------------------------------------------------------------
+
+// BroadcastConfig defines the named realtime broadcast channels
+// that clients could publish ephemeral messages to (e.g. typing indicators).
+type BroadcastConfig struct {
+	Channels []BroadcastChannel `form:"channels" json:"channels"`
+}
+
+// FindChannel returns the channel configuration matching the specified channel name.
+//
+// Exact channel names have precedence over the wildcard ones and
+// if more than one wildcard channel matches, the longest one is returned.
+//
+// The second returned value is the part of the name matched by the
+// wildcard (or empty string for exact matches).
+func (c *BroadcastConfig) FindChannel(name string) (*BroadcastChannel, string, bool) {
+	var wildcard *BroadcastChannel
+	var param string
+
+	for i := range c.Channels {
+		channel := &c.Channels[i]
+
+		if channel.Name == name {
+			return channel, "", true
+		}
+
+		prefix, ok := strings.CutSuffix(channel.Name, "*")
+		if ok && strings.HasPrefix(name, prefix) && (wildcard == nil || len(channel.Name) > len(wildcard.Name)) {
+			wildcard = channel
+			param = name[len(prefix):]
+		}
+	}
+
+	return wildcard, param, wildcard != nil
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (c BroadcastConfig) MarshalJSON() ([]byte, error) {
+	type alias BroadcastConfig
+
+	// serialize as empty array
+	if c.Channels == nil {
+		c.Channels = []BroadcastChannel{}
+	}
+
+	return json.Marshal(alias(c))
+}
+
+// Validate makes BroadcastConfig validatable by implementing [validation.Validatable] interface.
+func (c BroadcastConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Channels, validation.By(checkUniqueBroadcastChannelName)),
+	)
+}
+
+func checkUniqueBroadcastChannelName(value any) error {
+	channels, ok := value.([]BroadcastChannel)
+	if !ok {
+		return validators.ErrUnsupportedValueType
+	}
+
+	existing := make(map[string]struct{}, len(channels))
+
+	for i, channel := range channels {
+		if _, ok := existing[channel.Name]; ok {
+			return validation.Errors{
+				strconv.Itoa(i): validation.Errors{
+					"name": validation.NewError("validation_duplicated_broadcast_channel", "Broadcast channel with name {{.name}} already exists.").
+						SetParams(map[string]any{"name": channel.Name}),
+				},
+			}
+		}
+
+		existing[channel.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// BroadcastChannelNameRegex defines the allowed broadcast channel name characters.
+var BroadcastChannelNameRegex = regexp.MustCompile(`^[\w\-\.\:]+$`)
+
+var broadcastChannelConfigNameRegex = regexp.MustCompile(`^[\w\-\.\:]*[\w\-\.\:\*]$`)
+
+// BroadcastChannel defines a single named broadcast channel and its access rules.
+//
+// The rules use the same filter syntax as the collection API rules but instead of
+// record fields they could reference only the channel "name" and "param" fields,
+// the "@request.*" fields and the "@collection.*" fields, for example:
+//
+//	// channel "rooms:*" allowing only the room members to subscribe and publish
+//	@collection.members.room = param && @collection.members.user = @request.auth.id
+type BroadcastChannel struct {
+	// Name is the channel name.
+	//
+	// It could end with "*" to match all channels with the same prefix
+	// (e.g. "rooms:*" matches "rooms:abc" and its "param" field is "abc").
+	Name string `form:"name" json:"name"`
+
+	// SubscribeRule is the filter rule that a realtime client
+	// must satisfy in order to receive the channel messages.
+	//
+	// nil means that only superusers could subscribe
+	// and empty string - everyone could subscribe.
+	SubscribeRule *string `form:"subscribeRule" json:"subscribeRule"`
+
+	// PublishRule is the filter rule that a client must satisfy
+	// in order to publish messages to the channel.
+	//
+	// nil means that only superusers could publish
+	// and empty string - everyone could publish.
+	PublishRule *string `form:"publishRule" json:"publishRule"`
+}
+
+// Validate makes BroadcastChannel validatable by implementing [validation.Validatable] interface.
+func (c BroadcastChannel) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 255), validation.Match(broadcastChannelConfigNameRegex)),
+		validation.Field(&c.SubscribeRule, validation.By(checkBroadcastChannelRule)),
+		validation.Field(&c.PublishRule, validation.By(checkBroadcastChannelRule)),
+	)
+}
+
+// note: value could be either *string or string
+func checkBroadcastChannelRule(value any) error {
+	var rule string
+
+	switch v := value.(type) {
+	case *string:
+		if v != nil {
+			rule = *v
+		}
+	case string:
+		rule = v
+	default:
+		return validators.ErrUnsupportedValueType
+	}
+
+	if rule == "" {
+		return nil // nothing to check
+	}
+
+	if _, err := fexpr.Parse(rule); err != nil {
+		return validation.NewError("validation_invalid_rule", "Invalid rule. Raw error: "+err.Error())
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+type RealtimeConfig struct {
+	// QueueSize is the max number of queued (aka. not yet delivered) messages per realtime client.
+	//
+	// If not set, fallbacks to 256.
+	QueueSize int `form:"queueSize" json:"queueSize"`
+
+	// OverflowPolicy specifies how to handle a new client message when its queue is full:
+	//   - "dropOldest" (default) - discards the oldest queued message
+	//   - "disconnect" - terminates the client connection
+	OverflowPolicy string `form:"overflowPolicy" json:"overflowPolicy"`
+}
+
+// Validate makes RealtimeConfig validatable by implementing [validation.Validatable] interface.
+func (c RealtimeConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.QueueSize, validation.Min(0), validation.Max(10000)),
+		validation.Field(
+			&c.OverflowPolicy,
+			validation.In(string(subscriptions.OverflowDropOldest), string(subscriptions.OverflowDisconnect)),
+		),
+	)
+}
+
+// -------------------------------------------------------------------
+
+// DefaultImageTransformsMaxDimension is the default max allowed width and height of a transformed image.
+const DefaultImageTransformsMaxDimension = 2048
+
+type ImageTransformsConfig struct {
+	// Enabled enables the on-the-fly image transformations of the
+	// downloaded files via the "w", "h", "fit", "format" and "q" query parameters
+	// (see [filesystem.ParseImageTransform]).
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Allowed is a list with the transforms that could be applied
+	// without a signature, in the same query parameters format (e.g. "w=300&h=200&fit=contain").
+	Allowed []string `form:"allowed" json:"allowed"`
+
+	// Secret is the key used to verify the "sig" query parameter of the
+	// transforms that are not part of the Allowed list (see [filesystem.ImageTransform.Signature]).
+	//
+	// If empty, only the Allowed transforms could be applied.
+	Secret string `form:"secret" json:"secret,omitempty"`
+
+	// MaxDimension is the max allowed width and height of a transformed image.
+	//
+	// If not set, fallbacks to 2048.
+	MaxDimension int `form:"maxDimension" json:"maxDimension"`
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (c ImageTransformsConfig) MarshalJSON() ([]byte, error) {
+	type alias ImageTransformsConfig
+
+	// serialize as empty array
+	if c.Allowed == nil {
+		c.Allowed = []string{}
+	}
+
+	return json.Marshal(alias(c))
+}
+
+// Validate makes ImageTransformsConfig validatable by implementing [validation.Validatable] interface.
+func (c ImageTransformsConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Allowed, validation.By(checkImageTransforms)),
+		validation.Field(&c.Secret, validation.Length(32, 255)),
+		validation.Field(&c.MaxDimension, validation.Min(0), validation.Max(10000)),
+	)
+}
+
+// IsAllowed reports whether the transform is part of the Allowed list.
+func (c ImageTransformsConfig) IsAllowed(t filesystem.ImageTransform) bool {
+	str := t.String()
+
+	for _, raw := range c.Allowed {
+		query, err := url.ParseQuery(raw)
+		if err != nil {
+			continue
+		}
+
+		allowed, err := filesystem.ParseImageTransform(query)
+		if err == nil && allowed != nil && allowed.String() == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetMaxDimension returns the max allowed transformed image width and height
+// (fallbacks to [DefaultImageTransformsMaxDimension] if not set).
+func (c ImageTransformsConfig) GetMaxDimension() int {
+	if c.MaxDimension <= 0 {
+		return DefaultImageTransformsMaxDimension
+	}
+
+	return c.MaxDimension
+}
+
+func checkImageTransforms(value any) error {
+	v, ok := value.([]string)
+	if !ok {
+		return validators.ErrUnsupportedValueType
+	}
+
+	for i, raw := range v {
+		query, err := url.ParseQuery(raw)
+		if err == nil {
+			var t *filesystem.ImageTransform
+			t, err = filesystem.ParseImageTransform(query)
+			if err == nil && t == nil {
+				err = errors.New("missing transform parameters")
+			}
+		}
+
+		if err != nil {
+			return validation.Errors{
+				strconv.Itoa(i): validation.NewError("validation_invalid_image_transform", "Invalid image transform: "+err.Error()),
+			}
+		}
+	}
+
+	return nil
+}
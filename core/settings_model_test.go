@@ -1,6 +1,7 @@
 package core_test
 
 import (
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -9,7 +10,10 @@ import (
 
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
 	"github.com/thewandererbg/pgbase/tools/mailer"
+	"github.com/thewandererbg/pgbase/tools/security"
+	"github.com/thewandererbg/pgbase/tools/types"
 )
 
 func TestSettingsDelete(t *testing.T) {
@@ -99,6 +103,10 @@ func TestSettingsMarshalJSON(t *testing.T) {
 	settings.SMTP.Password = testSecret
 	settings.S3.Secret = testSecret
 	settings.Backups.S3.Secret = testSecret
+	settings.SCIM.Token = testSecret
+	settings.ImageTransforms.Secret = testSecret
+	settings.SMTP.DKIM.PrivateKey = testSecret
+	settings.SigningKeys.Keys = []core.SigningKeyConfig{{Id: "test", PrivateKey: testSecret}}
 
 	raw, err := json.Marshal(settings)
 	if err != nil {
@@ -106,11 +114,16 @@ func TestSettingsMarshalJSON(t *testing.T) {
 	}
 	rawStr := string(raw)
 
-	expected := `{"smtp":{"enabled":false,"port":0,"host":"","username":"abc","authMethod":"","tls":false,"localName":""},"backups":{"cron":"","cronMaxKeep":0,"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false}},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false},"meta":{"appName":"test123","appURL":"","senderName":"","senderAddress":"","hideControls":false},"rateLimits":{"rules":[],"enabled":false},"trustedProxy":{"headers":[],"useLeftmostIP":false},"batch":{"enabled":false,"maxRequests":0,"timeout":0,"maxBodySize":0},"logs":{"maxDays":0,"minLevel":0,"logIP":false,"logAuthId":false}}`
+	expected := `{"smtp":{"enabled":false,"port":0,"host":"","username":"abc","authMethod":"","tls":false,"localName":"","dkim":{"enabled":false,"domain":"","selector":"","headers":[]}},"backups":{"cron":"","cronMaxKeep":0,"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false,"presignedDownloads":false}},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false,"presignedDownloads":false},"meta":{"appName":"test123","appURL":"","senderName":"","senderAddress":"","hideControls":false},"rateLimits":{"rules":[],"enabled":false},"trustedProxy":{"headers":[],"useLeftmostIP":false},"batch":{"enabled":false,"maxRequests":0,"timeout":0,"maxBodySize":0},"logs":{"maxDays":0,"minLevel":0,"logIP":false,"logAuthId":false},"signingKeys":{"keys":[{"id":"test","collectionId":"","algorithm":"","active":false}]},"oauth2Server":{"enabled":false,"loginURL":"","codeDuration":0,"idTokenDuration":0},"scim":{"enabled":false,"collection":"","groupsField":"","groupNameField":"","activeField":""},"broadcast":{"channels":[]},"realtime":{"queueSize":0,"overflowPolicy":""},"imageTransforms":{"enabled":false,"allowed":[],"maxDimension":0},"postgresStorage":{"enabled":false,"chunkSize":0},"filesGC":{"cron":"","gracePeriod":0},"mailOutbox":{"enabled":false,"workers":0,"maxAttempts":0,"maxDays":0},"mailCatcher":{"enabled":false,"maxMessages":0},"cronHistory":{"maxDays":0}}`
 
 	if rawStr != expected {
 		t.Fatalf("Expected\n%v\ngot\n%v", expected, rawStr)
 	}
+
+	// the original private key should remain unchanged
+	if settings.SigningKeys.Keys[0].PrivateKey != testSecret {
+		t.Fatalf("Expected the original signing key private key to remain unchanged, got %q", settings.SigningKeys.Keys[0].PrivateKey)
+	}
 }
 
 func TestSettingsValidate(t *testing.T) {
@@ -304,6 +317,103 @@ func TestSMTPConfigValidate(t *testing.T) {
 	}
 }
 
+func TestDKIMConfigValidate(t *testing.T) {
+	rsaKey, err := security.GenerateSigningKey(security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKey, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		config         core.DKIMConfig
+		expectedErrors []string
+	}{
+		{
+			"zero values (disabled)",
+			core.DKIMConfig{},
+			[]string{},
+		},
+		{
+			"zero values (enabled)",
+			core.DKIMConfig{Enabled: true},
+			[]string{"domain", "selector", "privateKey"},
+		},
+		{
+			"invalid data",
+			core.DKIMConfig{
+				Enabled:    true,
+				Domain:     "invalid domain",
+				Selector:   "-invalid",
+				PrivateKey: "invalid",
+				Headers:    []string{"Subject", "", "In:valid"},
+			},
+			[]string{"domain", "selector", "privateKey", "headers"},
+		},
+		{
+			"valid data (RSA key)",
+			core.DKIMConfig{
+				Enabled:    true,
+				Domain:     "example.com",
+				Selector:   "mail",
+				PrivateKey: rsaKey,
+			},
+			[]string{},
+		},
+		{
+			"valid data (Ed25519 key and custom headers)",
+			core.DKIMConfig{
+				Enabled:    true,
+				Domain:     "example.com",
+				Selector:   "mail.2025-10",
+				PrivateKey: edKey,
+				Headers:    []string{"From", "Subject", "List-Unsubscribe"},
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestDKIMConfigSigner(t *testing.T) {
+	edKey, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := core.DKIMConfig{
+		Enabled:    true,
+		Domain:     "example.com",
+		Selector:   "mail",
+		PrivateKey: edKey,
+		Headers:    []string{"Subject"},
+	}
+
+	signer, err := config.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signer.Domain != "example.com" || signer.Selector != "mail" || len(signer.Headers) != 1 {
+		t.Fatalf("Unexpected signer %#v", signer)
+	}
+
+	if alg := signer.Algorithm(); alg != mailer.DKIMAlgorithmEd25519 {
+		t.Fatalf("Expected algorithm %q, got %q", mailer.DKIMAlgorithmEd25519, alg)
+	}
+}
+
 func TestS3ConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -485,6 +595,132 @@ func TestBatchConfigValidate(t *testing.T) {
 	}
 }
 
+func TestRealtimeConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.RealtimeConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.RealtimeConfig{},
+			[]string{},
+		},
+		{
+			"invalid data",
+			core.RealtimeConfig{
+				QueueSize:      -1,
+				OverflowPolicy: "invalid",
+			},
+			[]string{"queueSize", "overflowPolicy"},
+		},
+		{
+			"too large queue",
+			core.RealtimeConfig{
+				QueueSize: 10001,
+			},
+			[]string{"queueSize"},
+		},
+		{
+			"valid data",
+			core.RealtimeConfig{
+				QueueSize:      100,
+				OverflowPolicy: "disconnect",
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestImageTransformsConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.ImageTransformsConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.ImageTransformsConfig{},
+			[]string{},
+		},
+		{
+			"invalid data",
+			core.ImageTransformsConfig{
+				Allowed:      []string{"w=100", "fit=invalid"},
+				Secret:       "short",
+				MaxDimension: -1,
+			},
+			[]string{"allowed", "secret", "maxDimension"},
+		},
+		{
+			"allowed entry without transform params",
+			core.ImageTransformsConfig{
+				Allowed: []string{"a=1"},
+			},
+			[]string{"allowed"},
+		},
+		{
+			"too large max dimension",
+			core.ImageTransformsConfig{
+				MaxDimension: 10001,
+			},
+			[]string{"maxDimension"},
+		},
+		{
+			"valid data",
+			core.ImageTransformsConfig{
+				Enabled:      true,
+				Allowed:      []string{"w=100&h=100", "w=300&fit=contain&format=jpeg&q=80"},
+				Secret:       strings.Repeat("a", 32),
+				MaxDimension: 1000,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestImageTransformsConfigIsAllowed(t *testing.T) {
+	config := core.ImageTransformsConfig{
+		Allowed: []string{"invalid=1", "h=200&w=300", "w=100&h=100&fit=contain&format=jpg"},
+	}
+
+	scenarios := []struct {
+		transform filesystem.ImageTransform
+		expected  bool
+	}{
+		{filesystem.ImageTransform{Width: 300}, false},
+		{filesystem.ImageTransform{Width: 300, Height: 200}, true},
+		{filesystem.ImageTransform{Width: 300, Height: 200, Quality: 80}, false},
+		{filesystem.ImageTransform{Width: 100, Height: 100, Fit: "contain"}, false},
+		{filesystem.ImageTransform{Width: 100, Height: 100, Fit: "contain", Format: "jpeg"}, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.transform.String(), func(t *testing.T) {
+			result := config.IsAllowed(s.transform)
+			if result != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+		})
+	}
+}
+
 func TestRateLimitsConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -810,3 +1046,659 @@ func TestRateLimitRuleDurationTime(t *testing.T) {
 		})
 	}
 }
+
+func TestSigningKeysConfigValidate(t *testing.T) {
+	rsaKey, err := security.GenerateSigningKey(security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKey, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		config         core.SigningKeysConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.SigningKeysConfig{},
+			[]string{},
+		},
+		{
+			"invalid key data",
+			core.SigningKeysConfig{
+				Keys: []core.SigningKeyConfig{{Id: "!invalid", Algorithm: "HS256", PrivateKey: "invalid"}},
+			},
+			[]string{"keys"},
+		},
+		{
+			"algorithm mismatch",
+			core.SigningKeysConfig{
+				Keys: []core.SigningKeyConfig{{Id: "a", CollectionId: "c1", Algorithm: security.JWTAlgEdDSA, PrivateKey: rsaKey}},
+			},
+			[]string{"keys"},
+		},
+		{
+			"duplicated ids",
+			core.SigningKeysConfig{
+				Keys: []core.SigningKeyConfig{
+					{Id: "a", CollectionId: "c1", Algorithm: security.JWTAlgRS256, PrivateKey: rsaKey},
+					{Id: "a", CollectionId: "c2", Algorithm: security.JWTAlgEdDSA, PrivateKey: edKey},
+				},
+			},
+			[]string{"keys"},
+		},
+		{
+			"multiple active keys for the same collection",
+			core.SigningKeysConfig{
+				Keys: []core.SigningKeyConfig{
+					{Id: "a", CollectionId: "c1", Algorithm: security.JWTAlgRS256, PrivateKey: rsaKey, Active: true},
+					{Id: "b", CollectionId: "c1", Algorithm: security.JWTAlgEdDSA, PrivateKey: edKey, Active: true},
+				},
+			},
+			[]string{"keys"},
+		},
+		{
+			"valid data",
+			core.SigningKeysConfig{
+				Keys: []core.SigningKeyConfig{
+					{Id: "a", CollectionId: "c1", Algorithm: security.JWTAlgRS256, PrivateKey: rsaKey, Active: true},
+					{Id: "b", CollectionId: "c1", Algorithm: security.JWTAlgEdDSA, PrivateKey: edKey},
+					{Id: "c", CollectionId: "c2", Algorithm: security.JWTAlgEdDSA, PrivateKey: edKey, Active: true},
+				},
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestSigningKeysConfigRotate(t *testing.T) {
+	config := core.SigningKeysConfig{}
+
+	if _, err := config.Rotate("c1", "invalid"); err == nil {
+		t.Fatal("Expected error for unsupported algorithm")
+	}
+
+	k1, err := config.Rotate("c1", security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k2, err := config.Rotate("c2", security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k3, err := config.Rotate("c1", security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Keys) != 3 {
+		t.Fatalf("Expected 3 keys, got %d", len(config.Keys))
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Expected the rotated keys to be valid, got %v", err)
+	}
+
+	active1, ok := config.ActiveKey("c1")
+	if !ok || active1.Id != k3.Id {
+		t.Fatalf("Expected active c1 key %q, got %q", k3.Id, active1.Id)
+	}
+
+	active2, ok := config.ActiveKey("c2")
+	if !ok || active2.Id != k2.Id {
+		t.Fatalf("Expected active c2 key %q, got %q", k2.Id, active2.Id)
+	}
+
+	old, ok := config.FindKey(k1.Id)
+	if !ok || old.Active {
+		t.Fatalf("Expected the previous c1 key to be kept as inactive, got %v (%v)", old, ok)
+	}
+
+	jwks := config.JWKS()
+	if len(jwks) != 3 {
+		t.Fatalf("Expected 3 JWKs, got %d", len(jwks))
+	}
+	for i, k := range config.Keys {
+		if jwks[i].Kid != k.Id || jwks[i].Alg != k.Algorithm {
+			t.Fatalf("Expected JWK %d to match with key %q (%s), got %#v", i, k.Id, k.Algorithm, jwks[i])
+		}
+	}
+}
+
+func TestSigningKeyConfigSigner(t *testing.T) {
+	config := core.SigningKeysConfig{}
+
+	key, err := config.Rotate("c1", security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s1, err := key.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := key.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s1 != s2 {
+		t.Fatal("Expected the parsed signer to be cached")
+	}
+
+	// rotation
+	if _, err := config.Rotate("c1", security.JWTAlgRS256); err != nil {
+		t.Fatal(err)
+	}
+
+	s3, err := key.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s3 == s1 {
+		t.Fatal("Expected the cached signer to be invalidated after rotation")
+	}
+
+	// changed private key with the same id
+	changed := config.Keys[1]
+	changed.Id = key.Id
+
+	s4, err := changed.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := security.ParseSigningKey(changed.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s4 == s3 || !expected.(*rsa.PrivateKey).Equal(s4) {
+		t.Fatal("Expected the signer of the changed private key")
+	}
+}
+
+func TestSigningKeysConfigUnmarshalJSON(t *testing.T) {
+	config := core.SigningKeysConfig{
+		Keys: []core.SigningKeyConfig{
+			{Id: "a", CollectionId: "c1", PrivateKey: "key_a"},
+			{Id: "b", CollectionId: "c2", PrivateKey: "key_b"},
+		},
+	}
+
+	// reordered, without private keys, with changed private key and with new key
+	raw := `{"keys":[{"id":"b","collectionId":"c2"},{"id":"a","collectionId":"c1","privateKey":"key_a_new"},{"id":"c","collectionId":"c3"}]}`
+
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"b": "key_b",
+		"a": "key_a_new",
+		"c": "",
+	}
+
+	if len(config.Keys) != len(expected) {
+		t.Fatalf("Expected %d keys, got %d", len(expected), len(config.Keys))
+	}
+
+	for _, k := range config.Keys {
+		if k.PrivateKey != expected[k.Id] {
+			t.Fatalf("Expected key %q to have private key %q, got %q", k.Id, expected[k.Id], k.PrivateKey)
+		}
+	}
+}
+
+func TestSCIMConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.SCIMConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.SCIMConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.SCIMConfig{Enabled: true},
+			[]string{"token", "collection"},
+		},
+		{
+			"too short token",
+			core.SCIMConfig{Enabled: true, Token: "abc", Collection: "users"},
+			[]string{"token"},
+		},
+		{
+			"valid data",
+			core.SCIMConfig{Enabled: true, Token: strings.Repeat("a", 32), Collection: "users"},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestBroadcastConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.BroadcastConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.BroadcastConfig{},
+			[]string{},
+		},
+		{
+			"duplicated channel names",
+			core.BroadcastConfig{
+				Channels: []core.BroadcastChannel{
+					{Name: "typing"},
+					{Name: "rooms:*"},
+					{Name: "typing"},
+				},
+			},
+			[]string{"channels"},
+		},
+		{
+			"valid data",
+			core.BroadcastConfig{
+				Channels: []core.BroadcastChannel{
+					{Name: "typing"},
+					{Name: "rooms:*"},
+				},
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestBroadcastConfigFindChannel(t *testing.T) {
+	config := core.BroadcastConfig{
+		Channels: []core.BroadcastChannel{
+			{Name: "typing"},
+			{Name: "rooms:*"},
+			{Name: "rooms:private:*"},
+			{Name: "rooms:lobby"},
+		},
+	}
+
+	scenarios := []struct {
+		name          string
+		expectedName  string
+		expectedParam string
+	}{
+		{"", "", ""},
+		{"missing", "", ""},
+		{"typing", "typing", ""},
+		{"typing:abc", "", ""},
+		{"rooms:", "rooms:*", ""},
+		{"rooms:abc", "rooms:*", "abc"},
+		{"rooms:lobby", "rooms:lobby", ""},
+		{"rooms:private:abc", "rooms:private:*", "abc"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			channel, param, ok := config.FindChannel(s.name)
+
+			if ok != (s.expectedName != "") {
+				t.Fatalf("Expected ok %v, got %v", s.expectedName != "", ok)
+			}
+
+			if ok && channel.Name != s.expectedName {
+				t.Fatalf("Expected channel %q, got %q", s.expectedName, channel.Name)
+			}
+
+			if param != s.expectedParam {
+				t.Fatalf("Expected param %q, got %q", s.expectedParam, param)
+			}
+		})
+	}
+}
+
+func TestBroadcastChannelValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.BroadcastChannel
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.BroadcastChannel{},
+			[]string{"name"},
+		},
+		{
+			"invalid data",
+			core.BroadcastChannel{
+				Name:          "a*b",
+				SubscribeRule: types.Pointer("invalid ="),
+				PublishRule:   types.Pointer("(a = 1"),
+			},
+			[]string{"name", "subscribeRule", "publishRule"},
+		},
+		{
+			"valid data",
+			core.BroadcastChannel{
+				Name:          "rooms:*",
+				SubscribeRule: types.Pointer(""),
+				PublishRule:   types.Pointer("@request.auth.id != '' && param != ''"),
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestPostgresStorageConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.PostgresStorageConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.PostgresStorageConfig{},
+			[]string{},
+		},
+		{
+			"negative chunk size",
+			core.PostgresStorageConfig{ChunkSize: -1},
+			[]string{"chunkSize"},
+		},
+		{
+			"too large chunk size",
+			core.PostgresStorageConfig{ChunkSize: core.MaxPostgresStorageChunkSize + 1},
+			[]string{"chunkSize"},
+		},
+		{
+			"valid data",
+			core.PostgresStorageConfig{Enabled: true, ChunkSize: 512 * 1024},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestFilesGCConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.FilesGCConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.FilesGCConfig{},
+			[]string{},
+		},
+		{
+			"invalid data",
+			core.FilesGCConfig{
+				Cron:        "invalid",
+				GracePeriod: -1,
+			},
+			[]string{"cron", "gracePeriod"},
+		},
+		{
+			"valid data",
+			core.FilesGCConfig{
+				Cron:        "0 3 * * *",
+				GracePeriod: 3600,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestFilesGCConfigGetGracePeriod(t *testing.T) {
+	scenarios := []struct {
+		gracePeriod int64
+		expected    time.Duration
+	}{
+		{-1, core.DefaultFilesGCGracePeriod},
+		{0, core.DefaultFilesGCGracePeriod},
+		{60, time.Minute},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprint(s.gracePeriod), func(t *testing.T) {
+			config := core.FilesGCConfig{GracePeriod: s.gracePeriod}
+
+			if v := config.GetGracePeriod(); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestMailOutboxConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.MailOutboxConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.MailOutboxConfig{},
+			[]string{},
+		},
+		{
+			"invalid data",
+			core.MailOutboxConfig{
+				Workers:     101,
+				MaxAttempts: -1,
+				MaxDays:     -1,
+			},
+			[]string{"workers", "maxAttempts", "maxDays"},
+		},
+		{
+			"valid data",
+			core.MailOutboxConfig{
+				Enabled:     true,
+				Workers:     4,
+				MaxAttempts: 10,
+				MaxDays:     7,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestMailOutboxConfigDefaults(t *testing.T) {
+	scenarios := []struct {
+		config              core.MailOutboxConfig
+		expectedWorkers     int
+		expectedMaxAttempts int
+	}{
+		{core.MailOutboxConfig{}, core.DefaultMailOutboxWorkers, core.DefaultMailOutboxMaxAttempts},
+		{core.MailOutboxConfig{Workers: -1, MaxAttempts: -1}, core.DefaultMailOutboxWorkers, core.DefaultMailOutboxMaxAttempts},
+		{core.MailOutboxConfig{Workers: 3, MaxAttempts: 7}, 3, 7},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if v := s.config.GetWorkers(); v != s.expectedWorkers {
+				t.Fatalf("Expected workers %d, got %d", s.expectedWorkers, v)
+			}
+
+			if v := s.config.GetMaxAttempts(); v != s.expectedMaxAttempts {
+				t.Fatalf("Expected max attempts %d, got %d", s.expectedMaxAttempts, v)
+			}
+		})
+	}
+}
+
+func TestMailCatcherConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.MailCatcherConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.MailCatcherConfig{},
+			[]string{},
+		},
+		{
+			"invalid data",
+			core.MailCatcherConfig{
+				MaxMessages: -1,
+			},
+			[]string{"maxMessages"},
+		},
+		{
+			"valid data",
+			core.MailCatcherConfig{
+				Enabled:     true,
+				MaxMessages: 50,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestMailCatcherConfigGetMaxMessages(t *testing.T) {
+	scenarios := []struct {
+		maxMessages int
+		expected    int
+	}{
+		{-1, mailer.DefaultCatcherMaxMessages},
+		{0, mailer.DefaultCatcherMaxMessages},
+		{5, 5},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprint(s.maxMessages), func(t *testing.T) {
+			config := core.MailCatcherConfig{MaxMessages: s.maxMessages}
+
+			if v := config.GetMaxMessages(); v != s.expected {
+				t.Fatalf("Expected %d, got %d", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestCronHistoryConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.CronHistoryConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.CronHistoryConfig{},
+			[]string{},
+		},
+		{
+			"invalid data",
+			core.CronHistoryConfig{MaxDays: -1},
+			[]string{"maxDays"},
+		},
+		{
+			"valid data",
+			core.CronHistoryConfig{MaxDays: 30},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestCronHistoryConfigGetMaxDays(t *testing.T) {
+	scenarios := []struct {
+		maxDays  int
+		expected int
+	}{
+		{-1, core.DefaultCronHistoryMaxDays},
+		{0, core.DefaultCronHistoryMaxDays},
+		{30, 30},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprint(s.maxDays), func(t *testing.T) {
+			config := core.CronHistoryConfig{MaxDays: s.maxDays}
+
+			if v := config.GetMaxDays(); v != s.expected {
+				t.Fatalf("Expected %d, got %d", s.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+var (
+	_ Model = (*Upload)(nil)
+)
+
+const UploadsTableName = "_uploads"
+
+// UploadsMaxAge is the max inactivity duration of an upload
+// before it is considered expired and deleted by the cleanup cron.
+//
+// The expiration is extended on every received upload chunk.
+const UploadsMaxAge = 24 * time.Hour
+
+// uploadsStorageDir is the storage dir where the uploads content is stored.
+//
+// The dir starts with a dot to prevent collisions with the collections storage dirs.
+const uploadsStorageDir = ".uploads"
+
+// Upload defines a single resumable file upload stored in the auxiliary app database.
+//
+// The upload content is stored in the app filesystem as separate
+// chunks that are composed into a single file once the upload is completed.
+//
+// CollectionRef and RecordRef refer to the upload owner auth record, while
+// FieldCollectionRef and FieldName refer to the collection file field
+// the upload is bound to (if any).
+type Upload struct {
+	BaseModel
+
+	Created            types.DateTime `db:"created" json:"created"`
+	Expires            types.DateTime `db:"expires" json:"expires"`
+	CollectionRef      string         `db:"collectionRef" json:"collectionRef"`
+	RecordRef          string         `db:"recordRef" json:"recordRef"`
+	FieldCollectionRef string         `db:"fieldCollectionRef" json:"fieldCollectionRef"`
+	FieldName          string         `db:"fieldName" json:"fieldName"`
+	OriginalName       string         `db:"originalName" json:"originalName"`
+	Size               int64          `db:"size" json:"size"`
+	Offset             int64          `db:"offset" json:"offset"`
+}
+
+func (m *Upload) TableName() string {
+	return UploadsTableName
+}
+
+// IsCompleted reports whether the full upload content was received.
+func (m *Upload) IsCompleted() bool {
+	return m.Offset >= m.Size
+}
+
+// CanAttachTo reports whether the upload could be attached to the
+// specified collection file field.
+//
+// Uploads that are not bound to a specific file field could be attached to any.
+func (m *Upload) CanAttachTo(collectionId string, fieldName string) bool {
+	if m.FieldCollectionRef == "" && m.FieldName == "" {
+		return true
+	}
+
+	return m.FieldCollectionRef == collectionId && m.FieldName == fieldName
+}
+
+// BaseFilesPath implements [FilesManager] and returns the storage dir path used by the upload.
+//
+// The upload files are automatically deleted when the upload model is deleted.
+func (m *Upload) BaseFilesPath() string {
+	return uploadsStorageDir + "/" + m.Id
+}
+
+// ChunkKey returns the storage key of the upload chunk starting at offset.
+//
+// The offset is zero padded so that the chunk keys could be sorted lexicographically.
+func (m *Upload) ChunkKey(offset int64) string {
+	return fmt.Sprintf("%s/chunk_%020d", m.BaseFilesPath(), offset)
+}
+
+// FileKey returns the storage key of the composed file of a completed upload.
+func (m *Upload) FileKey() string {
+	return m.BaseFilesPath() + "/file"
+}
+
+func (app *BaseApp) registerUploadHooks() {
+	// run on every hour to cleanup the expired incomplete (or unused) uploads
+	app.Cron().Add("__pbUploadsCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredUploads(); err != nil {
+			app.Logger().Warn("Failed to delete expired uploads", "error", err)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// ErrUploadOffsetMismatch is returned when the stored upload offset
+// doesn't match with the expected one (e.g. because of a concurrent chunk write).
+var ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+
+// UploadQuery returns a new Upload select query.
+func (app *BaseApp) UploadQuery() *dbx.SelectQuery {
+	return app.AuxModelQuery(&Upload{})
+}
+
+// FindUploadById returns a single Upload model by its id.
+func (app *BaseApp) FindUploadById(id string) (*Upload, error) {
+	model := &Upload{}
+
+	err := app.UploadQuery().
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// CreateUpload stores a new empty resumable upload with the specified
+// total size for the provided auth collection and record owner.
+func (app *BaseApp) CreateUpload(collectionId string, recordId string, originalName string, size int64) (*Upload, error) {
+	return app.createUpload(collectionId, recordId, "", "", originalName, size)
+}
+
+// CreateFieldUpload is similar to [BaseApp.CreateUpload] but the new upload
+// is bound to the specified collection file field and it can't be
+// attached to a different one.
+func (app *BaseApp) CreateFieldUpload(
+	collectionId string,
+	recordId string,
+	fieldCollectionId string,
+	fieldName string,
+	originalName string,
+	size int64,
+) (*Upload, error) {
+	return app.createUpload(collectionId, recordId, fieldCollectionId, fieldName, originalName, size)
+}
+
+func (app *BaseApp) createUpload(
+	collectionId string,
+	recordId string,
+	fieldCollectionId string,
+	fieldName string,
+	originalName string,
+	size int64,
+) (*Upload, error) {
+	now := time.Now()
+
+	model := &Upload{}
+	model.Id = GenerateDefaultRandomId()
+	model.Created, _ = types.ParseDateTime(now)
+	model.Expires, _ = types.ParseDateTime(now.Add(UploadsMaxAge))
+	model.CollectionRef = collectionId
+	model.RecordRef = recordId
+	model.FieldCollectionRef = fieldCollectionId
+	model.FieldName = fieldName
+	model.OriginalName = originalName
+	model.Size = size
+
+	if err := app.AuxSave(model); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// AdvanceUploadOffset atomically changes the offset of the provided upload
+// to newOffset and extends its expiration date.
+//
+// Returns ErrUploadOffsetMismatch if the stored upload offset was
+// modified in the meantime (aka. it is different from upload.Offset).
+//
+// On success the upload model fields are updated with the new values.
+func (app *BaseApp) AdvanceUploadOffset(upload *Upload, newOffset int64) error {
+	expires, err := types.ParseDateTime(time.Now().Add(UploadsMaxAge))
+	if err != nil {
+		return err
+	}
+
+	result, err := app.AuxNonconcurrentDB().Update(
+		UploadsTableName,
+		dbx.Params{"offset": newOffset, "expires": expires.String()},
+		dbx.HashExp{"id": upload.Id, "offset": upload.Offset},
+	).Execute()
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUploadOffsetMismatch
+	}
+
+	upload.Offset = newOffset
+	upload.Expires = expires
+
+	return nil
+}
+
+// DeleteExpiredUploads deletes all uploads (and their stored files) with past expiration date.
+//
+// The upload models are deleted one by one so that the
+// related storage files could be also removed.
+func (app *BaseApp) DeleteExpiredUploads() error {
+	expired := []*Upload{}
+
+	err := app.UploadQuery().
+		AndWhere(dbx.NewExp("[[expires]] <= {:now}", dbx.Params{"now": types.NowDateTime().String()})).
+		All(&expired)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, upload := range expired {
+		if err := app.AuxDelete(upload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,168 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func TestCreateUpload(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	upload, err := app.CreateUpload("_pb_users_auth_", "4q1xlclmfloku33", "test.mp4", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if upload.Id == "" {
+		t.Fatal("Expected the upload id to be set")
+	}
+
+	if upload.Offset != 0 || upload.Size != 100 || upload.IsCompleted() {
+		t.Fatalf("Expected empty incomplete upload with size 100, got %v", upload)
+	}
+
+	if !upload.Expires.After(upload.Created) {
+		t.Fatalf("Expected expires to be after created, got %v and %v", upload.Expires, upload.Created)
+	}
+
+	found, err := app.FindUploadById(upload.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.OriginalName != "test.mp4" || found.RecordRef != "4q1xlclmfloku33" {
+		t.Fatalf("Unexpected stored upload %v", found)
+	}
+}
+
+func TestCreateFieldUpload(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	upload, err := app.CreateFieldUpload("_pb_users_auth_", "4q1xlclmfloku33", "wsmn24bux7wo113", "files", "test.mp4", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := app.FindUploadById(upload.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.FieldCollectionRef != "wsmn24bux7wo113" || found.FieldName != "files" {
+		t.Fatalf("Expected the upload to be bound to the files field, got %v", found)
+	}
+
+	scenarios := []struct {
+		collectionId string
+		fieldName    string
+		expected     bool
+	}{
+		{"wsmn24bux7wo113", "files", true},
+		{"wsmn24bux7wo113", "other", false},
+		{"_pb_users_auth_", "files", false},
+	}
+
+	for _, s := range scenarios {
+		if v := found.CanAttachTo(s.collectionId, s.fieldName); v != s.expected {
+			t.Fatalf("Expected CanAttachTo(%q, %q) %v, got %v", s.collectionId, s.fieldName, s.expected, v)
+		}
+	}
+
+	unbound, err := app.CreateUpload("_pb_users_auth_", "4q1xlclmfloku33", "test.mp4", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !unbound.CanAttachTo("wsmn24bux7wo113", "files") {
+		t.Fatal("Expected unbound upload to be attachable to any file field")
+	}
+}
+
+func TestFindUploadById(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.FindUploadById("missing"); err == nil {
+		t.Fatal("Expected error for missing upload, got nil")
+	}
+}
+
+func TestAdvanceUploadOffset(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	upload, err := app.CreateUpload("_pb_users_auth_", "4q1xlclmfloku33", "test.mp4", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a concurrent write with the same offset
+	stale := *upload
+
+	if err := app.AdvanceUploadOffset(upload, 60); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.AdvanceUploadOffset(&stale, 30); !errors.Is(err, core.ErrUploadOffsetMismatch) {
+		t.Fatalf("Expected ErrUploadOffsetMismatch, got %v", err)
+	}
+
+	if err := app.AdvanceUploadOffset(upload, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := app.FindUploadById(upload.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Offset != 100 || !found.IsCompleted() {
+		t.Fatalf("Expected completed upload with offset 100, got %v", found)
+	}
+}
+
+func TestDeleteExpiredUploads(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	for i := 0; i < 3; i++ {
+		upload, err := app.CreateUpload("_pb_users_auth_", "4q1xlclmfloku33", "test.mp4", 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		upload.Expires = upload.Created.Add(time.Duration(1-i) * time.Hour)
+		if err := app.AuxSave(upload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.DeleteExpiredUploads(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := []*core.Upload{}
+	if err := app.UploadQuery().All(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 remaining upload, got %d", len(result))
+	}
+}
@@ -67,19 +67,34 @@ func UploadedFileMimeType(validTypes []string) validation.RuleFunc {
 			return baseErr
 		}
 
-		f, err := v.Reader.Open()
-		if err != nil {
-			return baseErr
-		}
-		defer f.Close()
+		var filetype *mimetype.MIME
 
-		filetype, err := mimetype.DetectReader(f)
-		if err != nil {
-			return baseErr
+		if sr, ok := v.Reader.(filesystem.StorageFileReader); ok {
+			// already stored object -> check its stored content type
+			contentType := filesystem.BaseContentType(sr.ContentType())
+
+			filetype = mimetype.Lookup(contentType)
+
+			for _, t := range validTypes {
+				if strings.EqualFold(contentType, t) {
+					return nil // valid
+				}
+			}
+		} else {
+			f, err := v.Reader.Open()
+			if err != nil {
+				return baseErr
+			}
+			defer f.Close()
+
+			filetype, err = mimetype.DetectReader(f)
+			if err != nil {
+				return baseErr
+			}
 		}
 
 		for _, t := range validTypes {
-			if filetype.Is(t) {
+			if filetype != nil && filetype.Is(t) {
 				return nil // valid
 			}
 		}
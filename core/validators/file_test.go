@@ -1,7 +1,9 @@
 package validators_test
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -49,6 +51,11 @@ func TestUploadedFileMimeType(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	storedFile, err := filesystem.NewFileFromReader(&testStorageFileReader{key: "test", contentType: "image/jpeg"}, 4, "test.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	scenarios := []struct {
 		types       []string
 		file        *filesystem.File
@@ -60,6 +67,9 @@ func TestUploadedFileMimeType(t *testing.T) {
 		{[]string{"image/jpeg"}, file, true},
 		// test files are detected as "text/plain; charset=utf-8" content type
 		{[]string{"image/jpeg", "text/plain; charset=utf-8"}, file, false},
+		// stored files are checked by their stored content type
+		{[]string{"image/jpeg"}, storedFile, false},
+		{[]string{"image/png"}, storedFile, true},
 	}
 
 	for i, s := range scenarios {
@@ -73,3 +83,20 @@ func TestUploadedFileMimeType(t *testing.T) {
 		})
 	}
 }
+
+type testStorageFileReader struct {
+	key         string
+	contentType string
+}
+
+func (r *testStorageFileReader) Open() (io.ReadSeekCloser, error) {
+	return nil, errors.New("unexpected open")
+}
+
+func (r *testStorageFileReader) StorageKey() string {
+	return r.key
+}
+
+func (r *testStorageFileReader) ContentType() string {
+	return r.contentType
+}
@@ -0,0 +1,81 @@
+package forms
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+// SigningKeyRotate is a form struct to generate a new active
+// auth token signing key for an auth collection.
+type SigningKeyRotate struct {
+	app core.App
+
+	// Collection is the id or name of the auth collection to rotate the key for.
+	Collection string `form:"collection" json:"collection"`
+
+	// Algorithm is the JWT signing algorithm of the new key (RS256 or EdDSA).
+	Algorithm string `form:"algorithm" json:"algorithm"`
+}
+
+// NewSigningKeyRotate creates a new [SigningKeyRotate] form with initializer
+// config created from the provided [core.App] instance.
+func NewSigningKeyRotate(app core.App) *SigningKeyRotate {
+	return &SigningKeyRotate{
+		app:       app,
+		Algorithm: security.JWTAlgRS256,
+	}
+}
+
+// Validate makes the form validatable by implementing [validation.Validatable] interface.
+func (form *SigningKeyRotate) Validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Collection, validation.Required, validation.By(form.checkAuthCollection)),
+		validation.Field(&form.Algorithm, validation.Required, validation.In(security.JWTAlgRS256, security.JWTAlgEdDSA)),
+	)
+}
+
+func (form *SigningKeyRotate) checkAuthCollection(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	collection, _ := form.app.FindCachedCollectionByNameOrId(v)
+	if collection == nil || !collection.IsAuth() {
+		return validation.NewError("validation_invalid_auth_collection", "Missing or invalid auth collection.")
+	}
+
+	return nil
+}
+
+// Submit validates the form, generates a new active signing key for
+// the collection (deactivating the previous one) and persists it in the app settings.
+//
+// On success returns the public JWK of the new key.
+func (form *SigningKeyRotate) Submit() (security.JWK, error) {
+	if err := form.Validate(); err != nil {
+		return security.JWK{}, err
+	}
+
+	collection, err := form.app.FindCachedCollectionByNameOrId(form.Collection)
+	if err != nil {
+		return security.JWK{}, err
+	}
+
+	settings, err := form.app.Settings().Clone()
+	if err != nil {
+		return security.JWK{}, err
+	}
+
+	key, err := settings.SigningKeys.Rotate(collection.Id, form.Algorithm)
+	if err != nil {
+		return security.JWK{}, err
+	}
+
+	if err := form.app.Save(settings); err != nil {
+		return security.JWK{}, err
+	}
+
+	return key.JWK()
+}
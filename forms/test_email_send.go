@@ -7,6 +7,7 @@ import (
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/mails"
+	"github.com/thewandererbg/pgbase/tools/mailer"
 )
 
 const (
@@ -24,6 +25,12 @@ type TestEmailSend struct {
 	Email      string `form:"email" json:"email"`
 	Template   string `form:"template" json:"template"`
 	Collection string `form:"collection" json:"collection"` // optional, fallbacks to _superusers
+
+	// DKIM indicates whether to generate the DKIM-Signature
+	// of the test message (see [TestEmailSend.DKIMSignature]).
+	DKIM bool `form:"dkim" json:"dkim"`
+
+	dkimSignature string
 }
 
 // NewTestEmailSend creates and initializes new TestEmailSend form.
@@ -56,9 +63,33 @@ func (form *TestEmailSend) Validate() error {
 				TestTemplateAuthAlert,
 			),
 		),
+		validation.Field(&form.DKIM, validation.By(form.checkDKIM)),
 	)
 }
 
+func (form *TestEmailSend) checkDKIM(value any) error {
+	v, _ := value.(bool)
+	if !v {
+		return nil // nothing to check
+	}
+
+	if !form.app.Settings().SMTP.DKIM.Enabled {
+		return validation.NewError("validation_dkim_disabled", "DKIM signing is not enabled.")
+	}
+
+	return nil
+}
+
+// DKIMSignature returns the DKIM-Signature header generated for
+// the last submitted test email (available only if form.DKIM is set).
+//
+// Note that the signature is generated from a separately built
+// MIME representation of the test message so its timestamp, body hash
+// and signature values could differ from the ones of the actually sent email.
+func (form *TestEmailSend) DKIMSignature() string {
+	return form.dkimSignature
+}
+
 func (form *TestEmailSend) checkAuthCollection(value any) error {
 	v, _ := value.(string)
 	if v == "" {
@@ -99,18 +130,58 @@ func (form *TestEmailSend) Submit() error {
 	record.RefreshTokenKey()
 	record.SetEmail(form.Email)
 
+	app := form.app
+	if form.DKIM {
+		signer, err := form.app.Settings().SMTP.DKIM.Signer()
+		if err != nil {
+			return err
+		}
+
+		app = &dkimPreviewApp{App: form.app, signer: signer, signature: &form.dkimSignature}
+	}
+
 	switch form.Template {
 	case TestTemplateVerification:
-		return mails.SendRecordVerification(form.app, record)
+		return mails.SendRecordVerification(app, record)
 	case TestTemplatePasswordReset:
-		return mails.SendRecordPasswordReset(form.app, record)
+		return mails.SendRecordPasswordReset(app, record)
 	case TestTemplateEmailChange:
-		return mails.SendRecordChangeEmail(form.app, record, form.Email)
+		return mails.SendRecordChangeEmail(app, record, form.Email)
 	case TestTemplateOTP:
-		return mails.SendRecordOTP(form.app, record, "_PB_TEST_OTP_ID_", "123456")
+		return mails.SendRecordOTP(app, record, "_PB_TEST_OTP_ID_", "123456")
 	case TestTemplateAuthAlert:
-		return mails.SendRecordAuthAlert(form.app, record)
+		return mails.SendRecordAuthAlert(app, record)
 	default:
 		return errors.New("unknown template " + form.Template)
 	}
 }
+
+// dkimPreviewApp wraps the app mail client in order to
+// generate the DKIM signature of the sent test message.
+type dkimPreviewApp struct {
+	core.App
+	signer    *mailer.DKIMSigner
+	signature *string
+}
+
+// NewMailClient implements the [core.App.NewMailClient] interface method.
+func (app *dkimPreviewApp) NewMailClient() mailer.Mailer {
+	return &dkimPreviewMailer{Mailer: app.App.NewMailClient(), app: app}
+}
+
+type dkimPreviewMailer struct {
+	mailer.Mailer
+	app *dkimPreviewApp
+}
+
+// Send implements the [mailer.Mailer] interface.
+func (m *dkimPreviewMailer) Send(message *mailer.Message) error {
+	signature, err := m.app.signer.MessageSignature(message)
+	if err != nil {
+		return err
+	}
+
+	*m.app.signature = signature
+
+	return m.Mailer.Send(message)
+}
@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/forms"
 	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/security"
 )
 
 func TestEmailSendValidateAndSubmit(t *testing.T) {
@@ -94,3 +96,54 @@ func TestEmailSendValidateAndSubmit(t *testing.T) {
 		})
 	}
 }
+
+func TestEmailSendDKIM(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	form := forms.NewTestEmailSend(app)
+	form.Email = "test@example.com"
+	form.Template = forms.TestTemplateVerification
+	form.DKIM = true
+
+	// DKIM disabled
+	// ---
+	errs, ok := form.Submit().(validation.Errors)
+	if !ok {
+		t.Fatalf("Expected validation errors, got %v", errs)
+	}
+	if _, ok := errs["dkim"]; !ok || len(errs) != 1 {
+		t.Fatalf("Expected only dkim validation error, got %v", errs)
+	}
+
+	// DKIM enabled
+	// ---
+	key, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Settings().SMTP.DKIM = core.DKIMConfig{
+		Enabled:    true,
+		Domain:     "example.com",
+		Selector:   "mail",
+		PrivateKey: key,
+	}
+
+	if err := form.Submit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.TestMailer.TotalSend() != 1 {
+		t.Fatalf("Expected 1 email to be sent, got %d", app.TestMailer.TotalSend())
+	}
+
+	signature := form.DKIMSignature()
+	for _, part := range []string{"DKIM-Signature: v=1;", "a=ed25519-sha256", "d=example.com", "s=mail", "b="} {
+		if !strings.Contains(signature, part) {
+			t.Fatalf("Expected %q in the DKIM signature, got\n%s", part, signature)
+		}
+	}
+}
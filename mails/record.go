@@ -12,10 +12,13 @@ import (
 )
 
 // SendRecordAuthAlert sends a new device login alert to the specified auth record.
-func SendRecordAuthAlert(app core.App, authRecord *core.Record) error {
+//
+// The optional locales argument specifies the preferred locales (eg. from
+// the request Accept-Language header) used for picking a localized email template.
+func SendRecordAuthAlert(app core.App, authRecord *core.Record, locales ...string) error {
 	mailClient := app.NewMailClient()
 
-	subject, body, err := resolveEmailTemplate(app, authRecord, authRecord.Collection().AuthAlert.EmailTemplate, nil)
+	subject, body, err := resolveEmailTemplate(app, authRecord, core.EmailTemplateAuthAlert, locales, nil)
 	if err != nil {
 		return err
 	}
@@ -44,10 +47,12 @@ func SendRecordAuthAlert(app core.App, authRecord *core.Record) error {
 // SendRecordOTP sends OTP email to the specified auth record.
 //
 // This method will also update the "sentTo" field of the related OTP record to the mail sent To address (if the OTP exists and not already assigned).
-func SendRecordOTP(app core.App, authRecord *core.Record, otpId string, pass string) error {
+//
+// The optional locales argument is used for picking a localized email template (see [SendRecordAuthAlert]).
+func SendRecordOTP(app core.App, authRecord *core.Record, otpId string, pass string, locales ...string) error {
 	mailClient := app.NewMailClient()
 
-	subject, body, err := resolveEmailTemplate(app, authRecord, authRecord.Collection().OTP.EmailTemplate, map[string]any{
+	subject, body, err := resolveEmailTemplate(app, authRecord, core.EmailTemplateOTP, locales, map[string]any{
 		core.EmailPlaceholderOTPId: otpId,
 		core.EmailPlaceholderOTP:   pass,
 	})
@@ -75,7 +80,52 @@ func SendRecordOTP(app core.App, authRecord *core.Record, otpId string, pass str
 		"password": pass,
 	}
 
-	return app.OnMailerRecordOTPSend().Trigger(event, func(e *core.MailerRecordEvent) error {
+	return app.OnMailerRecordOTPSend().Trigger(event, sendRecordOTPMessage(otpId))
+}
+
+// SendRecordOTPMagicLink sends OTP magic link email to the specified auth record.
+//
+// Similar to [SendRecordOTP], this method will also update the "sentTo" field of the related OTP record.
+//
+// The optional locales argument is used for picking a localized email template (see [SendRecordAuthAlert]).
+func SendRecordOTPMagicLink(app core.App, authRecord *core.Record, otpId string, link string, locales ...string) error {
+	mailClient := app.NewMailClient()
+
+	subject, body, err := resolveEmailTemplate(app, authRecord, core.EmailTemplateOTPMagicLink, locales, map[string]any{
+		core.EmailPlaceholderOTPId:     otpId,
+		core.EmailPlaceholderMagicLink: link,
+	})
+	if err != nil {
+		return err
+	}
+
+	message := &mailer.Message{
+		From: mail.Address{
+			Name:    app.Settings().Meta.SenderName,
+			Address: app.Settings().Meta.SenderAddress,
+		},
+		To:      []mail.Address{{Address: authRecord.Email()}},
+		Subject: subject,
+		HTML:    body,
+	}
+
+	event := new(core.MailerRecordEvent)
+	event.App = app
+	event.Mailer = mailClient
+	event.Message = message
+	event.Record = authRecord
+	event.Meta = map[string]any{
+		"otpId":     otpId,
+		"magicLink": link,
+	}
+
+	return app.OnMailerRecordOTPSend().Trigger(event, sendRecordOTPMessage(otpId))
+}
+
+// sendRecordOTPMessage returns the default OTP email send handler
+// that also updates the OTP "sentTo" field after successful send.
+func sendRecordOTPMessage(otpId string) func(e *core.MailerRecordEvent) error {
+	return func(e *core.MailerRecordEvent) error {
 		err := e.Mailer.Send(e.Message)
 		if err != nil {
 			return err
@@ -114,11 +164,13 @@ func SendRecordOTP(app core.App, authRecord *core.Record, otpId string, pass str
 		}
 
 		return nil
-	})
+	}
 }
 
 // SendRecordPasswordReset sends a password reset request email to the specified auth record.
-func SendRecordPasswordReset(app core.App, authRecord *core.Record) error {
+//
+// The optional locales argument is used for picking a localized email template (see [SendRecordAuthAlert]).
+func SendRecordPasswordReset(app core.App, authRecord *core.Record, locales ...string) error {
 	token, tokenErr := authRecord.NewPasswordResetToken()
 	if tokenErr != nil {
 		return tokenErr
@@ -126,7 +178,7 @@ func SendRecordPasswordReset(app core.App, authRecord *core.Record) error {
 
 	mailClient := app.NewMailClient()
 
-	subject, body, err := resolveEmailTemplate(app, authRecord, authRecord.Collection().ResetPasswordTemplate, map[string]any{
+	subject, body, err := resolveEmailTemplate(app, authRecord, core.EmailTemplateResetPassword, locales, map[string]any{
 		core.EmailPlaceholderToken: token,
 	})
 	if err != nil {
@@ -156,7 +208,9 @@ func SendRecordPasswordReset(app core.App, authRecord *core.Record) error {
 }
 
 // SendRecordVerification sends a verification request email to the specified auth record.
-func SendRecordVerification(app core.App, authRecord *core.Record) error {
+//
+// The optional locales argument is used for picking a localized email template (see [SendRecordAuthAlert]).
+func SendRecordVerification(app core.App, authRecord *core.Record, locales ...string) error {
 	token, tokenErr := authRecord.NewVerificationToken()
 	if tokenErr != nil {
 		return tokenErr
@@ -164,7 +218,7 @@ func SendRecordVerification(app core.App, authRecord *core.Record) error {
 
 	mailClient := app.NewMailClient()
 
-	subject, body, err := resolveEmailTemplate(app, authRecord, authRecord.Collection().VerificationTemplate, map[string]any{
+	subject, body, err := resolveEmailTemplate(app, authRecord, core.EmailTemplateVerification, locales, map[string]any{
 		core.EmailPlaceholderToken: token,
 	})
 	if err != nil {
@@ -194,7 +248,9 @@ func SendRecordVerification(app core.App, authRecord *core.Record) error {
 }
 
 // SendRecordChangeEmail sends a change email confirmation email to the specified auth record.
-func SendRecordChangeEmail(app core.App, authRecord *core.Record, newEmail string) error {
+//
+// The optional locales argument is used for picking a localized email template (see [SendRecordAuthAlert]).
+func SendRecordChangeEmail(app core.App, authRecord *core.Record, newEmail string, locales ...string) error {
 	token, tokenErr := authRecord.NewEmailChangeToken(newEmail)
 	if tokenErr != nil {
 		return tokenErr
@@ -202,7 +258,7 @@ func SendRecordChangeEmail(app core.App, authRecord *core.Record, newEmail strin
 
 	mailClient := app.NewMailClient()
 
-	subject, body, err := resolveEmailTemplate(app, authRecord, authRecord.Collection().ConfirmEmailChangeTemplate, map[string]any{
+	subject, body, err := resolveEmailTemplate(app, authRecord, core.EmailTemplateConfirmEmailChange, locales, map[string]any{
 		core.EmailPlaceholderToken: token,
 	})
 	if err != nil {
@@ -241,12 +297,27 @@ var nonescapeTypes = []string{
 	core.FieldTypeNumber,
 }
 
+// resolveEmailTemplate renders the auth record collection email template of the specified kind.
+//
+// The localized template variant is picked based on the auth record locale field value (if configured)
+// followed by the provided preferred locales and the collection default locale.
 func resolveEmailTemplate(
 	app core.App,
 	authRecord *core.Record,
-	emailTemplate core.EmailTemplate,
+	templateKind string,
+	locales []string,
 	placeholders map[string]any,
 ) (subject string, body string, err error) {
+	collection := authRecord.Collection()
+
+	if localeField := collection.EmailLocalization.LocaleField; localeField != "" {
+		if recordLocale := authRecord.GetString(localeField); recordLocale != "" {
+			locales = append([]string{recordLocale}, locales...)
+		}
+	}
+
+	emailTemplate := collection.LocalizedEmailTemplate(templateKind, locales...)
+
 	if placeholders == nil {
 		placeholders = map[string]any{}
 	}
@@ -260,7 +331,7 @@ func resolveEmailTemplate(
 	}
 
 	// register default auth record placeholders
-	for _, field := range authRecord.Collection().Fields {
+	for _, field := range collection.Fields {
 		if field.GetHidden() {
 			continue
 		}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/mails"
 	"github.com/thewandererbg/pgbase/tests"
 )
@@ -104,6 +105,83 @@ func TestSendRecordVerification(t *testing.T) {
 	}
 }
 
+func TestSendRecordVerificationLocalized(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	user, _ := testApp.FindFirstRecordByData("users", "email", "test@example.com")
+
+	collection := user.Collection()
+	collection.EmailLocalization.LocaleField = "name"
+	collection.EmailLocalization.Templates = map[string]core.LocalizedEmailTemplates{
+		"de": {
+			VerificationTemplate: core.EmailTemplate{
+				Subject: "Bestätigen Sie Ihre {APP_NAME} E-Mail",
+				Body:    "de: {TOKEN}",
+			},
+		},
+		"bg": {
+			VerificationTemplate: core.EmailTemplate{
+				Subject: "Потвърдете {APP_NAME} имейла си",
+				Body:    "bg: {TOKEN}",
+			},
+		},
+	}
+
+	scenarios := []struct {
+		name            string
+		recordLocale    string
+		locales         []string
+		expectedSubject string
+		expectedBody    string
+	}{
+		{
+			"no matching locale",
+			"",
+			[]string{"fr"},
+			"Verify your " + testApp.Settings().Meta.AppName + " email",
+			"confirm-verification/",
+		},
+		{
+			"request locale",
+			"",
+			[]string{"fr", "de-CH"},
+			"Bestätigen Sie Ihre " + testApp.Settings().Meta.AppName + " E-Mail",
+			"de: ",
+		},
+		{
+			"record locale has priority over the request locale",
+			"bg",
+			[]string{"de"},
+			"Потвърдете " + testApp.Settings().Meta.AppName + " имейла си",
+			"bg: ",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			user.Set("name", s.recordLocale)
+
+			err := mails.SendRecordVerification(testApp, user, s.locales...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			message := testApp.TestMailer.LastMessage()
+
+			if message.Subject != s.expectedSubject {
+				t.Fatalf("Expected subject %q, got %q", s.expectedSubject, message.Subject)
+			}
+
+			if !strings.Contains(message.HTML, s.expectedBody) {
+				t.Fatalf("Couldn't find %s \nin\n %s", s.expectedBody, message.HTML)
+			}
+		})
+	}
+}
+
 func TestSendRecordChangeEmail(t *testing.T) {
 	t.Parallel()
 
@@ -166,3 +244,66 @@ func TestSendRecordOTP(t *testing.T) {
 		}
 	}
 }
+
+func TestSendRecordOTPMagicLink(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	user, _ := testApp.FindFirstRecordByData("users", "email", "test@example.com")
+	user.Collection().OTP.MagicLinkTemplate = core.EmailTemplate{
+		Subject: "Login link",
+		Body:    `<a href="{MAGIC_LINK}">{OTP_ID}</a>`,
+	}
+
+	err := mails.SendRecordOTPMagicLink(testApp, user, "test_otp_id", "https://example.com/link?token=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testApp.TestMailer.TotalSend() != 1 {
+		t.Fatalf("Expected one email to be sent, got %d", testApp.TestMailer.TotalSend())
+	}
+
+	expected := `<a href="https://example.com/link?token=abc">test_otp_id</a>`
+	if !strings.Contains(testApp.TestMailer.LastMessage().HTML, expected) {
+		t.Fatalf("Couldn't find %s \nin\n %s", expected, testApp.TestMailer.LastMessage().HTML)
+	}
+}
+
+func TestSendRecordPasswordResetWithMailOutbox(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	testApp.Settings().MailOutbox.Enabled = true
+
+	user, _ := testApp.FindFirstRecordByData("users", "email", "test@example.com")
+
+	err := mails.SendRecordPasswordReset(testApp, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testApp.TestMailer.TotalSend() != 0 {
+		t.Fatalf("Expected the email to be only enqueued, got %d sent", testApp.TestMailer.TotalSend())
+	}
+
+	processed, err := testApp.ProcessMailOutbox(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != 1 {
+		t.Fatalf("Expected 1 processed outbox message, got %d", processed)
+	}
+
+	if testApp.TestMailer.TotalSend() != 1 {
+		t.Fatalf("Expected one email to be sent, got %d", testApp.TestMailer.TotalSend())
+	}
+
+	if !strings.Contains(testApp.TestMailer.LastMessage().HTML, "/_/#/auth/confirm-password-reset") {
+		t.Fatalf("Expected the password reset email, got\n%s", testApp.TestMailer.LastMessage().HTML)
+	}
+}
@@ -0,0 +1,217 @@
+package mails
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/mail"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/spf13/cast"
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/mails/templates"
+	"github.com/thewandererbg/pgbase/tools/mailer"
+	pbtemplate "github.com/thewandererbg/pgbase/tools/template"
+	"github.com/thewandererbg/pgbase/tools/types"
+)
+
+// note: the registry caches the parsed templates by their full
+// combined content so any layout, partial or template change
+// results in a new cache entry.
+var templatesRegistry = pbtemplate.NewRegistry()
+
+// RenderedTemplate defines the result of a rendered mail template.
+type RenderedTemplate struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// SendTemplate renders the named mail template (see [RenderTemplate])
+// and sends it to the specified recipients.
+//
+// Example:
+//
+//	err := mails.SendTemplate(app, "welcome", []mail.Address{{Address: "test@example.com"}}, map[string]any{
+//		"name": "John",
+//	})
+func SendTemplate(app core.App, name string, to []mail.Address, data map[string]any) error {
+	if len(to) == 0 {
+		return errors.New("missing mail recipients")
+	}
+
+	rendered, err := RenderTemplate(app, name, data)
+	if err != nil {
+		return err
+	}
+
+	message := &mailer.Message{
+		From: mail.Address{
+			Name:    app.Settings().Meta.SenderName,
+			Address: app.Settings().Meta.SenderAddress,
+		},
+		To:      to,
+		Subject: rendered.Subject,
+		HTML:    rendered.HTML,
+		Text:    rendered.Text,
+	}
+
+	return app.NewMailClient().Send(message)
+}
+
+// RenderTemplate renders the named mail template with the provided data.
+//
+// The data values of the template placeholders are validated and
+// normalized based on their type (the required placeholders must be set).
+// The extra data keys are passed to the template as they are.
+//
+// In addition to the data keys, the templates could also make use of
+// the {{.APP_NAME}} and {{.APP_URL}} system placeholders.
+//
+// The html body is rendered as [html/template] using the template layout
+// (or the default system one) and all registered partials.
+// The subject and the optional plain text body are rendered as [text/template].
+func RenderTemplate(app core.App, name string, data map[string]any) (*RenderedTemplate, error) {
+	return renderTemplate(app, name, data, false)
+}
+
+// PreviewTemplate is similar to [RenderTemplate] but fills the missing
+// data placeholder values with the placeholders sample values.
+func PreviewTemplate(app core.App, name string, data map[string]any) (*RenderedTemplate, error) {
+	return renderTemplate(app, name, data, true)
+}
+
+func renderTemplate(app core.App, name string, data map[string]any, useSamples bool) (*RenderedTemplate, error) {
+	mt, err := app.FindMailTemplateByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find mail template %q: %w", name, err)
+	}
+
+	if mt.Type() != core.MailTemplateTypeTemplate {
+		return nil, fmt.Errorf("%q is a mail template %s and cannot be rendered directly", name, mt.Type())
+	}
+
+	params, err := resolveTemplateData(app, mt.Placeholders(), data, useSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := templates.Layout
+	if mt.Layout() != "" {
+		lt, err := app.FindMailTemplateByName(mt.Layout())
+		if err != nil || lt.Type() != core.MailTemplateTypeLayout {
+			return nil, fmt.Errorf("missing or invalid mail template %q layout %q", name, mt.Layout())
+		}
+		layout = lt.HTML()
+	}
+
+	partials, err := app.FindAllMailTemplatesByType(core.MailTemplateTypePartial)
+	if err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+	content.WriteString(layout)
+	for _, p := range partials {
+		content.WriteString(`{{define "` + p.Name() + `"}}` + p.HTML() + `{{end}}`)
+	}
+	content.WriteString(`{{define "content"}}` + mt.HTML() + `{{end}}`)
+
+	result := &RenderedTemplate{}
+
+	result.HTML, err = templatesRegistry.LoadString(content.String()).Render(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render mail template %q html: %w", name, err)
+	}
+
+	result.Subject, err = resolveTemplateContent(params, mt.Subject())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render mail template %q subject: %w", name, err)
+	}
+
+	if mt.Text() != "" {
+		result.Text, err = resolveTemplateContent(params, mt.Text())
+		if err != nil {
+			return nil, fmt.Errorf("failed to render mail template %q text: %w", name, err)
+		}
+	}
+
+	return result, nil
+}
+
+// resolveTemplateData validates and normalizes the template data based on the template placeholders.
+func resolveTemplateData(
+	app core.App,
+	placeholders []core.MailTemplatePlaceholder,
+	data map[string]any,
+	useSamples bool,
+) (map[string]any, error) {
+	result := make(map[string]any, len(data)+len(placeholders)+2)
+
+	result["APP_NAME"] = app.Settings().Meta.AppName
+	result["APP_URL"] = app.Settings().Meta.AppURL
+
+	maps.Copy(result, data)
+
+	errs := validation.Errors{}
+
+	for _, p := range placeholders {
+		v := data[p.Name]
+		if (v == nil || v == "") && useSamples {
+			v = p.Sample
+		}
+
+		if v == nil || v == "" {
+			if p.Required {
+				errs[p.Name] = validation.ErrRequired
+				continue
+			}
+		}
+
+		normalized, err := normalizePlaceholderValue(p.Type, v)
+		if err != nil {
+			errs[p.Name] = validation.NewError("validation_invalid_placeholder_value", "Invalid {{.type}} value.").
+				SetParams(map[string]any{"type": p.Type})
+			continue
+		}
+
+		result[p.Name] = normalized
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return result, nil
+}
+
+func normalizePlaceholderValue(placeholderType string, v any) (any, error) {
+	switch placeholderType {
+	case core.MailTemplatePlaceholderNumber:
+		return cast.ToFloat64E(v)
+	case core.MailTemplatePlaceholderBool:
+		return cast.ToBoolE(v)
+	case core.MailTemplatePlaceholderDate:
+		return types.ParseDateTime(v)
+	case core.MailTemplatePlaceholderURL:
+		str, err := cast.ToStringE(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := is.URL.Validate(str); err != nil {
+			return nil, err
+		}
+		return str, nil
+	case core.MailTemplatePlaceholderHTML:
+		str, err := cast.ToStringE(v)
+		if err != nil {
+			return nil, err
+		}
+		return template.HTML(str), nil
+	default:
+		return cast.ToStringE(v)
+	}
+}
@@ -0,0 +1,229 @@
+package mails_test
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/core"
+	"github.com/thewandererbg/pgbase/mails"
+	"github.com/thewandererbg/pgbase/tests"
+)
+
+func createTestMailTemplates(t *testing.T, app core.App) {
+	layout := core.NewMailTemplate(app)
+	layout.SetName("test_layout")
+	layout.SetType(core.MailTemplateTypeLayout)
+	layout.SetHTML(`<main>{{template "content" .}}</main>`)
+	if err := app.Save(layout); err != nil {
+		t.Fatal(err)
+	}
+
+	partial := core.NewMailTemplate(app)
+	partial.SetName("test_footer")
+	partial.SetType(core.MailTemplateTypePartial)
+	partial.SetHTML(`<footer>{{.APP_NAME}} team</footer>`)
+	if err := app.Save(partial); err != nil {
+		t.Fatal(err)
+	}
+
+	welcome := core.NewMailTemplate(app)
+	welcome.SetName("welcome")
+	welcome.SetType(core.MailTemplateTypeTemplate)
+	welcome.SetLayout(layout.Name())
+	welcome.SetSubject("Welcome to {{.APP_NAME}}, {{.name}}")
+	welcome.SetHTML(`<p>Hello {{.name}}</p>{{if .vip}}<p>vip</p>{{end}}<p>{{.credits}}</p>{{.note}}{{template "test_footer" .}}`)
+	welcome.SetText("Hello {{.name}}")
+	welcome.SetPlaceholders([]core.MailTemplatePlaceholder{
+		{Name: "name", Type: core.MailTemplatePlaceholderText, Required: true, Sample: "Sample"},
+		{Name: "vip", Type: core.MailTemplatePlaceholderBool},
+		{Name: "credits", Type: core.MailTemplatePlaceholderNumber, Sample: 10},
+		{Name: "note", Type: core.MailTemplatePlaceholderHTML},
+	})
+	if err := app.Save(welcome); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := core.NewMailTemplate(app)
+	plain.SetName("plain")
+	plain.SetType(core.MailTemplateTypeTemplate)
+	plain.SetSubject("Plain")
+	plain.SetHTML(`<p>plain</p>`)
+	if err := app.Save(plain); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	createTestMailTemplates(t, app)
+
+	appName := app.Settings().Meta.AppName
+
+	scenarios := []struct {
+		name            string
+		template        string
+		data            map[string]any
+		expectError     bool
+		expectedSubject string
+		expectedHTML    []string
+		expectedText    string
+	}{
+		{
+			"missing template",
+			"missing",
+			nil,
+			true,
+			"",
+			nil,
+			"",
+		},
+		{
+			"non-sendable template",
+			"test_footer",
+			nil,
+			true,
+			"",
+			nil,
+			"",
+		},
+		{
+			"missing required placeholder",
+			"welcome",
+			map[string]any{"credits": 1},
+			true,
+			"",
+			nil,
+			"",
+		},
+		{
+			"invalid placeholder value",
+			"welcome",
+			map[string]any{"name": "John", "credits": "abc"},
+			true,
+			"",
+			nil,
+			"",
+		},
+		{
+			"valid data with custom layout and partial",
+			"welcome",
+			map[string]any{"name": "<John>", "vip": "true", "credits": "5", "note": "<b>note</b>", "extra": 1},
+			false,
+			"Welcome to " + appName + ", <John>",
+			[]string{
+				"<main><p>Hello &lt;John&gt;</p>",
+				"<p>vip</p>",
+				"<p>5</p>",
+				"<b>note</b>",
+				"<footer>" + appName + " team</footer></main>",
+			},
+			"Hello <John>",
+		},
+		{
+			"default system layout",
+			"plain",
+			nil,
+			false,
+			"Plain",
+			[]string{"<!DOCTYPE html", "<p>plain</p>"},
+			"",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := mails.RenderTemplate(app, s.template, s.data)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if result.Subject != s.expectedSubject {
+				t.Fatalf("Expected subject %q, got %q", s.expectedSubject, result.Subject)
+			}
+
+			for _, part := range s.expectedHTML {
+				if !strings.Contains(result.HTML, part) {
+					t.Fatalf("Couldn't find %s \nin\n %s", part, result.HTML)
+				}
+			}
+
+			if result.Text != s.expectedText {
+				t.Fatalf("Expected text %q, got %q", s.expectedText, result.Text)
+			}
+		})
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	createTestMailTemplates(t, app)
+
+	result, err := mails.PreviewTemplate(app, "welcome", map[string]any{"credits": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, part := range []string{"<p>Hello Sample</p>", "<p>3</p>"} {
+		if !strings.Contains(result.HTML, part) {
+			t.Fatalf("Couldn't find %s \nin\n %s", part, result.HTML)
+		}
+	}
+}
+
+func TestSendTemplate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	createTestMailTemplates(t, app)
+
+	err := mails.SendTemplate(app, "welcome", nil, map[string]any{"name": "John"})
+	if err == nil {
+		t.Fatal("Expected error for missing recipients")
+	}
+
+	to := []mail.Address{{Address: "to@example.com"}}
+
+	err = mails.SendTemplate(app, "welcome", to, map[string]any{"name": "John"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if app.TestMailer.TotalSend() != 1 {
+		t.Fatalf("Expected one email to be sent, got %d", app.TestMailer.TotalSend())
+	}
+
+	message := app.TestMailer.LastMessage()
+
+	if len(message.To) != 1 || message.To[0].Address != "to@example.com" {
+		t.Fatalf("Expected to@example.com recipient, got %v", message.To)
+	}
+
+	if message.From.Address != app.Settings().Meta.SenderAddress {
+		t.Fatalf("Expected from %q, got %q", app.Settings().Meta.SenderAddress, message.From.Address)
+	}
+
+	if !strings.Contains(message.HTML, "<p>Hello John</p>") {
+		t.Fatalf("Expected the rendered template html, got\n%s", message.HTML)
+	}
+
+	if message.Text != "Hello John" {
+		t.Fatalf("Expected text %q, got %q", "Hello John", message.Text)
+	}
+}
@@ -0,0 +1,144 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+
+	"github.com/thewandererbg/pgbase/core"
+)
+
+// creates the OAuth2/OpenID Connect authorization server system collections
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		if err := createOAuth2ClientsCollection(txApp); err != nil {
+			return fmt.Errorf("_oauth2Clients error: %w", err)
+		}
+
+		if err := createOAuth2CodesCollection(txApp); err != nil {
+			return fmt.Errorf("_oauth2Codes error: %w", err)
+		}
+
+		return nil
+	}, func(txApp core.App) error {
+		tables := []string{
+			core.CollectionNameOAuth2Codes,
+			core.CollectionNameOAuth2Clients,
+		}
+
+		for _, name := range tables {
+			if _, err := txApp.DB().DropTable(name).Execute(); err != nil {
+				return err
+			}
+
+			_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": name}).Execute()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func createOAuth2ClientsCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameOAuth2Clients)
+	col.System = true
+
+	col.Fields.Add(&core.TextField{
+		Name:     "name",
+		System:   true,
+		Required: true,
+		Max:      255,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.PasswordField{
+		Name:   "secret",
+		System: true,
+		Hidden: true,
+	})
+	col.Fields.Add(&core.JSONField{
+		Name:     "redirectURIs",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_oauth2Clients_collectionRef", false, "collectionRef", "")
+
+	return txApp.Save(col)
+}
+
+func createOAuth2CodesCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameOAuth2Codes)
+	col.System = true
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "clientRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "code",
+		System:   true,
+		Hidden:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "redirectURI",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "scope",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "nonce",
+		System: true,
+		Hidden: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "codeChallenge",
+		System: true,
+		Hidden: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_oauth2Codes_code", true, "code", "")
+	col.AddIndex("idx_oauth2Codes_collectionRef_recordRef", false, "collectionRef,recordRef", "")
+
+	return txApp.Save(col)
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.AuxDB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_authAttempts}} (
+					[[id]]            TEXT PRIMARY KEY NOT NULL,
+					[[collectionRef]] TEXT DEFAULT '' NOT NULL,
+					[[recordRef]]     TEXT DEFAULT '' NOT NULL,
+					[[identity]]      TEXT DEFAULT '' NOT NULL,
+					[[ip]]            TEXT DEFAULT '' NOT NULL,
+					[[created]]       TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_authAttempts_collectionRef_recordRef ON {{_authAttempts}} ([[collectionRef]], [[recordRef]]);
+				CREATE INDEX IF NOT EXISTS idx_authAttempts_collectionRef_identity ON {{_authAttempts}} ([[collectionRef]], [[identity]]);
+				CREATE INDEX IF NOT EXISTS idx_authAttempts_created ON {{_authAttempts}} ([[created]]);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.AuxDB().DropTable("_authAttempts").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _authAttempts table doesn't exist
+			exists := txApp.AuxHasTable("_authAttempts")
+			return !exists, nil
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.AuxDB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_passwordHistory}} (
+					[[id]]            TEXT PRIMARY KEY NOT NULL,
+					[[collectionRef]] TEXT DEFAULT '' NOT NULL,
+					[[recordRef]]     TEXT DEFAULT '' NOT NULL,
+					[[hash]]          TEXT DEFAULT '' NOT NULL,
+					[[created]]       TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_passwordHistory_collectionRef_recordRef ON {{_passwordHistory}} ([[collectionRef]], [[recordRef]]);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.AuxDB().DropTable("_passwordHistory").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _passwordHistory table doesn't exist
+			exists := txApp.AuxHasTable("_passwordHistory")
+			return !exists, nil
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.AuxDB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_realtimeEvents}} (
+					[[id]]      BIGSERIAL PRIMARY KEY NOT NULL,
+					[[topic]]   TEXT DEFAULT '' NOT NULL,
+					[[action]]  TEXT DEFAULT '' NOT NULL,
+					[[record]]  JSONB DEFAULT '{}' NOT NULL,
+					[[created]] TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_realtimeEvents_topic_id ON {{_realtimeEvents}} ([[topic]], [[id]]);
+
+				CREATE TABLE IF NOT EXISTS {{_realtimeEventsPruned}} (
+					[[topic]]  TEXT PRIMARY KEY NOT NULL,
+					[[lastId]] BIGINT DEFAULT 0 NOT NULL
+				);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			if _, err := txApp.AuxDB().DropTable("_realtimeEventsPruned").Execute(); err != nil {
+				return err
+			}
+
+			_, err := txApp.AuxDB().DropTable("_realtimeEvents").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _realtimeEvents table doesn't exist
+			exists := txApp.AuxHasTable("_realtimeEvents")
+			return !exists, nil
+		},
+	})
+}
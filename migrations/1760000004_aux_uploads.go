@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.AuxDB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_uploads}} (
+					[[id]]                 TEXT PRIMARY KEY NOT NULL,
+					[[collectionRef]]      TEXT DEFAULT '' NOT NULL,
+					[[recordRef]]          TEXT DEFAULT '' NOT NULL,
+					[[fieldCollectionRef]] TEXT DEFAULT '' NOT NULL,
+					[[fieldName]]          TEXT DEFAULT '' NOT NULL,
+					[[originalName]]       TEXT DEFAULT '' NOT NULL,
+					[[size]]               BIGINT DEFAULT 0 NOT NULL,
+					[[offset]]             BIGINT DEFAULT 0 NOT NULL,
+					[[created]]            TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					[[expires]]            TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_uploads_expires ON {{_uploads}} ([[expires]]);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.AuxDB().DropTable("_uploads").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _uploads table doesn't exist
+			exists := txApp.AuxHasTable("_uploads")
+			return !exists, nil
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+// creates the tables used by the Postgres files storage driver
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.DB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_storageObjects}} (
+					[[key]]                TEXT COLLATE "C" PRIMARY KEY NOT NULL,
+					[[blobId]]             TEXT NOT NULL,
+					[[size]]               BIGINT DEFAULT 0 NOT NULL,
+					[[chunkSize]]          BIGINT DEFAULT 0 NOT NULL,
+					[[md5]]                BYTEA,
+					[[contentType]]        TEXT DEFAULT '' NOT NULL,
+					[[cacheControl]]       TEXT DEFAULT '' NOT NULL,
+					[[contentDisposition]] TEXT DEFAULT '' NOT NULL,
+					[[contentEncoding]]    TEXT DEFAULT '' NOT NULL,
+					[[contentLanguage]]    TEXT DEFAULT '' NOT NULL,
+					[[metadata]]           JSONB DEFAULT '{}' NOT NULL,
+					[[created]]            TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					[[updated]]            TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE TABLE IF NOT EXISTS {{_storageChunks}} (
+					[[blobId]] TEXT NOT NULL,
+					[[n]]      INTEGER NOT NULL,
+					[[data]]   BYTEA NOT NULL,
+					PRIMARY KEY ([[blobId]], [[n]])
+				);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			if _, err := txApp.DB().DropTable("_storageChunks").Execute(); err != nil {
+				return err
+			}
+
+			_, err := txApp.DB().DropTable("_storageObjects").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _storageObjects table doesn't exist
+			exists := txApp.HasTable("_storageObjects")
+			return !exists, nil
+		},
+	})
+}
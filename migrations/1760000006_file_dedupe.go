@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+// creates the deduplicated file blobs and references tables
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.DB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_fileBlobs}} (
+					[[hash]]    TEXT PRIMARY KEY NOT NULL,
+					[[size]]    BIGINT DEFAULT 0 NOT NULL,
+					[[refs]]    INTEGER DEFAULT 0 NOT NULL,
+					[[created]] TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					[[updated]] TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE TABLE IF NOT EXISTS {{_fileRefs}} (
+					[[collectionRef]] TEXT NOT NULL,
+					[[recordRef]]     TEXT NOT NULL,
+					[[filename]]      TEXT NOT NULL,
+					[[hash]]          TEXT NOT NULL,
+					[[created]]       TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					PRIMARY KEY ([[collectionRef]], [[recordRef]], [[filename]])
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_fileRefs_hash ON {{_fileRefs}} ([[hash]]);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			if _, err := txApp.DB().DropTable("_fileRefs").Execute(); err != nil {
+				return err
+			}
+
+			_, err := txApp.DB().DropTable("_fileBlobs").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _fileBlobs table doesn't exist
+			exists := txApp.HasTable("_fileBlobs")
+			return !exists, nil
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+// creates the mail outbox table
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.DB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_mailOutbox}} (
+					[[id]]          TEXT PRIMARY KEY NOT NULL,
+					[[status]]      TEXT DEFAULT 'pending' NOT NULL,
+					[[attempts]]    INTEGER DEFAULT 0 NOT NULL,
+					[[nextAttempt]] TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					[[lastError]]   TEXT DEFAULT '' NOT NULL,
+					[[message]]     JSONB DEFAULT '{}' NOT NULL,
+					[[attachments]] JSONB DEFAULT '{}' NOT NULL,
+					[[created]]     TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					[[updated]]     TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_mailOutbox_status_nextAttempt ON {{_mailOutbox}} ([[status]], [[nextAttempt]]);
+				CREATE INDEX IF NOT EXISTS idx_mailOutbox_created ON {{_mailOutbox}} ([[created]]);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.DB().DropTable("_mailOutbox").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _mailOutbox table doesn't exist
+			exists := txApp.HasTable("_mailOutbox")
+			return !exists, nil
+		},
+	})
+}
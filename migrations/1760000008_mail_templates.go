@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+
+	"github.com/thewandererbg/pgbase/core"
+)
+
+// creates the mail templates system collection
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		col := core.NewBaseCollection(core.CollectionNameMailTemplates)
+		col.System = true
+
+		col.Fields.Add(&core.TextField{
+			Name:     "name",
+			System:   true,
+			Required: true,
+			Max:      100,
+			Pattern:  `^[\w\-\.]+$`,
+		})
+		col.Fields.Add(&core.SelectField{
+			Name:      "type",
+			System:    true,
+			Required:  true,
+			MaxSelect: 1,
+			Values: []string{
+				core.MailTemplateTypeTemplate,
+				core.MailTemplateTypeLayout,
+				core.MailTemplateTypePartial,
+			},
+		})
+		col.Fields.Add(&core.TextField{
+			Name:   "subject",
+			System: true,
+			Max:    1000,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "html",
+			System:   true,
+			Required: true,
+			Max:      500000,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:   "text",
+			System: true,
+			Max:    500000,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:   "layout",
+			System: true,
+			Max:    100,
+		})
+		col.Fields.Add(&core.JSONField{
+			Name:   "placeholders",
+			System: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			System:   true,
+			OnCreate: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			System:   true,
+			OnCreate: true,
+			OnUpdate: true,
+		})
+		col.AddIndex("idx_mailTemplates_name", true, "name", "")
+		col.AddIndex("idx_mailTemplates_type", false, "type", "")
+
+		return txApp.Save(col)
+	}, func(txApp core.App) error {
+		if _, err := txApp.DB().DropTable(core.CollectionNameMailTemplates).Execute(); err != nil {
+			return err
+		}
+
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameMailTemplates}).Execute()
+
+		return err
+	})
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/thewandererbg/pgbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, err := txApp.AuxDB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_cronRuns}} (
+					[[id]]       TEXT PRIMARY KEY NOT NULL,
+					[[job]]      TEXT DEFAULT '' NOT NULL,
+					[[trigger]]  TEXT DEFAULT '' NOT NULL,
+					[[instance]] TEXT DEFAULT '' NOT NULL,
+					[[status]]   TEXT DEFAULT '' NOT NULL,
+					[[error]]    TEXT DEFAULT '' NOT NULL,
+					[[duration]] DOUBLE PRECISION DEFAULT 0 NOT NULL,
+					[[started]]  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+					[[ended]]    TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_cronRuns_job_started ON {{_cronRuns}} ([[job]], [[started]]);
+				CREATE INDEX IF NOT EXISTS idx_cronRuns_started ON {{_cronRuns}} ([[started]]);
+			`).Execute()
+
+			return err
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.AuxDB().DropTable("_cronRuns").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _cronRuns table doesn't exist
+			exists := txApp.AuxHasTable("_cronRuns")
+			return !exists, nil
+		},
+	})
+}
@@ -5,8 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
@@ -27,6 +27,7 @@ import (
 	"github.com/thewandererbg/pgbase/core"
 	"github.com/thewandererbg/pgbase/forms"
 	"github.com/thewandererbg/pgbase/mails"
+	"github.com/thewandererbg/pgbase/tools/cron"
 	"github.com/thewandererbg/pgbase/tools/filesystem"
 	"github.com/thewandererbg/pgbase/tools/hook"
 	"github.com/thewandererbg/pgbase/tools/inflector"
@@ -104,19 +105,17 @@ func cronBinds(app core.App, loader *goja.Runtime, executors *vmsPool) {
 	cronAdd := func(jobId, cronExpr, handler string) {
 		pr := goja.MustCompile(defaultScriptPath, "{("+handler+").apply(undefined)}", true)
 
-		err := app.Cron().Add(jobId, cronExpr, func() {
+		// note: the job errors are logged and stored in the cron runs history
+		err := app.Cron().AddWithOptions(jobId, cronExpr, cron.JobOptions{}, func(ctx context.Context) error {
 			err := executors.run(func(executor *goja.Runtime) error {
 				_, err := executor.RunProgram(pr)
 				return err
 			})
-
 			if err != nil {
-				app.Logger().Error(
-					"[cronAdd] failed to execute cron job",
-					slog.String("jobId", jobId),
-					slog.String("error", err.Error()),
-				)
+				return fmt.Errorf("[cronAdd] failed to execute cron job: %w", err)
 			}
+
+			return nil
 		})
 		if err != nil {
 			panic("[cronAdd] failed to register cron job " + jobId + ": " + err.Error())
@@ -625,6 +624,8 @@ func mailsBinds(vm *goja.Runtime) {
 	obj.Set("sendRecordVerification", mails.SendRecordVerification)
 	obj.Set("sendRecordChangeEmail", mails.SendRecordChangeEmail)
 	obj.Set("sendRecordOTP", mails.SendRecordOTP)
+	obj.Set("sendTemplate", mails.SendTemplate)
+	obj.Set("renderTemplate", mails.RenderTemplate)
 }
 
 func securityBinds(vm *goja.Runtime) {
@@ -763,6 +764,9 @@ func apisBinds(vm *goja.Runtime) {
 	obj.Set("enrichRecord", apis.EnrichRecord)
 	obj.Set("enrichRecords", apis.EnrichRecords)
 
+	// realtime helpers
+	obj.Set("broadcast", apis.Broadcast)
+
 	// api errors
 	registerFactoryAsConstructor(vm, "ApiError", router.NewApiError)
 	registerFactoryAsConstructor(vm, "NotFoundError", router.NewNotFoundError)
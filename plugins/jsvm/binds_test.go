@@ -754,7 +754,7 @@ func TestMailsBindsCount(t *testing.T) {
 	vm := goja.New()
 	mailsBinds(vm)
 
-	testBindsCount(vm, "$mails", 4, t)
+	testBindsCount(vm, "$mails", 6, t)
 }
 
 func TestMailsBinds(t *testing.T) {
@@ -766,6 +766,15 @@ func TestMailsBinds(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	mailTemplate := core.NewMailTemplate(app)
+	mailTemplate.SetName("welcome")
+	mailTemplate.SetType(core.MailTemplateTypeTemplate)
+	mailTemplate.SetSubject("Welcome {{.name}}")
+	mailTemplate.SetHTML("<p>Hello {{.name}}</p>")
+	if err := app.Save(mailTemplate); err != nil {
+		t.Fatal(err)
+	}
+
 	vm := goja.New()
 	baseBinds(vm)
 	mailsBinds(vm)
@@ -792,6 +801,16 @@ func TestMailsBinds(t *testing.T) {
 		if (!$app.testMailer.lastMessage().html.includes("test_otp_pass")) {
 			throw new Error("Expected record OTP email, got:" + JSON.stringify($app.testMailer.lastMessage()))
 		}
+
+		const rendered = $mails.renderTemplate($app, "welcome", {"name": "<John>"});
+		if (rendered.subject != "Welcome <John>" || !rendered.html.includes("<p>Hello &lt;John&gt;</p>")) {
+			throw new Error("Expected rendered welcome template, got:" + JSON.stringify(rendered))
+		}
+
+		$mails.sendTemplate($app, "welcome", [{"address": "to@example.com"}], {"name": "John"});
+		if ($app.testMailer.lastMessage().subject != "Welcome John") {
+			throw new Error("Expected welcome template email, got:" + JSON.stringify($app.testMailer.lastMessage()))
+		}
 	`)
 	if vmErr != nil {
 		t.Fatal(vmErr)
@@ -1048,7 +1067,7 @@ func TestApisBindsCount(t *testing.T) {
 	apisBinds(vm)
 
 	testBindsCount(vm, "this", 8, t)
-	testBindsCount(vm, "$apis", 11, t)
+	testBindsCount(vm, "$apis", 12, t)
 }
 
 func TestApisBindsApiError(t *testing.T) {
@@ -748,6 +748,7 @@ declare namespace $dbx {
 /**
  * ` + "`" + `$mails` + "`" + ` defines helpers to send common
  * auth records emails like verification, password reset, etc.
+ * and the DB stored mail templates.
  *
  * @group PocketBase
  */
@@ -756,6 +757,8 @@ declare namespace $mails {
   let sendRecordVerification:  mails.sendRecordVerification
   let sendRecordChangeEmail:   mails.sendRecordChangeEmail
   let sendRecordOTP:           mails.sendRecordOTP
+  let sendTemplate:            mails.sendTemplate
+  let renderTemplate:          mails.renderTemplate
 }
 
 // -------------------------------------------------------------------
@@ -1049,6 +1052,7 @@ declare namespace $apis {
   let bodyLimit:                     apis.bodyLimit
   let enrichRecord:                  apis.enrichRecord
   let enrichRecords:                 apis.enrichRecords
+  let broadcast:                     apis.broadcast
 
   /**
    * RecordAuthResponse writes standardized json record auth response
@@ -47,6 +47,11 @@ migrate((app) => {
     "emailChangeToken": {
       "duration": 1800
     },
+    "emailLocalization": {
+      "defaultLocale": "",
+      "localeField": "",
+      "templates": null
+    },
     "fields": [
       {
         "autogeneratePattern": "gen:ulid",
@@ -128,6 +133,19 @@ migrate((app) => {
       "CREATE UNIQUE INDEX ` + "`" + `idx_tokenKey_@TEST_RANDOM` + "`" + ` ON ` + "`" + `new_name` + "`" + ` (` + "`" + `tokenKey` + "`" + `)",
       "CREATE UNIQUE INDEX ` + "`" + `idx_email_@TEST_RANDOM` + "`" + ` ON ` + "`" + `new_name` + "`" + ` (` + "`" + `email` + "`" + `) WHERE email != ''"
     ],
+    "ldap": {
+      "baseDN": "",
+      "bindDN": "",
+      "emailAttribute": "mail",
+      "enabled": false,
+      "idAttribute": "",
+      "mappedFields": null,
+      "searchFilter": "",
+      "startTLS": false,
+      "tlsSkipVerify": false,
+      "url": "",
+      "userDN": ""
+    },
     "listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "`" + `test' = 0",
     "manageRule": "1 != 2",
     "mfa": {
@@ -152,13 +170,32 @@ migrate((app) => {
         "subject": "OTP for {APP_NAME}"
       },
       "enabled": false,
-      "length": 8
+      "length": 8,
+      "magicLink": false,
+      "magicLinkRedirectURL": "",
+      "magicLinkTemplate": {
+        "body": "<p>Hello,</p>\n<p>Click on the button below to login to your {APP_NAME} account.</p>\n<p>\n  <a class=\"btn\" href=\"{MAGIC_LINK}\" target=\"_blank\" rel=\"noopener\">Login</a>\n</p>\n<p><i>The link works only once and only in the browser where the login was requested. If you didn't ask for the login link, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+        "subject": "Login link for {APP_NAME}"
+      }
     },
     "passwordAuth": {
       "enabled": true,
       "identityFields": [
         "email"
-      ]
+      ],
+      "lockoutDuration": 900,
+      "lockoutThreshold": 0,
+      "lockoutWindow": 900
+    },
+    "passwordPolicy": {
+      "breachedListFile": "",
+      "disallowIdentity": false,
+      "historySize": 0,
+      "maxAge": 0,
+      "requireDigit": false,
+      "requireLowercase": false,
+      "requireSymbol": false,
+      "requireUppercase": false
     },
     "passwordResetToken": {
       "duration": 1800
@@ -223,6 +260,11 @@ func init() {
 			"emailChangeToken": {
 				"duration": 1800
 			},
+			"emailLocalization": {
+				"defaultLocale": "",
+				"localeField": "",
+				"templates": null
+			},
 			"fields": [
 				{
 					"autogeneratePattern": "gen:ulid",
@@ -304,6 +346,19 @@ func init() {
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_tokenKey_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `new_name` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `tokenKey` + "` + \"`\" + `" + `)",
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_email_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `new_name` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `email` + "` + \"`\" + `" + `) WHERE email != ''"
 			],
+			"ldap": {
+				"baseDN": "",
+				"bindDN": "",
+				"emailAttribute": "mail",
+				"enabled": false,
+				"idAttribute": "",
+				"mappedFields": null,
+				"searchFilter": "",
+				"startTLS": false,
+				"tlsSkipVerify": false,
+				"url": "",
+				"userDN": ""
+			},
 			"listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "` + \"`\" + `" + `test' = 0",
 			"manageRule": "1 != 2",
 			"mfa": {
@@ -328,13 +383,32 @@ func init() {
 					"subject": "OTP for {APP_NAME}"
 				},
 				"enabled": false,
-				"length": 8
+				"length": 8,
+				"magicLink": false,
+				"magicLinkRedirectURL": "",
+				"magicLinkTemplate": {
+					"body": "<p>Hello,</p>\n<p>Click on the button below to login to your {APP_NAME} account.</p>\n<p>\n  <a class=\"btn\" href=\"{MAGIC_LINK}\" target=\"_blank\" rel=\"noopener\">Login</a>\n</p>\n<p><i>The link works only once and only in the browser where the login was requested. If you didn't ask for the login link, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+					"subject": "Login link for {APP_NAME}"
+				}
 			},
 			"passwordAuth": {
 				"enabled": true,
 				"identityFields": [
 					"email"
-				]
+				],
+				"lockoutDuration": 900,
+				"lockoutThreshold": 0,
+				"lockoutWindow": 900
+			},
+			"passwordPolicy": {
+				"breachedListFile": "",
+				"disallowIdentity": false,
+				"historySize": 0,
+				"maxAge": 0,
+				"requireDigit": false,
+				"requireLowercase": false,
+				"requireSymbol": false,
+				"requireUppercase": false
 			},
 			"passwordResetToken": {
 				"duration": 1800
@@ -488,6 +562,11 @@ migrate((app) => {
     "emailChangeToken": {
       "duration": 1800
     },
+    "emailLocalization": {
+      "defaultLocale": "",
+      "localeField": "",
+      "templates": null
+    },
     "fields": [
       {
         "autogeneratePattern": "gen:ulid",
@@ -569,6 +648,19 @@ migrate((app) => {
       "CREATE UNIQUE INDEX ` + "`" + `idx_tokenKey_@TEST_RANDOM` + "`" + ` ON ` + "`" + `test123` + "`" + ` (` + "`" + `tokenKey` + "`" + `)",
       "CREATE UNIQUE INDEX ` + "`" + `idx_email_@TEST_RANDOM` + "`" + ` ON ` + "`" + `test123` + "`" + ` (` + "`" + `email` + "`" + `) WHERE email != ''"
     ],
+    "ldap": {
+      "baseDN": "",
+      "bindDN": "",
+      "emailAttribute": "mail",
+      "enabled": false,
+      "idAttribute": "",
+      "mappedFields": null,
+      "searchFilter": "",
+      "startTLS": false,
+      "tlsSkipVerify": false,
+      "url": "",
+      "userDN": ""
+    },
     "listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "`" + `test' = 0",
     "manageRule": "1 != 2",
     "mfa": {
@@ -593,13 +685,32 @@ migrate((app) => {
         "subject": "OTP for {APP_NAME}"
       },
       "enabled": false,
-      "length": 8
+      "length": 8,
+      "magicLink": false,
+      "magicLinkRedirectURL": "",
+      "magicLinkTemplate": {
+        "body": "<p>Hello,</p>\n<p>Click on the button below to login to your {APP_NAME} account.</p>\n<p>\n  <a class=\"btn\" href=\"{MAGIC_LINK}\" target=\"_blank\" rel=\"noopener\">Login</a>\n</p>\n<p><i>The link works only once and only in the browser where the login was requested. If you didn't ask for the login link, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+        "subject": "Login link for {APP_NAME}"
+      }
     },
     "passwordAuth": {
       "enabled": true,
       "identityFields": [
         "email"
-      ]
+      ],
+      "lockoutDuration": 900,
+      "lockoutThreshold": 0,
+      "lockoutWindow": 900
+    },
+    "passwordPolicy": {
+      "breachedListFile": "",
+      "disallowIdentity": false,
+      "historySize": 0,
+      "maxAge": 0,
+      "requireDigit": false,
+      "requireLowercase": false,
+      "requireSymbol": false,
+      "requireUppercase": false
     },
     "passwordResetToken": {
       "duration": 1800
@@ -667,6 +778,11 @@ func init() {
 			"emailChangeToken": {
 				"duration": 1800
 			},
+			"emailLocalization": {
+				"defaultLocale": "",
+				"localeField": "",
+				"templates": null
+			},
 			"fields": [
 				{
 					"autogeneratePattern": "gen:ulid",
@@ -748,6 +864,19 @@ func init() {
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_tokenKey_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `test123` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `tokenKey` + "` + \"`\" + `" + `)",
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_email_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `test123` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `email` + "` + \"`\" + `" + `) WHERE email != ''"
 			],
+			"ldap": {
+				"baseDN": "",
+				"bindDN": "",
+				"emailAttribute": "mail",
+				"enabled": false,
+				"idAttribute": "",
+				"mappedFields": null,
+				"searchFilter": "",
+				"startTLS": false,
+				"tlsSkipVerify": false,
+				"url": "",
+				"userDN": ""
+			},
 			"listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "` + \"`\" + `" + `test' = 0",
 			"manageRule": "1 != 2",
 			"mfa": {
@@ -772,13 +901,32 @@ func init() {
 					"subject": "OTP for {APP_NAME}"
 				},
 				"enabled": false,
-				"length": 8
+				"length": 8,
+				"magicLink": false,
+				"magicLinkRedirectURL": "",
+				"magicLinkTemplate": {
+					"body": "<p>Hello,</p>\n<p>Click on the button below to login to your {APP_NAME} account.</p>\n<p>\n  <a class=\"btn\" href=\"{MAGIC_LINK}\" target=\"_blank\" rel=\"noopener\">Login</a>\n</p>\n<p><i>The link works only once and only in the browser where the login was requested. If you didn't ask for the login link, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+					"subject": "Login link for {APP_NAME}"
+				}
 			},
 			"passwordAuth": {
 				"enabled": true,
 				"identityFields": [
 					"email"
-				]
+				],
+				"lockoutDuration": 900,
+				"lockoutThreshold": 0,
+				"lockoutWindow": 900
+			},
+			"passwordPolicy": {
+				"breachedListFile": "",
+				"disallowIdentity": false,
+				"historySize": 0,
+				"maxAge": 0,
+				"requireDigit": false,
+				"requireLowercase": false,
+				"requireSymbol": false,
+				"requireUppercase": false
 			},
 			"passwordResetToken": {
 				"duration": 1800
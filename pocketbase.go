@@ -148,10 +148,11 @@ func NewWithConfig(config Config) *PocketBase {
 }
 
 // Start starts the application, aka. registers the default system
-// commands (serve, superuser, version) and executes pb.RootCmd.
+// commands (serve, superuser, files, version) and executes pb.RootCmd.
 func (pb *PocketBase) Start() error {
 	// register system commands
 	pb.RootCmd.AddCommand(cmd.NewSuperuserCommand(pb))
+	pb.RootCmd.AddCommand(cmd.NewFilesCommand(pb))
 	pb.RootCmd.AddCommand(cmd.NewServeCommand(pb, !pb.hideStartBanner))
 
 	return pb.Execute()
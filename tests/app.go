@@ -221,6 +221,14 @@ func NewTestAppWithConfig(config core.BaseAppConfig) (*TestApp, error) {
 		Priority: -99999,
 	})
 
+	t.OnPubSubMessage().Bind(&hook.Handler[*core.PubSubMessageEvent]{
+		Func: func(e *core.PubSubMessageEvent) error {
+			t.registerEventCall("OnPubSubMessage")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
 	t.OnBackupCreate().Bind(&hook.Handler[*core.BackupEvent]{
 		Func: func(e *core.BackupEvent) error {
 			t.registerEventCall("OnBackupCreate")
@@ -633,6 +641,14 @@ func NewTestAppWithConfig(config core.BaseAppConfig) (*TestApp, error) {
 		Priority: -99999,
 	})
 
+	t.OnRealtimeClientMessage().Bind(&hook.Handler[*core.RealtimeClientMessageEvent]{
+		Func: func(e *core.RealtimeClientMessageEvent) error {
+			t.registerEventCall("OnRealtimeClientMessage")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
 	t.OnSettingsListRequest().Bind(&hook.Handler[*core.SettingsListRequestEvent]{
 		Func: func(e *core.SettingsListRequestEvent) error {
 			t.registerEventCall("OnSettingsListRequest")
@@ -769,6 +785,30 @@ func NewTestAppWithConfig(config core.BaseAppConfig) (*TestApp, error) {
 		Priority: -99999,
 	})
 
+	t.OnRecordAuthWithLDAPRequest().Bind(&hook.Handler[*core.RecordAuthWithLDAPRequestEvent]{
+		Func: func(e *core.RecordAuthWithLDAPRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithLDAPRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnOAuth2AuthorizeRequest().Bind(&hook.Handler[*core.OAuth2AuthorizeRequestEvent]{
+		Func: func(e *core.OAuth2AuthorizeRequestEvent) error {
+			t.registerEventCall("OnOAuth2AuthorizeRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnOAuth2TokenRequest().Bind(&hook.Handler[*core.OAuth2TokenRequestEvent]{
+		Func: func(e *core.OAuth2TokenRequestEvent) error {
+			t.registerEventCall("OnOAuth2TokenRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
 	t.OnRecordsListRequest().Bind(&hook.Handler[*core.RecordsListRequestEvent]{
 		Func: func(e *core.RecordsListRequestEvent) error {
 			t.registerEventCall("OnRecordsListRequest")
@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// TestLDAPEntry defines a single [TestLDAPServer] directory entry.
+type TestLDAPEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// TestLDAPServer is a minimal in-process LDAP server stand-in
+// that supports only simple binds and searches with equality filters.
+//
+// Example:
+//
+//	server, _ := tests.NewTestLDAPServer(tests.TestLDAPEntry{
+//		DN:         "uid=john,ou=people,dc=example,dc=com",
+//		Password:   "1234567890",
+//		Attributes: map[string][]string{"uid": {"john"}, "mail": {"john@example.com"}},
+//	})
+//	defer server.Close()
+//
+//	// use server.URL() as LDAP server url
+type TestLDAPServer struct {
+	listener net.Listener
+	entries  []TestLDAPEntry
+
+	mux   sync.Mutex
+	binds []string
+}
+
+// NewTestLDAPServer starts a new [TestLDAPServer] with the provided entries.
+func NewTestLDAPServer(entries ...TestLDAPEntry) (*TestLDAPServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &TestLDAPServer{listener: listener, entries: entries}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s, nil
+}
+
+// URL returns the "ldap://" url of the server.
+func (s *TestLDAPServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// Close stops the server.
+func (s *TestLDAPServer) Close() error {
+	return s.listener.Close()
+}
+
+// Binds returns the DNs of all successful binds.
+func (s *TestLDAPServer) Binds() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return append([]string{}, s.binds...)
+}
+
+func (s *TestLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageId, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		var responses []*ber.Packet
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			responses = append(responses, s.bind(op))
+		case ldap.ApplicationSearchRequest:
+			responses = s.search(op)
+		default:
+			// unbind or unsupported operation
+			return
+		}
+
+		for _, response := range responses {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, ""))
+			envelope.AppendChild(response)
+
+			if _, err := conn.Write(envelope.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *TestLDAPServer) bind(op *ber.Packet) *ber.Packet {
+	dn := op.Children[1].Data.String()
+	password := op.Children[2].Data.String()
+
+	if dn == "" && password == "" {
+		return testLDAPResult(ldap.ApplicationBindResponse, ldap.LDAPResultSuccess) // anonymous
+	}
+
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			s.mux.Lock()
+			s.binds = append(s.binds, entry.DN)
+			s.mux.Unlock()
+
+			return testLDAPResult(ldap.ApplicationBindResponse, ldap.LDAPResultSuccess)
+		}
+	}
+
+	return testLDAPResult(ldap.ApplicationBindResponse, ldap.LDAPResultInvalidCredentials)
+}
+
+var testLDAPEqualityRegex = regexp.MustCompile(`\(([^()=]+)=([^()*]*)\)`)
+
+func (s *TestLDAPServer) search(op *ber.Packet) []*ber.Packet {
+	baseDN := op.Children[0].Data.String()
+	scope, _ := op.Children[1].Value.(int64)
+	filter, err := ldap.DecompileFilter(op.Children[6])
+	if err != nil {
+		return []*ber.Packet{testLDAPResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)}
+	}
+
+	assertions := testLDAPEqualityRegex.FindAllStringSubmatch(filter, -1)
+
+	var found bool
+	var result []*ber.Packet
+
+	for _, entry := range s.entries {
+		if scope == ldap.ScopeBaseObject {
+			if !strings.EqualFold(entry.DN, baseDN) {
+				continue
+			}
+			found = true
+		} else if !strings.HasSuffix(strings.ToLower(entry.DN), strings.ToLower(baseDN)) {
+			continue
+		}
+
+		if !entry.matches(assertions) {
+			continue
+		}
+
+		item := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+		item.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, ""))
+
+		attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		for name, values := range entry.Attributes {
+			attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+
+			vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, v := range values {
+				vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+			}
+			attr.AppendChild(vals)
+
+			attrs.AppendChild(attr)
+		}
+		item.AppendChild(attrs)
+
+		result = append(result, item)
+	}
+
+	if scope == ldap.ScopeBaseObject && !found {
+		return []*ber.Packet{testLDAPResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject)}
+	}
+
+	return append(result, testLDAPResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+}
+
+// matches reports whether the entry satisfies all equality filter assertions
+// (except the objectClass ones which are ignored).
+func (entry TestLDAPEntry) matches(assertions [][]string) bool {
+	for _, assertion := range assertions {
+		if strings.EqualFold(assertion[1], "objectClass") {
+			continue
+		}
+
+		var ok bool
+		for name, values := range entry.Attributes {
+			if !strings.EqualFold(name, assertion[1]) {
+				continue
+			}
+
+			for _, v := range values {
+				if ldap.EscapeFilter(v) == assertion[2] {
+					ok = true
+				}
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func testLDAPResult(tag ber.Tag, code uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	return result
+}
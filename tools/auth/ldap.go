@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// NameLDAP is the provider name used for the LDAP linked external auths.
+const NameLDAP = "ldap"
+
+// LDAPUsernamePlaceholder is the placeholder that is replaced with the
+// escaped login username in the [LDAP.UserDN] and [LDAP.SearchFilter] templates.
+const LDAPUsernamePlaceholder = "{username}"
+
+// ErrLDAPInvalidCredentials is returned when the LDAP server rejects the user credentials
+// or when no (or more than one) matching directory entry is found.
+var ErrLDAPInvalidCredentials = errors.New("invalid LDAP credentials")
+
+// LDAP defines an LDAP/Active Directory password authentication provider.
+//
+// The user could be authenticated either with a direct bind using
+// the UserDN template (eg. "uid={username},ou=people,dc=example,dc=com")
+// or by searching for the user entry in BaseDN using the SearchFilter template
+// (eg. "(&(objectClass=person)(sAMAccountName={username}))") and then binding with its DN.
+type LDAP struct {
+	// URL is the LDAP server url, eg. "ldaps://ldap.example.com:636".
+	URL string
+
+	// StartTLS upgrades the plain "ldap://" connection to TLS.
+	StartTLS bool
+
+	// TLSSkipVerify disables the server certificate verification.
+	TLSSkipVerify bool
+
+	// BindDN and BindPassword are the optional service account
+	// credentials used for the user entry search (anonymous bind is used if empty).
+	BindDN       string
+	BindPassword string
+
+	// UserDN is the direct bind DN template.
+	UserDN string
+
+	// BaseDN is the user entry search base.
+	BaseDN string
+
+	// SearchFilter is the user entry search filter template.
+	SearchFilter string
+
+	// IdAttribute is the attribute with the unique user identifier
+	// (if empty, fallbacks to the entry DN).
+	IdAttribute string
+
+	// EmailAttribute is the attribute with the user email address.
+	EmailAttribute string
+
+	// Attributes is a list of additional entry attributes to load.
+	Attributes []string
+
+	// Timeout is the max duration of the whole authentication (default to 10s).
+	Timeout time.Duration
+}
+
+// Authenticate verifies the provided username and password against
+// the LDAP server and returns the loaded user entry data.
+//
+// All loaded attributes are available in [AuthUser.RawUser] (with their first value),
+// including the entry DN under the "dn" key.
+func (p *LDAP) Authenticate(ctx context.Context, username string, password string) (*AuthUser, error) {
+	if username == "" || password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: p.TLSSkipVerify}
+
+	conn, err := ldap.DialURL(
+		p.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the LDAP server: %w", err)
+	}
+	defer conn.Close()
+
+	conn.SetTimeout(timeout)
+
+	// abort any pending operation on context cancellation
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if p.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	attributes := []string{}
+	for _, attr := range append([]string{p.IdAttribute, p.EmailAttribute}, p.Attributes...) {
+		if attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+
+	var entry *ldap.Entry
+
+	if p.SearchFilter != "" {
+		if p.BindDN != "" {
+			err = conn.Bind(p.BindDN, p.BindPassword)
+		} else {
+			err = conn.UnauthenticatedBind("")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to bind with the LDAP search account: %w", err)
+		}
+
+		filter := strings.ReplaceAll(p.SearchFilter, LDAPUsernamePlaceholder, ldap.EscapeFilter(username))
+
+		entry, err = p.findEntry(conn, p.BaseDN, ldap.ScopeWholeSubtree, filter, attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.bindUser(conn, entry.DN, password); err != nil {
+			return nil, err
+		}
+	} else {
+		userDN := strings.ReplaceAll(p.UserDN, LDAPUsernamePlaceholder, ldap.EscapeDN(username))
+
+		if err := p.bindUser(conn, userDN, password); err != nil {
+			return nil, err
+		}
+
+		entry, err = p.findEntry(conn, userDN, ldap.ScopeBaseObject, "(objectClass=*)", attributes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rawUser := map[string]any{"dn": entry.DN}
+	for _, attr := range entry.Attributes {
+		if len(attr.Values) > 0 {
+			rawUser[attr.Name] = attr.Values[0]
+		}
+	}
+
+	user := &AuthUser{
+		Id:       entry.DN,
+		Email:    entry.GetEqualFoldAttributeValue(p.EmailAttribute),
+		Username: username,
+		RawUser:  rawUser,
+	}
+
+	if p.IdAttribute != "" {
+		user.Id = entry.GetEqualFoldAttributeValue(p.IdAttribute)
+		if user.Id == "" {
+			return nil, fmt.Errorf("missing LDAP id attribute %q", p.IdAttribute)
+		}
+	}
+
+	return user, nil
+}
+
+func (p *LDAP) bindUser(conn *ldap.Conn, dn string, password string) error {
+	err := conn.Bind(dn, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return ErrLDAPInvalidCredentials
+		}
+		return fmt.Errorf("failed to bind the LDAP user: %w", err)
+	}
+
+	return nil
+}
+
+func (p *LDAP) findEntry(conn *ldap.Conn, baseDN string, scope int, filter string, attributes []string) (*ldap.Entry, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		baseDN,
+		scope,
+		ldap.NeverDerefAliases,
+		2, // we need only 1 but fetch 2 to detect ambiguous filters
+		0,
+		false,
+		filter,
+		attributes,
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to search the LDAP user entry: %w", err)
+	}
+
+	if len(result.Entries) != 1 {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	return result.Entries[0], nil
+}
@@ -0,0 +1,112 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/auth"
+)
+
+func TestLDAPAuthenticate(t *testing.T) {
+	server, err := tests.NewTestLDAPServer(
+		tests.TestLDAPEntry{
+			DN:       "cn=admin,dc=example,dc=com",
+			Password: "admin_pass",
+		},
+		tests.TestLDAPEntry{
+			DN:       "uid=john,ou=people,dc=example,dc=com",
+			Password: "john_pass",
+			Attributes: map[string][]string{
+				"uid":         {"john"},
+				"mail":        {"john@example.com"},
+				"displayName": {"John Doe"},
+				"employeeId":  {"E001"},
+			},
+		},
+		tests.TestLDAPEntry{
+			DN:       "uid=jane,ou=people,dc=example,dc=com",
+			Password: "jane_pass",
+			Attributes: map[string][]string{
+				"uid": {"jane"},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	directBind := &auth.LDAP{
+		URL:            server.URL(),
+		UserDN:         "uid={username},ou=people,dc=example,dc=com",
+		EmailAttribute: "mail",
+		Attributes:     []string{"displayName"},
+	}
+
+	searchBind := &auth.LDAP{
+		URL:            server.URL(),
+		BindDN:         "cn=admin,dc=example,dc=com",
+		BindPassword:   "admin_pass",
+		BaseDN:         "ou=people,dc=example,dc=com",
+		SearchFilter:   "(&(objectClass=person)(uid={username}))",
+		IdAttribute:    "employeeId",
+		EmailAttribute: "mail",
+	}
+
+	scenarios := []struct {
+		name          string
+		provider      *auth.LDAP
+		username      string
+		password      string
+		expectedError error
+		expectedId    string
+		expectedEmail string
+	}{
+		{"direct bind with empty password", directBind, "john", "", auth.ErrLDAPInvalidCredentials, "", ""},
+		{"direct bind with invalid password", directBind, "john", "invalid", auth.ErrLDAPInvalidCredentials, "", ""},
+		{"direct bind with missing user", directBind, "missing", "john_pass", auth.ErrLDAPInvalidCredentials, "", ""},
+		{"direct bind with valid credentials", directBind, "john", "john_pass", nil, "uid=john,ou=people,dc=example,dc=com", "john@example.com"},
+		{"search bind with filter injection", searchBind, "*", "john_pass", auth.ErrLDAPInvalidCredentials, "", ""},
+		{"search bind with invalid password", searchBind, "john", "jane_pass", auth.ErrLDAPInvalidCredentials, "", ""},
+		{"search bind with missing id attribute", searchBind, "jane", "jane_pass", errors.New("missing id"), "", ""},
+		{"search bind with valid credentials", searchBind, "john", "john_pass", nil, "E001", "john@example.com"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			user, err := s.provider.Authenticate(context.Background(), s.username, s.password)
+
+			hasErr := err != nil
+			expectErr := s.expectedError != nil
+			if hasErr != expectErr {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", expectErr, hasErr, err)
+			}
+
+			if errors.Is(s.expectedError, auth.ErrLDAPInvalidCredentials) && !errors.Is(err, auth.ErrLDAPInvalidCredentials) {
+				t.Fatalf("Expected ErrLDAPInvalidCredentials, got %v", err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if user.Id != s.expectedId {
+				t.Fatalf("Expected id %q, got %q", s.expectedId, user.Id)
+			}
+
+			if user.Email != s.expectedEmail {
+				t.Fatalf("Expected email %q, got %q", s.expectedEmail, user.Email)
+			}
+
+			if user.Username != s.username {
+				t.Fatalf("Expected username %q, got %q", s.username, user.Username)
+			}
+
+			if user.RawUser["dn"] != "uid=john,ou=people,dc=example,dc=com" {
+				t.Fatalf("Expected dn raw user value, got %v", user.RawUser["dn"])
+			}
+		})
+	}
+}
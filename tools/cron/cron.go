@@ -5,15 +5,20 @@
 //
 //	c := cron.New()
 //	c.MustAdd("dailyReport", "0 0 * * *", func() { ... })
+//	c.MustAddWithOptions("sync", "*/5 * * * *", cron.JobOptions{Timeout: time.Minute}, func(ctx context.Context) error { ... })
 //	c.Start()
 package cron
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/thewandererbg/pgbase/tools/hook"
 )
 
 // Cron is a crontab-like struct for tasks/jobs scheduling.
@@ -22,6 +27,7 @@ type Cron struct {
 	ticker     *time.Ticker
 	startTimer *time.Timer
 	tickerDone chan bool
+	onRun      *hook.Hook[*JobRunEvent]
 	jobs       []*Job
 	interval   time.Duration
 	mux        sync.RWMutex
@@ -38,9 +44,17 @@ func New() *Cron {
 		timezone:   time.UTC,
 		jobs:       []*Job{},
 		tickerDone: make(chan bool),
+		onRun:      &hook.Hook[*JobRunEvent]{},
 	}
 }
 
+// OnRun returns the hook that is triggered on each job run.
+//
+// Call e.Next() to execute the job function and get its result.
+func (c *Cron) OnRun() *hook.Hook[*JobRunEvent] {
+	return c.onRun
+}
+
 // SetInterval changes the current cron tick interval
 // (it usually should be >= 1 minute).
 func (c *Cron) SetInterval(d time.Duration) {
@@ -83,6 +97,32 @@ func (c *Cron) Add(jobId string, cronExpr string, fn func()) error {
 		return errors.New("failed to add new cron job: fn must be non-nil function")
 	}
 
+	return c.AddWithOptions(jobId, cronExpr, JobOptions{}, func(ctx context.Context) error {
+		fn()
+		return nil
+	})
+}
+
+// MustAddWithOptions is similar to AddWithOptions() but panic on failure.
+func (c *Cron) MustAddWithOptions(jobId string, cronExpr string, options JobOptions, fn func(ctx context.Context) error) {
+	if err := c.AddWithOptions(jobId, cronExpr, options, fn); err != nil {
+		panic(err)
+	}
+}
+
+// AddWithOptions is similar to Add() but registers a context aware
+// cron job with custom options (e.g. run timeout).
+//
+// The returned fn error is reported as the job run result (see [Cron.OnRun]).
+func (c *Cron) AddWithOptions(jobId string, cronExpr string, options JobOptions, fn func(ctx context.Context) error) error {
+	if fn == nil {
+		return errors.New("failed to add new cron job: fn must be non-nil function")
+	}
+
+	if options.Timeout < 0 {
+		return errors.New("failed to add new cron job: timeout must be non-negative")
+	}
+
 	schedule, err := NewSchedule(cronExpr)
 	if err != nil {
 		return fmt.Errorf("failed to add new cron job: %w", err)
@@ -101,6 +141,9 @@ func (c *Cron) Add(jobId string, cronExpr string, fn func()) error {
 		id:       jobId,
 		fn:       fn,
 		schedule: schedule,
+		options:  options,
+		onRun:    c.onRun,
+		running:  &atomic.Int32{},
 	})
 
 	return nil
@@ -222,7 +265,9 @@ func (c *Cron) runDue(t time.Time) {
 
 	for _, j := range c.jobs {
 		if j.schedule.IsDue(moment) {
-			go j.Run()
+			// the job errors are reported via the OnRun hook
+			// and the overlapping runs are silently skipped
+			go j.run(context.Background(), JobTriggerSchedule)
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package cron
 
 import (
+	"context"
 	"encoding/json"
 	"slices"
 	"testing"
@@ -139,6 +140,54 @@ func TestCronAddAndRemove(t *testing.T) {
 	}
 }
 
+func TestCronAddWithOptions(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+
+	if err := c.AddWithOptions("test1", "* * * * *", JobOptions{}, nil); err == nil {
+		t.Fatal("Expected nil function error")
+	}
+
+	if err := c.AddWithOptions("test2", "* * * * *", JobOptions{Timeout: -1}, func(ctx context.Context) error { return nil }); err == nil {
+		t.Fatal("Expected negative timeout error")
+	}
+
+	if err := c.AddWithOptions("test3", "invalid", JobOptions{}, func(ctx context.Context) error { return nil }); err == nil {
+		t.Fatal("Expected invalid cron expression error")
+	}
+
+	options := JobOptions{Timeout: time.Minute, AllowOverlap: true}
+
+	if err := c.AddWithOptions("test4", "* * * * *", options, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := c.Jobs()
+	if len(jobs) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(jobs))
+	}
+
+	if jobs[0].Options() != options {
+		t.Fatalf("Expected options %v, got %v", options, jobs[0].Options())
+	}
+
+	// check that the job is bound to the cron OnRun hook
+	var triggered string
+	c.OnRun().BindFunc(func(e *JobRunEvent) error {
+		triggered = e.Job.Id()
+		return e.Next()
+	})
+
+	if err := jobs[0].RunWithContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if triggered != "test4" {
+		t.Fatalf("Expected OnRun to be triggered for test4, got %q", triggered)
+	}
+}
+
 func TestCronMustAdd(t *testing.T) {
 	t.Parallel()
 
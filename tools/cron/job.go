@@ -1,11 +1,64 @@
 package cron
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/hook"
+)
+
+const (
+	// JobTriggerSchedule indicates a job run started by the cron ticker.
+	JobTriggerSchedule = "schedule"
+
+	// JobTriggerManual indicates a job run started with [Job.Run] or [Job.RunWithContext].
+	JobTriggerManual = "manual"
+)
+
+var (
+	// ErrJobRunning is returned when trying to start a job run
+	// while the previous one is still running (and overlaps are not allowed).
+	ErrJobRunning = errors.New("the cron job is already running")
+
+	// ErrJobPanic is the base error of a recovered job panic.
+	ErrJobPanic = errors.New("the cron job panicked")
+)
+
+// JobOptions defines the optional cron job settings.
+type JobOptions struct {
+	// Timeout is the max duration of a single job run.
+	//
+	// The job function context is canceled when the timeout is reached.
+	// Leave it empty for no timeout.
+	Timeout time.Duration
+
+	// AllowOverlap allows starting a new job run
+	// while the previous one is still running.
+	AllowOverlap bool
+}
+
+// JobRunEvent defines the event triggered on each job run.
+type JobRunEvent struct {
+	hook.Event
+
+	// Context is the job run context (it is canceled on job timeout).
+	Context context.Context
+
+	Job     *Job
+	Trigger string
+}
 
 // Job defines a single registered cron job.
 type Job struct {
-	fn       func()
+	fn       func(ctx context.Context) error
 	schedule *Schedule
+	onRun    *hook.Hook[*JobRunEvent]
+	options  JobOptions
+	running  *atomic.Int32 // nil for jobs not registered with [Cron.AddWithOptions]
 	id       string
 }
 
@@ -19,22 +72,97 @@ func (j *Job) Expression() string {
 	return j.schedule.rawExpr
 }
 
-// Run runs the cron job function.
+// Options returns the cron job options.
+func (j *Job) Options() JobOptions {
+	return j.options
+}
+
+// IsRunning reports whether the cron job is currently running.
+func (j *Job) IsRunning() bool {
+	return j.running != nil && j.running.Load() > 0
+}
+
+// Run runs the cron job function (aka. manual trigger) and waits for it to complete.
+//
+// The run result is ignored. Use [Job.RunWithContext] if you want to check it.
 func (j *Job) Run() {
-	if j.fn != nil {
-		j.fn()
+	j.RunWithContext(context.Background())
+}
+
+// RunWithContext is similar to [Job.Run] but runs the cron job
+// with the specified parent context and returns the run result.
+//
+// Returns [ErrJobRunning] if the job doesn't allow overlaps and it is already running.
+func (j *Job) RunWithContext(ctx context.Context) error {
+	return j.run(ctx, JobTriggerManual)
+}
+
+func (j *Job) run(ctx context.Context, trigger string) error {
+	if j.running != nil {
+		if j.options.AllowOverlap {
+			j.running.Add(1)
+		} else if !j.running.CompareAndSwap(0, 1) {
+			return ErrJobRunning
+		}
+		defer j.running.Add(-1)
+	}
+
+	if j.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.options.Timeout)
+		defer cancel()
 	}
+
+	if j.onRun == nil {
+		return j.exec(ctx)
+	}
+
+	event := &JobRunEvent{Context: ctx, Job: j, Trigger: trigger}
+
+	return j.onRun.Trigger(event, func(e *JobRunEvent) error {
+		return j.exec(e.Context)
+	})
+}
+
+// exec executes the job function, recovering from any panic.
+//
+// A run that exceeded its timeout is reported as failed
+// even if the job function didn't handle the context cancellation.
+func (j *Job) exec(ctx context.Context) (err error) {
+	if j.fn == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanic, r)
+		}
+	}()
+
+	err = j.fn(ctx)
+
+	if err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = ctx.Err()
+	}
+
+	return err
 }
 
 // MarshalJSON implements [json.Marshaler] and export the current
 // jobs data into valid JSON.
 func (j Job) MarshalJSON() ([]byte, error) {
 	plain := struct {
-		Id         string `json:"id"`
-		Expression string `json:"expression"`
+		Id           string `json:"id"`
+		Expression   string `json:"expression"`
+		Timeout      int64  `json:"timeout"`
+		AllowOverlap bool   `json:"allowOverlap"`
+		Running      bool   `json:"running"`
 	}{
-		Id:         j.Id(),
-		Expression: j.Expression(),
+		Id:           j.Id(),
+		Expression:   j.Expression(),
+		Timeout:      int64(j.options.Timeout / time.Second),
+		AllowOverlap: j.options.AllowOverlap,
+		Running:      j.IsRunning(),
 	}
 
 	return json.Marshal(plain)
@@ -1,8 +1,15 @@
 package cron
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/hook"
 )
 
 func TestJobId(t *testing.T) {
@@ -40,7 +47,7 @@ func TestJobRun(t *testing.T) {
 	calls := ""
 
 	j1 := Job{}
-	j2 := Job{fn: func() { calls += "2" }}
+	j2 := Job{fn: func(ctx context.Context) error { calls += "2"; return nil }}
 
 	j1.Run()
 	j2.Run()
@@ -57,15 +64,160 @@ func TestJobMarshalJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	j := Job{id: "test_id", schedule: s}
+	j := Job{id: "test_id", schedule: s, options: JobOptions{Timeout: 90 * time.Second}}
 
 	raw, err := json.Marshal(j)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	expected := `{"id":"test_id","expression":"1 2 3 4 5"}`
+	expected := `{"id":"test_id","expression":"1 2 3 4 5","timeout":90,"allowOverlap":false,"running":false}`
 	if str := string(raw); str != expected {
 		t.Fatalf("Expected\n%s\ngot\n%s", expected, str)
 	}
 }
+
+func TestJobRunError(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		timeout       time.Duration
+		fn            func(ctx context.Context) error
+		expectedError error
+	}{
+		{
+			"returned error",
+			0,
+			func(ctx context.Context) error { return errors.New("test") },
+			nil,
+		},
+		{
+			"panic",
+			0,
+			func(ctx context.Context) error { panic("test") },
+			ErrJobPanic,
+		},
+		{
+			"timeout with context handling",
+			10 * time.Millisecond,
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			context.DeadlineExceeded,
+		},
+		{
+			"timeout without context handling",
+			10 * time.Millisecond,
+			func(ctx context.Context) error {
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+			context.DeadlineExceeded,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			j := Job{fn: s.fn, options: JobOptions{Timeout: s.timeout}}
+
+			err := j.RunWithContext(context.Background())
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if s.expectedError != nil && !errors.Is(err, s.expectedError) {
+				t.Fatalf("Expected error %v, got %v", s.expectedError, err)
+			}
+
+			if j.IsRunning() {
+				t.Fatal("Expected the job to not be running after completion")
+			}
+		})
+	}
+}
+
+func TestJobRunOverlap(t *testing.T) {
+	for _, allowOverlap := range []bool{false, true} {
+		t.Run("allowOverlap_"+map[bool]string{false: "false", true: "true"}[allowOverlap], func(t *testing.T) {
+			release := make(chan struct{})
+			started := make(chan struct{})
+
+			j := Job{
+				fn: func(ctx context.Context) error {
+					started <- struct{}{}
+					<-release
+					return nil
+				},
+				options: JobOptions{AllowOverlap: allowOverlap},
+				running: &atomic.Int32{},
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				j.Run()
+			}()
+
+			<-started
+
+			if !j.IsRunning() {
+				t.Fatal("Expected the job to be running")
+			}
+
+			if allowOverlap {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					j.Run()
+				}()
+				<-started
+			} else if err := j.RunWithContext(context.Background()); !errors.Is(err, ErrJobRunning) {
+				t.Fatalf("Expected ErrJobRunning, got %v", err)
+			}
+
+			close(release)
+			wg.Wait()
+
+			if j.IsRunning() {
+				t.Fatal("Expected the job to not be running")
+			}
+		})
+	}
+}
+
+func TestJobRunOnRunHook(t *testing.T) {
+	onRun := &hook.Hook[*JobRunEvent]{}
+
+	var calls []string
+
+	onRun.BindFunc(func(e *JobRunEvent) error {
+		calls = append(calls, "before_"+e.Trigger+"_"+e.Job.Id())
+		err := e.Next()
+		calls = append(calls, "after_"+err.Error())
+		return err
+	})
+
+	j := Job{
+		id:    "test",
+		onRun: onRun,
+		fn: func(ctx context.Context) error {
+			calls = append(calls, "fn")
+			return errors.New("test_error")
+		},
+	}
+
+	if err := j.RunWithContext(context.Background()); err == nil || err.Error() != "test_error" {
+		t.Fatalf("Expected test_error, got %v", err)
+	}
+
+	expected := []string{"before_manual_test", "fn", "after_test_error"}
+	if len(calls) != len(expected) {
+		t.Fatalf("Expected calls %v, got %v", expected, calls)
+	}
+	for i, c := range expected {
+		if calls[i] != c {
+			t.Fatalf("Expected calls %v, got %v", expected, calls)
+		}
+	}
+}
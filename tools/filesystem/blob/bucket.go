@@ -22,6 +22,7 @@ import (
 	"io"
 	"log"
 	"mime"
+	"net/http"
 	"runtime"
 	"strings"
 	"sync"
@@ -693,6 +694,82 @@ func (b *Bucket) Delete(ctx context.Context, key string) (err error) {
 	return wrapError(b.drv, b.drv.Delete(ctx, key), key)
 }
 
+// DefaultSignedURLExpiry is the default duration for SignedURLOptions.Expiry.
+const DefaultSignedURLExpiry = 1 * time.Hour
+
+// SignedURLOptions sets options for SignedURL.
+type SignedURLOptions struct {
+	// Expiry sets how long the returned URL is valid for.
+	// Defaults to DefaultSignedURLExpiry.
+	Expiry time.Duration
+
+	// Method is the HTTP method that can be used on the URL; one of "GET" or "PUT".
+	// Defaults to "GET".
+	Method string
+
+	// ContentType specifies the Content-Type HTTP header the user agent
+	// is required to use in the PUT request.
+	//
+	// For GET requests it overrides the Content-Type header of the response.
+	ContentType string
+
+	// ContentDisposition overrides the Content-Disposition header
+	// of the GET request response.
+	//
+	// It is ignored for other methods.
+	ContentDisposition string
+
+	// ContentLength specifies the exact Content-Length HTTP header
+	// the user agent is required to use in the PUT request.
+	//
+	// It is ignored for other methods or if zero.
+	ContentLength int64
+}
+
+// SignedURL returns a URL that can be used to GET (default) or PUT the blob
+// for the duration specified in opts.Expiry without credentials.
+//
+// A nil SignedURLOptions is treated the same as the zero value.
+//
+// If the driver doesn't support signed urls, it returns [errors.ErrUnsupported].
+func (b *Bucket) SignedURL(ctx context.Context, key string, opts *SignedURLOptions) (string, error) {
+	if !utf8.ValidString(key) {
+		return "", fmt.Errorf("SignedURL key must be a valid UTF-8 string: %q", key)
+	}
+
+	dopts := &SignedURLOptions{}
+	if opts != nil {
+		*dopts = *opts
+	}
+	if dopts.Expiry < 0 {
+		return "", errors.New("SignedURLOptions.Expiry must be >= 0")
+	}
+	if dopts.Expiry == 0 {
+		dopts.Expiry = DefaultSignedURLExpiry
+	}
+	switch dopts.Method {
+	case "":
+		dopts.Method = http.MethodGet
+	case http.MethodGet, http.MethodPut:
+	default:
+		return "", fmt.Errorf("unsupported SignedURLOptions.Method %q", dopts.Method)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return "", ErrClosed
+	}
+
+	url, err := b.drv.SignedURL(ctx, key, dopts)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return "", err
+	}
+
+	return url, wrapError(b.drv, err, key)
+}
+
 // Close releases any resources used for the bucket.
 //
 // @todo Consider removing it.
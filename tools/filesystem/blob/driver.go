@@ -89,6 +89,14 @@ type Driver interface {
 	// not exist, Delete must return an ErrNotFound.
 	Delete(ctx context.Context, key string) error
 
+	// SignedURL returns a URL that can be used to access the object
+	// associated with key without credentials (e.g. S3 presigned url).
+	//
+	// opts is guaranteed to be non-nil.
+	//
+	// If the driver doesn't support signed urls, it must return [errors.ErrUnsupported].
+	SignedURL(ctx context.Context, key string, opts *SignedURLOptions) (string, error)
+
 	// Close cleans up any resources used by the Bucket. Once Close is called,
 	// there will be no method calls to the Bucket other than As, ErrorAs, and
 	// ErrorCode. There may be open readers or writers that will receive calls.
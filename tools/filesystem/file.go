@@ -23,6 +23,22 @@ type FileReader interface {
 	Open() (io.ReadSeekCloser, error)
 }
 
+// StorageFileReader defines a [FileReader] of an object that is
+// already stored in the app storage (e.g. a completed upload).
+//
+// Files with such reader are copied directly within the storage
+// (e.g. with S3 CopyObject) instead of being downloaded and reuploaded
+// and their type is resolved from the stored object content type.
+type StorageFileReader interface {
+	FileReader
+
+	// StorageKey returns the storage key of the object.
+	StorageKey() string
+
+	// ContentType returns the stored object content type.
+	ContentType() string
+}
+
 // File defines a single file [io.ReadSeekCloser] resource.
 //
 // The file could be from a local path, multipart/form-data header, etc.
@@ -89,6 +105,30 @@ func NewFileFromMultipart(mh *multipart.FileHeader) (*File, error) {
 	return f, nil
 }
 
+// NewFileFromReader creates a new File from the provided FileReader
+// and its already known content size.
+//
+// This could be useful in case the file content is loaded lazily from
+// a custom source (e.g. a different storage location).
+func NewFileFromReader(reader FileReader, size int64, name string) (*File, error) {
+	if reader == nil {
+		return nil, errors.New("missing file reader")
+	}
+
+	if size <= 0 {
+		return nil, errors.New("cannot create an empty file")
+	}
+
+	f := &File{}
+
+	f.Reader = reader
+	f.Size = size
+	f.OriginalName = name
+	f.Name = normalizeName(f.Reader, f.OriginalName)
+
+	return f, nil
+}
+
 // NewFileFromURL creates a new File from the provided url by
 // downloading the resource and load it as BytesReader.
 //
@@ -253,6 +293,14 @@ func extractExtension(name string) string {
 
 // detectExtension tries to detect the extension from file mime type.
 func detectExtension(fr FileReader) (string, error) {
+	if sr, ok := fr.(StorageFileReader); ok {
+		mt := mimetype.Lookup(BaseContentType(sr.ContentType()))
+		if mt == nil {
+			return "", nil
+		}
+		return mt.Extension(), nil
+	}
+
 	r, err := fr.Open()
 	if err != nil {
 		return "", err
@@ -266,3 +314,10 @@ func detectExtension(fr FileReader) (string, error) {
 
 	return mt.Extension(), nil
 }
+
+// BaseContentType returns the content type without its parameters
+// (e.g. "text/plain; charset=utf-8" -> "text/plain").
+func BaseContentType(contentType string) string {
+	base, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(base)
+}
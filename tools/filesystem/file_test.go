@@ -100,6 +100,39 @@ func TestNewFileFromBytes(t *testing.T) {
 	}
 }
 
+func TestNewFileFromReader(t *testing.T) {
+	reader := &filesystem.BytesReader{Bytes: []byte("text\n")}
+
+	// nil reader
+	if _, err := filesystem.NewFileFromReader(nil, 5, "test.txt"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	// zero size
+	if _, err := filesystem.NewFileFromReader(reader, 0, "test.txt"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	originalName := "image_! noext"
+	normalizedNamePattern := regexp.QuoteMeta("image_noext_") + `\w{10}` + regexp.QuoteMeta(".txt")
+	f, err := filesystem.NewFileFromReader(reader, 5, originalName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Reader != reader {
+		t.Fatalf("Expected the provided reader to be used, got %v", f.Reader)
+	}
+	if f.Size != 5 {
+		t.Fatalf("Expected Size %v, got %v", 5, f.Size)
+	}
+	if f.OriginalName != originalName {
+		t.Fatalf("Expected OriginalName %q, got %q", originalName, f.OriginalName)
+	}
+	if match, err := regexp.Match(normalizedNamePattern, []byte(f.Name)); !match {
+		t.Fatalf("Expected Name to match %v, got %q (%v)", normalizedNamePattern, f.Name, err)
+	}
+}
+
 func TestNewFileFromMultipart(t *testing.T) {
 	formData, mp, err := tests.MockMultipartData(nil, "test")
 	if err != nil {
@@ -14,12 +14,15 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/fatih/color"
 	"github.com/gabriel-vasile/mimetype"
+	"github.com/pocketbase/dbx"
 	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
 	"github.com/thewandererbg/pgbase/tools/filesystem/internal/fileblob"
+	"github.com/thewandererbg/pgbase/tools/filesystem/internal/pgblob"
 	"github.com/thewandererbg/pgbase/tools/filesystem/internal/s3blob"
 	"github.com/thewandererbg/pgbase/tools/filesystem/internal/s3blob/s3"
 	"github.com/thewandererbg/pgbase/tools/list"
@@ -89,6 +92,27 @@ func NewLocal(dirPath string) (*System, error) {
 	return &System{ctx: ctx, bucket: blob.NewBucket(drv)}, nil
 }
 
+// NewPostgres initializes a new filesystem instance that stores
+// the files content in the provided Postgres db as chunked bytea rows.
+//
+// chunkSize is the size in bytes of the stored content chunks
+// (0 fallbacks to 1MB).
+//
+// The "_storageObjects" and "_storageChunks" tables are expected
+// to be already created (see the related system migration).
+//
+// NB! Make sure to call `Close()` after you are done working with it.
+func NewPostgres(db dbx.Builder, chunkSize int) (*System, error) {
+	ctx := context.Background() // default context
+
+	drv, err := pgblob.New(db, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &System{ctx: ctx, bucket: blob.NewBucket(drv)}, nil
+}
+
 // SetContext assigns the specified context to the current filesystem.
 func (s *System) SetContext(ctx context.Context) {
 	s.ctx = ctx
@@ -174,6 +198,60 @@ func (s *System) Copy(srcKey, dstKey string) error {
 	return s.bucket.Copy(s.ctx, dstKey, srcKey)
 }
 
+// Transfer copies the file stored at fileKey to the same location
+// in the dst filesystem (ex. another storage backend) preserving
+// its content type and other stored attributes.
+//
+// The file content is streamed, aka. it is never loaded as a whole in memory.
+//
+// If fileKey file doesn't exist, it returns ErrNotFound.
+//
+// If the dst file already exists, it is overwritten.
+func (s *System) Transfer(dst *System, fileKey string) error {
+	attrs, err := s.bucket.Attributes(s.ctx, fileKey)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.bucket.NewReader(s.ctx, fileKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(dst.ctx)
+	defer cancel()
+
+	w, err := dst.bucket.NewWriter(ctx, fileKey, &blob.WriterOptions{
+		CacheControl:       attrs.CacheControl,
+		ContentDisposition: attrs.ContentDisposition,
+		ContentEncoding:    attrs.ContentEncoding,
+		ContentLanguage:    attrs.ContentLanguage,
+		ContentType:        attrs.ContentType,
+		Metadata:           attrs.Metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.ReadFrom(r); err != nil {
+		cancel() // abort the write
+		return errors.Join(err, w.Close())
+	}
+
+	return w.Close()
+}
+
+// SignedURL returns a presigned url that could be used to directly access
+// the fileKey file from the storage service without credentials (e.g. S3 presigned GET or PUT url).
+//
+// A nil opts is treated the same as a GET url with the default expiry.
+//
+// The local filesystem doesn't support signed urls and returns [errors.ErrUnsupported].
+func (s *System) SignedURL(fileKey string, opts *blob.SignedURLOptions) (string, error) {
+	return s.bucket.SignedURL(s.ctx, fileKey, opts)
+}
+
 // List returns a flat list with info for all files under the specified prefix.
 func (s *System) List(prefix string) ([]*blob.ListObject, error) {
 	files := []*blob.ListObject{}
@@ -215,7 +293,14 @@ func (s *System) Upload(content []byte, fileKey string) error {
 }
 
 // UploadFile uploads the provided File to the fileKey location.
+//
+// If the file reader is [StorageFileReader], the stored object
+// is copied directly within the storage.
 func (s *System) UploadFile(file *File, fileKey string) error {
+	if sr, ok := file.Reader.(StorageFileReader); ok {
+		return s.Copy(sr.StorageKey(), fileKey)
+	}
+
 	f, err := file.Reader.Open()
 	if err != nil {
 		return err
@@ -299,6 +384,80 @@ func (s *System) UploadMultipart(fh *multipart.FileHeader, fileKey string) error
 	return w.Close()
 }
 
+// UploadReader streams the content of the provided reader into the fileKey location.
+//
+// If reading from r fails, the write is aborted and no file is created.
+func (s *System) UploadReader(r io.Reader, fileKey string) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	w, err := s.bucket.NewWriter(ctx, fileKey, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.ReadFrom(r); err != nil {
+		cancel() // abort the write
+		return errors.Join(err, w.Close())
+	}
+
+	return w.Close()
+}
+
+// Compose concatenates the content of the files stored at srcKeys
+// (in the specified order) into a single file at the dstKey location.
+//
+// The content is streamed, aka. the source files are never loaded
+// as a whole in memory (for S3 the destination is written as multipart upload).
+//
+// If one of the srcKeys files doesn't exist, it returns ErrNotFound
+// and the dstKey file is not created.
+//
+// If dstKey file already exists, it is overwritten.
+//
+// The dstKey file content type is detected from the beginning of the first source file.
+func (s *System) Compose(dstKey string, srcKeys ...string) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	opts := &blob.WriterOptions{}
+
+	if len(srcKeys) > 0 {
+		r, err := s.GetReader(srcKeys[0])
+		if err != nil {
+			return err
+		}
+
+		mt, err := mimetype.DetectReader(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+
+		opts.ContentType = mt.String()
+	}
+
+	w, err := s.bucket.NewWriter(ctx, dstKey, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, srcKey := range srcKeys {
+		r, err := s.GetReader(srcKey)
+		if err == nil {
+			_, err = w.ReadFrom(r)
+			r.Close()
+		}
+
+		if err != nil {
+			cancel() // abort the write
+			return errors.Join(err, w.Close())
+		}
+	}
+
+	return w.Close()
+}
+
 // Delete deletes stored file at fileKey location.
 //
 // If the file doesn't exist returns ErrNotFound.
@@ -435,13 +594,66 @@ func (s *System) Serve(res http.ResponseWriter, req *http.Request, fileKey strin
 	}
 	defer br.Close()
 
+	disposition, extContentType := serveContentHeaders(req, br.ContentType(), name)
+
+	setHeaderIfMissing(res, "Content-Disposition", disposition)
+	setHeaderIfMissing(res, "Content-Type", extContentType)
+	setHeaderIfMissing(res, "Content-Security-Policy", "default-src 'none'; media-src 'self'; style-src 'unsafe-inline'; sandbox")
+
+	// set a default cache-control header
+	// (valid for 30 days but the cache is allowed to reuse the file for any requests
+	// that are made in the last day while revalidating the res in the background)
+	setHeaderIfMissing(res, "Cache-Control", "max-age=2592000, stale-while-revalidate=86400")
+
+	http.ServeContent(res, req, name, br.ModTime(), br)
+
+	return nil
+}
+
+// ServeSignedURL redirects the client to a short-lived presigned url
+// of the file at fileKey location (e.g. S3 presigned GET url)
+// instead of proxying the file content through the app.
+//
+// The presigned url response Content-Disposition and Content-Type headers
+// follow the same rules as in [System.Serve], with the exception that
+// only the inline content types are allowed to be served inline since
+// the storage service cannot apply the sandbox Content-Security-Policy.
+//
+// Returns [errors.ErrUnsupported] if the storage doesn't support signed urls.
+func (s *System) ServeSignedURL(res http.ResponseWriter, req *http.Request, fileKey string, name string, expiry time.Duration) error {
+	attrs, err := s.Attributes(fileKey)
+	if err != nil {
+		return err
+	}
+
+	disposition, extContentType := serveContentHeaders(req, attrs.ContentType, name)
+
+	url, err := s.SignedURL(fileKey, &blob.SignedURLOptions{
+		Expiry:             expiry,
+		ContentType:        extContentType,
+		ContentDisposition: disposition,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the presigned url shouldn't be reused after its expiration
+	setHeaderIfMissing(res, "Cache-Control", "private, no-store")
+
+	http.Redirect(res, req, url, http.StatusTemporaryRedirect)
+
+	return nil
+}
+
+// serveContentHeaders returns the Content-Disposition and Content-Type
+// headers to use when serving a file with the specified real content type and name.
+func serveContentHeaders(req *http.Request, realContentType string, name string) (string, string) {
 	var forceAttachment bool
 	if raw := req.URL.Query().Get(forceAttachmentParam); raw != "" {
 		forceAttachment, _ = strconv.ParseBool(raw)
 	}
 
 	disposition := "attachment"
-	realContentType := br.ContentType()
 	if !forceAttachment && list.ExistInSlice(realContentType, inlineServeContentTypes) {
 		disposition = "inline"
 	}
@@ -453,18 +665,7 @@ func (s *System) Serve(res http.ResponseWriter, req *http.Request, fileKey strin
 		extContentType = ct
 	}
 
-	setHeaderIfMissing(res, "Content-Disposition", disposition+"; filename="+name)
-	setHeaderIfMissing(res, "Content-Type", extContentType)
-	setHeaderIfMissing(res, "Content-Security-Policy", "default-src 'none'; media-src 'self'; style-src 'unsafe-inline'; sandbox")
-
-	// set a default cache-control header
-	// (valid for 30 days but the cache is allowed to reuse the file for any requests
-	// that are made in the last day while revalidating the res in the background)
-	setHeaderIfMissing(res, "Cache-Control", "max-age=2592000, stale-while-revalidate=86400")
-
-	http.ServeContent(res, req, name, br.ModTime(), br)
-
-	return nil
+	return disposition + "; filename=" + name, extContentType
 }
 
 // note: expects key to be in a canonical form (eg. "accept-encoding" should be "Accept-Encoding").
@@ -14,6 +14,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/thewandererbg/pgbase/tools/filesystem"
@@ -305,6 +307,64 @@ func TestFileSystemUploadFile(t *testing.T) {
 	}
 }
 
+// testStorageFileReader is a [filesystem.StorageFileReader] that fails on Open
+// so that it could be ensured that the stored object content is not read.
+type testStorageFileReader struct {
+	key         string
+	contentType string
+}
+
+func (r *testStorageFileReader) Open() (io.ReadSeekCloser, error) {
+	return nil, errors.New("unexpected open")
+}
+
+func (r *testStorageFileReader) StorageKey() string {
+	return r.key
+}
+
+func (r *testStorageFileReader) ContentType() string {
+	return r.contentType
+}
+
+func TestFileSystemUploadFileStorageReader(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fsys, err := filesystem.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	reader := &testStorageFileReader{key: "test/sub1.txt", contentType: "text/plain"}
+
+	file, err := filesystem.NewFileFromReader(reader, 4, "sub1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(file.Name, ".txt") {
+		t.Fatalf("Expected the file extension to be resolved from the content type, got %q", file.Name)
+	}
+
+	fileKey := "newdir/copied.txt"
+
+	if err := fsys.UploadFile(file, fileKey); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := fsys.GetReader(fileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	raw, _ := io.ReadAll(r)
+	if str := string(raw); str != "sub1" {
+		t.Fatalf("Expected content %q, got %q", "sub1", str)
+	}
+}
+
 func TestFileSystemUpload(t *testing.T) {
 	dir := createTestDir(t)
 	defer os.RemoveAll(dir)
@@ -327,6 +387,89 @@ func TestFileSystemUpload(t *testing.T) {
 	}
 }
 
+func TestFileSystemUploadReader(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fsys, err := filesystem.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	// failed read
+	failedKey := "newdir/failed.txt"
+	failedReader := io.MultiReader(strings.NewReader("demo"), iotest.ErrReader(errors.New("test")))
+	if err := fsys.UploadReader(failedReader, failedKey); err == nil {
+		t.Fatal("Expected upload error, got nil")
+	}
+	if exists, _ := fsys.Exists(failedKey); exists {
+		t.Fatalf("Expected %s to not exist", failedKey)
+	}
+
+	// successful read
+	fileKey := "newdir/newkey.txt"
+	if err := fsys.UploadReader(strings.NewReader("demo"), fileKey); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := fsys.GetReader(fileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	raw, _ := io.ReadAll(r)
+	if str := string(raw); str != "demo" {
+		t.Fatalf("Expected content %q, got %q", "demo", str)
+	}
+}
+
+func TestFileSystemCompose(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fsys, err := filesystem.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	dst := "composed.txt"
+
+	// compose with missing file
+	if err := fsys.Compose(dst, "test/sub1.txt", "missing.txt"); err == nil {
+		t.Fatal("Expected compose error, got nil")
+	}
+	if exists, _ := fsys.Exists(dst); exists {
+		t.Fatalf("Expected %s to not exist", dst)
+	}
+
+	// compose existing files
+	if err := fsys.Compose(dst, "test/sub2.txt", "test/sub1.txt", "test/sub2.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := fsys.GetReader(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	raw, _ := io.ReadAll(r)
+	if str := string(raw); str != "sub2sub1sub2" {
+		t.Fatalf("Expected content %q, got %q", "sub2sub1sub2", str)
+	}
+
+	attrs, err := fsys.Attributes(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("Expected the detected content type, got %q", attrs.ContentType)
+	}
+}
+
 func TestFileSystemServe(t *testing.T) {
 	dir := createTestDir(t)
 	defer os.RemoveAll(dir)
@@ -688,6 +831,108 @@ func TestFileSystemCopy(t *testing.T) {
 	}
 }
 
+func TestFileSystemTransfer(t *testing.T) {
+	srcDir := createTestDir(t)
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp(os.TempDir(), "pb_test_dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	src, err := filesystem.NewLocal(srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	dst, err := filesystem.NewLocal(dstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	// transfer missing file
+	if err := src.Transfer(dst, "missing.png"); !errors.Is(err, filesystem.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	// transfer existing file
+	if err := src.Transfer(dst, "image.png"); err != nil {
+		t.Fatalf("Failed to transfer the file: %v", err)
+	}
+
+	srcAttrs, err := src.Attributes("image.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dstAttrs, err := dst.Attributes("image.png")
+	if err != nil {
+		t.Fatalf("Missing transferred file: %v", err)
+	}
+
+	if dstAttrs.ContentType != "image/png" {
+		t.Fatalf("Expected content type %q, got %q", "image/png", dstAttrs.ContentType)
+	}
+
+	if dstAttrs.Size != srcAttrs.Size {
+		t.Fatalf("Expected file size %d, got %d", srcAttrs.Size, dstAttrs.Size)
+	}
+}
+
+func TestFileSystemSignedURL(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fsys, err := filesystem.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	_, err = fsys.SignedURL("image.png", nil)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expected errors.ErrUnsupported for the local filesystem, got %v", err)
+	}
+}
+
+func TestFileSystemServeSignedURL(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fsys, err := filesystem.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	t.Run("missing file", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+
+		err := fsys.ServeSignedURL(res, req, "missing.txt", "test_name", time.Minute)
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("unsupported storage", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+
+		err := fsys.ServeSignedURL(res, req, "image.png", "test_name", time.Minute)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Fatalf("Expected errors.ErrUnsupported, got %v", err)
+		}
+
+		if res.Code != http.StatusOK || res.Header().Get("Location") != "" {
+			t.Fatalf("Expected no redirect response, got %d %q", res.Code, res.Header().Get("Location"))
+		}
+	})
+}
+
 func TestFileSystemList(t *testing.T) {
 	dir := createTestDir(t)
 	defer os.RemoveAll(dir)
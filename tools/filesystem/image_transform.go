@@ -0,0 +1,322 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
+	"github.com/thewandererbg/pgbase/tools/list"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+const (
+	ImageFitCover   = "cover"   // resize and crop from center to fill the WxH viewbox (default)
+	ImageFitContain = "contain" // fit inside the WxH viewbox without cropping
+	ImageFitFill    = "fill"    // stretch to the exact WxH size
+)
+
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatGIF  = "gif"
+
+	// ImageFormatAuto instructs the caller to pick one of the supported
+	// formats based on the client Accept header (see [NegotiateImageFormat]).
+	ImageFormatAuto = "auto"
+
+	// ImageFormatWebP is accepted as transform format but because there
+	// is no pure Go webp encoder it is normalized to [ImageFormatAuto]
+	// (aka. the result is one of the supported formats accepted by the client).
+	ImageFormatWebP = "webp"
+)
+
+// ImageTransformParams lists the query parameters used to describe an [ImageTransform].
+var ImageTransformParams = []string{"w", "h", "fit", "format", "q"}
+
+var imageFits = []string{ImageFitCover, ImageFitContain, ImageFitFill}
+
+// note: only the formats with available pure Go encoders are supported
+var imageFormats = map[string]imaging.Format{
+	ImageFormatJPEG: imaging.JPEG,
+	ImageFormatPNG:  imaging.PNG,
+	ImageFormatGIF:  imaging.GIF,
+}
+
+var imageFormatContentTypes = map[string]string{
+	ImageFormatJPEG: "image/jpeg",
+	ImageFormatPNG:  "image/png",
+	ImageFormatGIF:  "image/gif",
+}
+
+// ImageTransform describes an on-the-fly image transformation.
+type ImageTransform struct {
+	// Width is the result image width (0 to preserve the aspect ratio).
+	Width int
+
+	// Height is the result image height (0 to preserve the aspect ratio).
+	Height int
+
+	// Fit specifies how the image should be resized when both
+	// Width and Height are set (cover, contain or fill).
+	Fit string
+
+	// Format is the result image format (jpeg, png, gif or auto).
+	//
+	// webp is not supported as result format and it is normalized to auto.
+	//
+	// Empty string means the same format as the original image.
+	Format string
+
+	// Quality is the JPEG encoding quality (1-100).
+	//
+	// 0 means the default encoder quality.
+	Quality int
+}
+
+// ParseImageTransform parses and normalizes the [ImageTransformParams]
+// from the provided query values.
+//
+// Returns nil if none of the transform params is set.
+func ParseImageTransform(query url.Values) (*ImageTransform, error) {
+	if !slices.ContainsFunc(ImageTransformParams, query.Has) {
+		return nil, nil
+	}
+
+	t := &ImageTransform{
+		Fit:    strings.ToLower(query.Get("fit")),
+		Format: strings.ToLower(query.Get("format")),
+	}
+
+	var err error
+
+	if raw := query.Get("w"); raw != "" {
+		if t.Width, err = strconv.Atoi(raw); err != nil || t.Width < 0 {
+			return nil, errors.New("w must be a non-negative integer")
+		}
+	}
+
+	if raw := query.Get("h"); raw != "" {
+		if t.Height, err = strconv.Atoi(raw); err != nil || t.Height < 0 {
+			return nil, errors.New("h must be a non-negative integer")
+		}
+	}
+
+	if raw := query.Get("q"); raw != "" {
+		if t.Quality, err = strconv.Atoi(raw); err != nil || t.Quality < 1 || t.Quality > 100 {
+			return nil, errors.New("q must be an integer between 1 and 100")
+		}
+	}
+
+	if t.Fit != "" && !list.ExistInSlice(t.Fit, imageFits) {
+		return nil, fmt.Errorf("fit must be one of %v", imageFits)
+	}
+
+	switch t.Format {
+	case "jpg":
+		t.Format = ImageFormatJPEG
+	case ImageFormatWebP:
+		t.Format = ImageFormatAuto
+	}
+	if _, ok := imageFormats[t.Format]; t.Format != "" && t.Format != ImageFormatAuto && !ok {
+		return nil, fmt.Errorf("format must be one of %v", []string{ImageFormatJPEG, ImageFormatPNG, ImageFormatGIF, ImageFormatWebP, ImageFormatAuto})
+	}
+
+	// normalize the defaults so that equivalent transforms have the same representation
+	if t.Width == 0 || t.Height == 0 || t.Fit == ImageFitCover {
+		t.Fit = ""
+	}
+
+	return t, nil
+}
+
+// String returns the canonical query string representation of the transform
+// (e.g. "w=100&h=100&fit=contain&format=jpeg&q=80").
+func (t ImageTransform) String() string {
+	parts := make([]string, 0, len(ImageTransformParams))
+
+	if t.Width > 0 {
+		parts = append(parts, "w="+strconv.Itoa(t.Width))
+	}
+	if t.Height > 0 {
+		parts = append(parts, "h="+strconv.Itoa(t.Height))
+	}
+	if t.Fit != "" {
+		parts = append(parts, "fit="+t.Fit)
+	}
+	if t.Format != "" {
+		parts = append(parts, "format="+t.Format)
+	}
+	if t.Quality > 0 {
+		parts = append(parts, "q="+strconv.Itoa(t.Quality))
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// Key returns a short filename safe identifier of the transform
+// (e.g. "w100-h100-contain-q80").
+//
+// Note that the format is not part of the key because it is expected
+// to be reflected in the transformed file extension.
+func (t ImageTransform) Key() string {
+	parts := make([]string, 0, 4)
+
+	if t.Width > 0 {
+		parts = append(parts, "w"+strconv.Itoa(t.Width))
+	}
+	if t.Height > 0 {
+		parts = append(parts, "h"+strconv.Itoa(t.Height))
+	}
+	if t.Fit != "" {
+		parts = append(parts, t.Fit)
+	}
+	if t.Quality > 0 {
+		parts = append(parts, "q"+strconv.Itoa(t.Quality))
+	}
+
+	if len(parts) == 0 {
+		return "t"
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// Signature returns the HMAC-SHA256 signature of the transform
+// for the file at fileKey location.
+//
+// It could be used as "sig" query parameter to authorize
+// transforms that are not explicitly allowed.
+func (t ImageTransform) Signature(fileKey string, secret string) string {
+	return security.HS256(fileKey+"?"+t.String(), secret)
+}
+
+// NegotiateImageFormat returns the image format to use for the
+// [ImageFormatAuto] transforms based on the client Accept header.
+//
+// The original image format is preferred if it is accepted and
+// supported, otherwise fallbacks to jpeg or png (in this order).
+func NegotiateImageFormat(accept string, originalContentType string) string {
+	accepted := func(contentType string) bool {
+		return accept == "" ||
+			strings.Contains(accept, contentType) ||
+			strings.Contains(accept, "image/*") ||
+			strings.Contains(accept, "*/*")
+	}
+
+	if format := ImageFormatFromContentType(originalContentType); format != "" && accepted(originalContentType) {
+		return format
+	}
+
+	if strings.Contains(accept, imageFormatContentTypes[ImageFormatPNG]) &&
+		!strings.Contains(accept, imageFormatContentTypes[ImageFormatJPEG]) {
+		return ImageFormatPNG
+	}
+
+	return ImageFormatJPEG
+}
+
+// ImageFormatFromContentType returns the transform image format
+// matching the specified content type.
+//
+// Returns empty string if there is no supported matching format (e.g. "image/webp").
+func ImageFormatFromContentType(contentType string) string {
+	for format, ct := range imageFormatContentTypes {
+		if ct == contentType {
+			return format
+		}
+	}
+
+	return ""
+}
+
+// ImageFormatExt returns the file extension (with leading dot) of the
+// specified transform image format.
+//
+// Returns empty string for unknown formats.
+func ImageFormatExt(format string) string {
+	if _, ok := imageFormats[format]; !ok {
+		return ""
+	}
+
+	if format == ImageFormatJPEG {
+		return ".jpg"
+	}
+
+	return "." + format
+}
+
+// TransformImage applies the transform t to the image at originalKey
+// location and stores the result at dstKey location.
+//
+// The image EXIF orientation is applied before the transformation.
+//
+// If t.Format is not set, the result format is detected based on the
+// dstKey extension (fallbacks to png).
+func (s *System) TransformImage(originalKey string, dstKey string, t ImageTransform) error {
+	if t.Format == ImageFormatAuto {
+		return errors.New("the auto image format must be resolved before the transformation")
+	}
+
+	format, ok := imageFormats[t.Format]
+	if !ok {
+		var err error
+		format, err = imaging.FormatFromFilename(dstKey)
+		if err != nil {
+			format = imaging.PNG
+		}
+	}
+
+	// fetch the original
+	r, err := s.GetReader(originalKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// (note: only the first frame for animated image formats)
+	img, err := imaging.Decode(r, imaging.AutoOrientation(true))
+	if err != nil {
+		return err
+	}
+
+	var result image.Image = img
+
+	switch {
+	case t.Width == 0 && t.Height == 0:
+		// no resize (e.g. format conversion only)
+	case t.Width == 0 || t.Height == 0:
+		result = imaging.Resize(img, t.Width, t.Height, imaging.Linear)
+	case t.Fit == ImageFitContain:
+		result = imaging.Fit(img, t.Width, t.Height, imaging.Linear)
+	case t.Fit == ImageFitFill:
+		result = imaging.Resize(img, t.Width, t.Height, imaging.Linear)
+	default:
+		result = imaging.Fill(img, t.Width, t.Height, imaging.Center, imaging.Linear)
+	}
+
+	contentType := "image/" + strings.ToLower(format.String())
+
+	w, err := s.bucket.NewWriter(s.ctx, dstKey, &blob.WriterOptions{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+
+	var encodeOpts []imaging.EncodeOption
+	if t.Quality > 0 {
+		encodeOpts = append(encodeOpts, imaging.JPEGQuality(t.Quality))
+	}
+
+	if err := imaging.Encode(w, result, format, encodeOpts...); err != nil {
+		w.Close()
+		return err
+	}
+
+	// check for close errors to ensure that the result was really saved
+	return w.Close()
+}
@@ -0,0 +1,240 @@
+package filesystem_test
+
+import (
+	"image"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/thewandererbg/pgbase/tools/filesystem"
+)
+
+func TestParseImageTransform(t *testing.T) {
+	scenarios := []struct {
+		query       string
+		expectError bool
+		expected    string // canonical string, "-" for nil
+	}{
+		{"", false, "-"},
+		{"thumb=100x100&download=1", false, "-"},
+		{"w=abc", true, ""},
+		{"w=-1", true, ""},
+		{"h=abc", true, ""},
+		{"q=0", true, ""},
+		{"q=101", true, ""},
+		{"fit=invalid", true, ""},
+		{"format=bmp", true, ""},
+		{"w=100", false, "w=100"},
+		{"h=100&fit=contain", false, "h=100"},
+		{"w=100&h=200", false, "w=100&h=200"},
+		{"w=100&h=200&fit=cover", false, "w=100&h=200"},
+		{"w=100&h=200&fit=CONTAIN", false, "w=100&h=200&fit=contain"},
+		{"fit=fill&h=200&w=100", false, "w=100&h=200&fit=fill"},
+		{"format=jpg", false, "format=jpeg"},
+		{"format=WEBP&w=10", false, "w=10&format=auto"},
+		{"format=auto&q=80&w=10", false, "w=10&format=auto&q=80"},
+		{"w=", false, ""},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.query, func(t *testing.T) {
+			query, err := url.ParseQuery(s.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transform, err := filesystem.ParseImageTransform(query)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if s.expected == "-" {
+				if transform != nil {
+					t.Fatalf("Expected nil transform, got %v", transform)
+				}
+				return
+			}
+
+			if str := transform.String(); str != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, str)
+			}
+		})
+	}
+}
+
+func TestImageTransformKey(t *testing.T) {
+	scenarios := []struct {
+		transform filesystem.ImageTransform
+		expected  string
+	}{
+		{filesystem.ImageTransform{}, "t"},
+		{filesystem.ImageTransform{Format: "png"}, "t"},
+		{filesystem.ImageTransform{Width: 100}, "w100"},
+		{filesystem.ImageTransform{Width: 100, Height: 200, Fit: "contain", Format: "jpeg", Quality: 80}, "w100-h200-contain-q80"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.expected, func(t *testing.T) {
+			if key := s.transform.Key(); key != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, key)
+			}
+		})
+	}
+}
+
+func TestImageTransformSignature(t *testing.T) {
+	transform := filesystem.ImageTransform{Width: 100, Height: 200}
+
+	sig1 := transform.Signature("a/b/c.png", "secret1")
+	sig2 := transform.Signature("a/b/c.png", "secret2")
+	sig3 := transform.Signature("a/b/d.png", "secret1")
+	sig4 := filesystem.ImageTransform{Width: 100}.Signature("a/b/c.png", "secret1")
+
+	if sig1 == "" || sig1 == sig2 || sig1 == sig3 || sig1 == sig4 {
+		t.Fatalf("Expected unique signatures, got %q, %q, %q, %q", sig1, sig2, sig3, sig4)
+	}
+
+	if sig := transform.Signature("a/b/c.png", "secret1"); sig != sig1 {
+		t.Fatalf("Expected the same signature %q, got %q", sig1, sig)
+	}
+}
+
+func TestNegotiateImageFormat(t *testing.T) {
+	scenarios := []struct {
+		accept      string
+		contentType string
+		expected    string
+	}{
+		{"", "image/png", "png"},
+		{"", "image/webp", "jpeg"},
+		{"*/*", "image/gif", "gif"},
+		{"image/*", "image/png", "png"},
+		{"image/avif,image/webp,image/png,*/*;q=0.8", "image/png", "png"},
+		{"image/avif,image/webp,image/png", "image/webp", "png"},
+		{"image/jpeg,image/png", "image/webp", "jpeg"},
+		{"image/jpeg", "image/png", "jpeg"},
+		{"text/html", "image/png", "jpeg"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.accept+"_"+s.contentType, func(t *testing.T) {
+			result := filesystem.NegotiateImageFormat(s.accept, s.contentType)
+			if result != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestImageFormatExt(t *testing.T) {
+	scenarios := []struct {
+		format   string
+		expected string
+	}{
+		{"", ""},
+		{"auto", ""},
+		{"webp", ""},
+		{"jpeg", ".jpg"},
+		{"png", ".png"},
+		{"gif", ".gif"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.format, func(t *testing.T) {
+			if ext := filesystem.ImageFormatExt(s.format); ext != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, ext)
+			}
+		})
+	}
+}
+
+func TestFileSystemTransformImage(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fsys, err := filesystem.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	scenarios := []struct {
+		name             string
+		file             string
+		dst              string
+		transform        filesystem.ImageTransform
+		expectedMimeType string
+		expectedWidth    int
+		expectedHeight   int
+	}{
+		{"missing file", "missing.png", "t1.png", filesystem.ImageTransform{Width: 10}, "", 0, 0},
+		{"non-image file", "test/sub1.txt", "t2.png", filesystem.ImageTransform{Width: 10}, "", 0, 0},
+		{"unresolved auto format", "image.png", "t3.png", filesystem.ImageTransform{Width: 10, Format: "auto"}, "", 0, 0},
+		{"width only", "image.png", "t4.png", filesystem.ImageTransform{Width: 10}, "image/png", 10, 10},
+		{"height only", "image.png", "t5.png", filesystem.ImageTransform{Height: 10}, "image/png", 10, 10},
+		{"cover", "image.png", "t6.png", filesystem.ImageTransform{Width: 10, Height: 20}, "image/png", 10, 20},
+		{"contain", "image.png", "t7.png", filesystem.ImageTransform{Width: 10, Height: 20, Fit: "contain"}, "image/png", 1, 1},
+		{"fill", "image.png", "t8.png", filesystem.ImageTransform{Width: 10, Height: 20, Fit: "fill"}, "image/png", 10, 20},
+		{"format conversion only", "image.png", "t9", filesystem.ImageTransform{Format: "jpeg", Quality: 50}, "image/jpeg", 1, 1},
+		{"format from the dst extension", "image.jpg", "t10.gif", filesystem.ImageTransform{Width: 5}, "image/gif", 5, 5},
+		{"webp original", "image.webp", "t11", filesystem.ImageTransform{Width: 5}, "image/png", 5, 5},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			err := fsys.TransformImage(s.file, s.dst, s.transform)
+
+			expectErr := s.expectedMimeType == ""
+
+			hasErr := err != nil
+			if hasErr != expectErr {
+				t.Fatalf("Expected hasErr to be %v, got %v (%v)", expectErr, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			attrs, err := fsys.Attributes(s.dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if attrs.ContentType != s.expectedMimeType {
+				t.Fatalf("Expected stored content type %q, got %q", s.expectedMimeType, attrs.ContentType)
+			}
+
+			f, err := fsys.GetReader(s.dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			mt, err := mimetype.DetectReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mtStr := mt.String(); mtStr != s.expectedMimeType {
+				t.Fatalf("Expected MimeType %q, got %q", s.expectedMimeType, mtStr)
+			}
+
+			if _, err := f.Seek(0, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, _, err := image.DecodeConfig(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != s.expectedWidth || cfg.Height != s.expectedHeight {
+				t.Fatalf("Expected %dx%d image, got %dx%d", s.expectedWidth, s.expectedHeight, cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
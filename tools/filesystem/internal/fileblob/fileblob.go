@@ -528,6 +528,13 @@ func (drv *driver) Copy(ctx context.Context, dstKey, srcKey string) error {
 	return w.Close()
 }
 
+// SignedURL implements [blob/Driver.SignedURL].
+//
+// Signed urls are not supported for the local filesystem.
+func (drv *driver) SignedURL(ctx context.Context, key string, opts *blob.SignedURLOptions) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
 // Delete implements [blob/Driver.Delete].
 func (b *driver) Delete(ctx context.Context, key string) error {
 	path, err := b.path(key)
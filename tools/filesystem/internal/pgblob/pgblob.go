@@ -0,0 +1,606 @@
+// Package pgblob provides a blob.Bucket driver implementation that stores
+// the objects content in PostgreSQL as chunked bytea rows.
+//
+// The driver expects the following tables to exist (see the related system migration):
+//   - "_storageObjects" - one row per object with its key and attributes
+//   - "_storageChunks"  - the object content split into fixed size chunks
+//
+// The object content is written under a new random blob id and the object
+// row is switched to it only on writer Close, so that any previous object
+// with the same key remains readable until the write is completed.
+//
+// Blob keys ASCII characters 0-31 are escaped to "__0x<hex>__" because
+// Postgres text values cannot contain NUL characters.
+//
+// Example:
+//
+//	drv, _ := pgblob.New(db, 0)
+//	bucket := blob.NewBucket(drv)
+package pgblob
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+const (
+	ObjectsTable = "_storageObjects"
+	ChunksTable  = "_storageChunks"
+)
+
+// DefaultChunkSize is the default size in bytes of a single stored content chunk.
+const DefaultChunkSize = 1 << 20
+
+const defaultPageSize = 1000
+
+// New creates a new instance of the Postgres driver backed by the provided db.
+//
+// chunkSize is the size in bytes of the stored content chunks
+// (0 fallbacks to DefaultChunkSize).
+func New(db dbx.Builder, chunkSize int) (blob.Driver, error) {
+	if db == nil {
+		return nil, errors.New("pgblob.New: missing db instance")
+	}
+
+	if chunkSize < 0 {
+		return nil, errors.New("pgblob.New: chunkSize must be non-negative")
+	}
+
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	return &driver{db: db, chunkSize: chunkSize}, nil
+}
+
+type driver struct {
+	db        dbx.Builder
+	chunkSize int
+}
+
+// object represents a single "_storageObjects" row.
+type object struct {
+	Key                string    `db:"key"`
+	BlobId             string    `db:"blobId"`
+	Size               int64     `db:"size"`
+	ChunkSize          int64     `db:"chunkSize"`
+	MD5                []byte    `db:"md5"`
+	ContentType        string    `db:"contentType"`
+	CacheControl       string    `db:"cacheControl"`
+	ContentDisposition string    `db:"contentDisposition"`
+	ContentEncoding    string    `db:"contentEncoding"`
+	ContentLanguage    string    `db:"contentLanguage"`
+	Metadata           string    `db:"metadata"`
+	Created            time.Time `db:"created"`
+	Updated            time.Time `db:"updated"`
+}
+
+func (o *object) etag() string {
+	if len(o.MD5) == 0 {
+		return fmt.Sprintf(`W/"%x-%x"`, o.Updated.UnixNano(), o.Size)
+	}
+
+	return `"` + hex.EncodeToString(o.MD5) + `"`
+}
+
+// Close implements [blob/Driver.Close].
+func (drv *driver) Close() error {
+	return nil // the db connection is managed by the caller
+}
+
+// NormalizeError implements [blob/Driver.NormalizeError].
+func (drv *driver) NormalizeError(err error) error {
+	// already normalized
+	if errors.Is(err, blob.ErrNotFound) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.Join(err, blob.ErrNotFound)
+	}
+
+	return err
+}
+
+func (drv *driver) findObject(ctx context.Context, key string) (*object, error) {
+	obj := &object{}
+
+	err := drv.db.Select("*").
+		From(ObjectsTable).
+		Where(dbx.HashExp{"key": escapeKey(key)}).
+		Limit(1).
+		WithContext(ctx).
+		One(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// ListPaged implements [blob/Driver.ListPaged].
+func (drv *driver) ListPaged(ctx context.Context, opts *blob.ListOptions) (*blob.ListPage, error) {
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	prefix := escapeKey(opts.Prefix)
+	delimiter := escapeKey(opts.Delimiter)
+	cursor := string(opts.PageToken)
+
+	// If opts.Delimiter != "", lastPrefix contains the last "directory" key we
+	// added. It is used to avoid adding it again; all objects in this "directory"
+	// are collapsed to the single directory entry.
+	var lastPrefix string
+	if delimiter != "" && strings.HasSuffix(cursor, delimiter) {
+		lastPrefix = cursor
+	}
+
+	var result blob.ListPage
+
+	for {
+		rows := []*object{}
+
+		query := drv.db.Select("key", "size", "md5", "updated").
+			From(ObjectsTable).
+			OrderBy("key ASC").
+			Limit(int64(pageSize) + 1).
+			WithContext(ctx)
+
+		if prefix != "" {
+			query.AndWhere(dbx.NewExp("starts_with([[key]], {:prefix})", dbx.Params{"prefix": prefix}))
+		}
+
+		if cursor != "" {
+			query.AndWhere(dbx.NewExp("[[key]] > {:cursor}", dbx.Params{"cursor": cursor}))
+		}
+
+		if err := query.All(&rows); err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			cursor = row.Key
+
+			if lastPrefix != "" && strings.HasPrefix(row.Key, lastPrefix) {
+				continue // already added as "directory"
+			}
+
+			if len(result.Objects) == pageSize {
+				result.NextPageToken = []byte(result.Objects[len(result.Objects)-1].Key)
+				return &result, nil
+			}
+
+			if delimiter != "" {
+				if i := strings.Index(row.Key[len(prefix):], delimiter); i > -1 {
+					lastPrefix = row.Key[:len(prefix)+i+len(delimiter)]
+
+					result.Objects = append(result.Objects, &blob.ListObject{
+						Key:   unescapeKey(lastPrefix),
+						IsDir: true,
+					})
+					continue
+				}
+			}
+
+			result.Objects = append(result.Objects, &blob.ListObject{
+				Key:     unescapeKey(row.Key),
+				ModTime: row.Updated,
+				Size:    row.Size,
+				MD5:     row.MD5,
+			})
+		}
+
+		if len(rows) <= pageSize {
+			break // no more rows
+		}
+	}
+
+	return &result, nil
+}
+
+// Attributes implements [blob/Driver.Attributes].
+func (drv *driver) Attributes(ctx context.Context, key string) (*blob.Attributes, error) {
+	obj, err := drv.findObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]string
+	if obj.Metadata != "" {
+		if err := json.Unmarshal([]byte(obj.Metadata), &metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	return &blob.Attributes{
+		CacheControl:       obj.CacheControl,
+		ContentDisposition: obj.ContentDisposition,
+		ContentEncoding:    obj.ContentEncoding,
+		ContentLanguage:    obj.ContentLanguage,
+		ContentType:        obj.ContentType,
+		Metadata:           metadata,
+		CreateTime:         obj.Created,
+		ModTime:            obj.Updated,
+		Size:               obj.Size,
+		MD5:                obj.MD5,
+		ETag:               obj.etag(),
+	}, nil
+}
+
+// NewRangeReader implements [blob/Driver.NewRangeReader].
+func (drv *driver) NewRangeReader(ctx context.Context, key string, offset, length int64) (blob.DriverReader, error) {
+	obj, err := drv.findObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	end := obj.Size
+	if length >= 0 && offset+length < end {
+		end = offset + length
+	}
+
+	return &reader{
+		ctx:    ctx,
+		db:     drv.db,
+		obj:    obj,
+		offset: offset,
+		end:    end,
+		attrs: &blob.ReaderAttributes{
+			ContentType: obj.ContentType,
+			ModTime:     obj.Updated,
+			Size:        obj.Size,
+		},
+	}, nil
+}
+
+// NewTypedWriter implements [blob/Driver.NewTypedWriter].
+func (drv *driver) NewTypedWriter(ctx context.Context, key string, contentType string, opts *blob.WriterOptions) (blob.DriverWriter, error) {
+	chunkSize := drv.chunkSize
+	if opts.BufferSize > 0 {
+		chunkSize = opts.BufferSize
+	}
+
+	metadata := "{}"
+	if len(opts.Metadata) > 0 {
+		raw, err := json.Marshal(opts.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata = string(raw)
+	}
+
+	return &writer{
+		ctx:        ctx,
+		db:         drv.db,
+		key:        escapeKey(key),
+		blobId:     security.RandomString(30),
+		chunkSize:  chunkSize,
+		contentMD5: opts.ContentMD5,
+		md5hash:    md5.New(),
+		params: dbx.Params{
+			"contentType":        contentType,
+			"cacheControl":       opts.CacheControl,
+			"contentDisposition": opts.ContentDisposition,
+			"contentEncoding":    opts.ContentEncoding,
+			"contentLanguage":    opts.ContentLanguage,
+			"metadata":           metadata,
+		},
+	}, nil
+}
+
+// Copy implements [blob/Driver.Copy].
+func (drv *driver) Copy(ctx context.Context, dstKey, srcKey string) error {
+	src, err := drv.findObject(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+
+	blobId := security.RandomString(30)
+
+	_, err = drv.db.NewQuery(fmt.Sprintf(
+		"INSERT INTO {{%s}} ([[blobId]], [[n]], [[data]]) SELECT {:blobId}, [[n]], [[data]] FROM {{%s}} WHERE [[blobId]] = {:srcBlobId}",
+		ChunksTable, ChunksTable,
+	)).Bind(dbx.Params{
+		"blobId":    blobId,
+		"srcBlobId": src.BlobId,
+	}).WithContext(ctx).Execute()
+	if err != nil {
+		return err
+	}
+
+	return saveObject(ctx, drv.db, escapeKey(dstKey), blobId, src.Size, src.ChunkSize, src.MD5, dbx.Params{
+		"contentType":        src.ContentType,
+		"cacheControl":       src.CacheControl,
+		"contentDisposition": src.ContentDisposition,
+		"contentEncoding":    src.ContentEncoding,
+		"contentLanguage":    src.ContentLanguage,
+		"metadata":           src.Metadata,
+	})
+}
+
+// Delete implements [blob/Driver.Delete].
+func (drv *driver) Delete(ctx context.Context, key string) error {
+	var blobId string
+
+	err := drv.db.NewQuery(fmt.Sprintf(
+		"DELETE FROM {{%s}} WHERE [[key]] = {:key} RETURNING [[blobId]]",
+		ObjectsTable,
+	)).Bind(dbx.Params{"key": escapeKey(key)}).WithContext(ctx).Row(&blobId)
+	if err != nil {
+		return err
+	}
+
+	return deleteChunks(ctx, drv.db, blobId)
+}
+
+// SignedURL implements [blob/Driver.SignedURL].
+func (drv *driver) SignedURL(ctx context.Context, key string, opts *blob.SignedURLOptions) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+// -------------------------------------------------------------------
+
+// saveObject inserts or replaces the object with the specified key
+// and deletes the content chunks of the replaced object (if any).
+func saveObject(
+	ctx context.Context,
+	db dbx.Builder,
+	key string,
+	blobId string,
+	size int64,
+	chunkSize int64,
+	md5 []byte,
+	attrs dbx.Params,
+) error {
+	params := dbx.Params{
+		"key":       key,
+		"blobId":    blobId,
+		"size":      size,
+		"chunkSize": chunkSize,
+		"md5":       md5,
+	}
+	for k, v := range attrs {
+		params[k] = v
+	}
+
+	var oldBlobId sql.NullString
+
+	// note: the old blob id is selected in a CTE because the
+	// ON CONFLICT RETURNING clause can access only the new values
+	err := db.NewQuery(fmt.Sprintf(`
+		WITH old AS (SELECT [[blobId]] FROM {{%s}} WHERE [[key]] = {:key} FOR UPDATE)
+		INSERT INTO {{%s}} (
+			[[key]], [[blobId]], [[size]], [[chunkSize]], [[md5]],
+			[[contentType]], [[cacheControl]], [[contentDisposition]], [[contentEncoding]], [[contentLanguage]], [[metadata]]
+		) VALUES (
+			{:key}, {:blobId}, {:size}, {:chunkSize}, {:md5},
+			{:contentType}, {:cacheControl}, {:contentDisposition}, {:contentEncoding}, {:contentLanguage}, {:metadata}
+		)
+		ON CONFLICT ([[key]]) DO UPDATE SET
+			[[blobId]]             = EXCLUDED.[[blobId]],
+			[[size]]               = EXCLUDED.[[size]],
+			[[chunkSize]]          = EXCLUDED.[[chunkSize]],
+			[[md5]]                = EXCLUDED.[[md5]],
+			[[contentType]]        = EXCLUDED.[[contentType]],
+			[[cacheControl]]       = EXCLUDED.[[cacheControl]],
+			[[contentDisposition]] = EXCLUDED.[[contentDisposition]],
+			[[contentEncoding]]    = EXCLUDED.[[contentEncoding]],
+			[[contentLanguage]]    = EXCLUDED.[[contentLanguage]],
+			[[metadata]]           = EXCLUDED.[[metadata]],
+			[[updated]]            = CURRENT_TIMESTAMP
+		RETURNING (SELECT [[blobId]] FROM old)
+	`, ObjectsTable, ObjectsTable)).Bind(params).WithContext(ctx).Row(&oldBlobId)
+	if err != nil {
+		return errors.Join(err, deleteChunks(context.Background(), db, blobId))
+	}
+
+	if oldBlobId.Valid && oldBlobId.String != blobId {
+		return deleteChunks(ctx, db, oldBlobId.String)
+	}
+
+	return nil
+}
+
+func deleteChunks(ctx context.Context, db dbx.Builder, blobId string) error {
+	_, err := db.Delete(ChunksTable, dbx.HashExp{"blobId": blobId}).WithContext(ctx).Execute()
+	return err
+}
+
+// -------------------------------------------------------------------
+
+// reader streams the object content chunk by chunk.
+type reader struct {
+	ctx    context.Context
+	db     dbx.Builder
+	obj    *object
+	attrs  *blob.ReaderAttributes
+	offset int64 // the current read position
+	end    int64 // the read end position (exclusive)
+	buf    []byte
+}
+
+// Read implements [io/ReadCloser.Read].
+func (r *reader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.offset >= r.end {
+			return 0, io.EOF
+		}
+
+		if err := r.loadChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.offset += int64(n)
+
+	return n, nil
+}
+
+// loadChunk loads the chunk containing the current read position
+// and trims it to the remaining read range.
+func (r *reader) loadChunk() error {
+	n := r.offset / r.obj.ChunkSize
+	skip := r.offset % r.obj.ChunkSize
+
+	var data []byte
+
+	err := r.db.Select("data").
+		From(ChunksTable).
+		Where(dbx.HashExp{"blobId": r.obj.BlobId, "n": n}).
+		WithContext(r.ctx).
+		Row(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// e.g. the object was replaced or deleted while reading
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	if skip > int64(len(data)) {
+		return io.ErrUnexpectedEOF
+	}
+	data = data[skip:]
+
+	if remaining := r.end - r.offset; int64(len(data)) > remaining {
+		data = data[:remaining]
+	}
+
+	if len(data) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+
+	r.buf = data
+
+	return nil
+}
+
+// Close implements [io/ReadCloser.Close].
+func (r *reader) Close() error {
+	r.buf = nil
+	return nil
+}
+
+// Attributes implements [blob/DriverReader.Attributes].
+func (r *reader) Attributes() *blob.ReaderAttributes {
+	return r.attrs
+}
+
+// -------------------------------------------------------------------
+
+// writer buffers the written content and stores it as chunks
+// under a new blob id, switching the object to it on Close.
+type writer struct {
+	ctx        context.Context
+	db         dbx.Builder
+	key        string
+	blobId     string
+	chunkSize  int
+	params     dbx.Params
+	contentMD5 []byte
+	md5hash    hash.Hash
+	buf        bytes.Buffer
+	n          int   // the number of the next chunk
+	size       int64 // the total written bytes
+	err        error
+}
+
+// Write implements [io/WriteCloser.Write].
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf.Write(p)
+	w.md5hash.Write(p)
+	w.size += int64(len(p))
+
+	for w.buf.Len() >= w.chunkSize {
+		if err := w.flush(w.buf.Next(w.chunkSize)); err != nil {
+			w.err = err
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (w *writer) flush(data []byte) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := w.db.Insert(ChunksTable, dbx.Params{
+		"blobId": w.blobId,
+		"n":      w.n,
+		"data":   data,
+	}).WithContext(w.ctx).Execute()
+	if err != nil {
+		return err
+	}
+
+	w.n++
+
+	return nil
+}
+
+// Close implements [io/WriteCloser.Close].
+//
+// It stores the remaining buffered content and saves the object.
+// On error or canceled ctx, the already stored chunks are deleted.
+func (w *writer) Close() error {
+	err := w.err
+
+	if err == nil && w.buf.Len() > 0 {
+		err = w.flush(w.buf.Bytes())
+		w.buf.Reset()
+	}
+
+	if err == nil {
+		err = w.ctx.Err()
+	}
+
+	md5sum := w.md5hash.Sum(nil)
+	if err == nil && len(w.contentMD5) > 0 && !bytes.Equal(md5sum, w.contentMD5) {
+		err = fmt.Errorf("the ContentMD5 (%X) doesn't match with the written content (%X)", w.contentMD5, md5sum)
+	}
+
+	if err != nil {
+		// note: use a new context because the writer one could be already canceled
+		return errors.Join(err, deleteChunks(context.Background(), w.db, w.blobId))
+	}
+
+	return saveObject(w.ctx, w.db, w.key, w.blobId, w.size, int64(w.chunkSize), md5sum, w.params)
+}
+
+// -------------------------------------------------------------------
+
+// escapeKey does all required escaping for UTF-8 strings to work with Postgres text.
+func escapeKey(key string) string {
+	return blob.HexEscape(key, func(r []rune, i int) bool {
+		return r[i] < 32
+	})
+}
+
+// unescapeKey reverses escapeKey.
+func unescapeKey(key string) string {
+	return blob.HexUnescape(key)
+}
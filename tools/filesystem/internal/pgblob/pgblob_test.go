@@ -0,0 +1,373 @@
+package pgblob_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/thewandererbg/pgbase/tests"
+	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
+	"github.com/thewandererbg/pgbase/tools/filesystem/internal/pgblob"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name        string
+		db          dbx.Builder
+		chunkSize   int
+		expectError bool
+	}{
+		{"nil db", nil, 0, true},
+		{"negative chunk size", &dbx.DB{}, -1, true},
+		{"default chunk size", &dbx.DB{}, 0, false},
+		{"custom chunk size", &dbx.DB{}, 10, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			drv, err := pgblob.New(s.db, s.chunkSize)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if err == nil && drv == nil {
+				t.Fatal("Expected non-nil driver instance")
+			}
+		})
+	}
+}
+
+func TestDriverWriteAndRead(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	bucket := newTestBucket(t, app.DB(), 4)
+
+	ctx := context.Background()
+	content := []byte("0123456789abcdef\x00xyz")
+
+	err = writeAll(ctx, bucket, "a/b.txt", content, &blob.WriterOptions{
+		ContentType: "text/plain",
+		Metadata:    map[string]string{"original-filename": "b.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := bucket.Attributes(ctx, "a/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.Size != int64(len(content)) {
+		t.Fatalf("Expected size %d, got %d", len(content), attrs.Size)
+	}
+	if attrs.ContentType != "text/plain" {
+		t.Fatalf("Expected content type %q, got %q", "text/plain", attrs.ContentType)
+	}
+	if attrs.Metadata["original-filename"] != "b.txt" {
+		t.Fatalf("Expected original-filename metadata, got %v", attrs.Metadata)
+	}
+	if sum := md5.Sum(content); !bytes.Equal(attrs.MD5, sum[:]) {
+		t.Fatalf("Expected md5 %x, got %x", sum, attrs.MD5)
+	}
+
+	all, err := readAll(ctx, bucket, "a/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(all, content) {
+		t.Fatalf("Expected content %q, got %q", content, all)
+	}
+
+	rangeScenarios := []struct {
+		offset   int64
+		length   int64
+		expected string
+	}{
+		{0, 0, ""},
+		{0, 2, "01"},
+		{3, 3, "345"},
+		{3, 10, "3456789abc"},
+		{15, -1, "f\x00xyz"},
+		{18, 100, "yz"},
+		{int64(len(content)), -1, ""},
+	}
+
+	for _, s := range rangeScenarios {
+		r, err := bucket.NewRangeReader(ctx, "a/b.txt", s.offset, s.length)
+		if err != nil {
+			t.Fatalf("[%d-%d] %v", s.offset, s.length, err)
+		}
+
+		result, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("[%d-%d] %v", s.offset, s.length, err)
+		}
+
+		if string(result) != s.expected {
+			t.Fatalf("[%d-%d] Expected %q, got %q", s.offset, s.length, s.expected, result)
+		}
+	}
+
+	// seek
+	r, err := bucket.NewReader(ctx, "a/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := r.Seek(10, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "abcdef\x00xyz" {
+		t.Fatalf("Expected the content after the seek, got %q", rest)
+	}
+
+	// missing
+	_, err = bucket.Attributes(ctx, "missing")
+	if !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDriverOverwrite(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	bucket := newTestBucket(t, app.DB(), 2)
+
+	ctx := context.Background()
+
+	if err := writeAll(ctx, bucket, "test", []byte("abcdef"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeAll(ctx, bucket, "test", []byte("xyz"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := readAll(ctx, bucket, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "xyz" {
+		t.Fatalf("Expected the new content, got %q", content)
+	}
+
+	// the old chunks should have been deleted
+	assertTotalChunks(t, app.DB(), 2)
+}
+
+func TestDriverWriteMD5Mismatch(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	bucket := newTestBucket(t, app.DB(), 2)
+
+	ctx := context.Background()
+
+	err = writeAll(ctx, bucket, "test", []byte("abcdef"), &blob.WriterOptions{
+		ContentMD5: []byte("invalid"),
+	})
+	if err == nil {
+		t.Fatal("Expected md5 mismatch error")
+	}
+
+	if exists, _ := bucket.Exists(ctx, "test"); exists {
+		t.Fatal("Expected the object to not be created")
+	}
+
+	assertTotalChunks(t, app.DB(), 0)
+}
+
+func TestDriverCopyAndDelete(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	bucket := newTestBucket(t, app.DB(), 2)
+
+	ctx := context.Background()
+
+	if err := writeAll(ctx, bucket, "src", []byte("abcde"), &blob.WriterOptions{ContentType: "text/plain"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bucket.Copy(ctx, "dst", "missing"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for missing copy source, got %v", err)
+	}
+
+	if err := bucket.Copy(ctx, "dst", "src"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bucket.Delete(ctx, "src"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bucket.Delete(ctx, "src"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for already deleted object, got %v", err)
+	}
+
+	content, err := readAll(ctx, bucket, "dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "abcde" {
+		t.Fatalf("Expected the copied content, got %q", content)
+	}
+
+	attrs, err := bucket.Attributes(ctx, "dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.ContentType != "text/plain" {
+		t.Fatalf("Expected the copied content type, got %q", attrs.ContentType)
+	}
+
+	// only the dst chunks should remain
+	assertTotalChunks(t, app.DB(), 3)
+}
+
+func TestDriverListPaged(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	bucket := newTestBucket(t, app.DB(), 0)
+
+	ctx := context.Background()
+
+	keys := []string{"a/1", "a/2", "a/b/1", "a/b/2", "a/c/1", "b/1", "c"}
+	for _, key := range keys {
+		if err := writeAll(ctx, bucket, key, []byte(key), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []struct {
+		name      string
+		prefix    string
+		delimiter string
+		expected  []string
+	}{
+		{"all", "", "", keys},
+		{"prefix", "a/", "", []string{"a/1", "a/2", "a/b/1", "a/b/2", "a/c/1"}},
+		{"delimiter", "", "/", []string{"a/", "b/", "c"}},
+		{"prefix and delimiter", "a/", "/", []string{"a/1", "a/2", "a/b/", "a/c/"}},
+		{"missing prefix", "missing/", "", []string{}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := []string{}
+
+			// use a small page size to test the pagination
+			var token []byte
+			for {
+				objects, nextToken, err := bucket.ListPage(ctx, token, 2, &blob.ListOptions{
+					Prefix:    s.prefix,
+					Delimiter: s.delimiter,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, obj := range objects {
+					result = append(result, obj.Key)
+				}
+
+				if len(nextToken) == 0 {
+					break
+				}
+				token = nextToken
+			}
+
+			if strings.Join(result, ",") != strings.Join(s.expected, ",") {
+				t.Fatalf("Expected keys %v, got %v", s.expected, result)
+			}
+		})
+	}
+}
+
+func newTestBucket(t *testing.T, db dbx.Builder, chunkSize int) *blob.Bucket {
+	drv, err := pgblob.New(db, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return blob.NewBucket(drv)
+}
+
+func assertTotalChunks(t *testing.T, db dbx.Builder, expected int) {
+	var total int
+
+	err := db.Select("count(*)").From(pgblob.ChunksTable).Row(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != expected {
+		t.Fatalf("Expected %d stored chunks, got %d", expected, total)
+	}
+}
+
+func writeAll(ctx context.Context, bucket *blob.Bucket, key string, content []byte, opts *blob.WriterOptions) error {
+	w, err := bucket.NewWriter(ctx, key, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return errors.Join(err, w.Close())
+	}
+
+	return w.Close()
+}
+
+func readAll(ctx context.Context, bucket *blob.Bucket, key string) ([]byte, error) {
+	r, err := bucket.NewReader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,113 @@
+package s3
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPresignExpires is the max allowed presigned url expiration duration.
+const MaxPresignExpires = 7 * 24 * time.Hour
+
+// PresignURL returns a query string authenticated url (aka. presigned url)
+// for the specified method and object key that is valid for the provided expires duration.
+//
+// All headers set with the optional request funcs are also signed,
+// meaning that the client must send them with the exact same values
+// (e.g. "Content-Type" and "Content-Length" for a PUT request).
+//
+// The signing time could be explicitly specified with the "x-amz-date" header
+// (it is not included in the signed headers list).
+//
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func (s3 *S3) PresignURL(method string, key string, expires time.Duration, optFuncs ...func(*http.Request)) (string, error) {
+	if expires <= 0 || expires > MaxPresignExpires {
+		return "", errors.New("the presigned url expires duration must be between 1 second and 7 days")
+	}
+
+	req, err := http.NewRequest(method, s3.URL(key), nil)
+	if err != nil {
+		return "", err
+	}
+
+	// apply optional request funcs
+	for _, fn := range optFuncs {
+		if fn != nil {
+			fn(req)
+		}
+	}
+
+	reqDateTime, _ := time.Parse(dateTimeFormat, req.Header.Get("x-amz-date"))
+	if reqDateTime.IsZero() {
+		reqDateTime = time.Now().UTC()
+	}
+	req.Header.Del("x-amz-date")
+	req.Header.Set("host", req.URL.Host)
+
+	date := reqDateTime.Format("20060102")
+
+	scope := strings.Join([]string{
+		date,
+		s3.Region,
+		awsS3ServiceCode,
+		awsTerminationString,
+	}, "/")
+
+	// canonical headers
+	signed := make([]string, 0, len(req.Header))
+	canonical := make(map[string]string, len(req.Header))
+	for key, values := range req.Header {
+		normalizedKey := strings.ToLower(key)
+		signed = append(signed, normalizedKey)
+		canonical[normalizedKey] = strings.Join(values, ",")
+	}
+	slices.Sort(signed)
+
+	var canonicalHeaders strings.Builder
+	for _, key := range signed {
+		canonicalHeaders.WriteString(key)
+		canonicalHeaders.WriteString(":")
+		canonicalHeaders.WriteString(strings.TrimSpace(canonical[key]))
+		canonicalHeaders.WriteString("\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", awsSignAlgorithm)
+	query.Set("X-Amz-Credential", s3.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", reqDateTime.Format(dateTimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		escapeQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		awsSignAlgorithm,
+		reqDateTime.Format(dateTimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	query.Set("X-Amz-Signature", hex.EncodeToString(hmacSHA256(s3.signingKey(date), stringToSign)))
+
+	result, err := url.Parse(s3.URL(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the object url: %w", err)
+	}
+	result.RawQuery = escapeQuery(query)
+
+	return result.String(), nil
+}
@@ -207,11 +207,7 @@ func (s3 *S3) sign(req *http.Request) {
 	// 4. Derive a signing key for SigV4
 	// https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html#derive-signing-key
 	// ---------------------------------------------------------------
-	dateKey := hmacSHA256([]byte("AWS4"+s3.SecretKey), date)
-	dateRegionKey := hmacSHA256(dateKey, s3.Region)
-	dateRegionServiceKey := hmacSHA256(dateRegionKey, awsS3ServiceCode)
-	signingKey := hmacSHA256(dateRegionServiceKey, awsTerminationString)
-	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	signature := hex.EncodeToString(hmacSHA256(s3.signingKey(date), stringToSign))
 
 	// 5. Add the signature to the request
 	// https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html#add-signature-to-request
@@ -227,6 +223,14 @@ func (s3 *S3) sign(req *http.Request) {
 	req.Header.Set("authorization", authorization)
 }
 
+// signingKey derives the SigV4 signing key for the specified date (in YYYYMMDD format).
+func (s3 *S3) signingKey(date string) []byte {
+	dateKey := hmacSHA256([]byte("AWS4"+s3.SecretKey), date)
+	dateRegionKey := hmacSHA256(dateKey, s3.Region)
+	dateRegionServiceKey := hmacSHA256(dateRegionKey, awsS3ServiceCode)
+	return hmacSHA256(dateRegionServiceKey, awsTerminationString)
+}
+
 func sha256Hex(content []byte) string {
 	h := sha256.New()
 	h.Write(content)
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/thewandererbg/pgbase/tools/filesystem/blob"
 	"github.com/thewandererbg/pgbase/tools/filesystem/internal/s3blob"
@@ -185,6 +186,68 @@ func TestDriverDeleteEscaping(t *testing.T) {
 	}
 }
 
+func TestDriverSignedURL(t *testing.T) {
+	t.Parallel()
+
+	drv, err := s3blob.New(&s3.S3{
+		Bucket:    "test_bucket",
+		Region:    "test_region",
+		Endpoint:  "https://example.com",
+		AccessKey: "123",
+		SecretKey: "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		opts           *blob.SignedURLOptions
+		expectedPrefix string
+		expectedSigned string
+	}{
+		{
+			"GET (ignored content length)",
+			&blob.SignedURLOptions{Method: http.MethodGet, Expiry: time.Hour, ContentLength: 10},
+			"https://test_bucket.example.com/..__0x2f__abc/test.txt?",
+			"X-Amz-SignedHeaders=host",
+		},
+		{
+			"GET with response overrides",
+			&blob.SignedURLOptions{Method: http.MethodGet, Expiry: time.Hour, ContentType: "text/plain", ContentDisposition: "attachment; filename=test.txt"},
+			"https://test_bucket.example.com/..__0x2f__abc/test.txt?",
+			"X-Amz-SignedHeaders=host&response-content-disposition=attachment%3B%20filename%3Dtest.txt&response-content-type=text%2Fplain",
+		},
+		{
+			"PUT with content headers",
+			&blob.SignedURLOptions{Method: http.MethodPut, Expiry: time.Hour, ContentType: "text/plain", ContentLength: 10},
+			"https://test_bucket.example.com/..__0x2f__abc/test.txt?",
+			"X-Amz-SignedHeaders=content-length%3Bcontent-type%3Bhost",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := drv.SignedURL(context.Background(), "../abc/test.txt", s.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(result, s.expectedPrefix) {
+				t.Fatalf("Expected url prefix %q, got %q", s.expectedPrefix, result)
+			}
+
+			if !strings.Contains(result, s.expectedSigned) {
+				t.Fatalf("Expected url to contain %q, got %q", s.expectedSigned, result)
+			}
+
+			if !strings.Contains(result, "X-Amz-Expires=3600") {
+				t.Fatalf("Expected url to contain the expiry, got %q", result)
+			}
+		})
+	}
+}
+
 func TestDriverCopyEscaping(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,238 @@
+package mailer
+
+import (
+	"io"
+	"net/mail"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/thewandererbg/pgbase/tools/hook"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+var _ Mailer = (*Catcher)(nil)
+
+// DefaultCatcherMaxMessages is the default max number of messages kept by the [Catcher].
+const DefaultCatcherMaxMessages = 100
+
+// CapturedAttachment defines a single attachment of a [CapturedMessage].
+type CapturedAttachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+	Inline      bool   `json:"inline"`
+
+	// Content is the raw attachment content.
+	Content []byte `json:"-"`
+}
+
+// CapturedMessage defines a single message stored by the [Catcher].
+type CapturedMessage struct {
+	Id          string                `json:"id"`
+	Created     time.Time             `json:"created"`
+	From        mail.Address          `json:"from"`
+	To          []mail.Address        `json:"to"`
+	Cc          []mail.Address        `json:"cc"`
+	Bcc         []mail.Address        `json:"bcc"`
+	Subject     string                `json:"subject"`
+	HTML        string                `json:"html"`
+	Text        string                `json:"text"`
+	Headers     map[string]string     `json:"headers"`
+	Attachments []*CapturedAttachment `json:"attachments"`
+}
+
+// Attachment returns the message attachment with the specified name (if any).
+func (m *CapturedMessage) Attachment(name string) (*CapturedAttachment, bool) {
+	for _, a := range m.Attachments {
+		if a.Name == name {
+			return a, true
+		}
+	}
+
+	return nil, false
+}
+
+// Catcher implements [mailer.Mailer] interface and defines an in-memory
+// mail client that stores the sent messages instead of delivering them.
+//
+// This client is intended only for local development and testing.
+// When the max number of messages is reached, the oldest ones are discarded.
+type Catcher struct {
+	onSend *hook.Hook[*SendEvent]
+
+	mu          sync.RWMutex
+	messages    []*CapturedMessage
+	maxMessages int
+}
+
+// NewCatcher creates a new Catcher instance that keeps up to
+// maxMessages messages (fallbacks to [DefaultCatcherMaxMessages] if not set).
+func NewCatcher(maxMessages int) *Catcher {
+	c := &Catcher{}
+
+	c.SetMaxMessages(maxMessages)
+
+	return c
+}
+
+// OnSend implements [mailer.SendInterceptor] interface.
+func (c *Catcher) OnSend() *hook.Hook[*SendEvent] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.onSend == nil {
+		c.onSend = &hook.Hook[*SendEvent]{}
+	}
+	return c.onSend
+}
+
+// Send implements [mailer.Mailer] interface.
+func (c *Catcher) Send(m *Message) error {
+	c.mu.RLock()
+	onSend := c.onSend
+	c.mu.RUnlock()
+
+	if onSend != nil {
+		return onSend.Trigger(&SendEvent{Message: m}, func(e *SendEvent) error {
+			return c.send(e.Message)
+		})
+	}
+
+	return c.send(m)
+}
+
+func (c *Catcher) send(m *Message) error {
+	captured := &CapturedMessage{
+		Id:          security.PseudorandomString(15),
+		Created:     time.Now().UTC(),
+		From:        m.From,
+		To:          m.To,
+		Cc:          m.Cc,
+		Bcc:         m.Bcc,
+		Subject:     m.Subject,
+		HTML:        m.HTML,
+		Text:        m.Text,
+		Headers:     m.Headers,
+		Attachments: make([]*CapturedAttachment, 0, len(m.Attachments)+len(m.InlineAttachments)),
+	}
+
+	if err := captureAttachments(captured, m.Attachments, false); err != nil {
+		return err
+	}
+
+	if err := captureAttachments(captured, m.InlineAttachments, true); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = append(c.messages, captured)
+	c.trim()
+
+	return nil
+}
+
+func captureAttachments(captured *CapturedMessage, attachments map[string]io.Reader, inline bool) error {
+	names := make([]string, 0, len(attachments))
+	for name := range attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content, err := io.ReadAll(attachments[name])
+		if err != nil {
+			return err
+		}
+
+		captured.Attachments = append(captured.Attachments, &CapturedAttachment{
+			Name:        name,
+			ContentType: mimetype.Detect(content).String(),
+			Size:        len(content),
+			Inline:      inline,
+			Content:     content,
+		})
+	}
+
+	return nil
+}
+
+// SetMaxMessages updates the max number of the stored messages
+// (fallbacks to [DefaultCatcherMaxMessages] if not set).
+//
+// The oldest messages above the new limit are discarded.
+func (c *Catcher) SetMaxMessages(maxMessages int) {
+	if maxMessages <= 0 {
+		maxMessages = DefaultCatcherMaxMessages
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxMessages = maxMessages
+	c.trim()
+}
+
+// trim discards the oldest messages above the max limit.
+//
+// note: expects the caller to hold the write lock.
+func (c *Catcher) trim() {
+	if over := len(c.messages) - c.maxMessages; over > 0 {
+		c.messages = append([]*CapturedMessage(nil), c.messages[over:]...)
+	}
+}
+
+// Messages returns all stored messages ordered by the newest first.
+func (c *Catcher) Messages() []*CapturedMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]*CapturedMessage, len(c.messages))
+	for i, m := range c.messages {
+		result[len(c.messages)-1-i] = m
+	}
+
+	return result
+}
+
+// Message returns the stored message with the specified id (if any).
+func (c *Catcher) Message(id string) (*CapturedMessage, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, m := range c.messages {
+		if m.Id == id {
+			return m, true
+		}
+	}
+
+	return nil, false
+}
+
+// Delete removes the stored message with the specified id.
+//
+// Returns false if the message doesn't exist.
+func (c *Catcher) Delete(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, m := range c.messages {
+		if m.Id == id {
+			c.messages = append(c.messages[:i], c.messages[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Clear removes all stored messages.
+func (c *Catcher) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = nil
+}
@@ -0,0 +1,155 @@
+package mailer
+
+import (
+	"io"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestCatcherSend(t *testing.T) {
+	c := NewCatcher(0)
+
+	var hookCalls int
+	c.OnSend().BindFunc(func(e *SendEvent) error {
+		hookCalls++
+		e.Message.Subject += " (hooked)"
+		return e.Next()
+	})
+
+	err := c.Send(&Message{
+		From:    mail.Address{Address: "from@example.com"},
+		To:      []mail.Address{{Address: "to@example.com"}},
+		Subject: "test",
+		HTML:    "<p>test</p>",
+		Text:    "test",
+		Headers: map[string]string{"X-Test": "1"},
+		Attachments: map[string]io.Reader{
+			"b.txt": strings.NewReader("b"),
+			"a.txt": strings.NewReader("aaa"),
+		},
+		InlineAttachments: map[string]io.Reader{
+			"c.png": strings.NewReader("\x89PNG\r\n\x1a\n"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hookCalls != 1 {
+		t.Fatalf("Expected 1 hook call, got %d", hookCalls)
+	}
+
+	messages := c.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+
+	m := messages[0]
+
+	if m.Id == "" || m.Created.IsZero() {
+		t.Fatalf("Expected id and created to be set, got %q and %v", m.Id, m.Created)
+	}
+
+	if m.Subject != "test (hooked)" {
+		t.Fatalf("Expected subject %q, got %q", "test (hooked)", m.Subject)
+	}
+
+	if m.HTML != "<p>test</p>" || m.Text != "test" || m.Headers["X-Test"] != "1" {
+		t.Fatalf("Unexpected message body or headers: %v", m)
+	}
+
+	expectedAttachments := []struct {
+		name        string
+		size        int
+		inline      bool
+		contentType string
+	}{
+		{"a.txt", 3, false, "text/plain; charset=utf-8"},
+		{"b.txt", 1, false, "text/plain; charset=utf-8"},
+		{"c.png", 8, true, "image/png"},
+	}
+
+	if len(m.Attachments) != len(expectedAttachments) {
+		t.Fatalf("Expected %d attachments, got %d", len(expectedAttachments), len(m.Attachments))
+	}
+
+	for i, expected := range expectedAttachments {
+		a := m.Attachments[i]
+		if a.Name != expected.name || a.Size != expected.size || a.Inline != expected.inline || a.ContentType != expected.contentType {
+			t.Fatalf("[%d] Expected attachment %v, got %v", i, expected, a)
+		}
+	}
+
+	if a, ok := m.Attachment("a.txt"); !ok || string(a.Content) != "aaa" {
+		t.Fatalf("Expected a.txt attachment with content %q, got %v", "aaa", a)
+	}
+
+	if _, ok := m.Attachment("missing"); ok {
+		t.Fatal("Expected missing attachment")
+	}
+}
+
+func TestCatcherMaxMessages(t *testing.T) {
+	c := NewCatcher(2)
+
+	for _, subject := range []string{"a", "b", "c"} {
+		if err := c.Send(&Message{Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages := c.Messages()
+	if len(messages) != 2 || messages[0].Subject != "c" || messages[1].Subject != "b" {
+		t.Fatalf("Expected [c, b] messages, got %v", messages)
+	}
+
+	c.SetMaxMessages(1)
+
+	messages = c.Messages()
+	if len(messages) != 1 || messages[0].Subject != "c" {
+		t.Fatalf("Expected [c] message, got %v", messages)
+	}
+}
+
+func TestCatcherMessageDeleteAndClear(t *testing.T) {
+	c := NewCatcher(0)
+
+	for _, subject := range []string{"a", "b", "c"} {
+		if err := c.Send(&Message{Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := c.Messages()[1]
+
+	if m, ok := c.Message(b.Id); !ok || m.Subject != "b" {
+		t.Fatalf("Expected to find message b, got %v", m)
+	}
+
+	if _, ok := c.Message("missing"); ok {
+		t.Fatal("Expected missing message")
+	}
+
+	if c.Delete("missing") {
+		t.Fatal("Expected false for deleting a missing message")
+	}
+
+	if !c.Delete(b.Id) {
+		t.Fatal("Expected true for deleting an existing message")
+	}
+
+	if _, ok := c.Message(b.Id); ok {
+		t.Fatal("Expected message b to be deleted")
+	}
+
+	if total := len(c.Messages()); total != 2 {
+		t.Fatalf("Expected 2 messages, got %d", total)
+	}
+
+	c.Clear()
+
+	if total := len(c.Messages()); total != 0 {
+		t.Fatalf("Expected 0 messages, got %d", total)
+	}
+}
@@ -0,0 +1,355 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/domodwyer/mailyak/v3"
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+const (
+	DKIMAlgorithmRSA     = "rsa-sha256"
+	DKIMAlgorithmEd25519 = "ed25519-sha256"
+)
+
+// DefaultDKIMHeaders is the default list of the signed message header fields.
+var DefaultDKIMHeaders = []string{
+	"From",
+	"Reply-To",
+	"Subject",
+	"Date",
+	"To",
+	"Cc",
+	"Message-ID",
+	"MIME-Version",
+	"Content-Type",
+}
+
+// DKIMSigner signs the outgoing raw messages with a DKIM-Signature header
+// (RFC 6376) using relaxed/relaxed canonicalization.
+//
+// Both RSA (rsa-sha256) and Ed25519 (ed25519-sha256, RFC 8463) keys are supported.
+type DKIMSigner struct {
+	key crypto.Signer
+
+	// Domain is the signing domain ("d=" tag), eg. "example.com".
+	Domain string
+
+	// Selector is the DNS key record selector ("s=" tag), eg. "mail"
+	// for a public key published at "mail._domainkey.example.com".
+	Selector string
+
+	// Headers is the list of the header fields to sign (the "From" header is always signed).
+	//
+	// If empty, fallbacks to [DefaultDKIMHeaders].
+	Headers []string
+
+	// now is used to mock the signature timestamp in tests.
+	now func() time.Time
+}
+
+// NewDKIMSigner creates a new DKIMSigner from the provided PEM encoded RSA or Ed25519 private key.
+func NewDKIMSigner(domain string, selector string, privateKey string, headers []string) (*DKIMSigner, error) {
+	if domain == "" || selector == "" {
+		return nil, errors.New("the DKIM domain and selector are required")
+	}
+
+	key, err := security.ParseSigningKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DKIM private key: %w", err)
+	}
+
+	return &DKIMSigner{
+		key:      key,
+		Domain:   domain,
+		Selector: selector,
+		Headers:  headers,
+	}, nil
+}
+
+// Algorithm returns the signing algorithm of the signer key.
+func (s *DKIMSigner) Algorithm() string {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return DKIMAlgorithmEd25519
+	}
+
+	return DKIMAlgorithmRSA
+}
+
+// Sign returns a copy of the provided raw message (headers + body)
+// with a prepended DKIM-Signature header.
+//
+// The message line endings are normalized to CRLF before signing.
+func (s *DKIMSigner) Sign(raw []byte) ([]byte, error) {
+	normalized := normalizeCRLF(raw)
+
+	signature, err := s.signatureHeader(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(signature)+2+len(normalized))
+	result = append(result, signature...)
+	result = append(result, "\r\n"...)
+	result = append(result, normalized...)
+
+	return result, nil
+}
+
+// Signature generates and returns only the DKIM-Signature header
+// field (without the trailing CRLF) of the provided raw message.
+func (s *DKIMSigner) Signature(raw []byte) (string, error) {
+	return s.signatureHeader(normalizeCRLF(raw))
+}
+
+// MessageSignature generates the DKIM-Signature header field of the MIME
+// representation of the provided message (as it would be built by [SMTPClient]).
+//
+// Note that the MIME boundaries, the Date and the default Message-ID
+// headers are regenerated on every call, so the result differs from the
+// signature of the actually sent message, and that the message attachments readers are consumed.
+func (s *DKIMSigner) MessageSignature(m *Message) (string, error) {
+	yak := mailyak.New("", nil)
+
+	if err := populateMailYak(yak, m); err != nil {
+		return "", err
+	}
+
+	raw, err := yak.MimeBuf()
+	if err != nil {
+		return "", err
+	}
+
+	return s.Signature(raw.Bytes())
+}
+
+func (s *DKIMSigner) signatureHeader(raw []byte) (string, error) {
+	if s.key == nil {
+		return "", errors.New("missing DKIM private key")
+	}
+
+	headerPart, body := splitRawMessage(raw)
+
+	bodyHash := sha256.Sum256(canonicalizeBodyRelaxed(body))
+
+	fields := parseHeaderFields(headerPart)
+
+	// pick the signed header fields instances (from the bottom up)
+	signedNames := []string{}
+	signedFields := []string{}
+	used := map[int]struct{}{}
+	for _, name := range s.signedHeaders() {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if _, ok := used[i]; ok || !strings.EqualFold(fields[i].name, name) {
+				continue
+			}
+			used[i] = struct{}{}
+			signedNames = append(signedNames, fields[i].name)
+			signedFields = append(signedFields, fields[i].raw)
+			break
+		}
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+
+	value := fmt.Sprintf(
+		"v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%s; h=%s; bh=%s; b=",
+		s.Algorithm(),
+		s.Domain,
+		s.Selector,
+		strconv.FormatInt(now().Unix(), 10),
+		strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]),
+	)
+
+	h := sha256.New()
+	for _, f := range signedFields {
+		h.Write([]byte(canonicalizeHeaderRelaxed(f)))
+		h.Write([]byte("\r\n"))
+	}
+	// the signature header itself is hashed without the trailing CRLF
+	h.Write([]byte(canonicalizeHeaderRelaxed("DKIM-Signature: " + value)))
+	hashed := h.Sum(nil)
+
+	var sig []byte
+	var err error
+	switch key := s.key.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, hashed)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed)
+	default:
+		err = fmt.Errorf("unsupported DKIM key type %T", s.key)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "DKIM-Signature: " + value + foldBase64(base64.StdEncoding.EncodeToString(sig)), nil
+}
+
+// signedHeaders returns the list of the header fields to sign
+// ensuring that the "From" header is always included.
+func (s *DKIMSigner) signedHeaders() []string {
+	headers := s.Headers
+	if len(headers) == 0 {
+		headers = DefaultDKIMHeaders
+	}
+
+	for _, h := range headers {
+		if strings.EqualFold(h, "From") {
+			return headers
+		}
+	}
+
+	return append([]string{"From"}, headers...)
+}
+
+// -------------------------------------------------------------------
+
+type headerField struct {
+	name string
+	raw  string // the full unmodified header field (including the folded lines but without the last CRLF)
+}
+
+// splitRawMessage splits a CRLF normalized raw message into its header and body parts.
+func splitRawMessage(raw []byte) (string, []byte) {
+	if bytes.HasPrefix(raw, []byte("\r\n")) {
+		return "", raw[2:]
+	}
+
+	idx := bytes.Index(raw, []byte("\r\n\r\n"))
+	if idx < 0 {
+		return string(raw), nil
+	}
+
+	return string(raw[:idx+2]), raw[idx+4:]
+}
+
+// parseHeaderFields parses the header fields (including their folded lines) of a CRLF normalized header part.
+func parseHeaderFields(headerPart string) []headerField {
+	fields := []headerField{}
+
+	for _, line := range strings.Split(strings.TrimSuffix(headerPart, "\r\n"), "\r\n") {
+		if line == "" {
+			continue
+		}
+
+		// continuation line
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].raw += "\r\n" + line
+			continue
+		}
+
+		name, _, _ := strings.Cut(line, ":")
+		fields = append(fields, headerField{name: strings.TrimSpace(name), raw: line})
+	}
+
+	return fields
+}
+
+// canonicalizeHeaderRelaxed applies the "relaxed" header canonicalization
+// algorithm to a single header field (RFC 6376 section 3.4.2).
+func canonicalizeHeaderRelaxed(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+
+	// unfold
+	value = strings.ReplaceAll(value, "\r\n", "")
+
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(collapseWSP(value))
+}
+
+// canonicalizeBodyRelaxed applies the "relaxed" body canonicalization
+// algorithm to a CRLF normalized body (RFC 6376 section 3.4.4).
+func canonicalizeBodyRelaxed(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(collapseWSP(line), " ")
+	}
+
+	// ignore the empty lines at the end of the body
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// collapseWSP replaces all sequences of spaces and tabs with a single space.
+func collapseWSP(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	var inWSP bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' || c == '\t' {
+			if !inWSP {
+				sb.WriteByte(' ')
+			}
+			inWSP = true
+			continue
+		}
+		inWSP = false
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+// normalizeCRLF converts all bare LF and CR line endings to CRLF.
+func normalizeCRLF(raw []byte) []byte {
+	result := make([]byte, 0, len(raw)+len(raw)/50)
+
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\r':
+			result = append(result, '\r', '\n')
+			if i+1 < len(raw) && raw[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+			result = append(result, '\r', '\n')
+		default:
+			result = append(result, raw[i])
+		}
+	}
+
+	return result
+}
+
+// foldBase64 folds the provided base64 string into multiple header lines.
+//
+// The inserted whitespace is ignored by the verifiers.
+func foldBase64(s string) string {
+	const lineLength = 72
+
+	var sb strings.Builder
+	for len(s) > lineLength {
+		sb.WriteString(s[:lineLength])
+		sb.WriteString("\r\n\t")
+		s = s[lineLength:]
+	}
+	sb.WriteString(s)
+
+	return sb.String()
+}
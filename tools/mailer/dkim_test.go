@@ -0,0 +1,298 @@
+package mailer
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/security"
+)
+
+func TestCanonicalizeHeaderRelaxed(t *testing.T) {
+	// RFC 6376 section 3.4.5 examples
+	scenarios := []struct {
+		field    string
+		expected string
+	}{
+		{"A: X", "a:X"},
+		{"B : Y\t\r\n\tZ  ", "b:Y Z"},
+		{"Subject:  multiple   spaces \t ", "subject:multiple spaces"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.field, func(t *testing.T) {
+			if v := canonicalizeHeaderRelaxed(s.field); v != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeBodyRelaxed(t *testing.T) {
+	scenarios := []struct {
+		body     string
+		expected string
+	}{
+		{"", ""},
+		{"\r\n\r\n", ""},
+		// RFC 6376 section 3.4.5 example
+		{" C \r\nD \t E\r\n\r\n\r\n", " C\r\nD E\r\n"},
+		{"no trailing crlf", "no trailing crlf\r\n"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.body, func(t *testing.T) {
+			if v := string(canonicalizeBodyRelaxed([]byte(s.body))); v != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestNormalizeCRLF(t *testing.T) {
+	scenarios := []struct {
+		raw      string
+		expected string
+	}{
+		{"", ""},
+		{"a\r\nb", "a\r\nb"},
+		{"a\nb\n", "a\r\nb\r\n"},
+		{"a\rb\r\n\nc", "a\r\nb\r\n\r\nc"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.raw, func(t *testing.T) {
+			if v := string(normalizeCRLF([]byte(s.raw))); v != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestNewDKIMSigner(t *testing.T) {
+	rsaKey, err := security.GenerateSigningKey(security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKey, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name              string
+		domain            string
+		selector          string
+		key               string
+		expectError       bool
+		expectedAlgorithm string
+	}{
+		{"missing domain", "", "mail", rsaKey, true, ""},
+		{"missing selector", "example.com", "", rsaKey, true, ""},
+		{"invalid key", "example.com", "mail", "invalid", true, ""},
+		{"rsa key", "example.com", "mail", rsaKey, false, DKIMAlgorithmRSA},
+		{"ed25519 key", "example.com", "mail", edKey, false, DKIMAlgorithmEd25519},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			signer, err := NewDKIMSigner(s.domain, s.selector, s.key, nil)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if v := signer.Algorithm(); v != s.expectedAlgorithm {
+				t.Fatalf("Expected algorithm %q, got %q", s.expectedAlgorithm, v)
+			}
+		})
+	}
+}
+
+func TestDKIMSignerSign(t *testing.T) {
+	raw := "From: Sender <sender@example.com>\n" +
+		"To: to@example.com\n" +
+		"Subject: Hello\n" +
+		"  World\n" +
+		"X-Custom: skipped\n" +
+		"\n" +
+		"<p>Hello   world</p>  \n\n\n"
+
+	for _, alg := range []string{security.JWTAlgRS256, security.JWTAlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			pemKey, err := security.GenerateSigningKey(alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signer, err := NewDKIMSigner("example.com", "mail", pemKey, []string{"Subject", "To", "Date"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+			signed, err := signer.Sign([]byte(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signedStr := string(signed)
+
+			if !strings.HasPrefix(signedStr, "DKIM-Signature: v=1; a="+signer.Algorithm()+"; c=relaxed/relaxed; d=example.com; s=mail; t=1700000000; h=From:Subject:To; bh=") {
+				t.Fatalf("Unexpected DKIM-Signature header:\n%s", signedStr)
+			}
+
+			if !strings.HasSuffix(signedStr, "\r\n"+strings.ReplaceAll(raw, "\n", "\r\n")) {
+				t.Fatalf("Expected the CRLF normalized original message to be preserved, got\n%q", signedStr)
+			}
+
+			verifyDKIMSignature(t, signer.key.Public(), signed)
+
+			// tamper the message body
+			tampered := []byte(strings.Replace(signedStr, "Hello   world", "Hello world!", 1))
+			if err := checkDKIMSignature(signer.key.Public(), tampered); err == nil {
+				t.Fatal("Expected the tampered message verification to fail")
+			}
+
+			// whitespace changes are allowed by the relaxed canonicalization
+			relaxed := []byte(strings.Replace(signedStr, "Hello   world", "Hello world", 1))
+			verifyDKIMSignature(t, signer.key.Public(), relaxed)
+		})
+	}
+}
+
+func TestDKIMSignerMessageSignature(t *testing.T) {
+	pemKey, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewDKIMSigner("example.com", "mail", pemKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := signer.MessageSignature(&Message{
+		From:    mail.Address{Address: "sender@example.com"},
+		To:      []mail.Address{{Address: "to@example.com"}},
+		Subject: "test",
+		HTML:    "<p>test</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, part := range []string{"a=ed25519-sha256", "d=example.com", "s=mail", "h=From:Subject:Date:To:Message-ID:MIME-Version:Content-Type"} {
+		if !strings.Contains(signature, part) {
+			t.Fatalf("Expected %q in\n%s", part, signature)
+		}
+	}
+}
+
+func TestDKIMSignerSignedHeaders(t *testing.T) {
+	scenarios := []struct {
+		headers  []string
+		expected []string
+	}{
+		{nil, DefaultDKIMHeaders},
+		{[]string{"Subject"}, []string{"From", "Subject"}},
+		{[]string{"subject", "from"}, []string{"subject", "from"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(strings.Join(s.headers, ","), func(t *testing.T) {
+			signer := &DKIMSigner{Headers: s.headers}
+
+			result := signer.signedHeaders()
+
+			if strings.Join(result, ",") != strings.Join(s.expected, ",") {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+		})
+	}
+}
+
+// -------------------------------------------------------------------
+
+func verifyDKIMSignature(t *testing.T, publicKey crypto.PublicKey, signed []byte) {
+	if err := checkDKIMSignature(publicKey, signed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var dkimTagRegex = regexp.MustCompile(`(?:^|;)\s*([a-z]+)=([^;]*)`)
+
+// checkDKIMSignature is a minimal relaxed/relaxed DKIM verifier
+// of a message with a DKIM-Signature header as its first field.
+func checkDKIMSignature(publicKey crypto.PublicKey, signed []byte) error {
+	headerPart, body := splitRawMessage(normalizeCRLF(signed))
+	fields := parseHeaderFields(headerPart)
+
+	sigField := fields[0]
+	_, sigValue, _ := strings.Cut(sigField.raw, ":")
+
+	tags := map[string]string{}
+	for _, m := range dkimTagRegex.FindAllStringSubmatch(strings.ReplaceAll(sigValue, "\r\n", ""), -1) {
+		tags[m[1]] = strings.Join(strings.Fields(m[2]), "")
+	}
+
+	bodyHash := sha256.Sum256(canonicalizeBodyRelaxed(body))
+	if base64.StdEncoding.EncodeToString(bodyHash[:]) != tags["bh"] {
+		return errBodyHashMismatch
+	}
+
+	// pick the signed headers from the bottom up
+	h := sha256.New()
+	used := map[int]struct{}{}
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(fields) - 1; i > 0; i-- {
+			if _, ok := used[i]; ok || !strings.EqualFold(fields[i].name, name) {
+				continue
+			}
+			used[i] = struct{}{}
+			h.Write([]byte(canonicalizeHeaderRelaxed(fields[i].raw) + "\r\n"))
+			break
+		}
+	}
+
+	// the signature field with empty b= tag
+	bIndex := strings.LastIndex(sigField.raw, "b=")
+	h.Write([]byte(canonicalizeHeaderRelaxed(sigField.raw[:bIndex+2])))
+	hashed := h.Sum(nil)
+
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return err
+	}
+
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, hashed, sig) {
+			return errSignatureMismatch
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed, sig)
+	default:
+		return errSignatureMismatch
+	}
+}
+
+var (
+	errBodyHashMismatch  = errors.New("body hash mismatch")
+	errSignatureMismatch = errors.New("signature mismatch")
+)
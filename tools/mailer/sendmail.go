@@ -3,6 +3,8 @@ package mailer
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"mime"
 	"net/http"
 	"os/exec"
@@ -19,6 +21,9 @@ var _ Mailer = (*Sendmail)(nil)
 // This client is usually recommended only for development and testing.
 type Sendmail struct {
 	onSend *hook.Hook[*SendEvent]
+
+	// DKIM is an optional signer used to add a DKIM-Signature header to the sent messages.
+	DKIM *DKIMSigner
 }
 
 // OnSend implements [mailer.SendInterceptor] interface.
@@ -75,8 +80,18 @@ func (c *Sendmail) send(m *Message) error {
 	}
 	// ---
 
+	var stdin io.Reader = &buffer
+
+	if c.DKIM != nil {
+		signed, err := c.DKIM.Sign(buffer.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to DKIM sign the message: %w", err)
+		}
+		stdin = bytes.NewReader(signed)
+	}
+
 	sendmail := exec.Command(cmdPath, strings.Join(toAddresses, ","))
-	sendmail.Stdin = &buffer
+	sendmail.Stdin = stdin
 
 	return sendmail.Run()
 }
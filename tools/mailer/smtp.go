@@ -1,9 +1,12 @@
 package mailer
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/smtp"
+	"strconv"
 	"strings"
 
 	"github.com/domodwyer/mailyak/v3"
@@ -38,6 +41,9 @@ type SMTPClient struct {
 	//
 	// This is required only by some SMTP servers, such as Gmail SMTP-relay.
 	LocalName string
+
+	// DKIM is an optional signer used to add a DKIM-Signature header to the sent messages.
+	DKIM *DKIMSigner
 }
 
 // OnSend implements [mailer.SendInterceptor] interface.
@@ -86,6 +92,34 @@ func (c *SMTPClient) send(m *Message) error {
 		yak.LocalName(c.LocalName)
 	}
 
+	if err := populateMailYak(yak, m); err != nil {
+		return err
+	}
+
+	if c.DKIM == nil {
+		return yak.Send()
+	}
+
+	raw, err := yak.MimeBuf()
+	if err != nil {
+		return err
+	}
+
+	signed, err := c.DKIM.Sign(raw.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to DKIM sign the message: %w", err)
+	}
+
+	recipients := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	recipients = append(recipients, addressesToStrings(m.To, false)...)
+	recipients = append(recipients, addressesToStrings(m.Cc, false)...)
+	recipients = append(recipients, addressesToStrings(m.Bcc, false)...)
+
+	return c.sendRaw(smtpAuth, m.From.Address, recipients, signed)
+}
+
+// populateMailYak sets the mailyak instance fields from the provided message.
+func populateMailYak(yak *mailyak.MailYak, m *Message) error {
 	if m.From.Name != "" {
 		yak.FromName(m.From.Name)
 	}
@@ -151,7 +185,72 @@ func (c *SMTPClient) send(m *Message) error {
 		}
 	}
 
-	return yak.Send()
+	return nil
+}
+
+// sendRaw sends the already built raw message
+// (used for the messages that are modified after their MIME generation, eg. DKIM signed).
+func (c *SMTPClient) sendRaw(auth smtp.Auth, from string, recipients []string, raw []byte) error {
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+
+	var conn net.Conn
+	var err error
+	if c.TLS {
+		conn, err = tls.Dial("tcp", addr, &tls.Config{ServerName: c.Host})
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Quit()
+
+	if c.LocalName != "" {
+		if err := client.Hello(c.LocalName); err != nil {
+			return err
+		}
+	}
+
+	if !c.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: c.Host}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+
+	return w.Close()
 }
 
 // -------------------------------------------------------------------
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"cmp"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -10,6 +11,8 @@ import (
 	"net/http"
 	"net/netip"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/thewandererbg/pgbase/tools/filesystem"
@@ -96,6 +99,67 @@ func (e *Event) RemoteIP() string {
 	return parsed.StringExpanded()
 }
 
+// AcceptLanguages returns the language tags from the request
+// Accept-Language header sorted by their quality value (highest first).
+//
+// The wildcard "*" and the tags with q=0 are excluded.
+func (e *Event) AcceptLanguages() []string {
+	type weightedTag struct {
+		tag string
+		q   float64
+	}
+
+	parts := strings.Split(e.Request.Header.Get("Accept-Language"), ",")
+	if len(parts) > maxAcceptLanguages {
+		parts = parts[:maxAcceptLanguages]
+	}
+
+	tags := make([]weightedTag, 0, len(parts))
+
+	for _, part := range parts {
+		tag, params, _ := strings.Cut(part, ";")
+
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(name) != "q" {
+				continue
+			}
+
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			q = parsed
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		tags = append(tags, weightedTag{tag, q})
+	}
+
+	slices.SortStableFunc(tags, func(a, b weightedTag) int {
+		return cmp.Compare(b.q, a.q)
+	})
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+
+	return result
+}
+
+// maxAcceptLanguages is the max number of the parsed Accept-Language header tags.
+const maxAcceptLanguages = 20
+
 // FindUploadedFiles extracts all form files of "key" from a http request
 // and returns a slice with filesystem.File instances (if any).
 func (e *Event) FindUploadedFiles(key string) ([]*filesystem.File, error) {
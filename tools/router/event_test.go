@@ -219,6 +219,47 @@ func TestEventRemoteIP(t *testing.T) {
 	}
 }
 
+func TestEventAcceptLanguages(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		header   string
+		expected []string
+	}{
+		{"", []string{}},
+		{"*", []string{}},
+		{"de", []string{"de"}},
+		{"de-CH, de;q=0.9, en;q=0.8, *;q=0.5", []string{"de-CH", "de", "en"}},
+		{"en;q=0.5, fr, bg;q=0.7", []string{"fr", "bg", "en"}},
+		{"en;q=0, fr;q=invalid, bg", []string{"bg"}},
+		{"en , , fr", []string{"en", "fr"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.header, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept-Language", s.header)
+
+			event := router.Event{Request: req}
+
+			result := event.AcceptLanguages()
+
+			if len(result) != len(s.expected) {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+
+			for i, tag := range s.expected {
+				if result[i] != tag {
+					t.Fatalf("Expected %v, got %v", s.expected, result)
+				}
+			}
+		})
+	}
+}
+
 func TestFindUploadedFiles(t *testing.T) {
 	scenarios := []struct {
 		filename        string
@@ -0,0 +1,176 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// List with the supported asymmetric JWT signing algorithms.
+const (
+	JWTAlgRS256 = "RS256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+// rsaSigningKeyBits is the size of the generated RS256 signing keys.
+const rsaSigningKeyBits = 2048
+
+// GenerateSigningKey generates a new asymmetric private key for the
+// specified JWT algorithm (RS256 or EdDSA) and returns it as
+// PKCS #8 PEM encoded string.
+func GenerateSigningKey(alg string) (string, error) {
+	var key any
+	var err error
+
+	switch alg {
+	case JWTAlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, rsaSigningKeyBits)
+	case JWTAlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", fmt.Errorf("unsupported signing key algorithm %q", alg)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParseSigningKey parses the provided PEM encoded private key
+// (PKCS #8 or PKCS #1 for RSA) and returns it as [crypto.Signer].
+//
+// Only RSA and Ed25519 keys are supported.
+func ParseSigningKey(pemKey string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("invalid or missing PEM block")
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := key.(type) {
+	case *rsa.PrivateKey:
+		return v, nil
+	case ed25519.PrivateKey:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", key)
+	}
+}
+
+// SigningKeyAlg returns the JWT algorithm name associated with the
+// provided private or public key (RS256 or EdDSA).
+func SigningKeyAlg(key any) (string, error) {
+	method, err := signingMethodForKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return method.Alg(), nil
+}
+
+// JWK defines a single public JSON Web Key as described in [RFC 7517].
+//
+// [RFC 7517]: https://datatracker.ietf.org/doc/html/rfc7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA public key components
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519) public key components
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// NewJWK creates a new signature JWK from the provided RSA or Ed25519 public key.
+func NewJWK(publicKey crypto.PublicKey, kid string) (JWK, error) {
+	switch v := publicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: JWTAlgRS256,
+			N:   base64.RawURLEncoding.EncodeToString(v.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(v.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: JWTAlgEdDSA,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(v),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// PublicKey returns the [crypto.PublicKey] represented by the current JWK.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported JWK key type %q", k.Kty)
+	}
+}
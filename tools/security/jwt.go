@@ -1,7 +1,11 @@
 package security
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -54,3 +58,91 @@ func NewJWT(payload jwt.MapClaims, signingKey string, duration time.Duration) (s
 
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(signingKey))
 }
+
+// NewSignedJWT generates and returns new JWT signed with the provided
+// asymmetric private key.
+//
+// The signing method is resolved from the key type (RS256 for RSA keys
+// and EdDSA for Ed25519 keys).
+//
+// If kid is not empty, it is set as "kid" header of the generated token.
+func NewSignedJWT(payload jwt.MapClaims, signingKey crypto.Signer, kid string, duration time.Duration) (string, error) {
+	method, err := signingMethodForKey(signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"exp": time.Now().Add(duration).Unix(),
+	}
+
+	for k, v := range payload {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	return token.SignedString(signingKey)
+}
+
+// ParseSignedJWT verifies and parses asymmetric signed JWT (RS256 or EdDSA)
+// and returns its claims.
+//
+// keyFunc is called with the token "kid" header (could be empty) and
+// must return the public key that should be used for the verification.
+func ParseSignedJWT(token string, keyFunc func(kid string) (crypto.PublicKey, error)) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{JWTAlgRS256, JWTAlgEdDSA}))
+
+	parsedToken, err := parser.Parse(token, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key, err := keyFunc(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// ensure that the key matches with the token alg
+		method, err := signingMethodForKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if method.Alg() != t.Method.Alg() {
+			return nil, fmt.Errorf("the token alg %q doesn't match with the verification key alg %q", t.Method.Alg(), method.Alg())
+		}
+
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		return claims, nil
+	}
+
+	return nil, errors.New("unable to parse token")
+}
+
+// JWTHeader returns the unverified header of the provided JWT.
+func JWTHeader(token string) (map[string]any, error) {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.Header, nil
+}
+
+func signingMethodForKey(key any) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing key type %T", key)
+	}
+}
@@ -1,6 +1,8 @@
 package security_test
 
 import (
+	"crypto"
+	"errors"
 	"fmt"
 	"strconv"
 	"testing"
@@ -194,3 +196,118 @@ func TestNewJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAndParseSignedJWT(t *testing.T) {
+	t.Parallel()
+
+	rsaPEM, err := security.GenerateSigningKey(security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := security.ParseSigningKey(rsaPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPEM, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, err := security.ParseSigningKey(edPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys of the same type as the registered ones but not registered in the keyFunc
+	otherRSAPEM, err := security.GenerateSigningKey(security.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSAKey, err := security.ParseSigningKey(otherRSAPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherEdPEM, err := security.GenerateSigningKey(security.JWTAlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherEdKey, err := security.ParseSigningKey(otherEdPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]crypto.PublicKey{
+		"rsa": rsaKey.Public(),
+		"ed":  edKey.Public(),
+	}
+
+	keyFunc := func(kid string) (crypto.PublicKey, error) {
+		if k, ok := keys[kid]; ok {
+			return k, nil
+		}
+		return nil, errors.New("missing key")
+	}
+
+	scenarios := []struct {
+		name        string
+		signer      crypto.Signer
+		kid         string
+		duration    time.Duration
+		expectError bool
+	}{
+		{"rsa valid", rsaKey, "rsa", 10 * time.Second, false},
+		{"ed25519 valid", edKey, "ed", 10 * time.Second, false},
+		{"expired", rsaKey, "rsa", -10 * time.Second, true},
+		{"missing kid", rsaKey, "", 10 * time.Second, true},
+		{"unknown kid", rsaKey, "missing", 10 * time.Second, true},
+		{"ed25519 signer with rsa kid", edKey, "rsa", 10 * time.Second, true},
+		{"rsa signer with ed25519 kid", rsaKey, "ed", 10 * time.Second, true},
+		{"rsa kid with different rsa key", otherRSAKey, "rsa", 10 * time.Second, true},
+		{"ed25519 kid with different ed25519 key", otherEdKey, "ed", 10 * time.Second, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			token, err := security.NewSignedJWT(jwt.MapClaims{"name": "test"}, s.signer, s.kid, s.duration)
+			if err != nil {
+				t.Fatalf("Expected NewSignedJWT to succeed, got error %v", err)
+			}
+
+			header, err := security.JWTHeader(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kid, _ := header["kid"].(string); kid != s.kid {
+				t.Fatalf("Expected kid header %q, got %q", s.kid, kid)
+			}
+
+			claims, err := security.ParseSignedJWT(token, keyFunc)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if !hasErr && claims["name"] != "test" {
+				t.Fatalf("Expected name claim %q, got %v", "test", claims["name"])
+			}
+		})
+	}
+}
+
+func TestParseSignedJWTWithHS256Token(t *testing.T) {
+	t.Parallel()
+
+	token, err := security.NewJWT(jwt.MapClaims{"name": "test"}, "test", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = security.ParseSignedJWT(token, func(kid string) (crypto.PublicKey, error) {
+		return []byte("test"), nil
+	})
+	if err == nil {
+		t.Fatal("Expected HS256 tokens to be rejected")
+	}
+}
@@ -2,20 +2,26 @@ package subscriptions
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/thewandererbg/pgbase/tools/list"
 	"github.com/thewandererbg/pgbase/tools/store"
 )
 
-// Broker defines a struct for managing subscriptions clients.
+// Broker defines a struct for managing subscriptions clients
+// and their presence topics.
 type Broker struct {
 	store *store.Store[string, Client]
+
+	presence    map[string]map[string]PresenceMember
+	presenceMux sync.RWMutex
 }
 
 // NewBroker initializes and returns a new Broker instance.
 func NewBroker() *Broker {
 	return &Broker{
-		store: store.New[string, Client](nil),
+		store:    store.New[string, Client](nil),
+		presence: map[string]map[string]PresenceMember{},
 	}
 }
 
@@ -52,7 +58,8 @@ func (b *Broker) Register(client Client) {
 	b.store.Set(client.Id(), client)
 }
 
-// Unregister removes a single client by its id and marks it as discarded.
+// Unregister removes a single client by its id, marks it as discarded
+// and removes it from all presence topics.
 //
 // If client with clientId doesn't exist, this method does nothing.
 func (b *Broker) Unregister(clientId string) {
@@ -62,4 +69,8 @@ func (b *Broker) Unregister(clientId string) {
 	}
 	client.Discard()
 	b.store.Remove(clientId)
+
+	b.UntrackPresenceFunc(func(topic string, member PresenceMember) bool {
+		return member.IsLocal() && member.ClientId == clientId
+	})
 }
@@ -13,6 +13,41 @@ import (
 
 const optionsParam = "options"
 
+// DefaultQueueSize is the default max number of queued (aka. not yet consumed) client messages.
+const DefaultQueueSize = 256
+
+// ResyncMessageName is the name of the message that notifies the client
+// that some of its messages were lost and it should refetch its data.
+const ResyncMessageName = "PB_RESYNC"
+
+// OverflowPolicy defines how a client handles a new message when its queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest discards the oldest queued message to make room for the new one.
+	//
+	// A [ResyncMessageName] message is queued right after the discarded one(s)
+	// so that the client could detect the gap and refetch its data.
+	OverflowDropOldest OverflowPolicy = "dropOldest"
+
+	// OverflowDisconnect discards the client (aka. closes its channel)
+	// so that its connection handler could terminate the connection.
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+// ClientStats defines the message delivery stats of a single client.
+type ClientStats struct {
+	// Queued is the number of the currently queued messages.
+	Queued int `json:"queued"`
+
+	// Sent is the total number of the consumed (aka. delivered) messages
+	// (including the overflow resync messages).
+	Sent int64 `json:"sent"`
+
+	// Dropped is the total number of the discarded messages because of a full queue.
+	Dropped int64 `json:"dropped"`
+}
+
 // SubscriptionOptions defines the request options (query params, headers, etc.)
 // for a single subscription topic.
 type SubscriptionOptions struct {
@@ -73,28 +108,58 @@ type Client interface {
 
 	// Send sends the specified message to the client's channel (if not discarded).
 	Send(m Message)
+
+	// Stats returns the client message delivery stats.
+	Stats() ClientStats
 }
 
 // ensures that DefaultClient satisfies the Client interface
 var _ Client = (*DefaultClient)(nil)
 
 // DefaultClient defines a generic subscription client.
+//
+// The client messages are stored in a bounded queue (the buffered client channel)
+// so that a slow consumer doesn't block the message senders.
 type DefaultClient struct {
-	store         map[string]any
-	subscriptions map[string]SubscriptionOptions
-	channel       chan Message
-	id            string
-	mux           sync.RWMutex
-	isDiscarded   bool
+	store          map[string]any
+	subscriptions  map[string]SubscriptionOptions
+	channel        chan Message
+	id             string
+	overflowPolicy OverflowPolicy
+	enqueued       int64
+	dropped        int64
+	droppedQueued  int64
+	resyncAt       int64
+	mux            sync.RWMutex
+	isDiscarded    bool
 }
 
-// NewDefaultClient creates and returns a new DefaultClient instance.
+// NewDefaultClient creates and returns a new DefaultClient instance
+// with the default queue size and the [OverflowDropOldest] policy.
 func NewDefaultClient() *DefaultClient {
+	return NewDefaultClientWithQueue(DefaultQueueSize, OverflowDropOldest)
+}
+
+// NewDefaultClientWithQueue creates and returns a new DefaultClient instance
+// with the specified max queue size and overflow policy.
+//
+// Zero or negative queueSize fallbacks to [DefaultQueueSize] and
+// empty or unknown policy fallbacks to [OverflowDropOldest].
+func NewDefaultClientWithQueue(queueSize int, policy OverflowPolicy) *DefaultClient {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	if policy != OverflowDisconnect {
+		policy = OverflowDropOldest
+	}
+
 	return &DefaultClient{
-		id:            security.RandomString(40),
-		store:         map[string]any{},
-		channel:       make(chan Message),
-		subscriptions: map[string]SubscriptionOptions{},
+		id:             security.RandomString(40),
+		store:          map[string]any{},
+		channel:        make(chan Message, queueSize),
+		subscriptions:  map[string]SubscriptionOptions{},
+		overflowPolicy: policy,
 	}
 }
 
@@ -270,11 +335,94 @@ func (c *DefaultClient) IsDiscarded() bool {
 	return c.isDiscarded
 }
 
-// Send sends the specified message to the client's channel (if not discarded).
+// Send implements the [Client.Send] interface method.
+//
+// The method doesn't block. If the client queue is full, the
+// message is handled according to the client overflow policy.
 func (c *DefaultClient) Send(m Message) {
-	if c.IsDiscarded() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.isDiscarded {
+		return
+	}
+
+	select {
+	case c.channel <- m:
+		c.enqueued++
 		return
+	default:
 	}
 
-	c.Channel() <- m
+	// full queue
+	if c.overflowPolicy == OverflowDisconnect {
+		c.dropped++
+		close(c.channel)
+		c.isDiscarded = true
+		return
+	}
+
+	c.dropOldest()
+
+	// queue a resync message after the gap (if there isn't one already)
+	if !c.hasQueuedResync() {
+		c.dropOldest() // make room for the resync message
+
+		select {
+		case c.channel <- Message{Name: ResyncMessageName, Data: []byte(`{"reason":"overflow"}`)}:
+			c.enqueued++
+			c.resyncAt = c.enqueued
+		default:
+		}
+	}
+
+	select {
+	case c.channel <- m:
+		c.enqueued++
+	default:
+		c.dropped++
+	}
+}
+
+// dropOldest discards the oldest queued message (if any).
+//
+// Must be called with locked c.mux.
+func (c *DefaultClient) dropOldest() {
+	select {
+	case <-c.channel:
+		c.dropped++
+		c.droppedQueued++
+	default:
+	}
+}
+
+// hasQueuedResync reports whether the last queued resync message
+// is still in the queue (aka. not consumed or dropped yet).
+//
+// Because the messages are consumed in order, the resync message
+// is still queued if the number of the removed messages is less than its position.
+//
+// Must be called with locked c.mux.
+func (c *DefaultClient) hasQueuedResync() bool {
+	if c.resyncAt == 0 {
+		return false
+	}
+
+	removed := c.enqueued - int64(len(c.channel))
+
+	return removed < c.resyncAt
+}
+
+// Stats implements the [Client.Stats] interface method.
+func (c *DefaultClient) Stats() ClientStats {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	queued := len(c.channel)
+
+	return ClientStats{
+		Queued:  queued,
+		Sent:    c.enqueued - c.droppedQueued - int64(queued),
+		Dropped: c.dropped,
+	}
 }
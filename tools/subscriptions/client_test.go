@@ -242,3 +242,96 @@ func TestSend(t *testing.T) {
 		}
 	}
 }
+
+func TestNewDefaultClientWithQueue(t *testing.T) {
+	scenarios := []struct {
+		size         int
+		policy       subscriptions.OverflowPolicy
+		expectedSize int
+	}{
+		{0, "", subscriptions.DefaultQueueSize},
+		{-1, "invalid", subscriptions.DefaultQueueSize},
+		{5, subscriptions.OverflowDisconnect, 5},
+	}
+
+	for i, s := range scenarios {
+		c := subscriptions.NewDefaultClientWithQueue(s.size, s.policy)
+
+		if v := cap(c.Channel()); v != s.expectedSize {
+			t.Errorf("(%d) Expected queue size %d, got %d", i, s.expectedSize, v)
+		}
+	}
+}
+
+func TestSendOverflowDropOldest(t *testing.T) {
+	c := subscriptions.NewDefaultClientWithQueue(3, subscriptions.OverflowDropOldest)
+
+	c.Send(subscriptions.Message{Name: "m1"})
+	c.Send(subscriptions.Message{Name: "m2"})
+	c.Send(subscriptions.Message{Name: "m3"})
+	c.Send(subscriptions.Message{Name: "m4"}) // drops m1 and m2 (to make room for the resync message)
+	c.Send(subscriptions.Message{Name: "m5"}) // drops m3 (the resync message is still queued)
+
+	if c.IsDiscarded() {
+		t.Fatal("Expected the client to not be discarded")
+	}
+
+	stats := c.Stats()
+	if stats.Queued != 3 || stats.Sent != 0 || stats.Dropped != 3 {
+		t.Fatalf("Unexpected stats %#v", stats)
+	}
+
+	received := []string{(<-c.Channel()).Name, (<-c.Channel()).Name, (<-c.Channel()).Name}
+	expected := []string{subscriptions.ResyncMessageName, "m4", "m5"}
+	if strings.Join(received, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected messages %v, got %v", expected, received)
+	}
+
+	stats = c.Stats()
+	if stats.Queued != 0 || stats.Sent != 3 || stats.Dropped != 3 {
+		t.Fatalf("Unexpected stats after receive %#v", stats)
+	}
+
+	// the previous resync message was consumed -> queue a new one on the next overflow
+	c.Send(subscriptions.Message{Name: "m6"})
+	c.Send(subscriptions.Message{Name: "m7"})
+	c.Send(subscriptions.Message{Name: "m8"})
+	c.Send(subscriptions.Message{Name: "m9"})
+
+	received = []string{(<-c.Channel()).Name, (<-c.Channel()).Name, (<-c.Channel()).Name}
+	expected = []string{"m8", subscriptions.ResyncMessageName, "m9"}
+	if strings.Join(received, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected messages %v, got %v", expected, received)
+	}
+}
+
+func TestSendOverflowDisconnect(t *testing.T) {
+	c := subscriptions.NewDefaultClientWithQueue(2, subscriptions.OverflowDisconnect)
+
+	c.Send(subscriptions.Message{Name: "m1"})
+	c.Send(subscriptions.Message{Name: "m2"})
+
+	if c.IsDiscarded() {
+		t.Fatal("Expected the client to not be discarded before the overflow")
+	}
+
+	c.Send(subscriptions.Message{Name: "m3"})
+	c.Send(subscriptions.Message{Name: "m4"}) // ignored
+
+	if !c.IsDiscarded() {
+		t.Fatal("Expected the client to be discarded after the overflow")
+	}
+
+	stats := c.Stats()
+	if stats.Queued != 2 || stats.Sent != 0 || stats.Dropped != 1 {
+		t.Fatalf("Unexpected stats %#v", stats)
+	}
+
+	received := []string{}
+	for m := range c.Channel() {
+		received = append(received, m.Name)
+	}
+	if len(received) != 2 || received[0] != "m1" || received[1] != "m2" {
+		t.Fatalf("Expected the queued messages to be still consumable, got %v", received)
+	}
+}
@@ -0,0 +1,174 @@
+package subscriptions
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PresenceMember defines a single member of a presence topic.
+type PresenceMember struct {
+	// InstanceId is the id of the app instance that owns the member client
+	// (empty for the clients registered in the current broker).
+	InstanceId string `json:"-"`
+
+	ClientId         string          `json:"clientId"`
+	AuthId           string          `json:"authId"`
+	AuthCollectionId string          `json:"authCollectionId"`
+	State            json.RawMessage `json:"state"`
+	JoinedAt         time.Time       `json:"joinedAt"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+}
+
+// IsLocal reports whether the member client is registered in the current broker.
+func (m PresenceMember) IsLocal() bool {
+	return m.InstanceId == ""
+}
+
+// TrackPresence adds the member to the presence topic or
+// updates its state if the member client has already joined the topic.
+//
+// Returns the stored member and whether it is a new topic member.
+func (b *Broker) TrackPresence(topic string, member PresenceMember) (PresenceMember, bool) {
+	b.presenceMux.Lock()
+	defer b.presenceMux.Unlock()
+
+	now := time.Now()
+
+	members, ok := b.presence[topic]
+	if !ok {
+		members = map[string]PresenceMember{}
+		b.presence[topic] = members
+	}
+
+	key := presenceKey(member.InstanceId, member.ClientId)
+
+	existing, exists := members[key]
+	if exists {
+		member.JoinedAt = existing.JoinedAt
+	} else if member.JoinedAt.IsZero() {
+		member.JoinedAt = now
+	}
+
+	if member.UpdatedAt.IsZero() {
+		member.UpdatedAt = now
+	}
+
+	members[key] = member
+
+	return member, !exists
+}
+
+// UntrackPresence removes the member client from the presence topic.
+//
+// Returns the removed member and whether it was found.
+func (b *Broker) UntrackPresence(topic string, instanceId string, clientId string) (PresenceMember, bool) {
+	b.presenceMux.Lock()
+	defer b.presenceMux.Unlock()
+
+	members := b.presence[topic]
+
+	key := presenceKey(instanceId, clientId)
+
+	member, ok := members[key]
+	if !ok {
+		return member, false
+	}
+
+	delete(members, key)
+	if len(members) == 0 {
+		delete(b.presence, topic)
+	}
+
+	return member, true
+}
+
+// UntrackPresenceFunc removes all presence members for which fn returns true.
+//
+// Returns the removed members grouped by their topic.
+func (b *Broker) UntrackPresenceFunc(fn func(topic string, member PresenceMember) bool) map[string][]PresenceMember {
+	b.presenceMux.Lock()
+	defer b.presenceMux.Unlock()
+
+	result := map[string][]PresenceMember{}
+
+	for topic, members := range b.presence {
+		for key, member := range members {
+			if !fn(topic, member) {
+				continue
+			}
+
+			result[topic] = append(result[topic], member)
+
+			delete(members, key)
+		}
+
+		if len(members) == 0 {
+			delete(b.presence, topic)
+		}
+	}
+
+	return result
+}
+
+// Presence returns the members of the presence topic sorted by their join date.
+func (b *Broker) Presence(topic string) []PresenceMember {
+	b.presenceMux.RLock()
+	defer b.presenceMux.RUnlock()
+
+	result := make([]PresenceMember, 0, len(b.presence[topic]))
+	for _, member := range b.presence[topic] {
+		result = append(result, member)
+	}
+
+	slices.SortFunc(result, func(a, b PresenceMember) int {
+		if c := a.JoinedAt.Compare(b.JoinedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ClientId, b.ClientId)
+	})
+
+	return result
+}
+
+// FilterPresence returns all presence members for which fn returns true
+// grouped by their topic.
+func (b *Broker) FilterPresence(fn func(topic string, member PresenceMember) bool) map[string][]PresenceMember {
+	b.presenceMux.RLock()
+	defer b.presenceMux.RUnlock()
+
+	result := map[string][]PresenceMember{}
+
+	for topic, members := range b.presence {
+		for _, member := range members {
+			if fn(topic, member) {
+				result[topic] = append(result[topic], member)
+			}
+		}
+	}
+
+	return result
+}
+
+// ClientPresence returns the local client presence members indexed by their topic.
+func (b *Broker) ClientPresence(clientId string) map[string]PresenceMember {
+	b.presenceMux.RLock()
+	defer b.presenceMux.RUnlock()
+
+	result := map[string]PresenceMember{}
+
+	key := presenceKey("", clientId)
+
+	for topic, members := range b.presence {
+		if member, ok := members[key]; ok {
+			result[topic] = member
+		}
+	}
+
+	return result
+}
+
+func presenceKey(instanceId string, clientId string) string {
+	return instanceId + "/" + clientId
+}
@@ -0,0 +1,183 @@
+package subscriptions_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/subscriptions"
+)
+
+func TestTrackPresence(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	joinedAt := time.Now().Add(-1 * time.Hour)
+
+	member, isNew := b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1", JoinedAt: joinedAt})
+	if !isNew {
+		t.Fatal("Expected new member")
+	}
+	if !member.JoinedAt.Equal(joinedAt) {
+		t.Fatalf("Expected JoinedAt %v, got %v", joinedAt, member.JoinedAt)
+	}
+	if member.UpdatedAt.IsZero() {
+		t.Fatal("Expected UpdatedAt to be set")
+	}
+
+	// update
+	member, isNew = b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1", State: json.RawMessage(`{"x":1}`)})
+	if isNew {
+		t.Fatal("Expected existing member")
+	}
+	if !member.JoinedAt.Equal(joinedAt) {
+		t.Fatalf("Expected the original JoinedAt %v to be preserved, got %v", joinedAt, member.JoinedAt)
+	}
+	if string(member.State) != `{"x":1}` {
+		t.Fatalf("Expected the state to be updated, got %s", member.State)
+	}
+
+	// same client id but from different instance
+	_, isNew = b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1", InstanceId: "i2"})
+	if !isNew {
+		t.Fatal("Expected new remote member")
+	}
+
+	if total := len(b.Presence("a")); total != 2 {
+		t.Fatalf("Expected 2 members, got %d", total)
+	}
+}
+
+func TestUntrackPresence(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1"})
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c2"})
+
+	if _, ok := b.UntrackPresence("a", "i2", "c1"); ok {
+		t.Fatal("Expected remote member c1 to be missing")
+	}
+
+	if _, ok := b.UntrackPresence("missing", "", "c1"); ok {
+		t.Fatal("Expected missing topic")
+	}
+
+	member, ok := b.UntrackPresence("a", "", "c1")
+	if !ok || member.ClientId != "c1" {
+		t.Fatalf("Expected c1 to be removed, got %v (%v)", member, ok)
+	}
+
+	members := b.Presence("a")
+	if len(members) != 1 || members[0].ClientId != "c2" {
+		t.Fatalf("Expected only c2 member, got %v", members)
+	}
+}
+
+func TestUntrackPresenceFunc(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1"})
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c2", InstanceId: "i2"})
+	b.TrackPresence("b", subscriptions.PresenceMember{ClientId: "c3", InstanceId: "i2"})
+
+	removed := b.UntrackPresenceFunc(func(topic string, member subscriptions.PresenceMember) bool {
+		return member.InstanceId == "i2"
+	})
+
+	if len(removed["a"]) != 1 || removed["a"][0].ClientId != "c2" {
+		t.Fatalf("Expected c2 to be removed from topic a, got %v", removed["a"])
+	}
+
+	if len(removed["b"]) != 1 || removed["b"][0].ClientId != "c3" {
+		t.Fatalf("Expected c3 to be removed from topic b, got %v", removed["b"])
+	}
+
+	if total := len(b.Presence("a")); total != 1 {
+		t.Fatalf("Expected 1 member in topic a, got %d", total)
+	}
+
+	if total := len(b.Presence("b")); total != 0 {
+		t.Fatalf("Expected no members in topic b, got %d", total)
+	}
+}
+
+func TestFilterPresence(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1"})
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c2", InstanceId: "i2"})
+	b.TrackPresence("b", subscriptions.PresenceMember{ClientId: "c3"})
+
+	result := b.FilterPresence(func(topic string, member subscriptions.PresenceMember) bool {
+		return member.IsLocal()
+	})
+
+	if len(result["a"]) != 1 || result["a"][0].ClientId != "c1" {
+		t.Fatalf("Expected only c1 in topic a, got %v", result["a"])
+	}
+
+	if len(result["b"]) != 1 || result["b"][0].ClientId != "c3" {
+		t.Fatalf("Expected only c3 in topic b, got %v", result["b"])
+	}
+
+	// ensure that nothing was removed
+	if total := len(b.Presence("a")); total != 2 {
+		t.Fatalf("Expected 2 members in topic a, got %d", total)
+	}
+}
+
+func TestPresenceSort(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	now := time.Now()
+
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c3", JoinedAt: now})
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c2", JoinedAt: now})
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1", JoinedAt: now.Add(time.Second)})
+
+	members := b.Presence("a")
+
+	expected := []string{"c2", "c3", "c1"}
+	for i, id := range expected {
+		if members[i].ClientId != id {
+			t.Fatalf("Expected member %d to be %q, got %q", i, id, members[i].ClientId)
+		}
+	}
+}
+
+func TestClientPresence(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: "c1"})
+	b.TrackPresence("b", subscriptions.PresenceMember{ClientId: "c1"})
+	b.TrackPresence("c", subscriptions.PresenceMember{ClientId: "c1", InstanceId: "i2"})
+	b.TrackPresence("d", subscriptions.PresenceMember{ClientId: "c2"})
+
+	result := b.ClientPresence("c1")
+
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 local topics, got %v", result)
+	}
+
+	for _, topic := range []string{"a", "b"} {
+		if _, ok := result[topic]; !ok {
+			t.Fatalf("Missing topic %q", topic)
+		}
+	}
+}
+
+func TestUnregisterRemovesPresence(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	client := subscriptions.NewDefaultClient()
+	b.Register(client)
+
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: client.Id()})
+	b.TrackPresence("a", subscriptions.PresenceMember{ClientId: client.Id(), InstanceId: "i2"})
+
+	b.Unregister(client.Id())
+
+	members := b.Presence("a")
+	if len(members) != 1 || members[0].IsLocal() {
+		t.Fatalf("Expected only the remote member to remain, got %v", members)
+	}
+}
@@ -0,0 +1,174 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Upgrade upgrades the HTTP server connection to the WebSocket protocol.
+//
+// The optional header is appended to the handshake response.
+//
+// On failure Upgrade replies to the client with an HTTP error response.
+func Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	fail := func(status int, reason string) (*Conn, error) {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(status), status)
+		return nil, errors.New("websocket: " + reason)
+	}
+
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "the handshake request method must be GET")
+	}
+
+	if !headerContainsToken(r.Header.Values("Connection"), "upgrade") {
+		return fail(http.StatusBadRequest, "missing or invalid Connection header")
+	}
+
+	if !headerContainsToken(r.Header.Values("Upgrade"), "websocket") {
+		return fail(http.StatusBadRequest, "missing or invalid Upgrade header")
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusBadRequest, "unsupported Sec-WebSocket-Version")
+	}
+
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if key == "" {
+		return fail(http.StatusBadRequest, "missing Sec-WebSocket-Key header")
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "failed to hijack the connection: "+err.Error())
+	}
+
+	// reset the server deadlines
+	_ = netConn.SetDeadline(time.Time{})
+
+	var response strings.Builder
+	response.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	response.WriteString("Upgrade: websocket\r\n")
+	response.WriteString("Connection: Upgrade\r\n")
+	response.WriteString("Sec-WebSocket-Accept: " + computeAcceptKey(key) + "\r\n")
+	for k, values := range header {
+		for _, v := range values {
+			response.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	response.WriteString("\r\n")
+
+	if _, err := netConn.Write([]byte(response.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	var reader *bufio.Reader
+	if rw != nil && rw.Reader.Buffered() > 0 {
+		reader = rw.Reader
+	}
+
+	return newConn(netConn, reader, true), nil
+}
+
+// IsUpgradeRequest reports whether the request is a WebSocket handshake request.
+func IsUpgradeRequest(r *http.Request) bool {
+	return headerContainsToken(r.Header.Values("Connection"), "upgrade") &&
+		headerContainsToken(r.Header.Values("Upgrade"), "websocket")
+}
+
+// Dial opens a new client WebSocket connection to the specified "ws://" or "wss://" url.
+//
+// It is intended mainly for tests and internal tools.
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported url scheme %q", u.Scheme)
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var netConn net.Conn
+	if u.Scheme == "https" {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		netConn, err = dialer.DialContext(ctx, "tcp", host)
+	} else {
+		netConn, err = (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(netConn)
+
+	res, err := http.ReadResponse(reader, req)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		netConn.Close()
+		return nil, res, fmt.Errorf("websocket: unexpected handshake response status %d", res.StatusCode)
+	}
+
+	if res.Header.Get("Sec-WebSocket-Accept") != computeAcceptKey(key) {
+		netConn.Close()
+		return nil, res, errors.New("websocket: invalid Sec-WebSocket-Accept header")
+	}
+
+	_ = netConn.SetDeadline(time.Time{})
+
+	return newConn(netConn, reader, false), res, nil
+}
@@ -0,0 +1,404 @@
+// Package websocket implements a minimal RFC 6455 WebSocket
+// server (and client) connection.
+//
+// It supports only the features needed by the realtime API -
+// text and binary messages (including fragmented ones), ping/pong
+// control frames and the closing handshake (no extensions or subprotocols).
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType defines a WebSocket frame opcode.
+type MessageType int
+
+// Supported message types (aka. frame opcodes).
+const (
+	continuationFrame MessageType = 0
+
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+	CloseMessage  MessageType = 8
+	PingMessage   MessageType = 9
+	PongMessage   MessageType = 10
+)
+
+// Common close status codes.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+// DefaultReadLimit is the default max size of a single read message (in bytes).
+const DefaultReadLimit = 32 << 10
+
+const maxControlPayload = 125
+
+// acceptGUID is the RFC 6455 magic value used to compute the Sec-WebSocket-Accept header.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrReadLimit is returned when reading a message larger than the connection read limit.
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+
+	// ErrClosed is returned when writing to an already closed connection.
+	ErrClosed = errors.New("websocket: use of closed connection")
+
+	errProtocol = errors.New("websocket: protocol error")
+)
+
+// CloseError is returned by [Conn.ReadMessage] when the peer sends a close frame.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Error implements the [error] interface.
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// Conn represents a single WebSocket connection.
+//
+// Conn supports one concurrent reader and multiple concurrent writers.
+type Conn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	isServer bool
+
+	readLimit   int64
+	pongHandler func(data []byte)
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader, isServer bool) *Conn {
+	if reader == nil {
+		reader = bufio.NewReader(conn)
+	}
+
+	return &Conn{
+		conn:      conn,
+		reader:    reader,
+		isServer:  isServer,
+		readLimit: DefaultReadLimit,
+	}
+}
+
+// SetReadLimit sets the max size of a single read message (in bytes).
+//
+// Zero or negative value disables the limit.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetPongHandler registers a handler that is called on every received pong frame.
+//
+// The handler is called from the [Conn.ReadMessage] goroutine.
+func (c *Conn) SetPongHandler(handler func(data []byte)) {
+	c.pongHandler = handler
+}
+
+// SetReadDeadline sets the read deadline of the underlying network connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying network connection.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage reads the next text or binary message.
+//
+// Ping frames are automatically answered with pong and pong frames are passed to the pong handler (if any).
+//
+// Returns [*CloseError] if the peer initiated the closing handshake.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var messageType MessageType
+	var payload []byte
+
+	for {
+		fin, opcode, data, err := c.readFrame(int64(len(payload)))
+		if err != nil {
+			if errors.Is(err, ErrReadLimit) {
+				c.CloseWithCode(CloseMessageTooBig, "")
+			} else if errors.Is(err, errProtocol) {
+				c.CloseWithCode(CloseProtocolError, "")
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, data); err != nil && !errors.Is(err, ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				c.pongHandler(data)
+			}
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNoStatusReceived}
+			if len(data) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(data))
+				closeErr.Text = string(data[2:])
+			}
+
+			// echo the close frame to complete the closing handshake
+			echoCode := closeErr.Code
+			if echoCode == CloseNoStatusReceived {
+				echoCode = CloseNormalClosure
+			}
+			c.CloseWithCode(echoCode, "")
+
+			return 0, nil, closeErr
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				c.CloseWithCode(CloseProtocolError, "")
+				return 0, nil, fmt.Errorf("%w: unexpected data frame in the middle of a fragmented message", errProtocol)
+			}
+			messageType = opcode
+			payload = data
+		case continuationFrame:
+			if messageType == 0 {
+				c.CloseWithCode(CloseProtocolError, "")
+				return 0, nil, fmt.Errorf("%w: unexpected continuation frame", errProtocol)
+			}
+			payload = append(payload, data...)
+		default:
+			c.CloseWithCode(CloseProtocolError, "")
+			return 0, nil, fmt.Errorf("%w: unknown opcode %d", errProtocol, opcode)
+		}
+
+		if !fin {
+			continue
+		}
+
+		if messageType == TextMessage && !utf8.Valid(payload) {
+			c.CloseWithCode(CloseInvalidPayload, "")
+			return 0, nil, errors.New("websocket: invalid UTF-8 text message")
+		}
+
+		return messageType, payload, nil
+	}
+}
+
+// readFrame reads a single frame.
+//
+// currentSize is the size of the already read message fragments (used for the read limit check).
+func (c *Conn) readFrame(currentSize int64) (bool, MessageType, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: unsupported reserved bits", errProtocol)
+	}
+
+	opcode := MessageType(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+
+	// client frames must be masked and the server ones must not be
+	if masked != c.isServer {
+		return false, 0, nil, fmt.Errorf("%w: invalid frame masking", errProtocol)
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+		if length < 0 {
+			return false, 0, nil, fmt.Errorf("%w: invalid payload length", errProtocol)
+		}
+	}
+
+	if opcode >= CloseMessage && (!fin || length > maxControlPayload) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", errProtocol)
+	}
+
+	if opcode < CloseMessage && c.readLimit > 0 && currentSize+length > c.readLimit {
+		return false, 0, nil, ErrReadLimit
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, maskKey[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		maskBytes(maskKey, data)
+	}
+
+	return fin, opcode, data, nil
+}
+
+// WriteMessage writes a single (unfragmented) message frame.
+func (c *Conn) WriteMessage(messageType MessageType, data []byte) error {
+	if messageType >= CloseMessage && len(data) > maxControlPayload {
+		return errors.New("websocket: control frame payload is too large")
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+
+	return c.writeFrame(messageType, data)
+}
+
+// Ping sends a ping control frame.
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteMessage(PingMessage, data)
+}
+
+func (c *Conn) writeFrame(opcode MessageType, data []byte) error {
+	length := len(data)
+
+	frame := make([]byte, 0, 14+length)
+	frame = append(frame, 0x80|byte(opcode))
+
+	var maskBit byte
+	if !c.isServer {
+		maskBit = 0x80
+	}
+
+	switch {
+	case length <= 125:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	if c.isServer {
+		frame = append(frame, data...)
+	} else {
+		var maskKey [4]byte
+		if _, err := rand.Read(maskKey[:]); err != nil {
+			return err
+		}
+		frame = append(frame, maskKey[:]...)
+
+		start := len(frame)
+		frame = append(frame, data...)
+		maskBytes(maskKey, frame[start:])
+	}
+
+	_, err := c.conn.Write(frame)
+
+	return err
+}
+
+// CloseWithCode sends a close frame with the specified status code
+// and reason (if not already sent) and closes the underlying network connection.
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+
+	// ignore the close frame write error since the connection
+	// could be already closed by the peer
+	_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = c.WriteMessage(CloseMessage, payload)
+
+	return c.conn.Close()
+}
+
+// Close sends a normal closure frame (if not already sent) and closes the underlying network connection.
+func (c *Conn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// -------------------------------------------------------------------
+
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i%4]
+	}
+}
+
+func computeAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func generateKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// headerContainsToken reports whether the comma separated header values contain the specified token (case-insensitive).
+func headerContainsToken(values []string, token string) bool {
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thewandererbg/pgbase/tools/websocket"
+)
+
+func newEchoServer(t *testing.T, readLimit int64) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, http.Header{"X-Test": {"1"}})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if readLimit != 0 {
+			conn.SetReadLimit(readLimit)
+		}
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, res, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Header.Get("X-Test") != "1" {
+		t.Fatalf("Expected the extra handshake response header to be set, got %v", res.Header)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+func TestUpgradeInvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	server := newEchoServer(t, 0)
+	defer server.Close()
+
+	scenarios := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{
+			"non-GET request",
+			http.MethodPost,
+			map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ=="},
+			http.StatusMethodNotAllowed,
+		},
+		{
+			"missing upgrade headers",
+			http.MethodGet,
+			map[string]string{"Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ=="},
+			http.StatusBadRequest,
+		},
+		{
+			"unsupported version",
+			http.MethodGet,
+			map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ=="},
+			http.StatusBadRequest,
+		},
+		{
+			"missing key",
+			http.MethodGet,
+			map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13"},
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			req, err := http.NewRequest(s.method, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range s.headers {
+				req.Header.Set(k, v)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != s.status {
+				t.Fatalf("Expected status %d, got %d", s.status, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestIsUpgradeRequest(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		connection string
+		upgrade    string
+		expected   bool
+	}{
+		{"", "", false},
+		{"Upgrade", "", false},
+		{"", "websocket", false},
+		{"keep-alive, Upgrade", "WebSocket", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.connection+"_"+s.upgrade, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Connection", s.connection)
+			req.Header.Set("Upgrade", s.upgrade)
+
+			if v := websocket.IsUpgradeRequest(req); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestConnEcho(t *testing.T) {
+	t.Parallel()
+
+	server := newEchoServer(t, -1)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+	conn.SetReadLimit(-1)
+
+	scenarios := []struct {
+		messageType websocket.MessageType
+		data        string
+	}{
+		{websocket.TextMessage, "hello"},
+		{websocket.TextMessage, ""},
+		{websocket.BinaryMessage, strings.Repeat("a", 200)},   // 16-bit length
+		{websocket.BinaryMessage, strings.Repeat("b", 70000)}, // 64-bit length
+		{websocket.TextMessage, "unicode: жфа ✓"},
+	}
+
+	for i, s := range scenarios {
+		if err := conn.WriteMessage(s.messageType, []byte(s.data)); err != nil {
+			t.Fatalf("[%d] Failed to write message: %v", i, err)
+		}
+
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("[%d] Failed to read message: %v", i, err)
+		}
+
+		if messageType != s.messageType {
+			t.Fatalf("[%d] Expected message type %d, got %d", i, s.messageType, messageType)
+		}
+
+		if string(data) != s.data {
+			t.Fatalf("[%d] Expected data with length %d, got %d", i, len(s.data), len(data))
+		}
+	}
+}
+
+func TestConnPingPong(t *testing.T) {
+	t.Parallel()
+
+	server := newEchoServer(t, 0)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(data []byte) {
+		pong <- string(data)
+	})
+
+	if err := conn.Ping([]byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pong handler is invoked as part of the read loop
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "after" {
+		t.Fatalf("Expected the echo message to be read, got %q (%v)", data, err)
+	}
+
+	select {
+	case v := <-pong:
+		if v != "test" {
+			t.Fatalf("Expected pong payload %q, got %q", "test", v)
+		}
+	default:
+		t.Fatal("Expected the pong handler to be called")
+	}
+}
+
+func TestConnReadLimit(t *testing.T) {
+	t.Parallel()
+
+	server := newEchoServer(t, 10)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("01234567890")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected CloseError, got %v", err)
+	}
+
+	if closeErr.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("Expected close code %d, got %d", websocket.CloseMessageTooBig, closeErr.Code)
+	}
+}
+
+func TestConnClose(t *testing.T) {
+	t.Parallel()
+
+	server := newEchoServer(t, 0)
+	defer server.Close()
+
+	conn := dial(t, server)
+
+	if err := conn.CloseWithCode(websocket.CloseGoingAway, "bye"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("test")); !errors.Is(err, websocket.ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+}